@@ -1,38 +1,184 @@
 package cache
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // getLocationsForOS returns a list of cache locations specific to the given operating system.
+// Only the system locations are returned; developer and browser caches are selected by name.
 // Returns nil for unsupported operating systems.
 func getLocationsForOS(osName OS) []CacheLocation {
+	var system []CacheLocation
+	for _, location := range Registry(osName) {
+		if location.Type == SystemCache {
+			system = append(system, location)
+		}
+	}
+	return system
+}
+
+// Registry returns every cache location known on the given operating
+// system, system locations first. Paths follow the environment variables
+// the tools themselves read, such as GOCACHE and CARGO_HOME, and may not
+// exist. A location holding others, such as user-cache, skips them, so
+// they are only cleared when selected. Returns nil for unsupported
+// operating systems.
+func Registry(osName OS) []CacheLocation {
+	home, _ := os.UserHomeDir()
+	goPath := filepath.Join(home, "go")
+	if list := filepath.SplitList(os.Getenv("GOPATH")); len(list) > 0 && list[0] != "" {
+		goPath = list[0]
+	}
+	cargoHome := envPath("CARGO_HOME", filepath.Join(home, ".cargo"))
+	gradleHome := envPath("GRADLE_USER_HOME", filepath.Join(home, ".gradle"))
+
 	switch osName {
 	case Windows:
-		return []CacheLocation{
-			{
-				Path: filepath.Join(os.Getenv("LOCALAPPDATA"), "Temp"),
-			},
-
-			{
-				Path: filepath.Join(os.Getenv("LOCALAPPDATA"), "Microsoft", "Windows", "Explorer"),
-			},
-		}
+		localAppData := os.Getenv("LOCALAPPDATA")
+		return skipNested([]CacheLocation{
+			{Name: "temp", Description: "Temporary files", Path: filepath.Join(localAppData, "Temp"), Type: SystemCache},
+			{Name: "explorer", Description: "Explorer thumbnails", Path: filepath.Join(localAppData, "Microsoft", "Windows", "Explorer"), Type: SystemCache},
+			{Name: "go-build", Description: "Go build cache", Path: envPath("GOCACHE", filepath.Join(localAppData, "go-build")), Type: DevCache},
+			{Name: "go-mod", Description: "Go module cache", Path: envPath("GOMODCACHE", filepath.Join(goPath, "pkg", "mod")), Type: DevCache},
+			{Name: "npm", Description: "npm cache", Path: filepath.Join(envPath("npm_config_cache", filepath.Join(localAppData, "npm-cache")), "_cacache"), Type: DevCache},
+			{Name: "yarn", Description: "Yarn cache", Path: envPath("YARN_CACHE_FOLDER", filepath.Join(localAppData, "Yarn", "Cache")), Type: DevCache},
+			{Name: "pnpm", Description: "pnpm store", Path: filepath.Join(localAppData, "pnpm", "store"), Type: DevCache},
+			{Name: "pip", Description: "pip cache", Path: envPath("PIP_CACHE_DIR", filepath.Join(localAppData, "pip", "Cache")), Type: DevCache},
+			{Name: "cargo", Description: "Cargo registry", Path: filepath.Join(cargoHome, "registry"), Type: DevCache},
+			{Name: "gradle", Description: "Gradle caches", Path: filepath.Join(gradleHome, "caches"), Type: DevCache},
+			{Name: "maven", Description: "Maven local repository", Path: filepath.Join(home, ".m2", "repository"), Type: DevCache},
+			{Name: "ccache", Description: "ccache compiler cache", Path: envPath("CCACHE_DIR", filepath.Join(localAppData, "ccache")), Type: DevCache},
+			{Name: "chrome", Description: "Chrome cache", Path: filepath.Join(localAppData, "Google", "Chrome", "User Data", "Default", "Cache"), Type: BrowserCache},
+			{Name: "edge", Description: "Edge cache", Path: filepath.Join(localAppData, "Microsoft", "Edge", "User Data", "Default", "Cache"), Type: BrowserCache},
+			{Name: "firefox", Description: "Firefox cache", Path: filepath.Join(localAppData, "Mozilla", "Firefox", "Profiles"), Type: BrowserCache},
+		})
 	case Linux:
-		home, _ := os.UserHomeDir()
-		return []CacheLocation{
-			{
-				Path: "/tmp",
-			},
-			{
-				Path: "/var/tmp",
-			},
-			{
-				Path: filepath.Join(home, ".cache"),
-			},
-		}
+		cacheHome := envPath("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+		dataHome := envPath("XDG_DATA_HOME", filepath.Join(home, ".local", "share"))
+		return skipNested([]CacheLocation{
+			{Name: "tmp", Description: "Temporary files", Path: "/tmp", Type: SystemCache},
+			{Name: "var-tmp", Description: "Persistent temporary files", Path: "/var/tmp", Type: SystemCache},
+			{Name: "user-cache", Description: "User cache", Path: cacheHome, Type: SystemCache},
+			{Name: "go-build", Description: "Go build cache", Path: envPath("GOCACHE", filepath.Join(cacheHome, "go-build")), Type: DevCache},
+			{Name: "go-mod", Description: "Go module cache", Path: envPath("GOMODCACHE", filepath.Join(goPath, "pkg", "mod")), Type: DevCache},
+			{Name: "npm", Description: "npm cache", Path: filepath.Join(envPath("npm_config_cache", filepath.Join(home, ".npm")), "_cacache"), Type: DevCache},
+			{Name: "yarn", Description: "Yarn cache", Path: envPath("YARN_CACHE_FOLDER", filepath.Join(cacheHome, "yarn")), Type: DevCache},
+			{Name: "pnpm", Description: "pnpm store", Path: filepath.Join(dataHome, "pnpm", "store"), Type: DevCache},
+			{Name: "pip", Description: "pip cache", Path: envPath("PIP_CACHE_DIR", filepath.Join(cacheHome, "pip")), Type: DevCache},
+			{Name: "cargo", Description: "Cargo registry", Path: filepath.Join(cargoHome, "registry"), Type: DevCache},
+			{Name: "gradle", Description: "Gradle caches", Path: filepath.Join(gradleHome, "caches"), Type: DevCache},
+			{Name: "maven", Description: "Maven local repository", Path: filepath.Join(home, ".m2", "repository"), Type: DevCache},
+			{Name: "ccache", Description: "ccache compiler cache", Path: envPath("CCACHE_DIR", filepath.Join(cacheHome, "ccache")), Type: DevCache},
+			{Name: "chrome", Description: "Chrome cache", Path: filepath.Join(cacheHome, "google-chrome"), Type: BrowserCache},
+			{Name: "chromium", Description: "Chromium cache", Path: filepath.Join(cacheHome, "chromium"), Type: BrowserCache},
+			{Name: "firefox", Description: "Firefox cache", Path: filepath.Join(cacheHome, "mozilla", "firefox"), Type: BrowserCache},
+		})
 	default:
 		return nil
 	}
 }
+
+// skipNested sets the Skip list of every location to the paths of the
+// other locations below it, and returns the locations
+func skipNested(locations []CacheLocation) []CacheLocation {
+	for i := range locations {
+		locations[i].Skip = nil
+		for j, other := range locations {
+			if i != j && isBelow(other.Path, locations[i].Path) {
+				locations[i].Skip = append(locations[i].Skip, filepath.Clean(other.Path))
+			}
+		}
+	}
+	return locations
+}
+
+// isBelow reports whether path is strictly inside dir
+func isBelow(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// skips reports whether the walk of the location leaves out dir, which
+// belongs to another location
+func (l CacheLocation) skips(dir string) bool {
+	for _, skip := range l.Skip {
+		if dir == skip {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectLocations returns the locations of registry named by names, in
+// registry order. A name is either the name of a location or a cache type,
+// which selects every location of that type.
+func SelectLocations(registry []CacheLocation, names []string) ([]CacheLocation, error) {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !knownName(registry, name) {
+			return nil, fmt.Errorf("unknown cache location %q, want a type (system, app, dev, browser) or one of %s", name, strings.Join(locationNames(registry), ", "))
+		}
+		wanted[name] = true
+	}
+	if len(wanted) == 0 {
+		return nil, fmt.Errorf("no cache location selected")
+	}
+
+	var selected []CacheLocation
+	for _, location := range registry {
+		if wanted[location.Name] || wanted[string(location.Type)] {
+			selected = append(selected, location)
+		}
+	}
+	return selected, nil
+}
+
+// Exists reports whether the directory of the location is present
+func (l CacheLocation) Exists() bool {
+	info, err := os.Stat(l.Path)
+	return err == nil && info.IsDir()
+}
+
+// Matches reports whether a file of the location passes its age and size
+// limits and is counted and removed
+func (l CacheLocation) Matches(info os.FileInfo) bool {
+	if l.MinSize > 0 && info.Size() < l.MinSize {
+		return false
+	}
+	return l.OlderThan.IsZero() || info.ModTime().Before(l.OlderThan)
+}
+
+// knownName reports whether name is a location or a type of registry
+func knownName(registry []CacheLocation, name string) bool {
+	for _, location := range registry {
+		if location.Name == name || string(location.Type) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// locationNames returns the names of the locations of registry
+func locationNames(registry []CacheLocation) []string {
+	names := make([]string, 0, len(registry))
+	for _, location := range registry {
+		names = append(names, location.Name)
+	}
+	return names
+}
+
+// envPath returns the value of the environment variable key, or fallback
+// when it is unset
+func envPath(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -1,12 +1,14 @@
 package cache
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sync"
 
 	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/logging"
 )
 
 // Manager handles cache operations for different operating systems
@@ -14,6 +16,9 @@ type Manager struct {
 	Os          OS                      //made exportable for testing
 	Locations   []CacheLocation         //made exportable for testing
 	Filemanager filemanager.FileManager //made exportable for testing
+
+	SkipOpenFiles bool            // Leave files held open by a process alone when clearing
+	Custom        []CacheLocation // Locations from the rules, offered next to the built-in ones
 }
 
 // NewCacheManager creates a new cache manager instance for the current OS
@@ -25,8 +30,9 @@ func NewCacheManager(fm filemanager.FileManager) *Manager {
 	}
 }
 
-// ScanAllLocations concurrently scans all cache locations and returns their statistics
-func (m *Manager) ScanAllLocations() []ScanResult {
+// ScanAllLocations concurrently scans all cache locations and returns their
+// statistics. When ctx is done the totals counted so far are returned.
+func (m *Manager) ScanAllLocations(ctx context.Context) []ScanResult {
 	var resultsScan []ScanResult
 
 	var wg sync.WaitGroup
@@ -37,7 +43,8 @@ func (m *Manager) ScanAllLocations() []ScanResult {
 		go func() {
 			defer wg.Done()
 
-			result := m.scan(location.Path)
+			result := m.scan(ctx, location)
+			result.Name = location.Name
 
 			mu.Lock()
 			resultsScan = append(resultsScan, result)
@@ -51,9 +58,12 @@ func (m *Manager) ScanAllLocations() []ScanResult {
 }
 
 // scan analyzes a single cache location and returns its statistics
-func (m *Manager) scan(path string) ScanResult {
-	result := ScanResult{Path: path, FileCount: 0, Size: 0}
-	filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+func (m *Manager) scan(ctx context.Context, location CacheLocation) ScanResult {
+	result := ScanResult{Path: location.Path, FileCount: 0, Size: 0}
+	filepath.Walk(location.Path, func(path string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return filepath.SkipAll
+		}
 		if info == nil {
 			return nil
 		}
@@ -61,6 +71,12 @@ func (m *Manager) scan(path string) ScanResult {
 		if err != nil {
 			return nil
 		}
+		if info.IsDir() && location.skips(path) {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && !location.Matches(info) {
+			return nil
+		}
 
 		result.Size += info.Size()
 		result.FileCount++
@@ -70,10 +86,25 @@ func (m *Manager) scan(path string) ScanResult {
 	return result
 }
 
-// ClearCache removes all files from cache locations using OS-specific deletion methods
-func (m *Manager) ClearCache() (deleteError error) {
+// ClearCache removes all files from cache locations using OS-specific deletion
+// methods. Every removed file is recorded in the journal, which may be nil.
+// With SkipOpenFiles, files a process holds open are counted instead of
+// removed. When ctx is done clearing stops and ctx.Err() is returned.
+func (m *Manager) ClearCache(ctx context.Context, journal *logging.Journal) (result ClearResult, deleteError error) {
+	var openFiles *filemanager.OpenFiles
+	if m.SkipOpenFiles {
+		var err error
+		if openFiles, err = filemanager.ReadOpenFiles(); err != nil {
+			return result, err
+		}
+	}
+
 	for _, location := range m.Locations {
+		result.DeletedOpenSize += openFiles.DeletedSize(location.Path)
 		filepath.Walk(location.Path, func(path string, info os.FileInfo, err error) error {
+			if ctx.Err() != nil {
+				return filepath.SkipAll
+			}
 			if info == nil {
 				return nil
 			}
@@ -81,10 +112,25 @@ func (m *Manager) ClearCache() (deleteError error) {
 			if err != nil {
 				return nil
 			}
+			if info.IsDir() && location.skips(path) {
+				return filepath.SkipDir
+			}
 
 			if !info.IsDir() {
+				if !location.Matches(info) {
+					return nil
+				}
+				if openFiles.Contains(info) {
+					result.InUseFiles++
+					result.InUseSize += info.Size()
+					return nil
+				}
+
 				// Try normal deletion first
 				err := os.Remove(path)
+				if err != nil && makeWritable(filepath.Dir(path)) {
+					err = os.Remove(path)
+				}
 				if err != nil {
 					deleteError = err
 
@@ -102,14 +148,60 @@ func (m *Manager) ClearCache() (deleteError error) {
 						}
 					}
 				}
+				journal.Record(logging.NewFileOperation(path, info.Size(), logging.OperationDeleted, "cache", ""))
+				result.FilesDeleted++
 				return nil
 			}
 			return nil
 		})
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
 	}
 
-	return deleteError
+	return result, deleteError
 }
 func (m *Manager) GetOS() OS {
 	return m.Os
 }
+
+// Known returns every cache location known on the OS of the manager
+// followed by the custom ones. A custom location named like a built-in one
+// takes its place, and a location holding others skips them.
+func (m *Manager) Known() []CacheLocation {
+	custom := make(map[string]bool, len(m.Custom))
+	for _, location := range m.Custom {
+		custom[location.Name] = true
+	}
+
+	known := make([]CacheLocation, 0, len(m.Custom))
+	for _, location := range Registry(m.Os) {
+		if !custom[location.Name] {
+			known = append(known, location)
+		}
+	}
+	return skipNested(append(known, m.Custom...))
+}
+
+// Select replaces the locations of the manager with the known locations
+// named by names, each a location name such as go-build or a cache type
+// such as dev. Unknown names are an error and leave the locations unchanged.
+func (m *Manager) Select(names ...string) error {
+	locations, err := SelectLocations(m.Known(), names)
+	if err != nil {
+		return err
+	}
+	m.Locations = locations
+	return nil
+}
+
+// makeWritable gives the owner write permission on a read-only directory,
+// such as those of the Go module cache, so the files in it can be removed.
+// It reports whether the permissions changed.
+func makeWritable(dir string) bool {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() || info.Mode().Perm()&0200 != 0 {
+		return false
+	}
+	return os.Chmod(dir, info.Mode().Perm()|0200) == nil
+}
@@ -1,5 +1,7 @@
 package cache
 
+import "time"
+
 // CacheType represents the type of cache (system or application)
 type CacheType string
 
@@ -12,18 +14,37 @@ const (
 )
 
 const (
-	SystemCache CacheType = "system" // System-wide cache
-	AppCache    CacheType = "app"    // Application-specific cache
+	SystemCache  CacheType = "system"  // System-wide cache
+	AppCache     CacheType = "app"     // Application-specific cache
+	DevCache     CacheType = "dev"     // Build tool and package manager cache
+	BrowserCache CacheType = "browser" // Web browser cache
 )
 
 // CacheLocation represents a cache directory location with its path and type
 type CacheLocation struct {
-	Path string
-	Type string
+	Name        string    // Short name used to select the location, such as go-build
+	Description string    // What the location holds, such as "Go build cache"
+	Path        string    // Directory of the cache, which may not exist
+	Type        CacheType // Kind of cache, also usable to select every location of the kind
+	Skip        []string  // Directories below Path that are locations of their own and left out
+
+	OlderThan time.Time // Only files last modified before this time are counted and removed, zero for any age
+	MinSize   int64     // Only files at least this large are counted and removed, 0 for any size
+	Confirm   bool      // Whether clearing the location must be confirmed on its own
+	Custom    bool      // Whether the location was defined in the rules rather than built in
+}
+
+// ClearResult summarises a cache clear
+type ClearResult struct {
+	FilesDeleted    int64 // Number of files removed
+	InUseFiles      int64 // Files left alone because a process holds them open
+	InUseSize       int64 // Size of the files in use
+	DeletedOpenSize int64 // Space still held by removed cache files that processes have open
 }
 
 // ScanResult contains information about a cache scan operation
 type ScanResult struct {
+	Name      string // Name of the scanned location
 	FileCount int64  // Number of files found
 	Path      string // Path that was scanned
 	Size      int64  // Total size of cache in bytes
@@ -0,0 +1,93 @@
+package cleanup
+
+import (
+	"context"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/logging"
+)
+
+// ActionOptions set how ApplyAction carries out the actions of a run
+type ActionOptions struct {
+	Trash     bool                     // Files without a clause action go to the trash
+	Shred     bool                     // Files without a clause action are shredded
+	Shredding filemanager.ShredOptions // Passes and method of the shred action
+	Stats     *logging.ScanStatistics  // Collects shredded files and throughput, may be nil
+	Files     *filemanager.Pacer       // Paces removals to the rate limit, nil for none
+	Bytes     *filemanager.Pacer       // Paces archive reads and shred writes, nil for none
+
+	Rotation  filemanager.RotateOptions // Generations kept by the rotate action; CopyTruncate only applies to files held open
+	OpenFiles *filemanager.OpenFiles    // Files held open when the run started; nil treats every file as open
+}
+
+// RotationOpenFiles returns the open files that decide which files the
+// rotate action copies and truncates. A snapshot the run already read is
+// reused; otherwise one is read only when a clause rotates and CopyTruncate
+// is set. It is nil when /proc cannot be read, so that every rotated file
+// is copied and truncated.
+func RotationOpenFiles(rotation filemanager.RotateOptions, clauses []filemanager.Clause, known *filemanager.OpenFiles) *filemanager.OpenFiles {
+	if known != nil || !rotation.CopyTruncate || !rotates(clauses) {
+		return known
+	}
+	openFiles, _ := filemanager.ReadOpenFiles()
+	return openFiles
+}
+
+// rotates reports whether any of the clauses rotates the files it selects
+func rotates(clauses []filemanager.Clause) bool {
+	for _, clause := range clauses {
+		if clause.Action == filemanager.ActionRotate {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the action ApplyAction carries out for a file, with
+// ActionDefault turned into shred, trash or delete by the run settings
+func (o ActionOptions) Resolve(action filemanager.FileAction) filemanager.FileAction {
+	if action == filemanager.ActionDefault && o.Shred {
+		return filemanager.ActionShred
+	}
+	return action.Resolve(o.Trash)
+}
+
+// ApplyAction carries out the action of a scanned file, with ActionDefault
+// resolved by the shred and trash settings of the run, and returns the
+// operation to record. Skipped files are left alone and reported as ignored.
+// Other actions first wait for the rate limit, and give up when ctx is done.
+func ApplyAction(ctx context.Context, fm filemanager.FileManager, entry filemanager.FileEntry, opts ActionOptions) (logging.OperationType, error) {
+	action := opts.Resolve(entry.Action)
+
+	if action == filemanager.ActionSkip {
+		return logging.OperationIgnored, nil
+	}
+	if err := opts.Files.Wait(ctx, 1); err != nil {
+		return "", err
+	}
+
+	switch action {
+	case filemanager.ActionArchive:
+		_, err := filemanager.ArchiveFilePaced(ctx, entry.Path, opts.Bytes)
+		return logging.OperationArchived, err
+	case filemanager.ActionRotate:
+		rotation := opts.Rotation
+		rotation.CopyTruncate = rotation.CopyTruncate && (opts.OpenFiles == nil || entry.InUse || opts.OpenFiles.ContainsEntry(entry))
+		_, err := filemanager.RotateFilePaced(ctx, entry.Path, rotation, opts.Bytes)
+		return logging.OperationRotated, err
+	case filemanager.ActionShred:
+		start := time.Now()
+		written, err := filemanager.ShredFilePaced(ctx, entry.Path, opts.Shredding, opts.Bytes)
+		if err == nil && opts.Stats != nil {
+			opts.Stats.AddShred(entry.Size, written, time.Since(start))
+		}
+		return logging.OperationShredded, err
+	case filemanager.ActionTrash:
+		fm.MoveFileToTrash(entry.Path)
+		return logging.OperationTrashed, nil
+	default:
+		fm.DeleteFile(entry.Path)
+		return logging.OperationDeleted, nil
+	}
+}
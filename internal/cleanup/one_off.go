@@ -1,12 +1,14 @@
 package cleanup
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/logging"
 	"github.com/pashkov256/deletor/internal/rules"
 	"github.com/pashkov256/deletor/internal/utils"
 )
@@ -21,10 +23,33 @@ type OneOffCleanSpec struct {
 	MaxSize               int64
 	OlderThan             time.Time
 	NewerThan             time.Time
+	OlderThanAge          time.Duration // OlderThan relative to the load time, for long-running watches
+	NewerThanAge          time.Duration // NewerThan relative to the load time, for long-running watches
+	AccessedBefore        time.Time
+	AccessedAfter         time.Time
+	ChangedBefore         time.Time
+	ChangedAfter          time.Time
+	Owners                []int
+	NotOwners             []int
+	UIDs                  []int
+	Groups                []int
+	Perm                  *filemanager.PermFilter
+	Where                 filemanager.Expr
+	ContentTypes          []string
+	Clauses               []filemanager.Clause
 	IncludeSubfolders     bool
+	WalkLimits            filemanager.WalkLimits
 	DeleteEmptySubfolders bool
 	SendFilesToTrash      bool
+	Shred                 bool // Overwrite files before deleting them, unless a clause sets another action
+	ShredOptions          filemanager.ShredOptions
+	Rotation              filemanager.RotateOptions
+	Throttle              Throttle // Priority and rate limits of the run
+	SkipOpenFiles         bool     // Leave files held open by a process alone
 	LogToFile             bool
+	OneFileSystem         bool
+	SkipFilesystems       []string
+	Symlinks              filemanager.SymlinkPolicy
 }
 
 // OneOffCleanResult captures the outcome of a scheduled clean execution.
@@ -32,8 +57,16 @@ type OneOffCleanResult struct {
 	Path             string
 	FilesCleaned     int
 	BytesCleared     int64
+	FilesArchived    int   // Files archived or rotated, which keep their data and are not counted as cleaned
+	ArchivedSize     int64 // Size of the archived and rotated files before compression
 	EmptyDirsDeleted int
 	UsedTrash        bool
+	UsedShred        bool
+	ShredThroughput  float64 // Bytes overwritten per second, 0 when nothing was shredded
+	InUseFiles       int     // Matched files left alone because a process holds them open
+	InUseSize        int64   // Size of the files in use
+	DeletedOpenSize  int64   // Space held by removed files that processes still have open
+	Cancelled        bool
 	CompletedAt      time.Time
 }
 
@@ -60,6 +93,7 @@ func LoadOneOffCleanSpec(ruleManager rules.Rules) (*OneOffCleanSpec, error) {
 
 	var minSize, maxSize int64
 	var olderThan, newerThan time.Time
+	var accessedBefore, accessedAfter, changedBefore, changedAfter time.Time
 
 	if savedRules.MinSize != "" {
 		minSize, err = utils.ToBytes(savedRules.MinSize)
@@ -89,6 +123,92 @@ func LoadOneOffCleanSpec(ruleManager rules.Rules) (*OneOffCleanSpec, error) {
 		}
 	}
 
+	if savedRules.AccessedBefore != "" {
+		accessedBefore, err = utils.ParseTimeDuration(savedRules.AccessedBefore)
+		if err != nil {
+			return nil, fmt.Errorf("invalid saved accessed-before value: %w", err)
+		}
+	}
+
+	if savedRules.AccessedAfter != "" {
+		accessedAfter, err = utils.ParseTimeDuration(savedRules.AccessedAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid saved accessed-after value: %w", err)
+		}
+	}
+
+	if savedRules.ChangedBefore != "" {
+		changedBefore, err = utils.ParseTimeDuration(savedRules.ChangedBefore)
+		if err != nil {
+			return nil, fmt.Errorf("invalid saved changed-before value: %w", err)
+		}
+	}
+
+	if savedRules.ChangedAfter != "" {
+		changedAfter, err = utils.ParseTimeDuration(savedRules.ChangedAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid saved changed-after value: %w", err)
+		}
+	}
+
+	symlinks, err := filemanager.ParseSymlinkPolicy(savedRules.Symlinks)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved symlink policy: %w", err)
+	}
+
+	owners, err := filemanager.LookupOwners(savedRules.Owners)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved owners: %w", err)
+	}
+
+	notOwners, err := filemanager.LookupOwners(savedRules.NotOwners)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved excluded owners: %w", err)
+	}
+
+	groups, err := filemanager.LookupGroups(savedRules.Groups)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved groups: %w", err)
+	}
+
+	perm, err := filemanager.ParsePermFilter(savedRules.Perm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved permission filter: %w", err)
+	}
+
+	where, err := filemanager.ParseExpr(savedRules.Where)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved filter expression: %w", err)
+	}
+
+	clauses, err := rules.CompileClauses(savedRules.Clauses)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved clauses: %w", err)
+	}
+
+	contentTypes, err := filemanager.ParseContentTypes(savedRules.ContentTypes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved content types: %w", err)
+	}
+
+	shredMethod, err := filemanager.ParseShredMethod(savedRules.ShredMethod)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved shred method: %w", err)
+	}
+	shredding := filemanager.ShredOptions{Passes: savedRules.ShredPasses, Method: shredMethod}
+	rotation := filemanager.RotateOptions{Keep: savedRules.RotateKeep, CopyTruncate: savedRules.RotateCopyTruncate}
+
+	throttle, err := ParseThrottle(savedRules.IONice, savedRules.Nice, savedRules.RateLimit, savedRules.BWLimit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved throttle: %w", err)
+	}
+
+	limits := filemanager.WalkLimits{
+		MinDepth:      savedRules.MinDepth,
+		MaxDepth:      savedRules.MaxDepth,
+		MaxDirEntries: savedRules.MaxDirEntries,
+	}
+
 	return &OneOffCleanSpec{
 		Path:                  targetPath,
 		Extensions:            append([]string(nil), savedRules.Extensions...),
@@ -97,16 +217,102 @@ func LoadOneOffCleanSpec(ruleManager rules.Rules) (*OneOffCleanSpec, error) {
 		MaxSize:               maxSize,
 		OlderThan:             olderThan,
 		NewerThan:             newerThan,
+		OlderThanAge:          ageOf(olderThan),
+		NewerThanAge:          ageOf(newerThan),
+		AccessedBefore:        accessedBefore,
+		AccessedAfter:         accessedAfter,
+		ChangedBefore:         changedBefore,
+		ChangedAfter:          changedAfter,
+		Owners:                owners,
+		NotOwners:             notOwners,
+		UIDs:                  append([]int(nil), savedRules.UIDs...),
+		Groups:                groups,
+		Perm:                  perm,
+		Where:                 where,
+		ContentTypes:          contentTypes,
+		Clauses:               clauses,
 		IncludeSubfolders:     savedRules.IncludeSubfolders,
+		WalkLimits:            limits,
 		DeleteEmptySubfolders: savedRules.DeleteEmptySubfolders,
 		SendFilesToTrash:      savedRules.SendFilesToTrash,
+		Shred:                 savedRules.Shred,
+		ShredOptions:          shredding,
+		Rotation:              rotation,
+		Throttle:              throttle,
+		SkipOpenFiles:         savedRules.SkipOpenFiles,
 		LogToFile:             savedRules.LogToFile,
+		OneFileSystem:         savedRules.OneFileSystem,
+		SkipFilesystems:       append([]string(nil), savedRules.SkipFilesystems...),
+		Symlinks:              symlinks,
 	}, nil
 }
 
+// Rotates reports whether a clause of the spec rotates the files it selects
+func (s *OneOffCleanSpec) Rotates() bool {
+	return rotates(s.Clauses)
+}
+
+// actionOptions returns how the files of a run on spec are removed. The
+// pacers are new, so the options are made once per run.
+func (s *OneOffCleanSpec) actionOptions(stats *logging.ScanStatistics) ActionOptions {
+	files, bytes := s.Throttle.Pacers()
+	return ActionOptions{
+		Trash:     s.SendFilesToTrash,
+		Shred:     s.Shred,
+		Shredding: s.ShredOptions,
+		Stats:     stats,
+		Files:     files,
+		Bytes:     bytes,
+		Rotation:  s.Rotation,
+	}
+}
+
+// fileFilter builds the file filter of the spec. One-off runs and watches
+// both select files through it, so they apply the same filters.
+func (s *OneOffCleanSpec) fileFilter(fm filemanager.FileManager) *filemanager.FileFilter {
+	filter := fm.NewFileFilter(
+		s.MinSize,
+		s.MaxSize,
+		utils.ParseExtToMap(s.Extensions),
+		s.Exclude,
+		s.OlderThan,
+		s.NewerThan,
+	)
+	filter.OneFileSystem = s.OneFileSystem
+	filter.SkipFilesystems = s.SkipFilesystems
+	filter.Symlinks = s.Symlinks
+	filter.WalkLimits = s.WalkLimits
+	filter.AccessedBefore = s.AccessedBefore
+	filter.AccessedAfter = s.AccessedAfter
+	filter.ChangedBefore = s.ChangedBefore
+	filter.ChangedAfter = s.ChangedAfter
+	filter.Owners = s.Owners
+	filter.NotOwners = s.NotOwners
+	filter.UIDs = s.UIDs
+	filter.Groups = s.Groups
+	filter.Perm = s.Perm
+	filter.Where = s.Where
+	filter.ContentTypes = s.ContentTypes
+	filter.Clauses = s.Clauses
+	return filter
+}
+
+// ageOf turns a threshold parsed relative to now back into an age
+func ageOf(threshold time.Time) time.Duration {
+	if threshold.IsZero() {
+		return 0
+	}
+	return time.Since(threshold).Round(time.Second)
+}
+
 // RunOneOffClean executes a one-off cleanup run using a previously loaded
-// cleanup spec.
-func RunOneOffClean(fm filemanager.FileManager, spec *OneOffCleanSpec) (*OneOffCleanResult, error) {
+// cleanup spec. Each removed file is recorded in the operation journal. When
+// ctx is cancelled the run stops before the next file and the result counts
+// only what was already cleaned. The scan and the removals run with the
+// priority and rate limits of spec.Throttle, on a thread of their own, so
+// the rest of the process keeps its priority. With SkipOpenFiles, files a
+// process holds open are left alone and counted in the result.
+func RunOneOffClean(ctx context.Context, fm filemanager.FileManager, spec *OneOffCleanSpec) (*OneOffCleanResult, error) {
 	if fm == nil {
 		return nil, errors.New("file manager is required")
 	}
@@ -114,53 +320,96 @@ func RunOneOffClean(fm filemanager.FileManager, spec *OneOffCleanSpec) (*OneOffC
 		return nil, errors.New("cleanup spec is required")
 	}
 
-	filter := fm.NewFileFilter(
-		spec.MinSize,
-		spec.MaxSize,
-		utils.ParseExtToMap(spec.Extensions),
-		spec.Exclude,
-		spec.OlderThan,
-		spec.NewerThan,
-	)
+	filter := spec.fileFilter(fm)
+	if spec.SkipOpenFiles {
+		openFiles, err := filemanager.ReadOpenFiles()
+		if err != nil {
+			return nil, fmt.Errorf("cannot check for open files: %w", err)
+		}
+		filter.OpenFiles = openFiles
+	}
 
 	scanner := filemanager.NewFileScanner(fm, filter, false)
 
-	var toClean map[string]string
-	var totalBytes int64
+	journal := logging.OpenDefaultJournal(spec.Path)
 
-	if spec.IncludeSubfolders {
-		toClean, totalBytes = scanner.ScanFilesRecursively(spec.Path)
-	} else {
-		toClean, totalBytes = scanner.ScanFilesCurrentLevel(spec.Path)
-	}
+	// Files are removed as the scan finds them, so large trees are never
+	// held in memory
+	var cleaned, archived filemanager.ScanResult
+	stats := &logging.ScanStatistics{}
+	actions := spec.actionOptions(stats)
+	actions.OpenFiles = RotationOpenFiles(spec.Rotation, spec.Clauses, filter.OpenFiles)
+	emptyDirsDeleted := 0
+	clean := func(entry filemanager.FileEntry) {
+		if ctx.Err() != nil {
+			return
+		}
+		if entry.InUse {
+			cleaned.Add(entry)
+			return
+		}
 
-	for filePath := range toClean {
-		if spec.SendFilesToTrash {
-			fm.MoveFileToTrash(filePath)
-		} else {
-			fm.DeleteFile(filePath)
+		opType, err := ApplyAction(ctx, fm, entry, actions)
+		if err != nil {
+			return
+		}
+		journal.Record(logging.NewFileOperation(entry.Path, entry.Size, opType, "scheduled clean", entry.MatchedRule))
+		if opType.Removes() {
+			cleaned.Add(entry)
+		} else if opType != logging.OperationIgnored {
+			archived.Add(entry)
 		}
 	}
+	err := runThrottled(spec.Throttle, func() {
+		if spec.Throttle.lowersPriority() {
+			// Lowered priorities belong to this thread alone, so the scan
+			// reads its directories here rather than on a pool of workers
+			scanner.EachFile(ctx, spec.Path, spec.IncludeSubfolders, clean)
+		} else {
+			for entry := range scanner.StreamFiles(ctx, spec.Path, spec.IncludeSubfolders) {
+				if ctx.Err() != nil {
+					break
+				}
+				clean(entry)
+			}
+		}
 
-	emptyDirsDeleted := 0
-	if spec.DeleteEmptySubfolders {
-		emptyDirs := scanner.ScanEmptySubFolders(spec.Path)
-		emptyDirsDeleted = len(emptyDirs)
-		if emptyDirsDeleted > 0 {
-			fm.DeleteEmptySubfolders(spec.Path)
+		if spec.DeleteEmptySubfolders && ctx.Err() == nil {
+			// Only the directories the limited scan found are removed, the
+			// deepest first, as the scan lists parents before children
+			emptyDirs := scanner.ScanEmptySubFolders(ctx, spec.Path)
+			for i := len(emptyDirs) - 1; i >= 0 && ctx.Err() == nil; i-- {
+				if os.Remove(emptyDirs[i]) == nil {
+					emptyDirsDeleted++
+				}
+			}
 		}
+	})
+	if err != nil {
+		journal.Finish(err)
+		return nil, err
 	}
 
-	if spec.LogToFile && len(toClean) > 0 {
-		utils.LogDeletionToFile(toClean)
+	journal.Finish(ctx.Err())
+
+	if spec.LogToFile && cleaned.Len() > 0 {
+		utils.LogDeletionToFile(cleaned.DeletionRecords())
 	}
 
 	return &OneOffCleanResult{
 		Path:             spec.Path,
-		FilesCleaned:     len(toClean),
-		BytesCleared:     totalBytes,
+		FilesCleaned:     cleaned.Len() + len(cleaned.BrokenLinks),
+		BytesCleared:     cleaned.FreedSize(),
+		FilesArchived:    archived.Len(),
+		ArchivedSize:     archived.TotalSize,
 		EmptyDirsDeleted: emptyDirsDeleted,
 		UsedTrash:        spec.SendFilesToTrash,
+		UsedShred:        spec.Shred,
+		ShredThroughput:  stats.ShredThroughput(),
+		InUseFiles:       len(cleaned.InUse),
+		InUseSize:        cleaned.InUseSize(),
+		DeletedOpenSize:  filter.OpenFiles.DeletedSize(spec.Path),
+		Cancelled:        ctx.Err() != nil,
 		CompletedAt:      time.Now(),
 	}, nil
 }
@@ -0,0 +1,71 @@
+//go:build linux
+// +build linux
+
+package cleanup
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// I/O scheduling classes and the shift of the class in an I/O priority, as
+// in linux/ioprio.h
+const (
+	ioprioClassBE    = 2
+	ioprioClassIdle  = 3
+	ioprioClassShift = 13
+	ioprioWhoProcess = 1
+)
+
+// setThreadPriority applies the CPU and I/O priority of t to the calling
+// thread. Linux keeps both per thread, and a thread started later copies
+// them from the thread that created it.
+func setThreadPriority(t Throttle) error {
+	return setTaskPriority(0, t)
+}
+
+// SetProcessPriority applies the CPU and I/O priority of t to every thread
+// of the process, for commands that do nothing but clean up
+func SetProcessPriority(t Throttle) error {
+	if !t.lowersPriority() {
+		return nil
+	}
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		if err := setTaskPriority(tid, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setTaskPriority applies the priority of t to one thread of the process,
+// the calling one for tid 0
+func setTaskPriority(tid int, t Throttle) error {
+	if t.IONice != "" {
+		class, level := ioniceLevel(t.IONice)
+		prio := ioprioClassIdle << ioprioClassShift
+		if class == IONiceBestEffort {
+			prio = ioprioClassBE<<ioprioClassShift | level
+		}
+		if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), uintptr(prio)); errno != 0 {
+			return fmt.Errorf("failed to set I/O class %s: %w", t.IONice, errno)
+		}
+	}
+	if t.Nice != 0 {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, tid, t.Nice); err != nil {
+			return fmt.Errorf("failed to set nice %d: %w", t.Nice, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+// +build !linux
+
+package cleanup
+
+import "errors"
+
+// setThreadPriority is only implemented for Linux, where CPU and I/O
+// priorities belong to threads
+func setThreadPriority(t Throttle) error {
+	return errors.New("--ionice and --nice are only supported on Linux")
+}
+
+// SetProcessPriority is only implemented for Linux
+func SetProcessPriority(t Throttle) error {
+	if !t.lowersPriority() {
+		return nil
+	}
+	return setThreadPriority(t)
+}
@@ -0,0 +1,150 @@
+package cleanup
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/utils"
+)
+
+// IONiceIdle only gets disk time when no other process wants it
+const IONiceIdle = "idle"
+
+// IONiceBestEffort is the normal I/O class, with levels from 0 (highest)
+// to 7 (lowest)
+const IONiceBestEffort = "best-effort"
+
+// Throttle keeps a cleanup from crowding out other work on the machine. The
+// zero value runs at full speed with the priority the process already has.
+type Throttle struct {
+	IONice    string  // I/O class, idle or best-effort[:LEVEL]; empty keeps the current one
+	Nice      int     // CPU niceness from 1 to 19; 0 keeps the current one
+	RateLimit float64 // Files removed per second, 0 for no limit
+	BWLimit   int64   // Bytes per second read by archive and written by shred, 0 for no limit
+}
+
+// Validate reports an unknown I/O class, niceness outside 0-19 and negative
+// limits
+func (t Throttle) Validate() error {
+	if _, err := utils.ParseIONice(t.IONice); err != nil {
+		return err
+	}
+	if t.Nice < 0 || t.Nice > 19 {
+		return fmt.Errorf("nice must be between 0 and 19, got %d", t.Nice)
+	}
+	if t.RateLimit < 0 {
+		return fmt.Errorf("rate limit must not be negative, got %g", t.RateLimit)
+	}
+	if t.BWLimit < 0 {
+		return fmt.Errorf("bandwidth limit must not be negative, got %d", t.BWLimit)
+	}
+	return nil
+}
+
+// Override replaces the settings of t that are set in o
+func (t *Throttle) Override(o Throttle) {
+	if o.IONice != "" {
+		t.IONice = o.IONice
+	}
+	if o.Nice != 0 {
+		t.Nice = o.Nice
+	}
+	if o.RateLimit != 0 {
+		t.RateLimit = o.RateLimit
+	}
+	if o.BWLimit != 0 {
+		t.BWLimit = o.BWLimit
+	}
+}
+
+// String describes the settings of t, such as "I/O idle, nice 10, 200
+// files/s, 20.00 MB/s", or "none"
+func (t Throttle) String() string {
+	var parts []string
+	if t.IONice != "" {
+		parts = append(parts, "I/O "+t.IONice)
+	}
+	if t.Nice != 0 {
+		parts = append(parts, fmt.Sprintf("nice %d", t.Nice))
+	}
+	if t.RateLimit != 0 {
+		parts = append(parts, strconv.FormatFloat(t.RateLimit, 'g', 4, 64)+" files/s")
+	}
+	if t.BWLimit != 0 {
+		parts = append(parts, utils.FormatSize(t.BWLimit)+"/s")
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// lowersPriority reports whether t changes the CPU or I/O priority
+func (t Throttle) lowersPriority() bool {
+	return t.IONice != "" || t.Nice != 0
+}
+
+// Pacers returns the pacers of the file and byte limits, nil when unset
+func (t Throttle) Pacers() (files, bytes *filemanager.Pacer) {
+	return filemanager.NewPacer(t.RateLimit), filemanager.NewPacer(float64(t.BWLimit))
+}
+
+// ParseThrottle builds a throttle from its settings as they are written in
+// rules and flags, such as idle, 10, 200/s and 20MB/s
+func ParseThrottle(ionice string, nice int, rateLimit, bwLimit string) (Throttle, error) {
+	class, err := utils.ParseIONice(ionice)
+	if err != nil {
+		return Throttle{}, err
+	}
+	rate, err := utils.ParseRate(rateLimit)
+	if err != nil {
+		return Throttle{}, fmt.Errorf("invalid rate limit: %w", err)
+	}
+	bandwidth, err := utils.ParseByteRate(bwLimit)
+	if err != nil {
+		return Throttle{}, fmt.Errorf("invalid bandwidth limit: %w", err)
+	}
+
+	throttle := Throttle{IONice: class, Nice: nice, RateLimit: rate, BWLimit: bandwidth}
+	return throttle, throttle.Validate()
+}
+
+// ioniceLevel splits an I/O class into its name and level; the best-effort
+// level defaults to 7, the lowest
+func ioniceLevel(ionice string) (string, int) {
+	class, level, _ := strings.Cut(ionice, ":")
+	n, err := strconv.Atoi(level)
+	if err != nil {
+		n = 7
+	}
+	return class, n
+}
+
+// runThrottled runs fn with the CPU and I/O priority of t. On Linux both
+// belong to a thread, so fn runs on a thread locked for it, and only the
+// work fn does on its own goroutine gets the lowered priority; goroutines
+// it starts run at the priority of the rest of the process. The thread is
+// never unlocked: an unprivileged process cannot raise a priority it has
+// lowered, so the runtime throws the thread away when fn returns instead
+// of reusing it for the rest of the program.
+func runThrottled(t Throttle, fn func()) error {
+	if !t.lowersPriority() {
+		fn()
+		return nil
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		if err := setThreadPriority(t); err != nil {
+			errc <- err
+			return
+		}
+		fn()
+		errc <- nil
+	}()
+	return <-errc
+}
@@ -0,0 +1,324 @@
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/logging"
+	"github.com/pashkov256/deletor/internal/utils"
+)
+
+// DefaultWatchDebounce is how long a watch waits for events on a path to
+// settle before checking it, so files still being written are seen once
+const DefaultWatchDebounce = 500 * time.Millisecond
+
+// watchBuffer lets the event reader run ahead of the cleaner
+const watchBuffer = 1024
+
+// watchMaxBatches caps how many debounce periods changed paths may wait
+const watchMaxBatches = 10
+
+// watchInUseRetry is how long a file held open by a process waits before
+// it is checked again, since closing a file it only reads sends no event
+const watchInUseRetry = time.Minute
+
+// WatchResult summarises a finished watch
+type WatchResult struct {
+	Path         string
+	FilesCleaned int
+	BytesCleared int64
+	Archived     int // Files archived or rotated, which keep their data and are not counted as cleaned
+	Scheduled    int // Files still waiting to reach the age limit when the watch stopped
+	InUse        int // Times a file was left alone because a process held it open
+	UsedTrash    bool
+}
+
+// Watcher keeps a directory clean by applying a cleanup spec to files as
+// they are created or modified. Files that pass every filter except the age
+// limit are scheduled and removed once they are old enough. Removals are
+// recorded in the operation journal and the deletion log like a one-off run.
+type Watcher struct {
+	Debounce    time.Duration                    // Quiet period before changed paths are checked
+	OnScheduled func(path string, due time.Time) // Called when a file is scheduled or rescheduled, may be nil
+
+	// OnRemoved is called after each file an action was applied to, with
+	// the operation that was done, and may be nil
+	OnRemoved func(filemanager.FileEntry, logging.OperationType)
+
+	fm         filemanager.FileManager
+	spec       *OneOffCleanSpec
+	clauseAges []time.Duration // Age limit of each clause of the spec, 0 for any age
+	started    time.Time       // When the watcher was made, to move the other time limits along
+	walker     *filemanager.TreeWalker
+	journal    *logging.Journal
+	actions    ActionOptions
+	pending    map[string]struct{}  // Changed paths waiting for the debounce
+	due        map[string]time.Time // Scheduled files and when they reach the age limit
+	result     WatchResult
+	openFiles  *filemanager.OpenFiles // Files held open at the start of the batch, nil unless SkipOpenFiles
+}
+
+// NewWatcher creates a watcher for the directory, filters and clauses of
+// spec. The age limits are taken from OlderThanAge and NewerThanAge, and
+// the clause ages relative to now, so they keep moving with the clock while
+// the watch runs.
+func NewWatcher(fm filemanager.FileManager, spec *OneOffCleanSpec) (*Watcher, error) {
+	if fm == nil {
+		return nil, errors.New("file manager is required")
+	}
+	if spec == nil {
+		return nil, errors.New("cleanup spec is required")
+	}
+
+	clauseAges := make([]time.Duration, len(spec.Clauses))
+	for i, clause := range spec.Clauses {
+		clauseAges[i] = ageOf(clause.OlderThan)
+	}
+
+	return &Watcher{
+		Debounce:   DefaultWatchDebounce,
+		fm:         fm,
+		spec:       spec,
+		clauseAges: clauseAges,
+		started:    time.Now(),
+		walker:     filemanager.NewTreeWalker(spec.Path, spec.fileFilter(fm)),
+		actions:    spec.actionOptions(nil),
+		pending:    make(map[string]struct{}),
+		due:        make(map[string]time.Time),
+		result:     WatchResult{Path: spec.Path, UsedTrash: spec.SendFilesToTrash},
+	}, nil
+}
+
+// Run watches the spec path until ctx is done or watching fails. The files
+// already in the directory are checked first. Stopping the watch through
+// ctx is the normal way to end it and is not reported as an error.
+func (w *Watcher) Run(ctx context.Context) (*WatchResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	changed := make(chan string, watchBuffer)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- watchEvents(ctx, w.walker, w.spec.Path, w.spec.IncludeSubfolders, changed)
+	}()
+
+	w.journal = logging.OpenDefaultJournal(w.spec.Path)
+
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+	dueTimer := time.NewTimer(time.Hour)
+	dueTimer.Stop()
+
+	// A steady stream of events must not hold back checks forever
+	var batchStart time.Time
+	maxDelay := watchMaxBatches * w.Debounce
+
+	var err error
+loop:
+	for {
+		select {
+		case path := <-changed:
+			if len(w.pending) == 0 {
+				batchStart = time.Now()
+			}
+			w.pending[path] = struct{}{}
+			debounce.Reset(min(w.Debounce, time.Until(batchStart.Add(maxDelay))))
+		case <-debounce.C:
+			w.readOpenFiles()
+			for path := range w.pending {
+				if ctx.Err() != nil {
+					break
+				}
+				w.check(ctx, path)
+			}
+			clear(w.pending)
+			w.resetDueTimer(dueTimer)
+		case <-dueTimer.C:
+			w.readOpenFiles()
+			now := time.Now()
+			for path, due := range w.due {
+				if !due.After(now) && ctx.Err() == nil {
+					w.check(ctx, path)
+				}
+			}
+			w.resetDueTimer(dueTimer)
+		case err = <-watchErr:
+			break loop
+		case <-ctx.Done():
+			break loop
+		}
+	}
+
+	w.journal.Finish(err)
+	w.result.Scheduled = len(w.due)
+	return &w.result, err
+}
+
+// readOpenFiles takes a new snapshot of the open files for the next batch
+// of checks. When /proc cannot be read the files are checked without it.
+func (w *Watcher) readOpenFiles() {
+	if !w.spec.SkipOpenFiles {
+		return
+	}
+	w.openFiles, _ = filemanager.ReadOpenFiles()
+}
+
+// resetDueTimer arms the timer for the earliest scheduled file
+func (w *Watcher) resetDueTimer(timer *time.Timer) {
+	timer.Stop()
+
+	var next time.Time
+	for _, due := range w.due {
+		if next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+	if !next.IsZero() {
+		timer.Reset(time.Until(next))
+	}
+}
+
+// check applies the spec to a changed path. A directory is swept, since
+// files may have been created in it before it was watched. Files and
+// directories a one-off scan of the spec path would not look at, because of
+// the depth and directory size limits or the filesystems allowed, are left
+// alone.
+func (w *Watcher) check(ctx context.Context, path string) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		delete(w.due, path)
+		return
+	}
+	if !info.IsDir() {
+		if !w.walker.Visits(path) {
+			delete(w.due, path)
+			return
+		}
+		w.checkFile(ctx, path, info)
+		return
+	}
+	if path != w.spec.Path && !w.spec.IncludeSubfolders {
+		return
+	}
+
+	w.walker.Walk(ctx, path, w.spec.IncludeSubfolders, func(filePath string, fileInfo os.FileInfo) {
+		if ctx.Err() == nil {
+			w.checkFile(ctx, filePath, fileInfo)
+		}
+	})
+}
+
+// checkFile applies the action of a file that passes the filters now, or
+// schedules it if only an age limit keeps it
+func (w *Watcher) checkFile(ctx context.Context, path string, info os.FileInfo) {
+	now := time.Now()
+
+	filter := w.filter(now, true)
+	if filter.MatchesFilters(info, path) {
+		if w.openFiles.Contains(info) {
+			w.result.InUse++
+			w.schedule(path, now.Add(watchInUseRetry))
+			return
+		}
+		entry := filemanager.NewFileEntry(path, info)
+		if clause := filter.MatchedClause(info, path); clause != nil {
+			entry.MatchedRule, entry.Action = clause.Spec, clause.Action
+		} else {
+			entry.MatchedRule = filter.MatchedRule(info, path)
+		}
+		w.remove(ctx, entry)
+		return
+	}
+
+	unaged := w.filter(now, false)
+	if unaged.MatchesFilters(info, path) {
+		if age := w.ageLimit(unaged, unaged.MatchedClause(info, path)); age > 0 {
+			if due := info.ModTime().Add(age); due.After(now) {
+				w.schedule(path, due)
+				return
+			}
+		}
+	}
+	delete(w.due, path)
+}
+
+// ageLimit returns how old a file selected by clause, which may be nil,
+// must be before it is cleaned
+func (w *Watcher) ageLimit(filter *filemanager.FileFilter, clause *filemanager.Clause) time.Duration {
+	age := w.spec.OlderThanAge
+	for i := range filter.Clauses {
+		if &filter.Clauses[i] == clause {
+			age = max(age, w.clauseAges[i])
+		}
+	}
+	return age
+}
+
+// schedule checks path again at due, reporting new and moved dates
+func (w *Watcher) schedule(path string, due time.Time) {
+	if previous, ok := w.due[path]; !ok || !previous.Equal(due) {
+		w.due[path] = due
+		if w.OnScheduled != nil {
+			w.OnScheduled(path, due)
+		}
+	}
+}
+
+// filter builds the file filter of the spec, as a one-off run does, at the
+// given time. Without withAge the older-than limits of the spec and of the
+// clauses that select files are left out, to find files that only need to
+// age.
+func (w *Watcher) filter(now time.Time, withAge bool) *filemanager.FileFilter {
+	filter := w.spec.fileFilter(w.fm)
+	filter.OlderThan, filter.NewerThan = time.Time{}, time.Time{}
+	if withAge && w.spec.OlderThanAge > 0 {
+		filter.OlderThan = now.Add(-w.spec.OlderThanAge)
+	}
+	if w.spec.NewerThanAge > 0 {
+		filter.NewerThan = now.Add(-w.spec.NewerThanAge)
+	}
+
+	// Access and change time limits move along with the clock as well
+	elapsed := now.Sub(w.started)
+	for _, limit := range []*time.Time{&filter.AccessedBefore, &filter.AccessedAfter, &filter.ChangedBefore, &filter.ChangedAfter} {
+		if !limit.IsZero() {
+			*limit = limit.Add(elapsed)
+		}
+	}
+
+	filter.Clauses = make([]filemanager.Clause, len(w.spec.Clauses))
+	for i, clause := range w.spec.Clauses {
+		clause.OlderThan = time.Time{}
+		if age := w.clauseAges[i]; age > 0 && (withAge || clause.Action == filemanager.ActionSkip) {
+			clause.OlderThan = now.Add(-age)
+		}
+		filter.Clauses[i] = clause
+	}
+	return filter
+}
+
+// remove applies the action of a matched file and records it
+func (w *Watcher) remove(ctx context.Context, entry filemanager.FileEntry) {
+	delete(w.due, entry.Path)
+
+	opType, err := ApplyAction(ctx, w.fm, entry, w.actions)
+	if err != nil || opType == logging.OperationIgnored {
+		return
+	}
+	w.journal.Record(logging.NewFileOperation(entry.Path, entry.Size, opType, "watch", entry.MatchedRule))
+	if !opType.Removes() {
+		w.result.Archived++
+	} else {
+		if w.spec.LogToFile {
+			utils.LogDeletionToFile([]utils.DeletionRecord{{Path: entry.Path, Size: entry.Size}})
+		}
+		w.result.FilesCleaned++
+		w.result.BytesCleared += entry.Size
+	}
+	if w.OnRemoved != nil {
+		w.OnRemoved(entry, opType)
+	}
+}
@@ -0,0 +1,146 @@
+//go:build linux
+// +build linux
+
+package cleanup
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+	"golang.org/x/sys/unix"
+)
+
+// inotifyMask selects the events that can make a file match: new, written,
+// moved in or touched files. Removals are watched too, so files that are
+// gone are dropped from the schedule.
+const inotifyMask = unix.IN_CREATE | unix.IN_CLOSE_WRITE | unix.IN_MOVED_TO | unix.IN_ATTRIB |
+	unix.IN_DELETE | unix.IN_MOVED_FROM
+
+// inotifyWatches maps watch descriptors to the directories they watch
+type inotifyWatches struct {
+	fd     int
+	dirs   map[int]string
+	walker *filemanager.TreeWalker // Decides which directories a scan reads
+}
+
+// add watches dir, and with recursive every directory below it, as far as
+// a scan of the watch root reads them. Only a failure to watch dir itself
+// is an error; unreadable subdirectories are skipped.
+func (w *inotifyWatches) add(ctx context.Context, dir string, recursive bool) error {
+	if !w.walker.Reads(dir) {
+		return nil
+	}
+	if err := w.addDir(dir); err != nil {
+		return err
+	}
+	if !recursive {
+		return nil
+	}
+
+	for _, path := range w.walker.Subdirs(ctx, dir) {
+		if errors.Is(w.addDir(path), unix.ENOSPC) {
+			break
+		}
+	}
+	return nil
+}
+
+func (w *inotifyWatches) addDir(dir string) error {
+	wd, err := unix.InotifyAddWatch(w.fd, dir, inotifyMask|unix.IN_ONLYDIR)
+	if errors.Is(err, unix.ENOSPC) {
+		return fmt.Errorf("cannot watch %s: inotify watch limit reached, raise fs.inotify.max_user_watches: %w", dir, err)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot watch %s: %w", dir, err)
+	}
+	w.dirs[wd] = dir
+	return nil
+}
+
+// watchEvents reports paths that changed below root on changed, starting
+// with root itself once the watches are in place. New subdirectories are
+// watched as they appear when recursive is set and walker reads them, so
+// directories beyond the scan limits are never watched. An event queue
+// overflow is reported as root so the whole tree is checked again. It
+// returns nil when ctx is done.
+func watchEvents(ctx context.Context, walker *filemanager.TreeWalker, root string, recursive bool, changed chan<- string) error {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("inotify: %w", err)
+	}
+	// A non-blocking descriptor goes through the runtime poller, so closing
+	// the file wakes up a pending Read
+	file := os.NewFile(uintptr(fd), "inotify")
+	defer file.Close()
+	stop := context.AfterFunc(ctx, func() { file.Close() })
+	defer stop()
+
+	watches := &inotifyWatches{fd: fd, dirs: make(map[int]string), walker: walker}
+	if err := watches.add(ctx, root, recursive); err != nil {
+		return err
+	}
+	if !sendChanged(ctx, changed, root) {
+		return nil
+	}
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("inotify: %w", err)
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			wd := int(int32(binary.NativeEndian.Uint32(buf[offset:])))
+			mask := binary.NativeEndian.Uint32(buf[offset+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+			name := strings.TrimRight(string(buf[offset+unix.SizeofInotifyEvent:offset+unix.SizeofInotifyEvent+nameLen]), "\x00")
+			offset += unix.SizeofInotifyEvent + nameLen
+
+			var path string
+			switch {
+			case mask&unix.IN_Q_OVERFLOW != 0:
+				path = root
+			case mask&unix.IN_IGNORED != 0:
+				delete(watches.dirs, wd)
+				continue
+			default:
+				dir, ok := watches.dirs[wd]
+				if !ok {
+					continue
+				}
+				path = filepath.Join(dir, name)
+			}
+
+			if mask&unix.IN_ISDIR != 0 {
+				if !recursive || mask&(unix.IN_CREATE|unix.IN_MOVED_TO) == 0 {
+					continue
+				}
+				// The directory may already be gone again
+				watches.add(ctx, path, true)
+			}
+			if !sendChanged(ctx, changed, path) {
+				return nil
+			}
+		}
+	}
+}
+
+// sendChanged delivers a changed path unless ctx is done first
+func sendChanged(ctx context.Context, changed chan<- string, path string) bool {
+	select {
+	case changed <- path:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
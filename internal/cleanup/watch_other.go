@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package cleanup
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+// watchEvents is only implemented on top of Linux inotify
+func watchEvents(ctx context.Context, walker *filemanager.TreeWalker, root string, recursive bool, changed chan<- string) error {
+	return errors.New("watch mode needs inotify and is only supported on Linux")
+}
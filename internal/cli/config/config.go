@@ -1,6 +1,9 @@
 package config
 
 import (
+	"time"
+
+	"github.com/pashkov256/deletor/internal/cleanup"
 	"github.com/pashkov256/deletor/internal/filemanager"
 	"github.com/pashkov256/deletor/internal/rules"
 	"github.com/pashkov256/deletor/internal/utils"
@@ -9,18 +12,34 @@ import (
 // Config holds all command-line configuration options for the application
 type Config struct {
 	filemanager.FileFilterOptions
-	Directory          string   // Target directory to process
-	Extensions         []string // File extensions to include
-	IncludeSubdirs     bool     // Whether to process subdirectories
-	ShowProgress       bool     // Whether to display progress
-	IsCLIMode          bool     // Whether running in CLI mode
-	HaveProgress       bool     // Whether progress tracking is available
-	SkipConfirm        bool     // Whether to skip confirmation prompts
-	DeleteEmptyFolders bool     // Whether to remove empty directories
-	MoveFileToTrash    bool     // If true, files will be moved to trash instead of being permanently deleted
-	UseRules           bool     // Whether to use rules from configuration file
-	JsonLogsEnabled    bool     // Whether to generates JSON-formatted logs
-	JsonLogsPath       string   // Path to append JSON-formatted logs
+	Directory          string                // Target directory to process
+	Extensions         []string              // File extensions to include
+	Presets            []string              // Built-in presets merged into the filter by Resolve
+	Directories        []rules.DirectoryRule // Whole directories to clean as one item
+	Clauses            []rules.Clause        // Ordered per-pattern policies, the first match decides a file's action
+	IncludeSubdirs     bool                  // Whether to process subdirectories
+	ShowProgress       bool                  // Whether to display progress
+	IsCLIMode          bool                  // Whether running in CLI mode
+	HaveProgress       bool                  // Whether progress tracking is available
+	SkipConfirm        bool                  // Whether to skip confirmation prompts
+	DeleteEmptyFolders bool                  // Whether to remove empty directories
+	DeleteBrokenLinks  bool                  // Whether to remove dangling symbolic links
+	DeleteEmptyFiles   bool                  // Whether to remove zero-byte files
+	MoveFileToTrash    bool                  // If true, files will be moved to trash instead of being permanently deleted
+	UseRules           bool                  // Whether to use rules from configuration file
+	JsonLogsEnabled    bool                  // Whether to generates JSON-formatted logs
+	JsonLogsPath       string                // Path to append JSON-formatted logs
+	UseIndex           bool                  // Whether to list unchanged directories from the scan index
+	SkipOpenFiles      bool                  // Whether to leave files held open by a process alone
+
+	Shred        bool                     // Whether to overwrite files before deleting them
+	ShredOptions filemanager.ShredOptions // Passes and method of the overwrite
+	Throttle     cleanup.Throttle         // CPU and I/O priority and rate limits of the run
+
+	Rotation filemanager.RotateOptions // Generations kept by the rotate action and whether open files are copied and truncated
+
+	Origins  map[string]Origin // Where each layered setting came from, filled by Resolve
+	setFlags map[string]string // Raw values of explicitly set flags keyed by setting name
 }
 
 // LoadConfig initializes and returns a new Config instance with values from command-line flags
@@ -33,56 +52,30 @@ func (c *Config) GetConfig() *Config {
 	return c
 }
 
+// BuildFileFilter builds the filter of the config. Clause ages are turned
+// into cutoff times at the moment of the call.
 func (c *Config) BuildFileFilter() *filemanager.FileFilter {
-	return filemanager.NewFileFilterWithOptions(c.FileFilterOptions, utils.ParseExtToMap(c.Extensions))
+	options := c.FileFilterOptions
+	// Already validated when the clauses were parsed
+	options.Clauses, _ = rules.CompileClauses(c.Clauses)
+	return filemanager.NewFileFilterWithOptions(options, utils.ParseExtToMap(c.Extensions))
 }
 
-// GetWithRules returns a value from config if it's set, otherwise returns value from rules
-func (c *Config) GetWithRules(rules rules.Rules) *Config {
-	if c == nil {
-		c = &Config{}
-	}
-
-	// Get all rules at once
-	defaultRules, err := rules.GetRules()
-	if err != nil {
-		return c
-	}
-
-	// Get values from rules if not set in config
-	if len(c.Extensions) == 0 {
-		c.Extensions = defaultRules.Extensions
-	}
-	if c.Directory == "" && defaultRules.Path != "" {
-		c.Directory = utils.ExpandTilde(defaultRules.Path)
-	} else if c.Directory != "" && defaultRules.Path != "" {
-		c.Directory = utils.ExpandTilde(c.Directory)
-	}
-	if c.MinSize == 0 && defaultRules.MinSize != "" {
-		c.MinSize = utils.ToBytesOrDefault(defaultRules.MinSize)
-	}
-	if c.MaxSize == 0 && defaultRules.MaxSize != "" {
-		c.MaxSize = utils.ToBytesOrDefault(defaultRules.MaxSize)
-	}
-	if len(c.Exclude) == 0 {
-		c.Exclude = defaultRules.Exclude
-	}
-	if c.OlderThan.IsZero() && defaultRules.OlderThan != "" {
-		c.OlderThan, _ = utils.ParseTimeDuration(defaultRules.OlderThan)
-	}
-	if c.NewerThan.IsZero() && defaultRules.NewerThan != "" {
-		c.NewerThan, _ = utils.ParseTimeDuration(defaultRules.NewerThan)
-	}
-	if !c.IncludeSubdirs {
-		c.IncludeSubdirs = defaultRules.IncludeSubfolders
-	}
-	if !c.DeleteEmptyFolders {
-
-		c.DeleteEmptyFolders = defaultRules.DeleteEmptySubfolders
-	}
-	if !c.MoveFileToTrash {
-		c.MoveFileToTrash = defaultRules.SendFilesToTrash
+// BuildDirTargets converts the directory rules into scanner targets. Ages are
+// turned into cutoff times at the moment of the call.
+func (c *Config) BuildDirTargets() []filemanager.DirTarget {
+	targets := make([]filemanager.DirTarget, 0, len(c.Directories))
+	for _, dir := range c.Directories {
+		var untouchedSince time.Time
+		if dir.UntouchedFor != "" {
+			// Already validated when the rule was parsed
+			untouchedSince, _ = utils.ParseTimeDuration(dir.UntouchedFor)
+		}
+		targets = append(targets, filemanager.DirTarget{
+			Pattern:        dir.Pattern,
+			RequireSibling: dir.RequireSibling,
+			UntouchedSince: untouchedSince,
+		})
 	}
-
-	return c
+	return targets
 }
@@ -3,10 +3,15 @@ package config_test
 import (
 	"flag"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/pashkov256/deletor/internal/cleanup"
 	"github.com/pashkov256/deletor/internal/cli/config"
+	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/path"
+	"github.com/pashkov256/deletor/internal/rules"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -136,6 +141,9 @@ func TestBooleanFlags(t *testing.T) {
 		{"--subdirs", func(c *config.Config) bool { return c.IncludeSubdirs }},
 		{"--skip-confirm", func(c *config.Config) bool { return c.SkipConfirm }},
 		{"--prune-empty", func(c *config.Config) bool { return c.DeleteEmptyFolders }},
+		{"--broken-links", func(c *config.Config) bool { return c.DeleteBrokenLinks }},
+		{"--empty-files", func(c *config.Config) bool { return c.DeleteEmptyFiles }},
+		{"--use-index", func(c *config.Config) bool { return c.UseIndex }},
 	}
 
 	for _, tc := range testCases {
@@ -195,3 +203,388 @@ func TestAllFlagsTogether(t *testing.T) {
 	assert.True(t, cfg.SkipConfirm)
 	assert.True(t, cfg.DeleteEmptyFolders)
 }
+
+// setupRulesDir points the rules manager at a temporary config location
+func setupRulesDir(t *testing.T) {
+	t.Helper()
+	origAppDirName := path.AppDirName
+	path.AppDirName = "deletor_config_layers_test"
+	t.Cleanup(func() {
+		userConfigDir, _ := os.UserConfigDir()
+		os.RemoveAll(filepath.Join(userConfigDir, path.AppDirName))
+		path.AppDirName = origAppDirName
+	})
+}
+
+// TestResolveFlagFalseOverridesRules verifies explicitly set false flags win over rules
+func TestResolveFlagFalseOverridesRules(t *testing.T) {
+	setupRulesDir(t)
+	r := rules.NewRules()
+	assert.NoError(t, r.UpdateRules(
+		rules.WithExtensions([]string{".log"}),
+		rules.WithOptions(false, false, true, false, true, false, false, true, false, false),
+	))
+
+	cfg, err := config.ParseArgs("test", []string{"-d", t.TempDir(), "--rules", "--subdirs=false"})
+	assert.NoError(t, err)
+
+	resolved, err := cfg.Resolve(r)
+	assert.NoError(t, err)
+
+	assert.False(t, resolved.IncludeSubdirs)
+	assert.Equal(t, config.SourceFlag, resolved.Origins["subdirs"].Source)
+	assert.True(t, resolved.MoveFileToTrash)
+	assert.Equal(t, config.SourceUserRules, resolved.Origins["trash"].Source)
+	assert.Equal(t, []string{".log"}, resolved.Extensions)
+}
+
+// TestResolveProjectAndEnvLayers verifies project files are found upwards and env overrides them
+func TestResolveProjectAndEnvLayers(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "a", "b")
+	assert.NoError(t, os.MkdirAll(target, 0755))
+	projectFile := filepath.Join(root, path.ProjectConfigFileName)
+	assert.NoError(t, os.WriteFile(projectFile, []byte(`{"Extensions":["tmp"],"MinSize":"1kb","IncludeSubfolders":true}`), 0644))
+
+	t.Setenv("DELETOR_MIN_SIZE", "2kb")
+
+	cfg, err := config.ParseArgs("test", []string{"-d", target})
+	assert.NoError(t, err)
+
+	resolved, err := cfg.Resolve(rules.NewRules())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{".tmp"}, resolved.Extensions)
+	assert.Equal(t, config.Origin{Source: config.SourceProject, Detail: projectFile, Raw: "tmp"}, resolved.Origins["extensions"])
+	assert.True(t, resolved.IncludeSubdirs)
+	assert.Equal(t, int64(2*1024), resolved.MinSize)
+	assert.Equal(t, config.Origin{Source: config.SourceEnv, Detail: "DELETOR_MIN_SIZE", Raw: "2kb"}, resolved.Origins["min-size"])
+	assert.Equal(t, config.SourceFlag, resolved.Origins["directory"].Source)
+	assert.Equal(t, config.SourceDefault, resolved.Origins["trash"].Source)
+}
+
+// TestResolveFilesystemSettings verifies the mount options layer like other settings
+func TestResolveFilesystemSettings(t *testing.T) {
+	root := t.TempDir()
+	projectFile := filepath.Join(root, path.ProjectConfigFileName)
+	assert.NoError(t, os.WriteFile(projectFile, []byte(`{"OneFileSystem":true,"SkipFilesystems":["network"]}`), 0644))
+
+	t.Setenv("DELETOR_SKIP_FS", "Network,fuse.*")
+
+	cfg, err := config.ParseArgs("test", []string{"-d", root})
+	assert.NoError(t, err)
+	resolved, err := cfg.Resolve(rules.NewRules())
+	assert.NoError(t, err)
+
+	assert.True(t, resolved.OneFileSystem)
+	assert.Equal(t, config.SourceProject, resolved.Origins["one-file-system"].Source)
+	assert.Equal(t, []string{"network", "fuse.*"}, resolved.SkipFilesystems)
+	assert.Equal(t, config.SourceEnv, resolved.Origins["skip-fs"].Source)
+
+	filter := resolved.BuildFileFilter()
+	assert.True(t, filter.OneFileSystem)
+	assert.Equal(t, []string{"network", "fuse.*"}, filter.SkipFilesystems)
+
+	_, err = config.ParseArgs("test", []string{"--skip-fs", "nfs["})
+	assert.ErrorContains(t, err, "invalid filesystem pattern")
+}
+
+// TestResolveSymlinkPolicy verifies the symlink policy layers and is validated
+func TestResolveSymlinkPolicy(t *testing.T) {
+	root := t.TempDir()
+	projectFile := filepath.Join(root, path.ProjectConfigFileName)
+	assert.NoError(t, os.WriteFile(projectFile, []byte(`{"Symlinks":"broken"}`), 0644))
+
+	cfg, err := config.ParseArgs("test", []string{"-d", root})
+	assert.NoError(t, err)
+	resolved, err := cfg.Resolve(rules.NewRules())
+	assert.NoError(t, err)
+	assert.Equal(t, filemanager.SymlinkBroken, resolved.BuildFileFilter().Symlinks)
+	assert.Equal(t, config.SourceProject, resolved.Origins["symlinks"].Source)
+
+	cfg, err = config.ParseArgs("test", []string{"-d", root, "--symlinks", "follow"})
+	assert.NoError(t, err)
+	resolved, err = cfg.Resolve(rules.NewRules())
+	assert.NoError(t, err)
+	assert.Equal(t, filemanager.SymlinkFollow, resolved.Symlinks)
+	assert.Equal(t, config.SourceFlag, resolved.Origins["symlinks"].Source)
+
+	_, err = config.ParseArgs("test", []string{"--symlinks", "always"})
+	assert.ErrorContains(t, err, "unknown symlink policy")
+}
+
+// TestStatTimeFlags verifies the access and change time flags and their env variables
+func TestStatTimeFlags(t *testing.T) {
+	cfg, err := config.ParseArgs("test", []string{"--accessed-before", "90day", "--changed-after", "1hour"})
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(-90*24*time.Hour), cfg.AccessedBefore, 5*time.Second)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), cfg.ChangedAfter, 5*time.Second)
+	assert.True(t, cfg.AccessedAfter.IsZero())
+
+	t.Setenv("DELETOR_ACCESSED_AFTER", "1week")
+	cfg, err = config.ParseArgs("test", []string{"-d", t.TempDir()})
+	assert.NoError(t, err)
+	resolved, err := cfg.Resolve(nil)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(-7*24*time.Hour), resolved.BuildFileFilter().AccessedAfter, 5*time.Second)
+	assert.Equal(t, config.SourceEnv, resolved.Origins["accessed-after"].Source)
+
+	_, err = config.ParseArgs("test", []string{"--changed-before", "3fortnights"})
+	assert.ErrorContains(t, err, "invalid changed-before")
+}
+
+// TestOwnerFlags verifies owner names resolve to IDs and permissions parse find-style
+func TestOwnerFlags(t *testing.T) {
+	cfg, err := config.ParseArgs("test", []string{"--owner", "root", "--not-owner", "0", "--uid", "1000,1001", "--group", "0", "--perm", "-o+w"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0}, cfg.Owners)
+	assert.Equal(t, []int{0}, cfg.NotOwners)
+	assert.Equal(t, []int{1000, 1001}, cfg.UIDs)
+	assert.Equal(t, []int{0}, cfg.Groups)
+	assert.Equal(t, uint32(0002), cfg.Perm.Bits)
+	assert.Equal(t, filemanager.PermAll, cfg.Perm.Match)
+
+	t.Setenv("DELETOR_PERM", "/u+s")
+	cfg, err = config.ParseArgs("test", []string{"-d", t.TempDir()})
+	assert.NoError(t, err)
+	resolved, err := cfg.Resolve(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "/u+s", resolved.BuildFileFilter().Perm.String())
+	assert.Equal(t, config.SourceEnv, resolved.Origins["perm"].Source)
+
+	_, err = config.ParseArgs("test", []string{"--uid", "alice"})
+	assert.ErrorContains(t, err, "not a numeric user ID")
+
+	_, err = config.ParseArgs("test", []string{"--owner", "no-such-user-here"})
+	assert.ErrorContains(t, err, "unknown user")
+
+	_, err = config.ParseArgs("test", []string{"--perm", "o+q"})
+	assert.ErrorContains(t, err, "invalid perm")
+}
+
+// TestWhereFlag verifies --where parses into the filter and reports the column of errors
+func TestWhereFlag(t *testing.T) {
+	cfg, err := config.ParseArgs("test", []string{"-d", t.TempDir(), "--where", `ext in (log,tmp) and not path ~ "keep/**"`})
+	assert.NoError(t, err)
+	resolved, err := cfg.Resolve(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `ext in (log, tmp) and not path ~ keep/**`, resolved.BuildFileFilter().Where.String())
+	assert.Equal(t, config.SourceFlag, resolved.Origins["where"].Source)
+
+	_, err = config.ParseArgs("test", []string{"--where", "ext in (log) and agee > 7d"})
+	assert.ErrorContains(t, err, `invalid where: column 18: unknown field "agee"`)
+}
+
+func TestClausesFlag(t *testing.T) {
+	cfg, err := config.ParseArgs("test", []string{"-d", t.TempDir(), "--clauses", "*.log:older=7d:action=archive, *.tmp:older=1d:action=delete"})
+	assert.NoError(t, err)
+	resolved, err := cfg.Resolve(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "*.log:older=7d:action=archive,*.tmp:older=1d:action=delete", resolved.FormatValue("clauses"))
+	assert.Equal(t, config.SourceFlag, resolved.Origins["clauses"].Source)
+
+	clauses := resolved.BuildFileFilter().Clauses
+	if assert.Len(t, clauses, 2) {
+		assert.Equal(t, filemanager.ActionArchive, clauses[0].Action)
+		assert.WithinDuration(t, time.Now().Add(-7*24*time.Hour), clauses[0].OlderThan, time.Minute)
+		assert.Equal(t, "*.tmp:older=1d:action=delete", clauses[1].Spec)
+	}
+
+	t.Setenv("DELETOR_CLAUSES", "*.iso:older=90d:action=trash")
+	resolved, err = (&config.Config{Directory: t.TempDir()}).Resolve(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "*.iso:older=90d:action=trash", resolved.FormatValue("clauses"))
+
+	_, err = config.ParseArgs("test", []string{"--clauses", "*.log:action=burn"})
+	assert.ErrorContains(t, err, `invalid clauses: clause "*.log": unknown action "burn"`)
+	_, err = config.ParseArgs("test", []string{"--clauses", "*.iso:action=skip"})
+	assert.ErrorContains(t, err, "every clause skips")
+}
+
+// TestTypeFlag verifies --type checks its patterns and reaches the filter
+func TestTypeFlag(t *testing.T) {
+	cfg, err := config.ParseArgs("test", []string{"-d", t.TempDir(), "--type", "Image/*,archive"})
+	assert.NoError(t, err)
+	resolved, err := cfg.Resolve(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"image/*", "archive"}, resolved.BuildFileFilter().ContentTypes)
+	assert.Equal(t, "image/*,archive", resolved.FormatValue("type"))
+
+	_, err = config.ParseArgs("test", []string{"--type", "pictures"})
+	assert.ErrorContains(t, err, `invalid type: unknown content type "pictures"`)
+}
+
+// TestDepthFlags verifies the walk limits layer like other settings and are checked together
+func TestDepthFlags(t *testing.T) {
+	t.Setenv("DELETOR_MAX_DIR_ENTRIES", "5000")
+	cfg, err := config.ParseArgs("test", []string{"-d", t.TempDir(), "--subdirs", "--max-depth", "3", "--min-depth", "2"})
+	assert.NoError(t, err)
+	resolved, err := cfg.Resolve(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, filemanager.WalkLimits{MinDepth: 2, MaxDepth: 3, MaxDirEntries: 5000}, resolved.BuildFileFilter().WalkLimits)
+	assert.Equal(t, config.SourceEnv, resolved.Origins["max-dir-entries"].Source)
+
+	_, err = config.ParseArgs("test", []string{"--max-depth", "-1"})
+	assert.ErrorContains(t, err, "invalid max-depth")
+
+	cfg, err = config.ParseArgs("test", []string{"-d", t.TempDir(), "--max-depth", "2", "--min-depth", "3"})
+	assert.NoError(t, err)
+	_, err = cfg.Resolve(nil)
+	assert.ErrorContains(t, err, "minimum depth 3 is beyond the maximum depth 2")
+}
+
+func TestShredFlags(t *testing.T) {
+	t.Setenv("DELETOR_SHRED_METHOD", "dod")
+	cfg, err := config.ParseArgs("test", []string{"-d", t.TempDir(), "--shred", "--shred-passes", "7"})
+	assert.NoError(t, err)
+	resolved, err := cfg.Resolve(nil)
+	assert.NoError(t, err)
+	assert.True(t, resolved.Shred)
+	assert.Equal(t, filemanager.ShredOptions{Passes: 7, Method: filemanager.ShredDoD}, resolved.ShredOptions)
+	assert.Equal(t, config.SourceEnv, resolved.Origins["shred-method"].Source)
+
+	resolved, err = (&config.Config{Directory: t.TempDir()}).Resolve(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, filemanager.ShredOptions{Passes: filemanager.DefaultShredPasses, Method: filemanager.ShredDoD}, resolved.ShredOptions)
+
+	_, err = config.ParseArgs("test", []string{"--shred-passes", "0"})
+	assert.ErrorContains(t, err, "invalid shred-passes")
+	_, err = config.ParseArgs("test", []string{"--shred-method", "gutmann"})
+	assert.ErrorContains(t, err, "unknown shred method")
+
+	cfg, err = config.ParseArgs("test", []string{"-d", t.TempDir(), "--shred", "--trash"})
+	assert.NoError(t, err)
+	_, err = cfg.Resolve(nil)
+	assert.ErrorContains(t, err, "both shredded and moved to trash")
+}
+
+func TestThrottleFlags(t *testing.T) {
+	t.Setenv("DELETOR_BWLIMIT", "20MB/s")
+	cfg, err := config.ParseArgs("test", []string{"-d", t.TempDir(), "--ionice", "idle", "--nice", "10", "--rate-limit", "600/min"})
+	assert.NoError(t, err)
+	resolved, err := cfg.Resolve(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, cleanup.Throttle{IONice: "idle", Nice: 10, RateLimit: 10, BWLimit: 20 * 1024 * 1024}, resolved.Throttle)
+	assert.Equal(t, config.SourceEnv, resolved.Origins["bwlimit"].Source)
+	assert.Equal(t, "10/s", resolved.FormatValue("rate-limit"))
+
+	_, err = config.ParseArgs("test", []string{"--ionice", "realtime"})
+	assert.ErrorContains(t, err, "invalid ionice")
+	_, err = config.ParseArgs("test", []string{"--nice", "20"})
+	assert.ErrorContains(t, err, "invalid nice")
+	_, err = config.ParseArgs("test", []string{"--rate-limit", "5/fortnight"})
+	assert.ErrorContains(t, err, "invalid rate-limit")
+}
+
+// TestRotationFlags verifies --rotate-keep and --copytruncate
+func TestRotationFlags(t *testing.T) {
+	cfg, err := config.ParseArgs("test", []string{"-d", t.TempDir(), "--rotate-keep", "7", "--copytruncate"})
+	assert.NoError(t, err)
+	resolved, err := cfg.Resolve(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, filemanager.RotateOptions{Keep: 7, CopyTruncate: true}, resolved.Rotation)
+
+	resolved, err = (&config.Config{Directory: t.TempDir()}).Resolve(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, filemanager.DefaultRotateKeep, resolved.Rotation.Keep)
+
+	_, err = config.ParseArgs("test", []string{"--rotate-keep", "0"})
+	assert.ErrorContains(t, err, "invalid rotate-keep")
+}
+
+// TestSkipOpenFlag verifies --skip-open and its environment variable
+func TestSkipOpenFlag(t *testing.T) {
+	cfg, err := config.ParseArgs("test", []string{"-d", t.TempDir(), "--skip-open"})
+	assert.NoError(t, err)
+	resolved, err := cfg.Resolve(nil)
+	assert.NoError(t, err)
+	assert.True(t, resolved.SkipOpenFiles)
+	assert.Equal(t, config.SourceFlag, resolved.Origins["skip-open"].Source)
+
+	t.Setenv("DELETOR_SKIP_OPEN", "true")
+	resolved, err = (&config.Config{Directory: t.TempDir()}).Resolve(nil)
+	assert.NoError(t, err)
+	assert.True(t, resolved.SkipOpenFiles)
+	assert.Equal(t, "true", resolved.FormatValue("skip-open"))
+}
+
+// TestResolveInvalidEnv verifies invalid env values name the variable
+func TestResolveInvalidEnv(t *testing.T) {
+	t.Setenv("DELETOR_SUBDIRS", "maybe")
+
+	_, err := (&config.Config{Directory: t.TempDir()}).Resolve(nil)
+	assert.ErrorContains(t, err, "DELETOR_SUBDIRS")
+}
+
+// TestResolveLiteralConfig verifies configs built without flags keep their non-zero values
+func TestResolveLiteralConfig(t *testing.T) {
+	dir := t.TempDir()
+	resolved, err := (&config.Config{Directory: dir, IncludeSubdirs: true, SkipConfirm: true}).Resolve(nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, dir, resolved.Directory)
+	assert.True(t, resolved.IncludeSubdirs)
+	assert.True(t, resolved.SkipConfirm)
+	assert.Equal(t, config.SourceFlag, resolved.Origins["subdirs"].Source)
+}
+
+// TestResolvePresets verifies presets merge their patterns and only set an unset age limit
+func TestResolvePresets(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := config.ParseArgs("test", []string{"-d", dir, "--preset", "node,OS,editor", "--include", "*.bak"})
+	assert.NoError(t, err)
+
+	resolved, err := cfg.Resolve(nil)
+	assert.NoError(t, err)
+
+	// Presets neither widen the include patterns nor set an age for every file
+	assert.Equal(t, []string{"*.bak"}, resolved.Include)
+	assert.Empty(t, resolved.Exclude)
+	assert.True(t, resolved.OlderThan.IsZero())
+	assert.Equal(t, config.SourceDefault, resolved.Origins["older"].Source)
+	assert.Equal(t, []rules.DirectoryRule{{Pattern: "node_modules", RequireSibling: "package.json", UntouchedFor: "30d"}}, resolved.Directories)
+	assert.Equal(t, config.Origin{Source: config.SourcePreset, Detail: "node,os,editor", Raw: "node_modules:requires=package.json:untouched=30d"}, resolved.Origins["dirs"])
+
+	twoDaysAgo := time.Now().Add(-48 * time.Hour)
+	files := map[string]time.Time{
+		".DS_Store":                   time.Now(),
+		"fresh.swp":                   time.Now(),
+		"stale.swp":                   twoDaysAgo,
+		"notes.bak":                   time.Now(),
+		"readme.txt":                  twoDaysAgo,
+		"node_modules/lib/index.js":   twoDaysAgo,
+		"node_modules/lib/.DS_Store":  time.Now(),
+		"node_modules/lib/README.swp": time.Now(),
+	}
+	filter := resolved.BuildFileFilter()
+	matched := map[string]string{}
+	for name, modTime := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+		info, err := os.Stat(path)
+		assert.NoError(t, err)
+		if filter.MatchesFilters(info, path) {
+			matched[name] = filter.MatchedRule(info, path)
+		}
+	}
+	assert.Equal(t, map[string]string{
+		".DS_Store":                  "preset os",
+		"stale.swp":                  "preset editor",
+		"notes.bak":                  "*.bak",
+		"node_modules/lib/.DS_Store": "preset os",
+	}, matched)
+
+	cfg, err = config.ParseArgs("test", []string{"-d", t.TempDir(), "--preset", "editor", "--older", "3d"})
+	assert.NoError(t, err)
+	resolved, err = cfg.Resolve(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, config.SourceFlag, resolved.Origins["older"].Source)
+	assert.Len(t, resolved.PresetTerms, 1)
+
+	cfg, err = config.ParseArgs("test", []string{"-d", t.TempDir(), "--preset", "nope"})
+	assert.NoError(t, err)
+	_, err = cfg.Resolve(nil)
+	assert.ErrorContains(t, err, `unknown preset "nope"`)
+}
@@ -4,31 +4,92 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/pashkov256/deletor/internal/utils"
 )
 
 // GetFlags parses command-line flags and returns a Config instance with the parsed values.
 func GetFlags() *Config {
+	config, err := parseFlags(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fmt.Printf("Error %v\n", err)
+		os.Exit(1)
+	}
+	return config
+}
+
+// ParseArgs parses the given arguments with a dedicated flag set, which lets
+// subcommands accept the same flags as the main command.
+func ParseArgs(name string, args []string) (*Config, error) {
+	return parseFlags(flag.NewFlagSet(name, flag.ContinueOnError), args)
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) (*Config, error) {
 	config := &Config{}
 
-	extensions := flag.String("e", "", "File extensions to delete (comma-separated)")
-	excludeFlag := flag.String("exclude", "", "Exclude specific files/paths (e.g. data,backup)")
-	minSize := flag.String("min-size", "", "Minimum file size to delete (e.g. 10kb, 10mb, 10b)")
-	maxSize := flag.String("max-size", "", "Maximum file size to delete (e.g. 10kb, 10mb, 10b)")
-	dir := flag.String("d", ".", "Directory to scan")
-	includeSubdirsScan := flag.Bool("subdirs", false, "Include subdirectories in scan")
-	isCLIMode := flag.Bool("cli", false, "CLI mode (default is TUI)")
-	progress := flag.Bool("progress", false, "Display a progress bar during file scanning")
-	deleteEmptyFolders := flag.Bool("prune-empty", false, "Delete empty folders after scan")
-	skipConfirm := flag.Bool("skip-confirm", false, "Skip the confirmation of deletion?")
-	older := flag.String("older", "", "Modification time older than (e.g. 1sec, 2min, 3hour, 4day, 5week, 6month, 7year)")
-	newer := flag.String("newer", "", "Modification time newer than (e.g. 1sec, 2min, 3hour, 4day, 5week, 6month, 7year)")
-	moveToTrash := flag.Bool("trash", false, "Move files to trash?")
-	useRules := flag.Bool("rules", false, "Use rules from configuration file")
-	jsonLogsEnabled := flag.Bool("log-json", false, "Enable JSON-formatted logging. Use --log-json or --log-json \"/path/to/file\" to specify a path to write logs.")
-
-	flag.Parse()
+	extensions := fs.String("e", "", "File extensions to delete (comma-separated)")
+	excludeFlag := fs.String("exclude", "", "Exclude specific files/paths (e.g. data,backup)")
+	include := fs.String("include", "", "Only include file names, or directories with a trailing /, matching these globs (e.g. '*.swp,node_modules/')")
+	preset := fs.String("preset", "", "Apply built-in presets (comma-separated, see 'deletor presets')")
+	dirs := fs.String("dirs", "", "Delete whole directories by name (e.g. 'node_modules:requires=package.json:untouched=60d,target')")
+	minSize := fs.String("min-size", "", "Minimum file size to delete (e.g. 10kb, 10mb, 10b)")
+	maxSize := fs.String("max-size", "", "Maximum file size to delete (e.g. 10kb, 10mb, 10b)")
+	dir := fs.String("d", ".", "Directory to scan")
+	includeSubdirsScan := fs.Bool("subdirs", false, "Include subdirectories in scan")
+	maxDepth := fs.String("max-depth", "", "With -subdirs, do not look more than N levels below the directory (1 is the directory itself)")
+	minDepth := fs.String("min-depth", "", "With -subdirs, only match files at least N levels below the directory")
+	maxDirEntries := fs.String("max-dir-entries", "", "Do not descend into subdirectories holding more than N entries")
+	isCLIMode := fs.Bool("cli", false, "CLI mode (default is TUI)")
+	progress := fs.Bool("progress", false, "Display a progress bar during file scanning")
+	useIndex := fs.Bool("use-index", false, "Serve unchanged directories from the on-disk scan index")
+	deleteEmptyFolders := fs.Bool("prune-empty", false, "Delete empty folders after scan")
+	deleteBrokenLinks := fs.Bool("broken-links", false, "Find and delete dangling symbolic links after scan")
+	deleteEmptyFiles := fs.Bool("empty-files", false, "Find and delete zero-byte files after scan")
+	skipConfirm := fs.Bool("skip-confirm", false, "Skip the confirmation of deletion?")
+	older := fs.String("older", "", "Modification time older than (e.g. 1sec, 2min, 3hour, 4day, 5week, 6month, 7year)")
+	newer := fs.String("newer", "", "Modification time newer than (e.g. 1sec, 2min, 3hour, 4day, 5week, 6month, 7year)")
+	accessedBefore := fs.String("accessed-before", "", "Last access time older than (e.g. 1sec, 2min, 3hour, 4day, 5week, 6month, 7year)")
+	accessedAfter := fs.String("accessed-after", "", "Last access time newer than (e.g. 1sec, 2min, 3hour, 4day, 5week, 6month, 7year)")
+	changedBefore := fs.String("changed-before", "", "Inode change time older than (e.g. 1sec, 2min, 3hour, 4day, 5week, 6month, 7year)")
+	changedAfter := fs.String("changed-after", "", "Inode change time newer than (e.g. 1sec, 2min, 3hour, 4day, 5week, 6month, 7year)")
+	owner := fs.String("owner", "", "Only files owned by these users, by name or ID (comma-separated)")
+	notOwner := fs.String("not-owner", "", "Skip files owned by these users, by name or ID (comma-separated)")
+	uid := fs.String("uid", "", "Only files owned by these numeric user IDs (comma-separated)")
+	group := fs.String("group", "", "Only files whose group is one of these, by name or ID (comma-separated)")
+	perm := fs.String("perm", "", "Permission bits as in find -perm: exactly 644, all of -o+w, or any of /022")
+	where := fs.String("where", "", `Filter expression files must also match, e.g. 'ext in (log,tmp) and age > 7d and not path ~ "keep/**"'`)
+	clauses := fs.String("clauses", "", "Ordered per-pattern policies, the first match wins (e.g. '*.log:older=7d:action=archive,*.tmp:older=1d:action=delete')")
+	contentType := fs.String("type", "", "Only files whose sniffed content is one of these MIME types or categories, e.g. image/*,video/*,archive")
+	moveToTrash := fs.Bool("trash", false, "Move files to trash?")
+	shred := fs.Bool("shred", false, "Overwrite files before deleting them (ineffective on copy-on-write filesystems and SSDs)")
+	shredPasses := fs.String("shred-passes", "", "With -shred, number of overwrite passes (default 3)")
+	shredMethod := fs.String("shred-method", "", "With -shred, data to overwrite with: zeros, random (default) or dod")
+	rotateKeep := fs.String("rotate-keep", "", "Generations kept by the rotate clause action (default 5)")
+	copyTruncate := fs.Bool("copytruncate", false, "Rotate files held open by copying and truncating them instead of renaming them")
+	ionice := fs.String("ionice", "", "I/O priority: idle, or best-effort:LEVEL from 0 (highest) to 7")
+	nice := fs.String("nice", "", "CPU niceness from 1 to 19, higher yields more to other processes")
+	rateLimit := fs.String("rate-limit", "", "Remove at most this many files per time unit (e.g. 200/s, 1000/min)")
+	bwLimit := fs.String("bwlimit", "", "Read or write at most this much per second when archiving or shredding (e.g. 20MB/s)")
+	skipOpen := fs.Bool("skip-open", false, "Skip files a process holds open and report space held by deleted files still open (Linux only)")
+	useRules := fs.Bool("rules", false, "Use rules from configuration file")
+	oneFileSystem := fs.Bool("one-file-system", false, "Do not cross into other filesystems or mount points below the directory")
+	skipFS := fs.String("skip-fs", "", "Do not enter mounts of these filesystem types or groups (e.g. 'network,pseudo,fuse.*')")
+	symlinks := fs.String("symlinks", "", "Symbolic link policy: never (default), follow, or broken to list dangling links separately")
+	jsonLogsEnabled := fs.Bool("log-json", false, "Enable JSON-formatted logging. Use --log-json or --log-json \"/path/to/file\" to specify a path to write logs.")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Remember which layered settings were set explicitly, so that values
+	// like --subdirs=false can override lower config layers.
+	config.setFlags = make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		if s, ok := settingByFlag(f.Name); ok {
+			config.setFlags[s.Key] = f.Value.String()
+		}
+	})
 
 	*dir = utils.ExpandTilde(*dir)
 
@@ -37,6 +98,118 @@ func GetFlags() *Config {
 		config.Exclude = utils.ParseExcludeToSlice(*excludeFlag)
 	}
 
+	if *include != "" {
+		if err := config.setValue("include", *include); err != nil {
+			return nil, err
+		}
+	}
+
+	if *dirs != "" {
+		if err := config.setValue("dirs", *dirs); err != nil {
+			return nil, err
+		}
+	}
+
+	if *skipFS != "" {
+		if err := config.setValue("skip-fs", *skipFS); err != nil {
+			return nil, err
+		}
+	}
+
+	if *symlinks != "" {
+		if err := config.setValue("symlinks", *symlinks); err != nil {
+			return nil, err
+		}
+	}
+
+	if *accessedBefore != "" {
+		if err := config.setValue("accessed-before", *accessedBefore); err != nil {
+			return nil, err
+		}
+	}
+
+	if *accessedAfter != "" {
+		if err := config.setValue("accessed-after", *accessedAfter); err != nil {
+			return nil, err
+		}
+	}
+
+	if *changedBefore != "" {
+		if err := config.setValue("changed-before", *changedBefore); err != nil {
+			return nil, err
+		}
+	}
+
+	if *changedAfter != "" {
+		if err := config.setValue("changed-after", *changedAfter); err != nil {
+			return nil, err
+		}
+	}
+
+	if *owner != "" {
+		if err := config.setValue("owner", *owner); err != nil {
+			return nil, err
+		}
+	}
+
+	if *notOwner != "" {
+		if err := config.setValue("not-owner", *notOwner); err != nil {
+			return nil, err
+		}
+	}
+
+	if *uid != "" {
+		if err := config.setValue("uid", *uid); err != nil {
+			return nil, err
+		}
+	}
+
+	if *group != "" {
+		if err := config.setValue("group", *group); err != nil {
+			return nil, err
+		}
+	}
+
+	if *perm != "" {
+		if err := config.setValue("perm", *perm); err != nil {
+			return nil, err
+		}
+	}
+	if *where != "" {
+		if err := config.setValue("where", *where); err != nil {
+			return nil, err
+		}
+	}
+	for key, value := range map[string]string{"max-depth": *maxDepth, "min-depth": *minDepth, "max-dir-entries": *maxDirEntries} {
+		if value != "" {
+			if err := config.setValue(key, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for key, value := range map[string]string{"shred-passes": *shredPasses, "shred-method": *shredMethod, "rotate-keep": *rotateKeep,
+		"ionice": *ionice, "nice": *nice, "rate-limit": *rateLimit, "bwlimit": *bwLimit} {
+		if value != "" {
+			if err := config.setValue(key, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if *contentType != "" {
+		if err := config.setValue("type", *contentType); err != nil {
+			return nil, err
+		}
+	}
+	if *clauses != "" {
+		if err := config.setValue("clauses", *clauses); err != nil {
+			return nil, err
+		}
+	}
+
+	if *preset != "" {
+		config.Presets = utils.ParseExcludeToSlice(strings.ToLower(*preset))
+	}
+
 	// Convert extensions to slice
 	if *extensions != "" {
 		config.Extensions = utils.ParseExtToSlice(*extensions)
@@ -46,8 +219,7 @@ func GetFlags() *Config {
 	if *minSize != "" {
 		sizeBytes, err := utils.ToBytes(*minSize)
 		if err != nil {
-			fmt.Printf("Error parsing size: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("parsing size: %w", err)
 		}
 		config.MinSize = sizeBytes
 	}
@@ -56,8 +228,7 @@ func GetFlags() *Config {
 	if *maxSize != "" {
 		sizeBytes, err := utils.ToBytes(*maxSize)
 		if err != nil {
-			fmt.Printf("Error parsing size: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("parsing size: %w", err)
 		}
 		config.MaxSize = sizeBytes
 	}
@@ -66,8 +237,7 @@ func GetFlags() *Config {
 	if *older != "" {
 		olderThan, err := utils.ParseTimeDuration(*older)
 		if err != nil {
-			fmt.Printf("Error parsing older: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("parsing older: %w", err)
 		}
 		config.OlderThan = olderThan
 	}
@@ -76,8 +246,7 @@ func GetFlags() *Config {
 	if *newer != "" {
 		newerThan, err := utils.ParseTimeDuration(*newer)
 		if err != nil {
-			fmt.Printf("Error parsing newer: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("parsing newer: %w", err)
 		}
 		config.NewerThan = newerThan
 	}
@@ -85,18 +254,25 @@ func GetFlags() *Config {
 	// Get file path for outputting Json logs
 	if *jsonLogsEnabled {
 		config.JsonLogsEnabled = true
-		config.JsonLogsPath = utils.ParseJsonLogsPath(os.Args[1:], "--log-json")
+		config.JsonLogsPath = utils.ParseJsonLogsPath(args, "--log-json")
 	}
 
 	config.IsCLIMode = *isCLIMode
 	config.ShowProgress = *progress
 	config.HaveProgress = *progress
+	config.UseIndex = *useIndex
 	config.IncludeSubdirs = *includeSubdirsScan
 	config.Directory = *dir
 	config.SkipConfirm = *skipConfirm
 	config.DeleteEmptyFolders = *deleteEmptyFolders
+	config.DeleteBrokenLinks = *deleteBrokenLinks
+	config.DeleteEmptyFiles = *deleteEmptyFiles
 	config.MoveFileToTrash = *moveToTrash
+	config.Shred = *shred
+	config.SkipOpenFiles = *skipOpen
+	config.Rotation.CopyTruncate = *copyTruncate
 	config.UseRules = *useRules
+	config.OneFileSystem = *oneFileSystem
 
-	return config
+	return config, nil
 }
@@ -0,0 +1,1195 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/path"
+	"github.com/pashkov256/deletor/internal/rules"
+	"github.com/pashkov256/deletor/internal/utils"
+)
+
+// Source identifies the configuration layer an effective value came from.
+// Layers are listed from lowest to highest precedence.
+type Source string
+
+const (
+	SourceDefault   Source = "default"    // Built-in default value
+	SourceUserRules Source = "user rules" // User-level rule file (--rules)
+	SourceProject   Source = "project"    // Project .deletor.json found above the target
+	SourceEnv       Source = "env"        // DELETOR_* environment variable
+	SourceFlag      Source = "flag"       // Command-line flag
+	SourcePreset    Source = "preset"     // Built-in preset selected in any layer
+)
+
+// Origin records where an effective setting value came from
+type Origin struct {
+	Source Source // Layer that provided the value
+	Detail string // Flag name, env variable or file path of the layer
+	Raw    string // Value as written in that layer
+}
+
+// setting describes one layered configuration value and its names in every layer
+type setting struct {
+	Key  string // Canonical name, also used by `config explain`
+	Flag string // Command-line flag name
+	Env  string // Environment variable name
+}
+
+// settings lists all values resolved through the config layers, in display order
+var settings = []setting{
+	{Key: "directory", Flag: "d", Env: "DELETOR_DIR"},
+	{Key: "extensions", Flag: "e", Env: "DELETOR_EXTENSIONS"},
+	{Key: "exclude", Flag: "exclude", Env: "DELETOR_EXCLUDE"},
+	{Key: "include", Flag: "include", Env: "DELETOR_INCLUDE"},
+	{Key: "preset", Flag: "preset", Env: "DELETOR_PRESET"},
+	{Key: "dirs", Flag: "dirs", Env: "DELETOR_DIRS"},
+	{Key: "min-size", Flag: "min-size", Env: "DELETOR_MIN_SIZE"},
+	{Key: "max-size", Flag: "max-size", Env: "DELETOR_MAX_SIZE"},
+	{Key: "older", Flag: "older", Env: "DELETOR_OLDER"},
+	{Key: "newer", Flag: "newer", Env: "DELETOR_NEWER"},
+	{Key: "accessed-before", Flag: "accessed-before", Env: "DELETOR_ACCESSED_BEFORE"},
+	{Key: "accessed-after", Flag: "accessed-after", Env: "DELETOR_ACCESSED_AFTER"},
+	{Key: "changed-before", Flag: "changed-before", Env: "DELETOR_CHANGED_BEFORE"},
+	{Key: "changed-after", Flag: "changed-after", Env: "DELETOR_CHANGED_AFTER"},
+	{Key: "owner", Flag: "owner", Env: "DELETOR_OWNER"},
+	{Key: "not-owner", Flag: "not-owner", Env: "DELETOR_NOT_OWNER"},
+	{Key: "uid", Flag: "uid", Env: "DELETOR_UID"},
+	{Key: "group", Flag: "group", Env: "DELETOR_GROUP"},
+	{Key: "perm", Flag: "perm", Env: "DELETOR_PERM"},
+	{Key: "where", Flag: "where", Env: "DELETOR_WHERE"},
+	{Key: "type", Flag: "type", Env: "DELETOR_TYPE"},
+	{Key: "clauses", Flag: "clauses", Env: "DELETOR_CLAUSES"},
+	{Key: "subdirs", Flag: "subdirs", Env: "DELETOR_SUBDIRS"},
+	{Key: "max-depth", Flag: "max-depth", Env: "DELETOR_MAX_DEPTH"},
+	{Key: "min-depth", Flag: "min-depth", Env: "DELETOR_MIN_DEPTH"},
+	{Key: "max-dir-entries", Flag: "max-dir-entries", Env: "DELETOR_MAX_DIR_ENTRIES"},
+	{Key: "prune-empty", Flag: "prune-empty", Env: "DELETOR_PRUNE_EMPTY"},
+	{Key: "broken-links", Flag: "broken-links", Env: "DELETOR_BROKEN_LINKS"},
+	{Key: "empty-files", Flag: "empty-files", Env: "DELETOR_EMPTY_FILES"},
+	{Key: "trash", Flag: "trash", Env: "DELETOR_TRASH"},
+	{Key: "shred", Flag: "shred", Env: "DELETOR_SHRED"},
+	{Key: "shred-passes", Flag: "shred-passes", Env: "DELETOR_SHRED_PASSES"},
+	{Key: "shred-method", Flag: "shred-method", Env: "DELETOR_SHRED_METHOD"},
+	{Key: "rotate-keep", Flag: "rotate-keep", Env: "DELETOR_ROTATE_KEEP"},
+	{Key: "copytruncate", Flag: "copytruncate", Env: "DELETOR_COPYTRUNCATE"},
+	{Key: "ionice", Flag: "ionice", Env: "DELETOR_IONICE"},
+	{Key: "nice", Flag: "nice", Env: "DELETOR_NICE"},
+	{Key: "rate-limit", Flag: "rate-limit", Env: "DELETOR_RATE_LIMIT"},
+	{Key: "bwlimit", Flag: "bwlimit", Env: "DELETOR_BWLIMIT"},
+	{Key: "skip-open", Flag: "skip-open", Env: "DELETOR_SKIP_OPEN"},
+	{Key: "one-file-system", Flag: "one-file-system", Env: "DELETOR_ONE_FILE_SYSTEM"},
+	{Key: "skip-fs", Flag: "skip-fs", Env: "DELETOR_SKIP_FS"},
+	{Key: "symlinks", Flag: "symlinks", Env: "DELETOR_SYMLINKS"},
+}
+
+// SettingKeys returns the names of all layered settings in display order
+func SettingKeys() []string {
+	keys := make([]string, 0, len(settings))
+	for _, s := range settings {
+		keys = append(keys, s.Key)
+	}
+	return keys
+}
+
+// settingByFlag returns the setting bound to the given flag name
+func settingByFlag(name string) (setting, bool) {
+	for _, s := range settings {
+		if s.Flag == name {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// ProjectConfig is the schema of a project-local .deletor.json file.
+// Field names match the user rule file; nil fields are treated as unset.
+type ProjectConfig struct {
+	Extensions            *[]string              `json:",omitempty"`
+	Exclude               *[]string              `json:",omitempty"`
+	Include               *[]string              `json:",omitempty"`
+	Presets               *[]string              `json:",omitempty"`
+	Directories           *[]rules.DirectoryRule `json:",omitempty"`
+	MinSize               *string                `json:",omitempty"`
+	MaxSize               *string                `json:",omitempty"`
+	OlderThan             *string                `json:",omitempty"`
+	NewerThan             *string                `json:",omitempty"`
+	AccessedBefore        *string                `json:",omitempty"`
+	AccessedAfter         *string                `json:",omitempty"`
+	ChangedBefore         *string                `json:",omitempty"`
+	ChangedAfter          *string                `json:",omitempty"`
+	Owners                *[]string              `json:",omitempty"`
+	NotOwners             *[]string              `json:",omitempty"`
+	UIDs                  *[]int                 `json:",omitempty"`
+	Groups                *[]string              `json:",omitempty"`
+	Perm                  *string                `json:",omitempty"`
+	Where                 *string                `json:",omitempty"`
+	ContentTypes          *[]string              `json:",omitempty"`
+	Clauses               *[]rules.Clause        `json:",omitempty"`
+	IncludeSubfolders     *bool                  `json:",omitempty"`
+	MaxDepth              *int                   `json:",omitempty"`
+	MinDepth              *int                   `json:",omitempty"`
+	MaxDirEntries         *int                   `json:",omitempty"`
+	DeleteEmptySubfolders *bool                  `json:",omitempty"`
+	DeleteBrokenLinks     *bool                  `json:",omitempty"`
+	DeleteEmptyFiles      *bool                  `json:",omitempty"`
+	SendFilesToTrash      *bool                  `json:",omitempty"`
+	Shred                 *bool                  `json:",omitempty"`
+	ShredPasses           *int                   `json:",omitempty"`
+	ShredMethod           *string                `json:",omitempty"`
+	RotateKeep            *int                   `json:",omitempty"`
+	RotateCopyTruncate    *bool                  `json:",omitempty"`
+	IONice                *string                `json:",omitempty"`
+	Nice                  *int                   `json:",omitempty"`
+	RateLimit             *string                `json:",omitempty"`
+	BWLimit               *string                `json:",omitempty"`
+	SkipOpenFiles         *bool                  `json:",omitempty"`
+	OneFileSystem         *bool                  `json:",omitempty"`
+	SkipFilesystems       *[]string              `json:",omitempty"`
+	Symlinks              *string                `json:",omitempty"`
+}
+
+// rawValues returns the explicitly set values of the project file keyed by setting name
+func (p *ProjectConfig) rawValues() map[string]string {
+	values := make(map[string]string)
+	if p.Extensions != nil {
+		values["extensions"] = strings.Join(*p.Extensions, ",")
+	}
+	if p.Exclude != nil {
+		values["exclude"] = strings.Join(*p.Exclude, ",")
+	}
+	if p.Include != nil {
+		values["include"] = strings.Join(*p.Include, ",")
+	}
+	if p.Presets != nil {
+		values["preset"] = strings.Join(*p.Presets, ",")
+	}
+	if p.Directories != nil {
+		values["dirs"] = joinDirectoryRules(*p.Directories)
+	}
+	if p.MinSize != nil {
+		values["min-size"] = *p.MinSize
+	}
+	if p.MaxSize != nil {
+		values["max-size"] = *p.MaxSize
+	}
+	if p.OlderThan != nil {
+		values["older"] = *p.OlderThan
+	}
+	if p.NewerThan != nil {
+		values["newer"] = *p.NewerThan
+	}
+	if p.AccessedBefore != nil {
+		values["accessed-before"] = *p.AccessedBefore
+	}
+	if p.AccessedAfter != nil {
+		values["accessed-after"] = *p.AccessedAfter
+	}
+	if p.ChangedBefore != nil {
+		values["changed-before"] = *p.ChangedBefore
+	}
+	if p.ChangedAfter != nil {
+		values["changed-after"] = *p.ChangedAfter
+	}
+	if p.Owners != nil {
+		values["owner"] = strings.Join(*p.Owners, ",")
+	}
+	if p.NotOwners != nil {
+		values["not-owner"] = strings.Join(*p.NotOwners, ",")
+	}
+	if p.UIDs != nil {
+		values["uid"] = joinIDs(*p.UIDs)
+	}
+	if p.Groups != nil {
+		values["group"] = strings.Join(*p.Groups, ",")
+	}
+	if p.Perm != nil {
+		values["perm"] = *p.Perm
+	}
+	if p.Where != nil {
+		values["where"] = *p.Where
+	}
+	if p.ContentTypes != nil {
+		values["type"] = strings.Join(*p.ContentTypes, ",")
+	}
+	if p.Clauses != nil {
+		values["clauses"] = joinClauses(*p.Clauses)
+	}
+	if p.IncludeSubfolders != nil {
+		values["subdirs"] = strconv.FormatBool(*p.IncludeSubfolders)
+	}
+	if p.MaxDepth != nil {
+		values["max-depth"] = strconv.Itoa(*p.MaxDepth)
+	}
+	if p.MinDepth != nil {
+		values["min-depth"] = strconv.Itoa(*p.MinDepth)
+	}
+	if p.MaxDirEntries != nil {
+		values["max-dir-entries"] = strconv.Itoa(*p.MaxDirEntries)
+	}
+	if p.DeleteEmptySubfolders != nil {
+		values["prune-empty"] = strconv.FormatBool(*p.DeleteEmptySubfolders)
+	}
+	if p.DeleteBrokenLinks != nil {
+		values["broken-links"] = strconv.FormatBool(*p.DeleteBrokenLinks)
+	}
+	if p.DeleteEmptyFiles != nil {
+		values["empty-files"] = strconv.FormatBool(*p.DeleteEmptyFiles)
+	}
+	if p.SendFilesToTrash != nil {
+		values["trash"] = strconv.FormatBool(*p.SendFilesToTrash)
+	}
+	if p.Shred != nil {
+		values["shred"] = strconv.FormatBool(*p.Shred)
+	}
+	if p.ShredPasses != nil {
+		values["shred-passes"] = strconv.Itoa(*p.ShredPasses)
+	}
+	if p.ShredMethod != nil {
+		values["shred-method"] = *p.ShredMethod
+	}
+	if p.RotateKeep != nil {
+		values["rotate-keep"] = strconv.Itoa(*p.RotateKeep)
+	}
+	if p.RotateCopyTruncate != nil {
+		values["copytruncate"] = strconv.FormatBool(*p.RotateCopyTruncate)
+	}
+	if p.IONice != nil {
+		values["ionice"] = *p.IONice
+	}
+	if p.Nice != nil {
+		values["nice"] = strconv.Itoa(*p.Nice)
+	}
+	if p.RateLimit != nil {
+		values["rate-limit"] = *p.RateLimit
+	}
+	if p.BWLimit != nil {
+		values["bwlimit"] = *p.BWLimit
+	}
+	if p.SkipOpenFiles != nil {
+		values["skip-open"] = strconv.FormatBool(*p.SkipOpenFiles)
+	}
+	if p.OneFileSystem != nil {
+		values["one-file-system"] = strconv.FormatBool(*p.OneFileSystem)
+	}
+	if p.SkipFilesystems != nil {
+		values["skip-fs"] = strings.Join(*p.SkipFilesystems, ",")
+	}
+	if p.Symlinks != nil {
+		values["symlinks"] = *p.Symlinks
+	}
+	return values
+}
+
+// Resolve builds the effective configuration by layering, from lowest to
+// highest precedence: built-in defaults, user rules (only with --rules), the
+// nearest project .deletor.json above the target directory, DELETOR_*
+// environment variables and explicitly set flags. The selected presets are
+// merged in last. Every layered value records its Origin so `deletor config
+// explain` can show where it came from.
+func (c *Config) Resolve(ruleManager rules.Rules) (*Config, error) {
+	if c == nil {
+		c = &Config{Directory: "."}
+	}
+
+	resolved := *c
+	resolved.Origins = make(map[string]Origin, len(settings))
+	for _, s := range settings {
+		resolved.resetValue(s.Key)
+		resolved.Origins[s.Key] = Origin{Source: SourceDefault, Raw: resolved.formatValue(s.Key)}
+	}
+
+	if c.UseRules && ruleManager != nil {
+		if err := resolved.applyRules(ruleManager); err != nil {
+			return nil, err
+		}
+	}
+
+	envValues := make(map[string]string)
+	envNames := make(map[string]string)
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.Env); ok {
+			envValues[s.Key] = value
+			envNames[s.Key] = s.Env
+		}
+	}
+
+	flagValues := c.flagValues()
+
+	// The project file is searched from the target directory, so the target
+	// itself must be known before the project layer is applied.
+	target := resolved.Directory
+	if raw, ok := envValues["directory"]; ok {
+		target = utils.ExpandTilde(raw)
+	}
+	if _, ok := flagValues["directory"]; ok {
+		target = c.Directory
+	}
+
+	projectPath, err := FindProjectConfig(target)
+	if err != nil {
+		return nil, err
+	}
+	if projectPath != "" {
+		project, err := ReadProjectConfig(projectPath)
+		if err != nil {
+			return nil, err
+		}
+		for key, raw := range project.rawValues() {
+			if err := resolved.setValue(key, raw); err != nil {
+				return nil, fmt.Errorf("%s: %w", projectPath, err)
+			}
+			resolved.Origins[key] = Origin{Source: SourceProject, Detail: projectPath, Raw: raw}
+		}
+	}
+
+	for key, raw := range envValues {
+		if err := resolved.setValue(key, raw); err != nil {
+			return nil, fmt.Errorf("%s: %w", envNames[key], err)
+		}
+		resolved.Origins[key] = Origin{Source: SourceEnv, Detail: envNames[key], Raw: raw}
+	}
+
+	for _, s := range settings {
+		raw, ok := flagValues[s.Key]
+		if !ok {
+			continue
+		}
+		flagName := "--" + s.Flag
+		if len(s.Flag) == 1 {
+			flagName = "-" + s.Flag
+		}
+		resolved.copyValue(c, s.Key)
+		resolved.Origins[s.Key] = Origin{Source: SourceFlag, Detail: flagName, Raw: raw}
+	}
+
+	if err := resolved.applyPresets(); err != nil {
+		return nil, err
+	}
+	if err := resolved.WalkLimits.Validate(); err != nil {
+		return nil, err
+	}
+	if resolved.Shred && resolved.MoveFileToTrash {
+		return nil, errors.New("files cannot be both shredded and moved to trash, choose --shred or --trash")
+	}
+
+	return &resolved, nil
+}
+
+// applyPresets adds each selected preset to the filter as its own term, and
+// their directory rules to the ones set by the layers
+func (c *Config) applyPresets() error {
+	if len(c.Presets) == 0 {
+		return nil
+	}
+
+	terms, directories, err := rules.ExpandPresets(c.Presets)
+	if err != nil {
+		return err
+	}
+	c.PresetTerms = terms
+
+	if len(directories) > 0 {
+		if c.Origins["dirs"].Source == SourceDefault {
+			c.Origins["dirs"] = Origin{Source: SourcePreset, Detail: strings.Join(c.Presets, ","), Raw: joinDirectoryRules(directories)}
+		}
+		c.Directories = append(c.Directories, directories...)
+	}
+	return nil
+}
+
+// applyRules layers the saved user rules over the current values. The rule
+// file cannot distinguish false from unset, so only non-empty values apply.
+func (c *Config) applyRules(ruleManager rules.Rules) error {
+	savedRules, err := ruleManager.GetRules()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("load rules: %w", err)
+	}
+
+	values := make(map[string]string)
+	if savedRules.Path != "" {
+		values["directory"] = savedRules.Path
+	}
+	if len(savedRules.Extensions) > 0 {
+		values["extensions"] = strings.Join(savedRules.Extensions, ",")
+	}
+	if len(savedRules.Exclude) > 0 {
+		values["exclude"] = strings.Join(savedRules.Exclude, ",")
+	}
+	if len(savedRules.Include) > 0 {
+		values["include"] = strings.Join(savedRules.Include, ",")
+	}
+	if len(savedRules.Presets) > 0 {
+		values["preset"] = strings.Join(savedRules.Presets, ",")
+	}
+	if len(savedRules.Directories) > 0 {
+		values["dirs"] = joinDirectoryRules(savedRules.Directories)
+	}
+	if savedRules.MinSize != "" {
+		values["min-size"] = savedRules.MinSize
+	}
+	if savedRules.MaxSize != "" {
+		values["max-size"] = savedRules.MaxSize
+	}
+	if savedRules.OlderThan != "" {
+		values["older"] = savedRules.OlderThan
+	}
+	if savedRules.NewerThan != "" {
+		values["newer"] = savedRules.NewerThan
+	}
+	if savedRules.AccessedBefore != "" {
+		values["accessed-before"] = savedRules.AccessedBefore
+	}
+	if savedRules.AccessedAfter != "" {
+		values["accessed-after"] = savedRules.AccessedAfter
+	}
+	if savedRules.ChangedBefore != "" {
+		values["changed-before"] = savedRules.ChangedBefore
+	}
+	if savedRules.ChangedAfter != "" {
+		values["changed-after"] = savedRules.ChangedAfter
+	}
+	if len(savedRules.Owners) > 0 {
+		values["owner"] = strings.Join(savedRules.Owners, ",")
+	}
+	if len(savedRules.NotOwners) > 0 {
+		values["not-owner"] = strings.Join(savedRules.NotOwners, ",")
+	}
+	if len(savedRules.UIDs) > 0 {
+		values["uid"] = joinIDs(savedRules.UIDs)
+	}
+	if len(savedRules.Groups) > 0 {
+		values["group"] = strings.Join(savedRules.Groups, ",")
+	}
+	if savedRules.Perm != "" {
+		values["perm"] = savedRules.Perm
+	}
+	if savedRules.Where != "" {
+		values["where"] = savedRules.Where
+	}
+	if len(savedRules.ContentTypes) > 0 {
+		values["type"] = strings.Join(savedRules.ContentTypes, ",")
+	}
+	if len(savedRules.Clauses) > 0 {
+		values["clauses"] = joinClauses(savedRules.Clauses)
+	}
+	if savedRules.IncludeSubfolders {
+		values["subdirs"] = "true"
+	}
+	if savedRules.MaxDepth > 0 {
+		values["max-depth"] = strconv.Itoa(savedRules.MaxDepth)
+	}
+	if savedRules.MinDepth > 0 {
+		values["min-depth"] = strconv.Itoa(savedRules.MinDepth)
+	}
+	if savedRules.MaxDirEntries > 0 {
+		values["max-dir-entries"] = strconv.Itoa(savedRules.MaxDirEntries)
+	}
+	if savedRules.DeleteEmptySubfolders {
+		values["prune-empty"] = "true"
+	}
+	if savedRules.DeleteBrokenLinks {
+		values["broken-links"] = "true"
+	}
+	if savedRules.DeleteEmptyFiles {
+		values["empty-files"] = "true"
+	}
+	if savedRules.SendFilesToTrash {
+		values["trash"] = "true"
+	}
+	if savedRules.Shred {
+		values["shred"] = "true"
+	}
+	if savedRules.ShredPasses > 0 {
+		values["shred-passes"] = strconv.Itoa(savedRules.ShredPasses)
+	}
+	if savedRules.ShredMethod != "" {
+		values["shred-method"] = savedRules.ShredMethod
+	}
+	if savedRules.RotateKeep > 0 {
+		values["rotate-keep"] = strconv.Itoa(savedRules.RotateKeep)
+	}
+	if savedRules.RotateCopyTruncate {
+		values["copytruncate"] = "true"
+	}
+	if savedRules.IONice != "" {
+		values["ionice"] = savedRules.IONice
+	}
+	if savedRules.Nice > 0 {
+		values["nice"] = strconv.Itoa(savedRules.Nice)
+	}
+	if savedRules.RateLimit != "" {
+		values["rate-limit"] = savedRules.RateLimit
+	}
+	if savedRules.BWLimit != "" {
+		values["bwlimit"] = savedRules.BWLimit
+	}
+	if savedRules.SkipOpenFiles {
+		values["skip-open"] = "true"
+	}
+	if savedRules.OneFileSystem {
+		values["one-file-system"] = "true"
+	}
+	if len(savedRules.SkipFilesystems) > 0 {
+		values["skip-fs"] = strings.Join(savedRules.SkipFilesystems, ",")
+	}
+	if savedRules.Symlinks != "" {
+		values["symlinks"] = savedRules.Symlinks
+	}
+
+	rulesPath := ruleManager.GetRulesPath()
+	for key, raw := range values {
+		if err := c.setValue(key, raw); err != nil {
+			return fmt.Errorf("%s: %w", rulesPath, err)
+		}
+		c.Origins[key] = Origin{Source: SourceUserRules, Detail: rulesPath, Raw: raw}
+	}
+	return nil
+}
+
+// flagValues returns the raw values of explicitly set flags keyed by setting
+// name. Configs built without GetFlags carry no tracking information, so their
+// non-zero values are treated as set.
+func (c *Config) flagValues() map[string]string {
+	if c.setFlags != nil {
+		return c.setFlags
+	}
+
+	values := make(map[string]string)
+	zero := &Config{}
+	zero.resetValue("directory")
+	for _, s := range settings {
+		raw := c.formatValue(s.Key)
+		if raw != zero.formatValue(s.Key) {
+			values[s.Key] = raw
+		}
+	}
+	return values
+}
+
+// setValue parses a raw layer value and stores it in the matching field
+func (c *Config) setValue(key, raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch key {
+	case "directory":
+		c.Directory = utils.ExpandTilde(raw)
+	case "extensions":
+		c.Extensions = utils.ParseExtToSlice(raw)
+	case "exclude":
+		c.Exclude = utils.ParseExcludeToSlice(raw)
+	case "include":
+		include := utils.ParseExcludeToSlice(raw)
+		for _, pattern := range include {
+			if _, err := filepath.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+				return fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+			}
+		}
+		c.Include = include
+	case "preset":
+		c.Presets = utils.ParseExcludeToSlice(strings.ToLower(raw))
+	case "dirs":
+		var directories []rules.DirectoryRule
+		for _, spec := range utils.ParseExcludeToSlice(raw) {
+			dir, err := rules.ParseDirectoryRule(spec)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			directories = append(directories, dir)
+		}
+		c.Directories = directories
+	case "min-size", "max-size":
+		var size int64
+		if raw != "" {
+			parsed, err := utils.ToBytes(raw)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			size = parsed
+		}
+		if key == "min-size" {
+			c.MinSize = size
+		} else {
+			c.MaxSize = size
+		}
+	case "older", "newer", "accessed-before", "accessed-after", "changed-before", "changed-after":
+		var t time.Time
+		if raw != "" {
+			parsed, err := utils.ParseTimeDuration(raw)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			t = parsed
+		}
+		switch key {
+		case "older":
+			c.OlderThan = t
+		case "newer":
+			c.NewerThan = t
+		case "accessed-before":
+			c.AccessedBefore = t
+		case "accessed-after":
+			c.AccessedAfter = t
+		case "changed-before":
+			c.ChangedBefore = t
+		case "changed-after":
+			c.ChangedAfter = t
+		}
+	case "owner", "not-owner":
+		ids, err := filemanager.LookupOwners(utils.ParseExcludeToSlice(raw))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		if key == "owner" {
+			c.Owners = ids
+		} else {
+			c.NotOwners = ids
+		}
+	case "uid":
+		var ids []int
+		for _, value := range utils.ParseExcludeToSlice(raw) {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %q is not a numeric user ID", key, value)
+			}
+			ids = append(ids, int(id))
+		}
+		c.UIDs = ids
+	case "group":
+		ids, err := filemanager.LookupGroups(utils.ParseExcludeToSlice(raw))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		c.Groups = ids
+	case "perm":
+		perm, err := filemanager.ParsePermFilter(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		c.Perm = perm
+	case "where":
+		where, err := filemanager.ParseExpr(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		c.Where = where
+	case "max-depth", "min-depth", "max-dir-entries":
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid %s: %q is not a non-negative number", key, raw)
+		}
+		switch key {
+		case "max-depth":
+			c.MaxDepth = n
+		case "min-depth":
+			c.MinDepth = n
+		case "max-dir-entries":
+			c.MaxDirEntries = n
+		}
+	case "shred-passes":
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid %s: %q is not a positive number", key, raw)
+		}
+		c.ShredOptions.Passes = n
+	case "shred-method":
+		method, err := filemanager.ParseShredMethod(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		c.ShredOptions.Method = method
+	case "rotate-keep":
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid %s: %q is not a positive number", key, raw)
+		}
+		c.Rotation.Keep = n
+	case "ionice":
+		class, err := utils.ParseIONice(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		c.Throttle.IONice = class
+	case "nice":
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || n < 0 || n > 19 {
+			return fmt.Errorf("invalid %s: %q is not a number from 0 to 19", key, raw)
+		}
+		c.Throttle.Nice = n
+	case "rate-limit":
+		rate, err := utils.ParseRate(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		c.Throttle.RateLimit = rate
+	case "bwlimit":
+		bandwidth, err := utils.ParseByteRate(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		c.Throttle.BWLimit = bandwidth
+	case "type":
+		types, err := filemanager.ParseContentTypes(utils.ParseExcludeToSlice(raw))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		c.ContentTypes = types
+	case "clauses":
+		var clauses []rules.Clause
+		for _, spec := range utils.ParseExcludeToSlice(raw) {
+			clause, err := rules.ParseClause(spec)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			clauses = append(clauses, clause)
+		}
+		if err := rules.ValidateClauses(clauses); err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		c.Clauses = clauses
+	case "skip-fs":
+		skip := utils.ParseExcludeToSlice(strings.ToLower(raw))
+		for _, pattern := range skip {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid filesystem pattern %q: %w", pattern, err)
+			}
+		}
+		c.SkipFilesystems = skip
+	case "symlinks":
+		policy, err := filemanager.ParseSymlinkPolicy(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		c.Symlinks = policy
+	case "subdirs", "prune-empty", "broken-links", "empty-files", "trash", "shred", "copytruncate", "skip-open", "one-file-system":
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %q is not a boolean", key, raw)
+		}
+		switch key {
+		case "subdirs":
+			c.IncludeSubdirs = value
+		case "prune-empty":
+			c.DeleteEmptyFolders = value
+		case "broken-links":
+			c.DeleteBrokenLinks = value
+		case "empty-files":
+			c.DeleteEmptyFiles = value
+		case "trash":
+			c.MoveFileToTrash = value
+		case "shred":
+			c.Shred = value
+		case "copytruncate":
+			c.Rotation.CopyTruncate = value
+		case "skip-open":
+			c.SkipOpenFiles = value
+		case "one-file-system":
+			c.OneFileSystem = value
+		}
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	return nil
+}
+
+// copyValue copies a single setting from src
+func (c *Config) copyValue(src *Config, key string) {
+	switch key {
+	case "directory":
+		c.Directory = src.Directory
+	case "extensions":
+		c.Extensions = append([]string(nil), src.Extensions...)
+	case "exclude":
+		c.Exclude = append([]string(nil), src.Exclude...)
+	case "include":
+		c.Include = append([]string(nil), src.Include...)
+	case "preset":
+		c.Presets = append([]string(nil), src.Presets...)
+	case "dirs":
+		c.Directories = append([]rules.DirectoryRule(nil), src.Directories...)
+	case "min-size":
+		c.MinSize = src.MinSize
+	case "max-size":
+		c.MaxSize = src.MaxSize
+	case "older":
+		c.OlderThan = src.OlderThan
+	case "newer":
+		c.NewerThan = src.NewerThan
+	case "accessed-before":
+		c.AccessedBefore = src.AccessedBefore
+	case "accessed-after":
+		c.AccessedAfter = src.AccessedAfter
+	case "changed-before":
+		c.ChangedBefore = src.ChangedBefore
+	case "changed-after":
+		c.ChangedAfter = src.ChangedAfter
+	case "owner":
+		c.Owners = append([]int(nil), src.Owners...)
+	case "not-owner":
+		c.NotOwners = append([]int(nil), src.NotOwners...)
+	case "uid":
+		c.UIDs = append([]int(nil), src.UIDs...)
+	case "group":
+		c.Groups = append([]int(nil), src.Groups...)
+	case "perm":
+		c.Perm = src.Perm
+	case "where":
+		c.Where = src.Where
+	case "type":
+		c.ContentTypes = append([]string(nil), src.ContentTypes...)
+	case "max-depth":
+		c.MaxDepth = src.MaxDepth
+	case "min-depth":
+		c.MinDepth = src.MinDepth
+	case "max-dir-entries":
+		c.MaxDirEntries = src.MaxDirEntries
+	case "clauses":
+		c.Clauses = append([]rules.Clause(nil), src.Clauses...)
+	case "subdirs":
+		c.IncludeSubdirs = src.IncludeSubdirs
+	case "prune-empty":
+		c.DeleteEmptyFolders = src.DeleteEmptyFolders
+	case "broken-links":
+		c.DeleteBrokenLinks = src.DeleteBrokenLinks
+	case "empty-files":
+		c.DeleteEmptyFiles = src.DeleteEmptyFiles
+	case "trash":
+		c.MoveFileToTrash = src.MoveFileToTrash
+	case "shred":
+		c.Shred = src.Shred
+	case "shred-passes":
+		c.ShredOptions.Passes = src.ShredOptions.Passes
+	case "shred-method":
+		c.ShredOptions.Method = src.ShredOptions.Method
+	case "rotate-keep":
+		c.Rotation.Keep = src.Rotation.Keep
+	case "copytruncate":
+		c.Rotation.CopyTruncate = src.Rotation.CopyTruncate
+	case "ionice":
+		c.Throttle.IONice = src.Throttle.IONice
+	case "nice":
+		c.Throttle.Nice = src.Throttle.Nice
+	case "rate-limit":
+		c.Throttle.RateLimit = src.Throttle.RateLimit
+	case "bwlimit":
+		c.Throttle.BWLimit = src.Throttle.BWLimit
+	case "skip-open":
+		c.SkipOpenFiles = src.SkipOpenFiles
+	case "one-file-system":
+		c.OneFileSystem = src.OneFileSystem
+	case "skip-fs":
+		c.SkipFilesystems = append([]string(nil), src.SkipFilesystems...)
+	case "symlinks":
+		c.Symlinks = src.Symlinks
+	}
+}
+
+// resetValue restores the built-in default of a single setting
+func (c *Config) resetValue(key string) {
+	switch key {
+	case "directory":
+		c.Directory = "."
+	case "extensions":
+		c.Extensions = nil
+	case "exclude":
+		c.Exclude = nil
+	case "include":
+		c.Include = nil
+	case "preset":
+		c.Presets = nil
+	case "dirs":
+		c.Directories = nil
+	case "min-size":
+		c.MinSize = 0
+	case "max-size":
+		c.MaxSize = 0
+	case "older":
+		c.OlderThan = time.Time{}
+	case "newer":
+		c.NewerThan = time.Time{}
+	case "accessed-before":
+		c.AccessedBefore = time.Time{}
+	case "accessed-after":
+		c.AccessedAfter = time.Time{}
+	case "changed-before":
+		c.ChangedBefore = time.Time{}
+	case "changed-after":
+		c.ChangedAfter = time.Time{}
+	case "owner":
+		c.Owners = nil
+	case "not-owner":
+		c.NotOwners = nil
+	case "uid":
+		c.UIDs = nil
+	case "group":
+		c.Groups = nil
+	case "perm":
+		c.Perm = nil
+	case "where":
+		c.Where = nil
+	case "type":
+		c.ContentTypes = nil
+	case "max-depth":
+		c.MaxDepth = 0
+	case "min-depth":
+		c.MinDepth = 0
+	case "max-dir-entries":
+		c.MaxDirEntries = 0
+	case "clauses":
+		c.Clauses = nil
+	case "subdirs":
+		c.IncludeSubdirs = false
+	case "prune-empty":
+		c.DeleteEmptyFolders = false
+	case "broken-links":
+		c.DeleteBrokenLinks = false
+	case "empty-files":
+		c.DeleteEmptyFiles = false
+	case "trash":
+		c.MoveFileToTrash = false
+	case "shred":
+		c.Shred = false
+	case "shred-passes":
+		c.ShredOptions.Passes = filemanager.DefaultShredPasses
+	case "shred-method":
+		c.ShredOptions.Method = filemanager.ShredRandom
+	case "rotate-keep":
+		c.Rotation.Keep = filemanager.DefaultRotateKeep
+	case "copytruncate":
+		c.Rotation.CopyTruncate = false
+	case "ionice":
+		c.Throttle.IONice = ""
+	case "nice":
+		c.Throttle.Nice = 0
+	case "rate-limit":
+		c.Throttle.RateLimit = 0
+	case "bwlimit":
+		c.Throttle.BWLimit = 0
+	case "skip-open":
+		c.SkipOpenFiles = false
+	case "one-file-system":
+		c.OneFileSystem = false
+	case "skip-fs":
+		c.SkipFilesystems = nil
+	case "symlinks":
+		c.Symlinks = filemanager.SymlinkNever
+	}
+}
+
+// formatValue renders the current value of a setting for display
+func (c *Config) formatValue(key string) string {
+	switch key {
+	case "directory":
+		return c.Directory
+	case "extensions":
+		return strings.Join(c.Extensions, ",")
+	case "exclude":
+		return strings.Join(c.Exclude, ",")
+	case "include":
+		return strings.Join(c.Include, ",")
+	case "preset":
+		return strings.Join(c.Presets, ",")
+	case "dirs":
+		return joinDirectoryRules(c.Directories)
+	case "min-size":
+		if c.MinSize == 0 {
+			return ""
+		}
+		return utils.FormatSize(c.MinSize)
+	case "max-size":
+		if c.MaxSize == 0 {
+			return ""
+		}
+		return utils.FormatSize(c.MaxSize)
+	case "older":
+		if c.OlderThan.IsZero() {
+			return ""
+		}
+		return c.OlderThan.Format(time.DateTime)
+	case "newer":
+		if c.NewerThan.IsZero() {
+			return ""
+		}
+		return c.NewerThan.Format(time.DateTime)
+	case "accessed-before":
+		return formatTime(c.AccessedBefore)
+	case "accessed-after":
+		return formatTime(c.AccessedAfter)
+	case "changed-before":
+		return formatTime(c.ChangedBefore)
+	case "changed-after":
+		return formatTime(c.ChangedAfter)
+	case "owner":
+		return joinOwners(c.Owners)
+	case "not-owner":
+		return joinOwners(c.NotOwners)
+	case "uid":
+		return joinIDs(c.UIDs)
+	case "group":
+		return joinIDs(c.Groups)
+	case "perm":
+		return c.Perm.String()
+	case "where":
+		if c.Where == nil {
+			return ""
+		}
+		return c.Where.String()
+	case "type":
+		return strings.Join(c.ContentTypes, ",")
+	case "max-depth":
+		return strconv.Itoa(c.MaxDepth)
+	case "min-depth":
+		return strconv.Itoa(c.MinDepth)
+	case "max-dir-entries":
+		return strconv.Itoa(c.MaxDirEntries)
+	case "clauses":
+		return joinClauses(c.Clauses)
+	case "subdirs":
+		return strconv.FormatBool(c.IncludeSubdirs)
+	case "prune-empty":
+		return strconv.FormatBool(c.DeleteEmptyFolders)
+	case "broken-links":
+		return strconv.FormatBool(c.DeleteBrokenLinks)
+	case "empty-files":
+		return strconv.FormatBool(c.DeleteEmptyFiles)
+	case "trash":
+		return strconv.FormatBool(c.MoveFileToTrash)
+	case "shred":
+		return strconv.FormatBool(c.Shred)
+	case "shred-passes":
+		return strconv.Itoa(c.ShredOptions.Passes)
+	case "shred-method":
+		return string(c.ShredOptions.Method)
+	case "rotate-keep":
+		return strconv.Itoa(c.Rotation.Keep)
+	case "copytruncate":
+		return strconv.FormatBool(c.Rotation.CopyTruncate)
+	case "ionice":
+		return c.Throttle.IONice
+	case "nice":
+		return strconv.Itoa(c.Throttle.Nice)
+	case "rate-limit":
+		if c.Throttle.RateLimit == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(c.Throttle.RateLimit, 'g', -1, 64) + "/s"
+	case "bwlimit":
+		if c.Throttle.BWLimit == 0 {
+			return ""
+		}
+		return utils.FormatSize(c.Throttle.BWLimit) + "/s"
+	case "skip-open":
+		return strconv.FormatBool(c.SkipOpenFiles)
+	case "one-file-system":
+		return strconv.FormatBool(c.OneFileSystem)
+	case "skip-fs":
+		return strings.Join(c.SkipFilesystems, ",")
+	case "symlinks":
+		if c.Symlinks == "" {
+			return string(filemanager.SymlinkNever)
+		}
+		return string(c.Symlinks)
+	}
+	return ""
+}
+
+// formatTime renders a time limit for display, empty when it is unset
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateTime)
+}
+
+// joinIDs renders user or group IDs as a comma-separated list
+func joinIDs(ids []int) string {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, strconv.Itoa(id))
+	}
+	return strings.Join(values, ",")
+}
+
+// joinOwners renders user IDs by name where the user is known
+func joinOwners(ids []int) string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, filemanager.OwnerName(id))
+	}
+	return strings.Join(names, ",")
+}
+
+// joinDirectoryRules renders directory rules in their compact comma-separated form
+func joinDirectoryRules(directories []rules.DirectoryRule) string {
+	specs := make([]string, 0, len(directories))
+	for _, dir := range directories {
+		specs = append(specs, dir.String())
+	}
+	return strings.Join(specs, ",")
+}
+
+// joinClauses renders clauses in their compact comma-separated form
+func joinClauses(clauses []rules.Clause) string {
+	specs := make([]string, 0, len(clauses))
+	for _, clause := range clauses {
+		specs = append(specs, clause.String())
+	}
+	return strings.Join(specs, ",")
+}
+
+// FormatValue renders the effective value of a layered setting for display
+func (c *Config) FormatValue(key string) string {
+	return c.formatValue(key)
+}
+
+// FindProjectConfig walks up from dir looking for a project config file and
+// returns its path, or an empty string if none exists.
+func FindProjectConfig(dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("resolve target directory: %w", err)
+	}
+
+	for {
+		candidate := filepath.Join(absDir, path.ProjectConfigFileName)
+		info, err := os.Stat(candidate)
+		if err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(absDir)
+		if parent == absDir {
+			return "", nil
+		}
+		absDir = parent
+	}
+}
+
+// ReadProjectConfig loads and parses a project config file
+func ReadProjectConfig(filePath string) (*ProjectConfig, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	project := &ProjectConfig{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(project); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filePath, err)
+	}
+	return project, nil
+}
@@ -2,12 +2,15 @@ package output
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/utils"
 )
 
 // Printer handles formatted output with color coding for different message types
@@ -67,6 +70,80 @@ func (p *Printer) PrintFilesTable(files map[string]string) {
 	}
 }
 
+// PrintFileEntries prints scanned files with their sizes and owners in path
+// order. The owner column is left out where the platform reports no owner,
+// and the sniffed content type follows the path when the filter matched types.
+func (p *Printer) PrintFileEntries(entries []filemanager.FileEntry) {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	white := color.New(color.FgWhite).SprintFunc()
+	magenta := color.New(color.FgMagenta).SprintFunc()
+
+	sizes := make([]string, len(entries))
+	owners := make([]string, len(entries))
+	maxSizeLen, maxOwnerLen := 0, 0
+	for i, entry := range entries {
+		sizes[i] = utils.FormatSize(entry.Size)
+		if len(sizes[i]) > maxSizeLen {
+			maxSizeLen = len(sizes[i])
+		}
+		owners[i] = filemanager.OwnerName(entry.UID)
+		if len(owners[i]) > maxOwnerLen {
+			maxOwnerLen = len(owners[i])
+		}
+	}
+
+	for i, entry := range entries {
+		// Clauses may pick a different action per file
+		action := ""
+		if entry.ContentType != "" {
+			action = "  " + cyan(entry.ContentType)
+		}
+		if entry.Action != filemanager.ActionDefault {
+			action += "  " + magenta(string(entry.Action))
+		}
+		if maxOwnerLen == 0 {
+			fmt.Printf("%s  %s%s\n", yellow(fmt.Sprintf("%-*s", maxSizeLen, sizes[i])), white(entry.Path), action)
+			continue
+		}
+		fmt.Printf("%s  %s  %s%s\n",
+			yellow(fmt.Sprintf("%-*s", maxSizeLen, sizes[i])),
+			cyan(fmt.Sprintf("%-*s", maxOwnerLen, owners[i])),
+			white(entry.Path), action)
+	}
+}
+
+// PrintBrokenLinks prints dangling symbolic links with the targets they point to
+func (p *Printer) PrintBrokenLinks(entries []filemanager.FileEntry) {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	white := color.New(color.FgWhite).SprintFunc()
+
+	for _, entry := range entries {
+		target, _ := os.Readlink(entry.Path)
+		fmt.Printf("%s  %s -> %s\n", yellow("LINK"), white(entry.Path), target)
+	}
+}
+
+// PrintInUseFiles prints matched files that a process holds open
+func (p *Printer) PrintInUseFiles(entries []filemanager.FileEntry) {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	white := color.New(color.FgWhite).SprintFunc()
+
+	for _, entry := range entries {
+		fmt.Printf("%s  %s  %s\n", yellow("OPEN"), utils.FormatSize(entry.Size), white(entry.Path))
+	}
+}
+
+// PrintEmptyFiles prints a list of zero-byte files
+func (p *Printer) PrintEmptyFiles(entries []filemanager.FileEntry) {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	white := color.New(color.FgWhite).SprintFunc()
+
+	for _, entry := range entries {
+		fmt.Printf("%s  %s\n", yellow("FILE"), white(entry.Path))
+	}
+}
+
 // PrintEmptyDirs prints a list of empty directories
 func (p *Printer) PrintEmptyDirs(files []string) {
 	yellow := color.New(color.FgYellow).SprintFunc()
@@ -77,29 +154,78 @@ func (p *Printer) PrintEmptyDirs(files []string) {
 	}
 }
 
+// PrintSettings prints rows as aligned columns under a highlighted header
+func (p *Printer) PrintSettings(header []string, rows [][]string) {
+	bold := color.New(color.Bold).SprintFunc()
+
+	widths := make([]int, len(header))
+	for i, title := range header {
+		widths[i] = len(title)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) && len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	printRow := func(cells []string, format func(a ...interface{}) string) {
+		parts := make([]string, len(cells))
+		for i, cell := range cells {
+			parts[i] = format(fmt.Sprintf("%-*s", widths[i], cell))
+		}
+		fmt.Println(strings.TrimRight(strings.Join(parts, "  "), " "))
+	}
+
+	printRow(header, bold)
+	for _, row := range rows {
+		printRow(row, fmt.Sprint)
+	}
+}
+
 // AskForConfirmation prompts the user for confirmation with a yes/no question
 func (p *Printer) AskForConfirmation(s string) bool {
+	return p.AskForConfirmationContext(context.Background(), s)
+}
+
+// AskForConfirmationContext prompts like AskForConfirmation, but answers no
+// as soon as ctx is done, so an interrupt at the prompt deletes nothing
+func (p *Printer) AskForConfirmationContext(ctx context.Context, s string) bool {
 	bold := color.New(color.Bold).SprintFunc()
 	green := color.New(color.FgGreen).SprintFunc()
 
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Printf("%s %s ", bold(s), green("[y/n]:"))
 
-	for {
-		response, err := reader.ReadString('\n')
-		if err != nil {
-			log.Fatal(err)
+	answers := make(chan bool, 1)
+	go func() {
+		for {
+			response, err := reader.ReadString('\n')
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			response = strings.ToLower(strings.TrimSpace(response))
+
+			fmt.Print("\n")
+
+			switch response {
+			case "y", "yes":
+				answers <- true
+				return
+			case "n", "no":
+				answers <- false
+				return
+			}
 		}
+	}()
 
-		response = strings.ToLower(strings.TrimSpace(response))
-
+	select {
+	case answer := <-answers:
+		return answer
+	case <-ctx.Done():
 		fmt.Print("\n")
-
-		switch response {
-		case "y", "yes":
-			return true
-		case "n", "no":
-			return false
-		}
+		return false
 	}
 }
@@ -0,0 +1,182 @@
+package filemanager
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileAction selects what happens to a file a clause matches
+type FileAction string
+
+const (
+	// ActionDefault deletes or trashes the file as the run is set up
+	ActionDefault FileAction = ""
+	// ActionDelete removes the file permanently
+	ActionDelete FileAction = "delete"
+	// ActionTrash moves the file to the system trash
+	ActionTrash FileAction = "trash"
+	// ActionShred overwrites the file before removing it permanently
+	ActionShred FileAction = "shred"
+	// ActionArchive compresses the file in place to a .gz next to it
+	ActionArchive FileAction = "archive"
+	// ActionRotate renames the file to its first generation and shifts and
+	// compresses the older ones, as logrotate does
+	ActionRotate FileAction = "rotate"
+	// ActionSkip keeps the file, so later clauses do not select it either
+	ActionSkip FileAction = "skip"
+)
+
+// FileActions returns the accepted action names
+func FileActions() []string {
+	return []string{string(ActionDelete), string(ActionTrash), string(ActionShred), string(ActionArchive), string(ActionRotate), string(ActionSkip)}
+}
+
+// ParseFileAction parses an action name. An empty name selects ActionDefault.
+func ParseFileAction(name string) (FileAction, error) {
+	switch action := FileAction(strings.ToLower(strings.TrimSpace(name))); action {
+	case ActionDefault, ActionDelete, ActionTrash, ActionShred, ActionArchive, ActionRotate, ActionSkip:
+		return action, nil
+	}
+	return "", fmt.Errorf("unknown action %q, want one of %s", name, strings.Join(FileActions(), ", "))
+}
+
+// Resolve turns ActionDefault into trash or delete by the run's trash setting
+func (a FileAction) Resolve(trash bool) FileAction {
+	if a != ActionDefault {
+		return a
+	}
+	if trash {
+		return ActionTrash
+	}
+	return ActionDelete
+}
+
+// Clause is one entry of an ordered list of per-pattern policies. The first
+// clause whose pattern and limits match a file decides its action.
+type Clause struct {
+	Spec      string     // Compact form of the clause, recorded as the rule applied
+	Pattern   string     // Glob matched against the file name, or the path when it contains "/"
+	OlderThan time.Time  // Only files last modified before this time, zero for any age
+	MinSize   int64      // Minimum file size in bytes, 0 for no limit
+	MaxSize   int64      // Maximum file size in bytes, 0 for no limit
+	Action    FileAction // What happens to the matched files
+}
+
+// Expr compiles the pattern and limits of the clause into an expression
+func (c Clause) Expr() Expr {
+	field := "name"
+	if strings.Contains(c.Pattern, "/") {
+		field = "path"
+	}
+	pattern, err := NewCompare(field, "~", c.Pattern)
+	if err != nil {
+		// Malformed patterns match nothing, as with filepath.Match
+		pattern = &CompareExpr{Field: field, Op: "~", Values: []string{c.Pattern}}
+	}
+
+	terms := []Expr{pattern}
+	if !c.OlderThan.IsZero() {
+		terms = append(terms, ageCompare("age", ">", c.OlderThan))
+	}
+	if c.MinSize > 0 {
+		e, _ := NewCompare("size", ">=", strconv.FormatInt(c.MinSize, 10))
+		terms = append(terms, e)
+	}
+	if c.MaxSize > 0 {
+		e, _ := NewCompare("size", "<=", strconv.FormatInt(c.MaxSize, 10))
+		terms = append(terms, e)
+	}
+	if len(terms) == 1 {
+		return pattern
+	}
+	return &AndExpr{Terms: terms}
+}
+
+// clausesExpr selects the files whose first matching clause does not skip
+// them. Each selecting clause is guarded by the skip clauses before it.
+func clausesExpr(clauses []Clause) Expr {
+	var selected, skipped []Expr
+	for _, clause := range clauses {
+		e := clause.Expr()
+		if clause.Action == ActionSkip {
+			skipped = append(skipped, e)
+			continue
+		}
+		if len(skipped) > 0 {
+			guard := skipped[0]
+			if len(skipped) > 1 {
+				guard = &OrExpr{Terms: append([]Expr(nil), skipped...)}
+			}
+			e = &AndExpr{Terms: []Expr{&NotExpr{X: guard}, e}}
+		}
+		selected = append(selected, e)
+	}
+
+	switch len(selected) {
+	case 0:
+		// Only skip clauses select nothing; no file is smaller than 0 bytes
+		e, _ := NewCompare("size", "<", "0")
+		return e
+	case 1:
+		return selected[0]
+	}
+	return &OrExpr{Terms: selected}
+}
+
+// ArchiveFile compresses a file to path.gz next to it and removes the
+// original. The archive keeps the permission bits and modification time of
+// the file; an existing archive is never overwritten.
+func ArchiveFile(path string) (string, error) {
+	return ArchiveFilePaced(context.Background(), path, nil)
+}
+
+// ArchiveFilePaced is ArchiveFile with the bytes read from the file paced
+// by pacer, which may be nil. It gives up when ctx is done.
+func ArchiveFilePaced(ctx context.Context, path string, pacer *Pacer) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	target := path + ".gz"
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return "", err
+	}
+
+	zw := gzip.NewWriter(dst)
+	zw.Name = filepath.Base(path)
+	zw.ModTime = info.ModTime()
+	_, err = io.Copy(zw, &pacedReader{ctx: ctx, r: src, pacer: pacer})
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+		return "", err
+	}
+
+	os.Chtimes(target, info.ModTime(), info.ModTime())
+	src.Close()
+	if err := os.Remove(path); err != nil {
+		os.Remove(target)
+		return "", err
+	}
+	return target, nil
+}
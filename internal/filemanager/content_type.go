@@ -0,0 +1,219 @@
+package filemanager
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// sniffLen is how much of a file is read to detect its type, as much as
+// http.DetectContentType considers
+const sniffLen = 512
+
+// isoMagicOffset is where an ISO 9660 image has its first volume descriptor
+// identifier, after 16 sectors of system area
+const isoMagicOffset = 0x8001
+
+// Content types detected by our own signatures, which
+// http.DetectContentType does not know or reports under other names
+const (
+	contentTypeEmpty      = "inode/x-empty"
+	contentTypeTar        = "application/x-tar"
+	contentTypeISO        = "application/x-iso9660-image"
+	contentTypeExecutable = "application/x-executable"
+	contentTypeCore       = "application/x-coredump"
+)
+
+// contentSignature is a magic number at a fixed offset of a file
+type contentSignature struct {
+	offset int
+	magic  []byte
+	mime   string
+}
+
+// contentSignatures are checked in order before http.DetectContentType.
+// ELF files are told apart by their header in detectELF.
+var contentSignatures = []contentSignature{
+	{0, []byte("PK\x03\x04"), "application/zip"},
+	{0, []byte("PK\x05\x06"), "application/zip"},
+	{0, []byte("\x1f\x8b"), "application/gzip"},
+	{0, []byte("BZh"), "application/x-bzip2"},
+	{0, []byte("\xfd7zXZ\x00"), "application/x-xz"},
+	{0, []byte("\x28\xb5\x2f\xfd"), "application/zstd"},
+	{0, []byte("7z\xbc\xaf\x27\x1c"), "application/x-7z-compressed"},
+	{0, []byte("Rar!\x1a\x07"), "application/vnd.rar"},
+	{257, []byte("ustar"), contentTypeTar},
+}
+
+// contentCategories are the names a content type filter accepts besides MIME
+// types, each standing for a set of types
+var contentCategories = map[string][]string{
+	"archive": {
+		"application/zip", "application/gzip", "application/x-gzip", "application/x-bzip2",
+		"application/x-xz", "application/zstd", "application/x-7z-compressed",
+		"application/vnd.rar", "application/x-rar-compressed", contentTypeTar,
+	},
+	"disk-image": {contentTypeISO},
+	"executable": {contentTypeExecutable},
+	"core":       {contentTypeCore},
+	"empty":      {contentTypeEmpty},
+}
+
+// ContentCategories returns the category names a content type filter
+// accepts, sorted
+func ContentCategories() []string {
+	names := make([]string, 0, len(contentCategories))
+	for name := range contentCategories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DetectContentType sniffs the MIME type of a regular file from its first
+// bytes. Archives, ISO images, ELF executables and core dumps are recognized
+// by their signatures, everything else by http.DetectContentType. The type
+// is returned without parameters such as the charset.
+func DetectContentType(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", path)
+	}
+	if info.Size() == 0 {
+		return contentTypeEmpty, nil
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	head = head[:n]
+
+	if mime, ok := detectELF(head); ok {
+		return mime, nil
+	}
+	for _, sig := range contentSignatures {
+		if len(head) >= sig.offset+len(sig.magic) && bytes.Equal(head[sig.offset:sig.offset+len(sig.magic)], sig.magic) {
+			return sig.mime, nil
+		}
+	}
+	if info.Size() >= isoMagicOffset+5 {
+		magic := make([]byte, 5)
+		if _, err := f.ReadAt(magic, isoMagicOffset); err == nil && string(magic) == "CD001" {
+			return contentTypeISO, nil
+		}
+	}
+
+	mime, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return mime, nil
+}
+
+// detectELF tells core dumps from other ELF files by the object type in the
+// header
+func detectELF(head []byte) (string, bool) {
+	if len(head) < 18 || !bytes.HasPrefix(head, []byte("\x7fELF")) {
+		return "", false
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if head[5] == 2 {
+		order = binary.BigEndian
+	}
+	const etCore = 4
+	if order.Uint16(head[16:18]) == etCore {
+		return contentTypeCore, true
+	}
+	return contentTypeExecutable, true
+}
+
+// ParseContentTypes checks content type patterns: a MIME type such as
+// application/pdf, a wildcard such as image/*, or a category such as
+// archive. The patterns are returned lower-cased.
+func ParseContentTypes(patterns []string) ([]string, error) {
+	parsed := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		major, minor, isMIME := strings.Cut(pattern, "/")
+		switch {
+		case isMIME && major != "" && minor != "" && !strings.Contains(minor, "/"):
+		case !isMIME && contentCategories[pattern] != nil:
+		default:
+			return nil, fmt.Errorf("unknown content type %q, use a MIME type such as image/* or one of %s",
+				pattern, strings.Join(ContentCategories(), ", "))
+		}
+		parsed = append(parsed, pattern)
+	}
+	return parsed, nil
+}
+
+// MatchContentType reports whether a detected type matches one of the
+// patterns accepted by ParseContentTypes
+func MatchContentType(mime string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if types, ok := contentCategories[pattern]; ok {
+			for _, t := range types {
+				if mime == t {
+					return true
+				}
+			}
+			continue
+		}
+		if major, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mime, major+"/") {
+				return true
+			}
+			continue
+		}
+		if mime == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// contentTypeMatcher sniffs each file and matches its type against the
+// patterns. Files that cannot be read match neither = nor !=.
+func contentTypeMatcher(op string, values []string) (func(os.FileInfo, string) bool, error) {
+	if op != "=" && op != "!=" && op != "in" && op != "not in" {
+		return nil, opError("type", op, "=, != or in")
+	}
+	patterns, err := ParseContentTypes(values)
+	if err != nil {
+		return nil, err
+	}
+	want := op == "=" || op == "in"
+	return func(info os.FileInfo, path string) bool {
+		if !info.Mode().IsRegular() && info.Mode().Type() != os.ModeSymlink {
+			return false
+		}
+		mime, err := DetectContentType(path)
+		if err != nil {
+			return false
+		}
+		return MatchContentType(mime, patterns) == want
+	}, nil
+}
+
+// UsesContentTypes reports whether the filter sniffs file contents, so
+// scans should report the detected type of each match
+func (f *FileFilter) UsesContentTypes() bool {
+	return len(f.ContentTypes) > 0 || f.Where != nil && usesField(f.Where, "type")
+}
@@ -0,0 +1,178 @@
+package filemanager
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Bios-Marcel/wastebasket/v2"
+)
+
+// DirTarget matches whole directories that are cleaned as a single item
+type DirTarget struct {
+	Pattern        string    // Glob matched against the directory name, e.g. node_modules
+	RequireSibling string    // File that must exist next to the directory, e.g. package.json
+	UntouchedSince time.Time // Nothing in the subtree may be modified after this time
+}
+
+// DirMatch is a directory selected by a DirTarget
+type DirMatch struct {
+	Path         string    // Directory path
+	Size         int64     // Space freed by removing the subtree, hard links counted once
+	Files        int       // Number of files in the subtree
+	LastModified time.Time // Latest modification time in the subtree
+
+	links []FileEntry // Files in the subtree with more than one hard link
+}
+
+// matches reports whether the target pattern matches the directory name
+func (t DirTarget) matches(name string) bool {
+	matched, _ := filepath.Match(t.Pattern, name)
+	return matched
+}
+
+// guardsPass checks the optional sibling and age guards of a target
+func (t DirTarget) guardsPass(path string, match DirMatch) bool {
+	if t.RequireSibling != "" {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(path), t.RequireSibling)); err != nil {
+			return false
+		}
+	}
+	if !t.UntouchedSince.IsZero() && match.LastModified.After(t.UntouchedSince) {
+		return false
+	}
+	return true
+}
+
+// ScanDirTargets finds directories below dir that match one of the targets
+// and pass its guards. A matched directory is not descended into, so nested
+// matches are reported as part of their outermost parent. Without recursive
+// only the direct children of dir are considered. Directories are searched
+// within the depth limits, filesystems and symlink policy of the filter, but
+// a link to a directory is never matched, as removing it frees nothing. When
+// ctx is done the scan stops and a directory still being measured is left
+// out. The total counts a file hard-linked from several matches once, and
+// not at all when some of its links are outside every match.
+func (s *FileScanner) ScanDirTargets(ctx context.Context, dir string, targets []DirTarget, recursive bool) (matches []DirMatch, totalSize int64) {
+	mounts := newMountGuard(dir, s.filter)
+	limits := walkLimits(s.filter)
+	maxEntries := limits.MaxDirEntries
+	if !recursive {
+		limits.MaxDepth = 1
+	}
+
+	var (
+		mu    sync.Mutex
+		links []FileEntry
+	)
+	opts := walkOptions{workers: walkWorkers, mounts: mounts, visited: followLinks(s.filter), limits: limits}
+	walkTree(ctx, dir, opts, func(path string, d fs.DirEntry) bool {
+		if !d.IsDir() {
+			return false
+		}
+		info, err := d.Info()
+		if err != nil {
+			return false
+		}
+		if s.filter != nil && !s.filter.ExcludeFilter(info, path) {
+			return false
+		}
+
+		for _, target := range targets {
+			if !target.matches(d.Name()) {
+				continue
+			}
+			if link, err := os.Lstat(path); err != nil || link.Mode()&os.ModeSymlink != 0 {
+				return true
+			}
+
+			match, complete := measureDir(ctx, path, mounts, maxEntries)
+			if !complete || !target.guardsPass(path, match) {
+				return false
+			}
+			mu.Lock()
+			matches = append(matches, match)
+			totalSize += match.Size - freedSize(match.links)
+			links = append(links, match.links...)
+			mu.Unlock()
+			return false
+		}
+		return true
+	})
+
+	totalSize += freedSize(links)
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Path < matches[j].Path })
+	return matches, totalSize
+}
+
+// measureDir sums the sizes of all files in a subtree and finds its latest
+// modification time. Symbolic links are counted but not followed, and files
+// with several hard links count only when all their links are in the
+// subtree. The subtree is removed as a whole, so complete is false when some
+// directory in it could not be read, is on a filesystem the mount guard
+// rejects or holds more than maxEntries entries, and when ctx is done.
+func measureDir(ctx context.Context, dir string, mounts *mountGuard, maxEntries int) (match DirMatch, complete bool) {
+	match = DirMatch{Path: dir}
+	root, err := os.Lstat(dir)
+	if err != nil {
+		return match, false
+	}
+	match.LastModified = root.ModTime()
+
+	var (
+		dirs, read int
+		skipped    bool
+	)
+	// At depth 1 the entry limit applies to dir itself as well
+	opts := walkOptions{workers: 1, limits: WalkLimits{MaxDirEntries: maxEntries}, depth: 1}
+	opts.read = func(string) { read++ }
+	walkTree(ctx, dir, opts, func(path string, d fs.DirEntry) bool {
+		info, err := d.Info()
+		if err != nil {
+			skipped = true
+			return false
+		}
+		if info.ModTime().After(match.LastModified) {
+			match.LastModified = info.ModTime()
+		}
+		if d.IsDir() {
+			if !mounts.allows(path) {
+				skipped = true
+				return false
+			}
+			dirs++
+			return true
+		}
+		match.Files++
+		if entry := NewFileEntry(path, info); entry.Links > 1 && entry.Inode != 0 {
+			match.links = append(match.links, entry)
+		} else {
+			match.Size += info.Size()
+		}
+		return true
+	})
+	match.Size += freedSize(match.links)
+	return match, ctx.Err() == nil && !skipped && read == dirs+1
+}
+
+// TrashDir moves a directory tree to the system trash, reporting when it
+// could not be moved
+func (f *defaultFileManager) TrashDir(dir string) error {
+	return wastebasket.Trash(dir)
+}
+
+// DeleteDir removes a directory tree as a single operation. The directory is
+// first renamed inside its parent, so it disappears from its original path at
+// once even if removing the contents later fails part way.
+func (f *defaultFileManager) DeleteDir(dir string) error {
+	staging := filepath.Join(filepath.Dir(dir), fmt.Sprintf(".deletor-removing-%s-%d", filepath.Base(dir), time.Now().UnixNano()))
+	if err := os.Rename(dir, staging); err != nil {
+		return err
+	}
+	return os.RemoveAll(staging)
+}
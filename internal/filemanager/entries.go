@@ -0,0 +1,114 @@
+package filemanager
+
+import (
+	"io/fs"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/utils"
+)
+
+// FileEntry is a file found by a scan
+type FileEntry struct {
+	Path        string      // Full path of the file
+	Size        int64       // Size in bytes
+	Mode        fs.FileMode // Type and permission bits
+	ModTime     time.Time   // Last modification time
+	AccessTime  time.Time   // Last access time, zero where the platform does not report it
+	ChangeTime  time.Time   // Last inode change time, zero where the platform does not report it
+	UID         int         // Owner user ID, -1 where the platform does not report it
+	GID         int         // Owner group ID, -1 where the platform does not report it
+	Device      uint64      // ID of the device holding the file, 0 where unknown
+	Inode       uint64      // Inode number, 0 where unknown
+	Links       uint64      // Number of hard links, 0 where unknown
+	BrokenLink  bool        // Symbolic link whose target does not exist
+	InUse       bool        // Held open by a process, so removing it frees no space yet
+	MatchedRule string      // Filter pattern that selected the file, empty when any file matches
+	Action      FileAction  // Action of the clause that selected the file, ActionDefault without clauses
+	ContentType string      // MIME type sniffed from the contents, empty unless the filter matches types
+}
+
+// NewFileEntry builds an entry from the file info returned by the walk
+func NewFileEntry(path string, info os.FileInfo) FileEntry {
+	entry := FileEntry{
+		Path:    path,
+		Size:    info.Size(),
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+		UID:     -1,
+		GID:     -1,
+	}
+	fillPlatformInfo(&entry, info)
+	return entry
+}
+
+// ScanResult is the collected outcome of a scan
+type ScanResult struct {
+	Entries     []FileEntry // Matched files sorted by path
+	TotalSize   int64       // Sum of all entry sizes, hard links counted once per link
+	BrokenLinks []FileEntry // Dangling symbolic links, sorted by path and kept out of Entries
+	InUse       []FileEntry // Matched files held open by a process, sorted by path and kept out of Entries
+}
+
+// CollectEntries drains a stream of entries into a ScanResult
+func CollectEntries(entries <-chan FileEntry) ScanResult {
+	var result ScanResult
+	for entry := range entries {
+		result.Add(entry)
+	}
+	result.Sort()
+	return result
+}
+
+// Add appends an entry and counts its size. Broken links go to BrokenLinks
+// and files in use to InUse.
+func (r *ScanResult) Add(entry FileEntry) {
+	if entry.BrokenLink {
+		r.BrokenLinks = append(r.BrokenLinks, entry)
+		return
+	}
+	if entry.InUse {
+		r.InUse = append(r.InUse, entry)
+		return
+	}
+	r.Entries = append(r.Entries, entry)
+	r.TotalSize += entry.Size
+}
+
+// Sort orders the entries, broken links and files in use by path
+func (r *ScanResult) Sort() {
+	sort.Slice(r.Entries, func(i, j int) bool { return r.Entries[i].Path < r.Entries[j].Path })
+	sort.Slice(r.BrokenLinks, func(i, j int) bool { return r.BrokenLinks[i].Path < r.BrokenLinks[j].Path })
+	sort.Slice(r.InUse, func(i, j int) bool { return r.InUse[i].Path < r.InUse[j].Path })
+}
+
+// FreedSize returns the space removing every entry actually frees. Unlike
+// TotalSize it counts a hard-linked file once, and not at all when some of
+// its links are outside the result.
+func (r ScanResult) FreedSize() int64 {
+	return freedSize(r.Entries)
+}
+
+// InUseSize returns the size of the matched files held open by a process
+func (r ScanResult) InUseSize() int64 {
+	var size int64
+	for _, entry := range r.InUse {
+		size += entry.Size
+	}
+	return size
+}
+
+// Len returns the number of entries
+func (r ScanResult) Len() int {
+	return len(r.Entries)
+}
+
+// DeletionRecords converts the entries to the records written to the deletion logs
+func (r ScanResult) DeletionRecords() []utils.DeletionRecord {
+	records := make([]utils.DeletionRecord, 0, len(r.Entries))
+	for _, entry := range r.Entries {
+		records = append(records, utils.DeletionRecord{Path: entry.Path, Size: entry.Size})
+	}
+	return records
+}
@@ -0,0 +1,57 @@
+//go:build darwin
+// +build darwin
+
+package filemanager
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fillPlatformInfo adds the owner, access and change times and link
+// identity reported by stat
+func fillPlatformInfo(entry *FileEntry, info os.FileInfo) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	entry.UID, entry.GID, _ = fileOwner(info)
+	entry.Device = uint64(stat.Dev)
+	entry.Inode = uint64(stat.Ino)
+	entry.Links = uint64(stat.Nlink)
+	entry.AccessTime, entry.ChangeTime, _ = fileTimes(info)
+}
+
+// fileTimes returns the access and inode change times reported by stat
+func fileTimes(info os.FileInfo) (atime, ctime time.Time, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	return time.Unix(stat.Atimespec.Sec, stat.Atimespec.Nsec), time.Unix(stat.Ctimespec.Sec, stat.Ctimespec.Nsec), true
+}
+
+// fileOwner returns the user and group IDs reported by stat
+func fileOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int(stat.Uid), int(stat.Gid), true
+	}
+	return -1, -1, false
+}
+
+// fileInode returns the inode number reported by stat, or 0 when unknown
+func fileInode(info os.FileInfo) uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Ino)
+	}
+	return 0
+}
+
+// fileDevice returns the ID of the device holding the file
+func fileDevice(info os.FileInfo) (uint64, bool) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Dev), true
+	}
+	return 0, false
+}
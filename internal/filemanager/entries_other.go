@@ -0,0 +1,27 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package filemanager
+
+import (
+	"os"
+	"time"
+)
+
+// fillPlatformInfo leaves the owner, access and change times and link identity unset
+// where stat data is not available
+func fillPlatformInfo(entry *FileEntry, info os.FileInfo) {}
+
+// fileTimes reports no access or change time, so filters on them match nothing
+func fileTimes(info os.FileInfo) (atime, ctime time.Time, ok bool) {
+	return time.Time{}, time.Time{}, false
+}
+
+// fileOwner reports no owner, so owner and group filters match nothing
+func fileOwner(info os.FileInfo) (uid, gid int, ok bool) { return -1, -1, false }
+
+// fileInode returns 0, so only the modification time validates index entries
+func fileInode(info os.FileInfo) uint64 { return 0 }
+
+// fileDevice reports no device, so the walk is not limited to one filesystem
+func fileDevice(info os.FileInfo) (uint64, bool) { return 0, false }
@@ -0,0 +1,652 @@
+package filemanager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/utils"
+)
+
+// Expr is a node of a filter expression. ParseExpr builds expressions from
+// the --where language and FileFilter.Expr compiles the filter flags into
+// the same nodes.
+type Expr interface {
+	Match(info os.FileInfo, path string) bool
+	String() string
+}
+
+// AndExpr matches files matching every term
+type AndExpr struct {
+	Terms []Expr
+}
+
+// OrExpr matches files matching at least one term
+type OrExpr struct {
+	Terms []Expr
+}
+
+// NotExpr matches files the inner expression does not match
+type NotExpr struct {
+	X Expr
+}
+
+// CompareExpr compares one field of a file with the values written after
+// the operator. Build it with NewCompare so the values are checked once.
+type CompareExpr struct {
+	Field  string
+	Op     string   // =, !=, <, <=, >, >=, ~, !~, in or not in
+	Values []string // Values as written, one unless Op is in or not in
+
+	match func(info os.FileInfo, path string) bool
+	stat  bool // Needs a fresh stat, as it compares inode data the index may hold stale
+}
+
+func (e *AndExpr) Match(info os.FileInfo, path string) bool {
+	for _, term := range e.Terms {
+		if !term.Match(info, path) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *OrExpr) Match(info os.FileInfo, path string) bool {
+	for _, term := range e.Terms {
+		if term.Match(info, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *NotExpr) Match(info os.FileInfo, path string) bool {
+	return !e.X.Match(info, path)
+}
+
+func (e *CompareExpr) Match(info os.FileInfo, path string) bool {
+	if e.match == nil {
+		return false
+	}
+	return e.match(info, path)
+}
+
+func (e *AndExpr) String() string { return joinTerms(e.Terms, " and ") }
+func (e *OrExpr) String() string  { return joinTerms(e.Terms, " or ") }
+
+func (e *NotExpr) String() string {
+	return "not " + groupTerm(e.X)
+}
+
+func (e *CompareExpr) String() string {
+	values := make([]string, len(e.Values))
+	for i, value := range e.Values {
+		values[i] = quoteValue(value)
+	}
+	if e.Op == "in" || e.Op == "not in" {
+		return fmt.Sprintf("%s %s (%s)", e.Field, e.Op, strings.Join(values, ", "))
+	}
+	return fmt.Sprintf("%s %s %s", e.Field, e.Op, strings.Join(values, ""))
+}
+
+// joinTerms joins the terms of an and or or, grouping nested ones
+func joinTerms(terms []Expr, sep string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = groupTerm(term)
+	}
+	return strings.Join(parts, sep)
+}
+
+// groupTerm wraps and and or terms in parentheses
+func groupTerm(e Expr) string {
+	switch e.(type) {
+	case *AndExpr, *OrExpr:
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+// quoteValue quotes values the parser would otherwise split or misread
+func quoteValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\n\r\"'(),=<>!~&|") || isKeyword(value) {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+	}
+	return value
+}
+
+// ExprFields lists the fields a --where expression can compare
+var ExprFields = []string{"name", "path", "dir", "ext", "size", "age", "mtime", "atime", "ctime", "owner", "uid", "group", "perm", "type"}
+
+// NewCompare builds a comparison of a field with one value, or with a list
+// for in and not in. Sizes take units such as 10mb, the time fields take
+// the age of the timestamp such as 7d, owners and groups are resolved to
+// IDs here, ~ matches globs where ** also crosses "/", and type sniffs the
+// file contents for a MIME type or category such as image/* or archive.
+func NewCompare(field, op string, values ...string) (*CompareExpr, error) {
+	field, op = strings.ToLower(field), strings.ToLower(op)
+	if op == "==" {
+		op = "="
+	}
+	if len(values) == 0 || (len(values) > 1 && op != "in" && op != "not in") {
+		return nil, fmt.Errorf("%s %s needs one value", field, op)
+	}
+
+	var positive string
+	switch op {
+	case "=", "!=", "in", "not in":
+		positive = "="
+	case "~", "!~":
+		positive = "~"
+	case "<", "<=", ">", ">=":
+		positive = op
+	default:
+		return nil, fmt.Errorf("unknown operator %q", op)
+	}
+
+	e := &CompareExpr{Field: field, Op: op, Values: values}
+	var err error
+	switch field {
+	case "name", "path", "dir", "ext":
+		e.match, err = stringMatcher(field, positive, values)
+	case "size":
+		e.match, err = sizeMatcher(positive, values[0])
+		e.stat = true
+	case "age", "mtime", "atime", "ctime":
+		e.match, err = ageMatcher(field, positive, values[0])
+		e.stat = true
+	case "owner", "uid", "group":
+		e.match, err = idMatcher(field, positive, values)
+		e.stat = true
+	case "perm":
+		e.match, err = permMatcher(positive, values[0])
+		e.stat = true
+	case "type":
+		// Negates itself, so unreadable files match neither way
+		e.match, err = contentTypeMatcher(op, values)
+	default:
+		return nil, fmt.Errorf("unknown field %q, expected one of %s", field, strings.Join(ExprFields, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if (op == "!=" || op == "!~" || op == "not in") && field != "type" {
+		e.match = negate(field, e.match)
+	}
+	return e, nil
+}
+
+// negate inverts a field match. Files whose owner or times are unknown
+// still fail, as they fail the positive comparison.
+func negate(field string, match func(os.FileInfo, string) bool) func(os.FileInfo, string) bool {
+	return func(info os.FileInfo, path string) bool {
+		return statKnown(field, info) && !match(info, path)
+	}
+}
+
+// statKnown reports whether the file info carries the data a field compares
+func statKnown(field string, info os.FileInfo) bool {
+	switch field {
+	case "atime", "ctime", "owner", "uid", "group":
+		if _, cached := info.(indexedInfo); cached {
+			return false
+		}
+	}
+	switch field {
+	case "atime", "ctime":
+		_, _, ok := fileTimes(info)
+		return ok
+	case "owner", "uid", "group":
+		_, _, ok := fileOwner(info)
+		return ok
+	}
+	return true
+}
+
+// opError reports an operator a field does not support
+func opError(field, op, supported string) error {
+	return fmt.Errorf("%s does not support %s, use %s", field, op, supported)
+}
+
+// stringMatcher compares names, paths, parent directory names or
+// extensions. A path pattern not starting with "/" may match from any
+// directory of the path.
+func stringMatcher(field, op string, values []string) (func(os.FileInfo, string) bool, error) {
+	if op != "=" && op != "~" {
+		return nil, opError(field, op, "=, !=, ~, !~ or in")
+	}
+	values = append([]string(nil), values...)
+	for i, value := range values {
+		if field == "ext" && value != "" && !strings.HasPrefix(value, ".") {
+			values[i] = "." + value
+		}
+		if op == "~" {
+			if err := validateGlob(values[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	matches := func(s string) bool {
+		for _, value := range values {
+			if op == "=" && s == value || op == "~" && matchGlob(value, s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch field {
+	case "name":
+		return func(info os.FileInfo, path string) bool { return matches(info.Name()) }, nil
+	case "ext":
+		return func(info os.FileInfo, path string) bool { return matches(filepath.Ext(info.Name())) }, nil
+	case "dir":
+		return func(info os.FileInfo, path string) bool {
+			for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+				if matches(dir) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	return func(info os.FileInfo, path string) bool {
+		path = filepath.ToSlash(path)
+		if op == "=" {
+			return matches(path)
+		}
+		for _, pattern := range values {
+			if matchPathGlob(pattern, path) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// matchPathGlob matches a path pattern against the whole path when it starts
+// with "/", or else against the path and every part after a "/"
+func matchPathGlob(pattern, path string) bool {
+	if strings.HasPrefix(pattern, "/") || matchGlob(pattern, path) {
+		return matchGlob(pattern, path)
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && matchGlob(pattern, path[i+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// sizeMatcher compares the file size with a size such as 10mb or a plain
+// number of bytes
+func sizeMatcher(op, value string) (func(os.FileInfo, string) bool, error) {
+	size, err := parseExprSize(value)
+	if err != nil {
+		return nil, err
+	}
+	return func(info os.FileInfo, path string) bool {
+		return compareInt(info.Size(), op, size)
+	}, nil
+}
+
+// parseExprSize accepts a plain number of bytes or a size with a unit
+func parseExprSize(value string) (int64, error) {
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n, nil
+	}
+	n, err := utils.ToBytes(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return n, nil
+}
+
+// compareInt applies a comparison operator to two numbers
+func compareInt(a int64, op string, b int64) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return a == b
+}
+
+// ageMatcher compares how long ago a timestamp was with a duration such as
+// 7d. The cutoff is fixed when the expression is built, like the --older
+// flag.
+func ageMatcher(field, op, value string) (func(os.FileInfo, string) bool, error) {
+	if op == "=" || op == "~" {
+		return nil, opError(field, op, "<, <=, > or >=")
+	}
+	cutoff, err := utils.ParseTimeDuration(value)
+	if err != nil || cutoff.IsZero() {
+		return nil, fmt.Errorf("invalid age %q, use a duration such as 30min, 12h or 7d", value)
+	}
+	return newAgeMatcher(field, op, cutoff), nil
+}
+
+// newAgeMatcher compares a timestamp with a cutoff time: an age greater than
+// the duration means a time before the cutoff
+func newAgeMatcher(field, op string, cutoff time.Time) func(os.FileInfo, string) bool {
+	return func(info os.FileInfo, path string) bool {
+		t := info.ModTime()
+		if field == "atime" || field == "ctime" {
+			if _, cached := info.(indexedInfo); cached {
+				return false
+			}
+			atime, ctime, ok := fileTimes(info)
+			if !ok {
+				return false
+			}
+			if t = atime; field == "ctime" {
+				t = ctime
+			}
+		}
+		switch op {
+		case ">":
+			return t.Before(cutoff)
+		case ">=":
+			return !t.After(cutoff)
+		case "<":
+			return t.After(cutoff)
+		}
+		return !t.Before(cutoff)
+	}
+}
+
+// idMatcher compares the owner or group of a file. Names are resolved to
+// IDs once; uid only takes numeric IDs.
+func idMatcher(field, op string, values []string) (func(os.FileInfo, string) bool, error) {
+	if op != "=" {
+		return nil, opError(field, op, "=, != or in")
+	}
+	var ids []int
+	var err error
+	switch field {
+	case "owner":
+		ids, err = LookupOwners(values)
+	case "group":
+		ids, err = LookupGroups(values)
+	default:
+		for _, value := range values {
+			id, parseErr := strconv.ParseUint(value, 10, 32)
+			if parseErr != nil {
+				return nil, fmt.Errorf("invalid uid: %q is not a numeric user ID", value)
+			}
+			ids = append(ids, int(id))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newIDMatcher(field, ids), nil
+}
+
+// newIDMatcher matches files whose owner, or group for the group field, is
+// one of the IDs
+func newIDMatcher(field string, ids []int) func(os.FileInfo, string) bool {
+	return func(info os.FileInfo, path string) bool {
+		if _, cached := info.(indexedInfo); cached {
+			return false
+		}
+		uid, gid, ok := fileOwner(info)
+		if !ok {
+			return false
+		}
+		if field == "group" {
+			return containsID(ids, gid)
+		}
+		return containsID(ids, uid)
+	}
+}
+
+// permMatcher matches permission bits like find -perm
+func permMatcher(op, value string) (func(os.FileInfo, string) bool, error) {
+	if op != "=" {
+		return nil, opError("perm", op, "= or !=")
+	}
+	perm, err := ParsePermFilter(value)
+	if err != nil {
+		return nil, err
+	}
+	if perm == nil {
+		return nil, fmt.Errorf("invalid permission mode %q", value)
+	}
+	return func(info os.FileInfo, path string) bool { return perm.Matches(info.Mode()) }, nil
+}
+
+// usesStat reports whether an expression compares anything but names. Info
+// served from the index carries no owners or access and change times, and
+// its size, modification time and mode are stale once a file is rewritten in
+// place, which leaves the mtime of its directory alone.
+func usesStat(e Expr) bool {
+	switch e := e.(type) {
+	case *AndExpr:
+		return anyUsesStat(e.Terms)
+	case *OrExpr:
+		return anyUsesStat(e.Terms)
+	case *NotExpr:
+		return usesStat(e.X)
+	case *CompareExpr:
+		return e.stat
+	}
+	return false
+}
+
+func anyUsesStat(terms []Expr) bool {
+	for _, term := range terms {
+		if usesStat(term) {
+			return true
+		}
+	}
+	return false
+}
+
+// usesField reports whether an expression compares the field
+func usesField(e Expr, field string) bool {
+	switch e := e.(type) {
+	case *AndExpr:
+		return anyUsesField(e.Terms, field)
+	case *OrExpr:
+		return anyUsesField(e.Terms, field)
+	case *NotExpr:
+		return usesField(e.X, field)
+	case *CompareExpr:
+		return e.Field == field
+	}
+	return false
+}
+
+func anyUsesField(terms []Expr, field string) bool {
+	for _, term := range terms {
+		if usesField(term, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expr compiles the filter into one expression: every flag becomes a term
+// of an and, followed by Where. It returns nil when the filter matches every
+// file.
+func (f *FileFilter) Expr() Expr {
+	var terms []Expr
+	add := func(e *CompareExpr, match func(os.FileInfo, string) bool) {
+		e.match = match
+		terms = append(terms, e)
+	}
+
+	for _, pattern := range f.Exclude {
+		escaped := escapeGlob(pattern)
+		dir, _ := NewCompare("path", "~", "**"+escaped+"/**")
+		name, _ := NewCompare("name", "~", escaped+"*")
+		terms = append(terms, &NotExpr{X: &OrExpr{Terms: []Expr{dir, name}}})
+	}
+
+	if len(f.Include) > 0 || len(f.PresetTerms) > 0 {
+		include := &OrExpr{}
+		for _, pattern := range f.Include {
+			field := "name"
+			if dirPattern, ok := strings.CutSuffix(pattern, "/"); ok {
+				field, pattern = "dir", dirPattern
+			}
+			e := &CompareExpr{Field: field, Op: "~", Values: []string{pattern}}
+			e.match, _ = stringMatcher(field, "~", e.Values)
+			if e.match == nil {
+				// Malformed patterns match nothing, as with filepath.Match
+				e.match = func(os.FileInfo, string) bool { return false }
+			}
+			include.Terms = append(include.Terms, e)
+		}
+		for _, preset := range f.PresetTerms {
+			include.Terms = append(include.Terms, preset.Expr)
+		}
+		terms = append(terms, include)
+	}
+
+	if len(f.Extensions) > 0 {
+		exts := make([]string, 0, len(f.Extensions))
+		for ext := range f.Extensions {
+			exts = append(exts, ext)
+		}
+		sort.Strings(exts)
+		set := f.Extensions
+		add(&CompareExpr{Field: "ext", Op: "in", Values: exts}, func(info os.FileInfo, path string) bool {
+			_, ok := set[filepath.Ext(info.Name())]
+			return ok
+		})
+	}
+
+	if f.MaxSize > 0 {
+		e, _ := NewCompare("size", "<=", strconv.FormatInt(f.MaxSize, 10))
+		terms = append(terms, e)
+	}
+	if f.MinSize > 0 {
+		e, _ := NewCompare("size", ">=", strconv.FormatInt(f.MinSize, 10))
+		terms = append(terms, e)
+	}
+
+	if !f.OlderThan.IsZero() && !f.NewerThan.IsZero() {
+		// Support 'between' range regardless of which is earlier
+		start, end := f.OlderThan, f.NewerThan
+		if end.Before(start) {
+			start, end = end, start
+		}
+		terms = append(terms, ageCompare("age", "<=", start), ageCompare("age", ">=", end))
+	} else {
+		if !f.OlderThan.IsZero() {
+			terms = append(terms, ageCompare("age", ">", f.OlderThan))
+		}
+		if !f.NewerThan.IsZero() {
+			terms = append(terms, ageCompare("age", "<", f.NewerThan))
+		}
+	}
+
+	if f.Perm != nil {
+		perm := f.Perm
+		add(&CompareExpr{Field: "perm", Op: "=", Values: []string{perm.String()}, stat: true}, func(info os.FileInfo, path string) bool {
+			return perm.Matches(info.Mode())
+		})
+	}
+
+	for _, limit := range []struct {
+		field string
+		op    string
+		time  time.Time
+	}{
+		{"atime", ">", f.AccessedBefore},
+		{"atime", "<", f.AccessedAfter},
+		{"ctime", ">", f.ChangedBefore},
+		{"ctime", "<", f.ChangedAfter},
+	} {
+		if !limit.time.IsZero() {
+			terms = append(terms, ageCompare(limit.field, limit.op, limit.time))
+		}
+	}
+
+	if owners := append(append([]int(nil), f.Owners...), f.UIDs...); len(owners) > 0 {
+		terms = append(terms, idCompare("owner", "in", owners))
+	}
+	if len(f.NotOwners) > 0 {
+		terms = append(terms, idCompare("owner", "not in", f.NotOwners))
+	}
+	if len(f.Groups) > 0 {
+		terms = append(terms, idCompare("group", "in", f.Groups))
+	}
+
+	if f.Where != nil {
+		terms = append(terms, f.Where)
+	}
+	if len(f.Clauses) > 0 {
+		terms = append(terms, clausesExpr(f.Clauses))
+	}
+	// Last, so only files passing every cheap term are read
+	if len(f.ContentTypes) > 0 {
+		e, _ := NewCompare("type", "in", f.ContentTypes...)
+		terms = append(terms, e)
+	}
+
+	switch len(terms) {
+	case 0:
+		return nil
+	case 1:
+		return terms[0]
+	}
+	return &AndExpr{Terms: terms}
+}
+
+// ageCompare builds an age comparison from a cutoff time set by a flag
+func ageCompare(field, op string, cutoff time.Time) *CompareExpr {
+	return &CompareExpr{
+		Field:  field,
+		Op:     op,
+		Values: []string{formatAge(time.Since(cutoff))},
+		match:  newAgeMatcher(field, op, cutoff),
+		stat:   true,
+	}
+}
+
+// idCompare builds an owner or group comparison from resolved IDs
+func idCompare(field, op string, ids []int) *CompareExpr {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
+	}
+	match := newIDMatcher(field, ids)
+	if op == "not in" {
+		match = negate(field, match)
+	}
+	return &CompareExpr{Field: field, Op: op, Values: values, match: match, stat: true}
+}
+
+// formatAge writes a duration in the largest unit that keeps it whole, in a
+// form the expression parser reads back. Negative ages are cutoffs in the
+// future and are kept as seconds.
+func formatAge(d time.Duration) string {
+	seconds := int64(d.Round(time.Second) / time.Second)
+	for _, unit := range []struct {
+		suffix  string
+		seconds int64
+	}{
+		{"d", 24 * 60 * 60},
+		{"h", 60 * 60},
+		{"min", 60},
+	} {
+		if seconds != 0 && seconds%unit.seconds == 0 {
+			return strconv.FormatInt(seconds/unit.seconds, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(seconds, 10) + "s"
+}
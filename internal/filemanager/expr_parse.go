@@ -0,0 +1,346 @@
+package filemanager
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExprError is a syntax or value error in a filter expression
+type ExprError struct {
+	Pos int    // Byte offset of the offending token
+	Msg string // What is wrong at that offset
+}
+
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Pos+1, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// describe names a token in error messages
+func (t token) describe() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// keywords combine comparisons, matched case-insensitively
+var keywords = map[string]bool{"and": true, "or": true, "not": true, "in": true}
+
+func isKeyword(word string) bool {
+	return keywords[strings.ToLower(word)]
+}
+
+// operators lists the comparison and logical operators, longest first
+var operators = []string{"==", "!=", "<=", ">=", "!~", "&&", "||", "=", "<", ">", "~", "!"}
+
+// tokenize splits an expression into words, quoted strings, operators,
+// parentheses and commas
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case c == '"' || c == '\'':
+			text, n, err := readQuoted(input[i:])
+			if err != nil {
+				return nil, &ExprError{Pos: i, Msg: err.Error()}
+			}
+			tokens = append(tokens, token{tokenString, text, i})
+			i += n
+		default:
+			if op := readOperator(input[i:]); op != "" {
+				tokens = append(tokens, token{tokenOp, op, i})
+				i += len(op)
+				continue
+			}
+			start := i
+			for i < len(input) && !strings.ContainsRune(" \t\n\r(),\"'", rune(input[i])) && readOperator(input[i:]) == "" {
+				i++
+			}
+			tokens = append(tokens, token{tokenWord, input[start:i], start})
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(input)}), nil
+}
+
+// readOperator returns the operator at the start of s, if any
+func readOperator(s string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// readQuoted reads a string in single or double quotes, where a backslash
+// escapes the quote or another backslash
+func readQuoted(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (s[i+1] == quote || s[i+1] == '\\'):
+			i++
+			b.WriteByte(s[i])
+		case s[i] == quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string, missing the closing %c", quote)
+}
+
+// exprParser is a recursive descent parser over the tokens of an expression:
+//
+//	expr    = and { ("or" | "||") and }
+//	and     = unary { ("and" | "&&") unary }
+//	unary   = ("not" | "!") unary | "(" expr ")" | compare
+//	compare = field op value | field ["not"] "in" "(" value { "," value } ")"
+type exprParser struct {
+	tokens []token
+	pos    int
+
+	keepNames bool // Leave user and group names unresolved
+}
+
+// ParseExpr parses a filter expression such as
+//
+//	ext in (log,tmp) and age > 7d and not path ~ "keep/**"
+//
+// An empty expression returns nil, which matches every file.
+func ParseExpr(input string) (Expr, error) {
+	return parseExpr(input, false)
+}
+
+// CheckExpr reports errors in an expression without resolving user and
+// group names, so saved rules stay valid on machines without those users
+func CheckExpr(input string) error {
+	_, err := parseExpr(input, true)
+	return err
+}
+
+func parseExpr(input string, keepNames bool) (Expr, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, nil
+	}
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens, keepNames: keepNames}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, p.errorf(next, "unexpected %s, expected and, or or the end of the expression", next.describe())
+	}
+	return expr, nil
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) errorf(at token, format string, args ...any) error {
+	return &ExprError{Pos: at.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// isWord reports whether a token is the unquoted keyword or operator
+func isWord(t token, words ...string) bool {
+	if t.kind != tokenWord && t.kind != tokenOp {
+		return false
+	}
+	for _, word := range words {
+		if strings.EqualFold(t.text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *exprParser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	terms := []Expr{left}
+	for isWord(p.peek(), "or", "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, right)
+	}
+	if len(terms) == 1 {
+		return left, nil
+	}
+	return &OrExpr{Terms: terms}, nil
+}
+
+func (p *exprParser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	terms := []Expr{left}
+	for isWord(p.peek(), "and", "&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, right)
+	}
+	if len(terms) == 1 {
+		return left, nil
+	}
+	return &AndExpr{Terms: terms}, nil
+}
+
+func (p *exprParser) parseUnary() (Expr, error) {
+	t := p.peek()
+	switch {
+	case isWord(t, "not", "!"):
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{X: x}, nil
+	case t.kind == tokenLParen:
+		p.next()
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.errorf(closing, "expected ) to close the ( at column %d, found %s", t.pos+1, closing.describe())
+		}
+		return x, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *exprParser) parseCompare() (Expr, error) {
+	field := p.next()
+	if field.kind != tokenWord || isKeyword(field.text) {
+		return nil, p.errorf(field, "expected a field, found %s (fields: %s)", field.describe(), strings.Join(ExprFields, ", "))
+	}
+	if !isExprField(field.text) {
+		return nil, p.errorf(field, "unknown field %q (fields: %s)", field.text, strings.Join(ExprFields, ", "))
+	}
+
+	opToken := p.next()
+	var op string
+	switch {
+	case isWord(opToken, "in"):
+		op = "in"
+	case isWord(opToken, "not") && isWord(p.peek(), "in"):
+		p.next()
+		op = "not in"
+	case opToken.kind == tokenOp && opToken.text != "&&" && opToken.text != "||" && opToken.text != "!":
+		op = opToken.text
+	default:
+		return nil, p.errorf(opToken, "expected an operator after %s, found %s (operators: =, !=, <, <=, >, >=, ~, !~, in, not in)", field.text, opToken.describe())
+	}
+
+	var values []string
+	if op == "in" || op == "not in" {
+		open := p.next()
+		if open.kind != tokenLParen {
+			return nil, p.errorf(open, "expected ( after %s, found %s", op, open.describe())
+		}
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			sep := p.next()
+			if sep.kind == tokenRParen {
+				break
+			}
+			if sep.kind != tokenComma {
+				return nil, p.errorf(sep, "expected , or ) in the list, found %s", sep.describe())
+			}
+		}
+	} else {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = []string{value}
+	}
+
+	if p.keepNames && (strings.EqualFold(field.text, "owner") || strings.EqualFold(field.text, "group")) {
+		if op != "=" && op != "!=" && op != "in" && op != "not in" {
+			return nil, p.errorf(field, "%v", opError(strings.ToLower(field.text), op, "=, != or in"))
+		}
+		return &CompareExpr{Field: strings.ToLower(field.text), Op: op, Values: values}, nil
+	}
+	e, err := NewCompare(field.text, op, values...)
+	if err != nil {
+		return nil, p.errorf(field, "%v", err)
+	}
+	return e, nil
+}
+
+// parseValue reads a word or quoted string. Keywords must be quoted.
+func (p *exprParser) parseValue() (string, error) {
+	t := p.next()
+	if t.kind == tokenString || t.kind == tokenWord && !isKeyword(t.text) {
+		return t.text, nil
+	}
+	return "", p.errorf(t, "expected a value, found %s (quote values with spaces or operators)", t.describe())
+}
+
+// isExprField reports whether name is one of ExprFields
+func isExprField(name string) bool {
+	for _, field := range ExprFields {
+		if strings.EqualFold(field, name) {
+			return true
+		}
+	}
+	return false
+}
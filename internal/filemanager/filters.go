@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,14 +14,54 @@ type FileFilterOptions struct {
 	MinSize   int64     // Minimum file size in bytes
 	MaxSize   int64     // Maximum file size in bytes
 	Exclude   []string  // Patterns to exclude from results
+	Include   []string  // Globs a file name, or a parent directory name when ending in "/", must match
 	OlderThan time.Time // Only include files older than this time
 	NewerThan time.Time // Only include files newer than this time
+
+	AccessedBefore time.Time // Only include files last read before this time
+	AccessedAfter  time.Time // Only include files last read after this time
+	ChangedBefore  time.Time // Only include files whose inode last changed before this time
+	ChangedAfter   time.Time // Only include files whose inode last changed after this time
+
+	Owners    []int       // Only include files owned by one of these user IDs, resolved from names
+	UIDs      []int       // Only include files owned by one of these numeric user IDs, with Owners
+	NotOwners []int       // Exclude files owned by these user IDs
+	Groups    []int       // Only include files whose group is one of these IDs
+	Perm      *PermFilter // Only include files whose permission bits match, as find -perm
+
+	OneFileSystem   bool     // Do not descend into other filesystems or mount points below the scan root
+	SkipFilesystems []string // Filesystem types or groups (network, pseudo) whose mounts are not entered
+
+	Symlinks SymlinkPolicy // How symbolic links are walked and reported, empty for SymlinkNever
+
+	WalkLimits // Depth and directory size limits of recursive walks
+
+	Where Expr // Expression files must also match, parsed from --where
+
+	PresetTerms []PresetTerm // Built-in presets, each selecting files on its own as an include pattern does
+
+	ContentTypes []string // MIME types, wildcards such as image/* or categories such as archive sniffed from file contents
+
+	Clauses []Clause // Ordered per-pattern policies, the first match decides a file's action
+}
+
+// PresetTerm is the compiled criteria of one built-in preset. Its patterns,
+// excludes and age limit only apply to the files the preset selects.
+type PresetTerm struct {
+	Name string // Preset name, reported as the rule that selected a file
+	Expr Expr   // Criteria a file must match to be selected by the preset
 }
 
 // FileFilter defines criteria for filtering files
 type FileFilter struct {
 	FileFilterOptions
 	Extensions map[string]struct{} // Set of allowed file extensions
+	OpenFiles  *OpenFiles          // Matched files held open here are reported as in use, nil to not check
+
+	compileOnce sync.Once
+	expr        Expr   // Compiled criteria, nil when every file matches
+	needsStat   bool   // The expression compares data only a fresh stat has
+	clauseExprs []Expr // Compiled Clauses, in order
 }
 
 func NewFileFilterWithOptions(options FileFilterOptions, extensions map[string]struct{}) *FileFilter {
@@ -40,54 +81,50 @@ func (d *defaultFileManager) NewFileFilter(minSize, maxSize int64, extensions ma
 	}, extensions)
 }
 
-// MatchesFilters checks if a file matches all filter criteria
+// MatchesFilters checks if a file matches all filter criteria. The criteria
+// are compiled into an expression on first use, so the filter must not
+// change once matching starts.
 func (f *FileFilter) MatchesFilters(info os.FileInfo, path string) bool {
-	if !f.ExcludeFilter(info, path) {
-		return false
-	}
+	expr := f.compiled()
+	return expr == nil || expr.Match(info, path)
+}
 
-	if len(f.Extensions) > 0 {
-		_, existExt := f.Extensions[filepath.Ext(info.Name())]
-		if !existExt {
-			return false
-		}
-	}
-	if f.MaxSize > 0 {
-		if info.Size() > f.MaxSize {
-			return false
-		}
-	}
-	if f.MinSize > 0 {
-		if info.Size() < f.MinSize {
-			return false
-		}
-	}
+// SelectsFiles reports whether the filter narrows files by more than its
+// exclude patterns, which directory scans share, so that a scan with it does
+// not match every file
+func (f *FileFilter) SelectsFiles() bool {
+	options := f.FileFilterOptions
+	options.Exclude = nil
+	return NewFileFilterWithOptions(options, f.Extensions).Expr() != nil
+}
 
-	modTime := info.ModTime()
-	if !f.OlderThan.IsZero() && !f.NewerThan.IsZero() {
-		// Support 'between' range regardless of which is earlier
-		start := f.OlderThan
-		end := f.NewerThan
-		if end.Before(start) {
-			start, end = end, start
-		}
-		if modTime.Before(start) || modTime.After(end) {
-			return false
-		}
-	} else {
-		if !f.OlderThan.IsZero() {
-			if !f.OlderThanFilter(info) {
-				return false
-			}
+// compiled returns the expression of the filter, compiling it once
+func (f *FileFilter) compiled() Expr {
+	f.compileOnce.Do(func() {
+		f.expr = f.Expr()
+		f.needsStat = f.expr != nil && usesStat(f.expr)
+		for _, clause := range f.Clauses {
+			f.clauseExprs = append(f.clauseExprs, clause.Expr())
 		}
-		if !f.NewerThan.IsZero() {
-			if !f.NewerThanFilter(info) {
-				return false
-			}
+	})
+	return f.expr
+}
+
+// containsID reports whether id is in ids
+func containsID(ids []int, id int) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
 		}
 	}
+	return false
+}
 
-	return true
+// UsesAccessTimes reports whether the filter compares access times, which
+// mounts with noatime do not record
+func (f *FileFilter) UsesAccessTimes() bool {
+	return !f.AccessedBefore.IsZero() || !f.AccessedAfter.IsZero() ||
+		f.Where != nil && usesField(f.Where, "atime")
 }
 
 // ExcludeFilter checks if a file should be excluded based on path patterns
@@ -105,6 +142,69 @@ func (f *FileFilter) ExcludeFilter(info os.FileInfo, path string) bool {
 	return true
 }
 
+// IncludeFilter checks if a file matches at least one include pattern or
+// preset. A pattern ending in "/" matches the name of any parent directory,
+// other patterns match the file name.
+func (f *FileFilter) IncludeFilter(info os.FileInfo, path string) bool {
+	if len(f.Include) == 0 && len(f.PresetTerms) == 0 {
+		return true
+	}
+	_, ok := f.matchInclude(info, path)
+	return ok
+}
+
+// matchInclude returns the first include pattern or preset matching the file
+func (f *FileFilter) matchInclude(info os.FileInfo, path string) (string, bool) {
+	dirs := strings.Split(filepath.ToSlash(filepath.Dir(path)), "/")
+	for _, pattern := range f.Include {
+		if dirPattern, ok := strings.CutSuffix(pattern, "/"); ok {
+			for _, dir := range dirs {
+				if matched, _ := filepath.Match(dirPattern, dir); matched {
+					return pattern, true
+				}
+			}
+			continue
+		}
+		if matched, _ := filepath.Match(pattern, info.Name()); matched {
+			return pattern, true
+		}
+	}
+	for _, preset := range f.PresetTerms {
+		if preset.Expr.Match(info, path) {
+			return "preset " + preset.Name, true
+		}
+	}
+	return "", false
+}
+
+// MatchedClause returns the first clause matching a file, nil when the
+// filter has no clauses or none matches
+func (f *FileFilter) MatchedClause(info os.FileInfo, path string) *Clause {
+	f.compiled()
+	for i, expr := range f.clauseExprs {
+		if expr.Match(info, path) {
+			return &f.Clauses[i]
+		}
+	}
+	return nil
+}
+
+// MatchedRule names the clause, include pattern, preset or extension that
+// selected a file passing the filter. It is empty when the filter selects
+// files by size or age alone.
+func (f *FileFilter) MatchedRule(info os.FileInfo, path string) string {
+	if clause := f.MatchedClause(info, path); clause != nil {
+		return clause.Spec
+	}
+	if pattern, ok := f.matchInclude(info, path); ok {
+		return pattern
+	}
+	if len(f.Extensions) > 0 {
+		return filepath.Ext(info.Name())
+	}
+	return ""
+}
+
 // OlderThanFilter checks if a file is older than the specified time
 func (f *FileFilter) OlderThanFilter(info os.FileInfo) bool {
 	return info.ModTime().Before(f.OlderThan)
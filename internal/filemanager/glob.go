@@ -0,0 +1,140 @@
+package filemanager
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// errBadGlob reports a glob with an unclosed class or a trailing escape
+var errBadGlob = errors.New("syntax error in pattern")
+
+// matchGlob matches s against a glob. As with filepath.Match, * and ?
+// stop at "/", [...] matches a class and \ escapes the next character;
+// ** also matches across "/". Malformed patterns match nothing.
+func matchGlob(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			crossDirs := strings.HasPrefix(pattern, "**")
+			pattern = strings.TrimLeft(pattern, "*")
+			for i := 0; i <= len(s); i++ {
+				if matchGlob(pattern, s[i:]) {
+					return true
+				}
+				if i < len(s) && s[i] == '/' && !crossDirs {
+					return false
+				}
+			}
+			return false
+		case '?':
+			if s == "" || s[0] == '/' {
+				return false
+			}
+			_, n := utf8.DecodeRuneInString(s)
+			pattern, s = pattern[1:], s[n:]
+		case '[':
+			if s == "" || s[0] == '/' {
+				return false
+			}
+			r, n := utf8.DecodeRuneInString(s)
+			matched, width, err := matchClass(pattern, r)
+			if err != nil || !matched {
+				return false
+			}
+			pattern, s = pattern[width:], s[n:]
+		default:
+			if pattern[0] == '\\' {
+				if len(pattern) < 2 {
+					return false
+				}
+				pattern = pattern[1:]
+			}
+			want, n := utf8.DecodeRuneInString(pattern)
+			got, m := utf8.DecodeRuneInString(s)
+			if s == "" || want != got {
+				return false
+			}
+			pattern, s = pattern[n:], s[m:]
+		}
+	}
+	return s == ""
+}
+
+// matchClass matches r against the class at the start of pattern and
+// returns the width of the class. A leading ^ or ! negates it.
+func matchClass(pattern string, r rune) (bool, int, error) {
+	i := 1
+	negated := i < len(pattern) && (pattern[i] == '^' || pattern[i] == '!')
+	if negated {
+		i++
+	}
+	matched := false
+	for first := true; ; first = false {
+		if i >= len(pattern) {
+			return false, 0, errBadGlob
+		}
+		if pattern[i] == ']' && !first {
+			return matched != negated, i + 1, nil
+		}
+		lo, n, err := classRune(pattern[i:])
+		if err != nil {
+			return false, 0, err
+		}
+		i += n
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			if hi, n, err = classRune(pattern[i+1:]); err != nil {
+				return false, 0, err
+			}
+			i += 1 + n
+		}
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+}
+
+// classRune reads one possibly escaped character of a class
+func classRune(s string) (rune, int, error) {
+	if s[0] == '\\' {
+		if len(s) < 2 {
+			return 0, 0, errBadGlob
+		}
+		r, n := utf8.DecodeRuneInString(s[1:])
+		return r, n + 1, nil
+	}
+	r, n := utf8.DecodeRuneInString(s)
+	return r, n, nil
+}
+
+// validateGlob reports malformed classes and escapes in a glob
+func validateGlob(pattern string) error {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			if i++; i >= len(pattern) {
+				return errBadGlob
+			}
+		case '[':
+			_, width, err := matchClass(pattern[i:], 0)
+			if err != nil {
+				return err
+			}
+			i += width - 1
+		}
+	}
+	return nil
+}
+
+// escapeGlob escapes the characters a glob would treat as special
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,350 @@
+package filemanager
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/path"
+)
+
+// IndexVersion is bumped whenever the layout of the index file changes. An
+// index written by another version is discarded and rebuilt on the next scan.
+const IndexVersion = 1
+
+// indexRacyWindow keeps directories modified this recently out of the index.
+// A file added within the same timestamp tick as an earlier change would not
+// move the directory mtime, so such listings are read again next time.
+const indexRacyWindow = 2 * time.Second
+
+// DirIndex caches directory listings on disk, keyed by directory path and
+// validated by the directory mtime and inode. A directory whose mtime and
+// inode are unchanged since it was indexed is listed from the index without
+// reading it; any other directory is read again and its entry replaced. The
+// cached size and times of a file can be stale when the file was rewritten
+// in place, so scans re-read the files they match before reporting them.
+// A nil *DirIndex reads every directory directly.
+type DirIndex struct {
+	path string
+
+	mu      sync.Mutex
+	dirs    map[string]*indexedDir
+	updated time.Time
+	dirty   bool
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// IndexStats describes the contents and use of an index
+type IndexStats struct {
+	Path     string    // Location of the index file
+	Dirs     int       // Number of indexed directories
+	Entries  int       // Number of indexed files and subdirectories
+	FileSize int64     // Size of the index file in bytes, 0 before the first save
+	Updated  time.Time // When the index was last saved, zero if never
+	Hits     int64     // Directories listed from the index since it was opened
+	Misses   int64     // Directories read from disk since it was opened
+}
+
+// indexFile is the on-disk layout of the index
+type indexFile struct {
+	Version int
+	Updated time.Time
+	Dirs    map[string]*indexedDir
+}
+
+// indexedDir is the cached listing of one directory
+type indexedDir struct {
+	ModTime int64  // Directory mtime in nanoseconds when it was read
+	Inode   uint64 // Directory inode, 0 where the platform does not report it
+	Entries []indexedEntry
+}
+
+// indexedEntry is a cached directory entry. It implements fs.DirEntry.
+type indexedEntry struct {
+	FileName string
+	FileMode fs.FileMode
+	FileSize int64
+	MTime    int64 // Modification time in nanoseconds
+}
+
+func (e indexedEntry) Name() string               { return e.FileName }
+func (e indexedEntry) IsDir() bool                { return e.FileMode.IsDir() }
+func (e indexedEntry) Type() fs.FileMode          { return e.FileMode.Type() }
+func (e indexedEntry) Info() (fs.FileInfo, error) { return indexedInfo{e}, nil }
+
+// indexedInfo is the file info of a cached entry. Sys returns nil, so owner
+// and access time are unknown until the file is read again.
+type indexedInfo struct {
+	entry indexedEntry
+}
+
+func (i indexedInfo) Name() string       { return i.entry.FileName }
+func (i indexedInfo) Size() int64        { return i.entry.FileSize }
+func (i indexedInfo) Mode() fs.FileMode  { return i.entry.FileMode }
+func (i indexedInfo) ModTime() time.Time { return time.Unix(0, i.entry.MTime) }
+func (i indexedInfo) IsDir() bool        { return i.entry.FileMode.IsDir() }
+func (i indexedInfo) Sys() any           { return nil }
+
+// DefaultIndexPath returns the location of the index in the user config directory
+func DefaultIndexPath() string {
+	userConfigDir, _ := os.UserConfigDir()
+	return filepath.Join(userConfigDir, path.AppDirName, path.IndexFileName)
+}
+
+// OpenDirIndex loads the index stored at indexPath. A missing, unreadable or
+// outdated index file yields an empty index that is written on the next Save.
+func OpenDirIndex(indexPath string) (*DirIndex, error) {
+	x := &DirIndex{path: indexPath, dirs: make(map[string]*indexedDir)}
+
+	file, err := os.Open(indexPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return x, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var stored indexFile
+	if err := gob.NewDecoder(file).Decode(&stored); err != nil || stored.Version != IndexVersion {
+		x.dirty = true
+		return x, nil
+	}
+	if stored.Dirs != nil {
+		x.dirs = stored.Dirs
+	}
+	x.updated = stored.Updated
+	return x, nil
+}
+
+// ReadDir lists a directory like os.ReadDir, from the index when the
+// directory is unchanged since it was indexed
+func (x *DirIndex) ReadDir(dir string) ([]fs.DirEntry, error) {
+	if x == nil {
+		return os.ReadDir(dir)
+	}
+
+	key := indexKey(dir)
+	info, err := os.Stat(dir)
+	if err != nil {
+		x.mu.Lock()
+		x.forgetTree(key)
+		x.mu.Unlock()
+		return nil, err
+	}
+	modTime, inode := info.ModTime().UnixNano(), fileInode(info)
+
+	x.mu.Lock()
+	cached, ok := x.dirs[key]
+	x.mu.Unlock()
+	if ok && cached.ModTime == modTime && cached.Inode == inode {
+		x.hits.Add(1)
+		entries := make([]fs.DirEntry, len(cached.Entries))
+		for i, entry := range cached.Entries {
+			entries[i] = entry
+		}
+		return entries, nil
+	}
+
+	x.misses.Add(1)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	listing := &indexedDir{ModTime: modTime, Inode: inode, Entries: make([]indexedEntry, 0, len(entries))}
+	for _, entry := range entries {
+		entryInfo, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		listing.Entries = append(listing.Entries, indexedEntry{
+			FileName: entry.Name(),
+			FileMode: entryInfo.Mode(),
+			FileSize: entryInfo.Size(),
+			MTime:    entryInfo.ModTime().UnixNano(),
+		})
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if ok {
+		// Subdirectories that disappeared take their own listings with them
+		present := make(map[string]bool, len(listing.Entries))
+		for _, entry := range listing.Entries {
+			present[entry.FileName] = entry.IsDir()
+		}
+		for _, entry := range cached.Entries {
+			if entry.IsDir() && !present[entry.FileName] {
+				x.forgetTree(filepath.Join(key, entry.FileName))
+			}
+		}
+	}
+	if time.Since(info.ModTime()) < indexRacyWindow {
+		delete(x.dirs, key)
+	} else {
+		x.dirs[key] = listing
+	}
+	x.dirty = true
+
+	return entries, nil
+}
+
+// forgetTree drops a directory and everything indexed below it. The caller
+// holds x.mu.
+func (x *DirIndex) forgetTree(dir string) {
+	if _, ok := x.dirs[dir]; ok {
+		delete(x.dirs, dir)
+		x.dirty = true
+	}
+	prefix := dir + string(filepath.Separator)
+	for key := range x.dirs {
+		if strings.HasPrefix(key, prefix) {
+			delete(x.dirs, key)
+			x.dirty = true
+		}
+	}
+}
+
+// Rebuild drops the indexed listings and indexes every directory below the
+// given roots again. It stops early and returns ctx.Err() once ctx is done.
+func (x *DirIndex) Rebuild(ctx context.Context, roots []string) error {
+	x.mu.Lock()
+	x.dirs = make(map[string]*indexedDir)
+	x.dirty = true
+	x.mu.Unlock()
+
+	for _, root := range roots {
+		if _, err := os.Stat(root); err != nil {
+			return err
+		}
+		walkTree(ctx, root, walkOptions{workers: walkWorkers, index: x}, func(path string, d fs.DirEntry) bool {
+			return d.IsDir()
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Save writes the index to disk if it changed since it was opened. The file
+// is replaced atomically, so a concurrent reader never sees a partial index.
+func (x *DirIndex) Save() error {
+	if x == nil {
+		return nil
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if !x.dirty {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(x.path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(x.path), path.IndexFileName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	updated := time.Now()
+	if err := gob.NewEncoder(tmp).Encode(indexFile{Version: IndexVersion, Updated: updated, Dirs: x.dirs}); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), x.path); err != nil {
+		return err
+	}
+
+	x.updated = updated
+	x.dirty = false
+	return nil
+}
+
+// Clear empties the index and removes its file
+func (x *DirIndex) Clear() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.dirs = make(map[string]*indexedDir)
+	x.updated = time.Time{}
+	x.dirty = false
+	if err := os.Remove(x.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Stats reports the size of the index and how often it was used
+func (x *DirIndex) Stats() IndexStats {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	stats := IndexStats{
+		Path:    x.path,
+		Dirs:    len(x.dirs),
+		Updated: x.updated,
+		Hits:    x.hits.Load(),
+		Misses:  x.misses.Load(),
+	}
+	for _, dir := range x.dirs {
+		stats.Entries += len(dir.Entries)
+	}
+	if info, err := os.Stat(x.path); err == nil {
+		stats.FileSize = info.Size()
+	}
+	return stats
+}
+
+// indexKey returns the absolute, cleaned form of dir, so the same directory
+// reached through different relative paths shares one entry
+func indexKey(dir string) string {
+	if filepath.IsAbs(dir) {
+		return filepath.Clean(dir)
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return filepath.Clean(dir)
+}
+
+// matchFresh checks a file against the filter. Info served from the index
+// may be stale, so a cached match is confirmed against the file on disk and
+// the fresh info is returned for reporting. Filters comparing anything but
+// names, such as sizes, ages or owners, always use the file on disk, as a
+// file rewritten in place may now pass them.
+func matchFresh(filter *FileFilter, path string, info os.FileInfo) (os.FileInfo, bool) {
+	if _, cached := info.(indexedInfo); cached && filter.compiled() != nil && filter.needsStat {
+		fresh, err := os.Lstat(path)
+		if err != nil {
+			return nil, false
+		}
+		return fresh, filter.MatchesFilters(fresh, path)
+	}
+	if !filter.MatchesFilters(info, path) {
+		return info, false
+	}
+	if _, cached := info.(indexedInfo); !cached {
+		return info, true
+	}
+	fresh, err := os.Lstat(path)
+	if err != nil {
+		return nil, false
+	}
+	return fresh, filter.MatchesFilters(fresh, path)
+}
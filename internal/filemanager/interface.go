@@ -1,6 +1,7 @@
 package filemanager
 
 import (
+	"context"
 	"os"
 	"time"
 )
@@ -8,22 +9,42 @@ import (
 // FileManager defines the interface for file system operations
 type FileManager interface {
 	NewFileFilter(minSize, maxSize int64, extensions map[string]struct{}, exclude []string, olderThan, newerThan time.Time) *FileFilter
-	WalkFilesWithFilter(callback func(fi os.FileInfo, path string), dir string, filter *FileFilter)
-	MoveFilesToTrash(dir string, extensions []string, exclude []string, minSize, maxSize int64, olderThan, newerThan time.Time)
-	DeleteFiles(dir string, extensions []string, exclude []string, minSize, maxSize int64, olderThan, newerThan time.Time)
-	DeleteEmptySubfolders(dir string)
+	WalkFilesWithFilter(ctx context.Context, callback func(fi os.FileInfo, path string), dir string, filter *FileFilter) error
+	MoveFilesToTrash(ctx context.Context, dir string, extensions []string, exclude []string, minSize, maxSize int64, olderThan, newerThan time.Time) error
+	DeleteFiles(ctx context.Context, dir string, extensions []string, exclude []string, minSize, maxSize int64, olderThan, newerThan time.Time) error
+	DeleteEmptySubfolders(ctx context.Context, dir string) error
 	IsEmptyDir(dir string) bool
 	ExpandTilde(path string) string
-	CalculateDirSize(path string) int64
+	CalculateDirSize(ctx context.Context, path string) int64
 	DeleteFile(filePath string)
 	MoveFileToTrash(filePath string)
+	DeleteDir(dir string) error
+	TrashDir(dir string) error
 }
 
 // defaultFileManager implements the FileManager interface
 type defaultFileManager struct {
+	index  *DirIndex  // Optional directory index, nil to read directories directly
+	limits WalkLimits // Depth and directory size limits of CalculateDirSize
 }
 
 // NewFileManager creates a new instance of the default file manager
 func NewFileManager() FileManager {
 	return &defaultFileManager{}
 }
+
+// NewFileManagerWithIndex creates a file manager that lists unchanged
+// directories from the given index when walking and sizing directories
+func NewFileManagerWithIndex(index *DirIndex) FileManager {
+	return &defaultFileManager{index: index}
+}
+
+// Index returns the directory index used by the file manager, nil if none
+func (f *defaultFileManager) Index() *DirIndex {
+	return f.index
+}
+
+// indexedFileManager is implemented by file managers that use a directory index
+type indexedFileManager interface {
+	Index() *DirIndex
+}
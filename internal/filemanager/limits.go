@@ -0,0 +1,93 @@
+package filemanager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WalkLimits bounds how deep walks go, like find -mindepth and -maxdepth.
+// The walk root is at depth 0 and the entries directly in it at depth 1.
+type WalkLimits struct {
+	MinDepth      int // Entries above this depth are not reported, 0 for no limit
+	MaxDepth      int // Entries below this depth are not read, 0 for no limit
+	MaxDirEntries int // Subdirectories holding more entries are not entered, 0 for no limit
+}
+
+// Validate reports negative limits and a minimum depth beyond the maximum
+func (l WalkLimits) Validate() error {
+	switch {
+	case l.MinDepth < 0:
+		return fmt.Errorf("minimum depth %d is negative", l.MinDepth)
+	case l.MaxDepth < 0:
+		return fmt.Errorf("maximum depth %d is negative", l.MaxDepth)
+	case l.MaxDirEntries < 0:
+		return fmt.Errorf("directory entry limit %d is negative", l.MaxDirEntries)
+	case l.MaxDepth > 0 && l.MinDepth > l.MaxDepth:
+		return fmt.Errorf("minimum depth %d is beyond the maximum depth %d", l.MinDepth, l.MaxDepth)
+	}
+	return nil
+}
+
+// reports reports whether entries at the depth are visited
+func (l WalkLimits) reports(depth int) bool {
+	return depth >= l.MinDepth
+}
+
+// descends reports whether a directory at the depth is read
+func (l WalkLimits) descends(depth int) bool {
+	return l.MaxDepth == 0 || depth < l.MaxDepth
+}
+
+// exceeds reports whether a directory of n entries is over the limit
+func (l WalkLimits) exceeds(n int) bool {
+	return l.MaxDirEntries > 0 && n > l.MaxDirEntries
+}
+
+// tooManyEntries reports whether dir holds more entries than the limit, for
+// walks that do not list the directory themselves. At most one entry past
+// the limit is read, so huge directories are not listed.
+func (l WalkLimits) tooManyEntries(dir string) bool {
+	if l.MaxDirEntries == 0 {
+		return false
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	names, _ := f.Readdirnames(l.MaxDirEntries + 1)
+	return len(names) > l.MaxDirEntries
+}
+
+// walkLimits returns the limits of a filter, none for a nil filter
+func walkLimits(filter *FileFilter) WalkLimits {
+	if filter == nil {
+		return WalkLimits{}
+	}
+	return filter.WalkLimits
+}
+
+// pathDepth returns how many levels path is below root, -1 when it is not
+// below root
+func pathDepth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return -1
+	}
+	if rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// WithWalkLimits returns a file manager like fm, sharing its index, whose
+// directory sizes stay within the limits. Other FileManager implementations
+// are returned unchanged.
+func WithWalkLimits(fm FileManager, limits WalkLimits) FileManager {
+	if f, ok := fm.(*defaultFileManager); ok {
+		return &defaultFileManager{index: f.index, limits: limits}
+	}
+	return fm
+}
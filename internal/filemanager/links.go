@@ -0,0 +1,148 @@
+package filemanager
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+)
+
+// SymlinkPolicy selects how scans treat symbolic links
+type SymlinkPolicy string
+
+const (
+	// SymlinkNever matches links as entries of their own and never follows them
+	SymlinkNever SymlinkPolicy = "never"
+	// SymlinkFollow descends into links to directories, reading every
+	// directory once so link loops end the walk instead of repeating it
+	SymlinkFollow SymlinkPolicy = "follow"
+	// SymlinkBroken does not follow links and reports links whose target is
+	// missing as a separate category
+	SymlinkBroken SymlinkPolicy = "broken"
+)
+
+// SymlinkPolicies returns the accepted policy names, the default first
+func SymlinkPolicies() []string {
+	return []string{string(SymlinkNever), string(SymlinkFollow), string(SymlinkBroken)}
+}
+
+// ParseSymlinkPolicy parses a policy name. An empty name selects SymlinkNever.
+func ParseSymlinkPolicy(name string) (SymlinkPolicy, error) {
+	switch policy := SymlinkPolicy(strings.ToLower(strings.TrimSpace(name))); policy {
+	case "":
+		return SymlinkNever, nil
+	case SymlinkNever, SymlinkFollow, SymlinkBroken:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown symlink policy %q, want one of %s", name, strings.Join(SymlinkPolicies(), ", "))
+}
+
+// isSymlink reports whether a directory entry is a symbolic link
+func isSymlink(d fs.DirEntry) bool {
+	return d.Type()&fs.ModeSymlink != 0
+}
+
+// isBrokenLink reports whether path is a symbolic link whose target does not exist
+func isBrokenLink(path string) bool {
+	_, err := os.Stat(path)
+	return err != nil && os.IsNotExist(err)
+}
+
+// followLinks returns the visited set for a walk under the filter's symlink
+// policy, or nil when the walk does not follow links
+func followLinks(filter *FileFilter) *visitedDirs {
+	if filter == nil || filter.Symlinks != SymlinkFollow {
+		return nil
+	}
+	return newVisitedDirs()
+}
+
+// fileID identifies a file on disk across all of its hard links
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// visitedDirs remembers the directories a walk that follows links has read,
+// so a directory reached again through a link is not read twice
+type visitedDirs struct {
+	mu   sync.Mutex
+	seen map[fileID]struct{}
+}
+
+func newVisitedDirs() *visitedDirs {
+	return &visitedDirs{seen: make(map[fileID]struct{})}
+}
+
+// claim reports whether dir has not been read yet and marks it as read. A
+// directory whose device and inode are unknown is always claimed.
+func (v *visitedDirs) claim(dir string) bool {
+	if v == nil {
+		return true
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
+	dev, ok := fileDevice(info)
+	ino := fileInode(info)
+	if !ok || ino == 0 {
+		return true
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, seen := v.seen[fileID{dev, ino}]; seen {
+		return false
+	}
+	v.seen[fileID{dev, ino}] = struct{}{}
+	return true
+}
+
+// freedSize returns the space that removing all of the entries frees. A file
+// with several hard links is counted once, and only when every one of its
+// links is among the entries; otherwise the remaining links keep its data.
+// Entries whose inode is unknown are counted in full.
+func freedSize(entries []FileEntry) int64 {
+	type linkCount struct {
+		size  int64
+		links uint64 // Links the file has on disk
+		found uint64 // Links among the entries
+	}
+
+	var freed int64
+	groups := make(map[fileID]*linkCount)
+	for _, entry := range entries {
+		if entry.Links <= 1 || entry.Inode == 0 {
+			freed += entry.Size
+			continue
+		}
+		id := fileID{entry.Device, entry.Inode}
+		group, ok := groups[id]
+		if !ok {
+			group = &linkCount{size: entry.Size, links: entry.Links}
+			groups[id] = group
+		}
+		group.found++
+	}
+	for _, group := range groups {
+		if group.found >= group.links {
+			freed += group.size
+		}
+	}
+	return freed
+}
+
+// FreedSizeOf returns the space removing the files at paths frees, counting
+// hard links like ScanResult.FreedSize. Files that cannot be read count as
+// nothing, so call it before removing the files.
+func FreedSizeOf(paths []string) int64 {
+	entries := make([]FileEntry, 0, len(paths))
+	for _, path := range paths {
+		if info, err := os.Lstat(path); err == nil {
+			entries = append(entries, NewFileEntry(path, info))
+		}
+	}
+	return freedSize(entries)
+}
@@ -0,0 +1,172 @@
+package filemanager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Mount is a mounted filesystem from the system mount table
+type Mount struct {
+	Point   string   // Absolute mount point
+	Type    string   // Filesystem type, e.g. ext4, nfs4 or proc
+	Source  string   // Mounted device, share or pseudo source
+	Options []string // Per-mount options, e.g. rw, nosuid or noatime
+}
+
+// HasOption reports whether the filesystem is mounted with an option
+func (m Mount) HasOption(name string) bool {
+	for _, option := range m.Options {
+		if option == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MountOf returns the mount holding path, the one with the longest mount
+// point that contains it. It reports false without a readable mount table.
+func MountOf(path string) (Mount, bool) {
+	mounts, err := ReadMounts()
+	if err != nil {
+		return Mount{}, false
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	var found Mount
+	ok := false
+	for _, mount := range mounts {
+		prefix := strings.TrimSuffix(mount.Point, string(filepath.Separator)) + string(filepath.Separator)
+		if path != mount.Point && !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		// Later entries are mounted over earlier ones at the same point
+		if !ok || len(mount.Point) >= len(found.Point) {
+			found, ok = mount, true
+		}
+	}
+	return found, ok
+}
+
+// NoAtimeMount returns the mount holding path when it is mounted noatime.
+// Reads do not update access times there, so access time filters compare
+// against when files were created rather than last read.
+func NoAtimeMount(path string) (Mount, bool) {
+	mount, ok := MountOf(path)
+	if !ok || !mount.HasOption("noatime") {
+		return Mount{}, false
+	}
+	return mount, true
+}
+
+// filesystemGroups are names that can be used with SkipFilesystems in place
+// of listing every filesystem type of a kind
+var filesystemGroups = map[string][]string{
+	"network": {"nfs", "nfs4", "cifs", "smb3", "smbfs", "ncpfs", "afs", "9p", "ceph", "glusterfs", "lustre", "fuse.sshfs", "fuse.rclone", "davfs"},
+	"pseudo": {"proc", "sysfs", "devtmpfs", "devpts", "cgroup", "cgroup2", "securityfs", "debugfs", "tracefs",
+		"pstore", "bpf", "mqueue", "hugetlbfs", "configfs", "fusectl", "autofs", "binfmt_misc", "efivarfs", "selinuxfs",
+		"rpc_pipefs", "nsfs"},
+}
+
+// FilesystemGroups returns the group names accepted by MatchFilesystem
+func FilesystemGroups() []string {
+	return []string{"network", "pseudo"}
+}
+
+// MatchFilesystem reports whether a filesystem type matches one of the
+// patterns. A pattern is a group name such as network or pseudo, or a glob
+// matched against the type, e.g. nfs* or fuse.*.
+func MatchFilesystem(patterns []string, fsType string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if group, ok := filesystemGroups[pattern]; ok {
+			for _, member := range group {
+				if member == fsType {
+					return true
+				}
+			}
+			continue
+		}
+		if matched, _ := filepath.Match(pattern, fsType); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// mountGuard decides whether a walk may descend into a directory, keeping
+// it on the filesystem of the root and out of skipped mount types. A nil
+// guard allows every directory.
+type mountGuard struct {
+	root     string
+	absRoot  string
+	rootDev  uint64
+	checkDev bool
+	blocked  map[string]struct{} // Mount points below the root that are not entered
+}
+
+// newMountGuard builds the guard for a walk of root with the filter's
+// OneFileSystem and SkipFilesystems options, or nil when neither is set.
+// Without a readable mount table only the device check applies.
+func newMountGuard(root string, filter *FileFilter) *mountGuard {
+	if filter == nil || (!filter.OneFileSystem && len(filter.SkipFilesystems) == 0) {
+		return nil
+	}
+
+	g := &mountGuard{root: root, absRoot: root, blocked: make(map[string]struct{})}
+	if abs, err := filepath.Abs(root); err == nil {
+		g.absRoot = abs
+	}
+	if filter.OneFileSystem {
+		if info, err := os.Stat(root); err == nil {
+			g.rootDev, g.checkDev = fileDevice(info)
+		}
+	}
+
+	// Bind mounts keep the device of their source, so with OneFileSystem
+	// every mount point below the root is a boundary as well
+	mounts, _ := ReadMounts()
+	prefix := strings.TrimSuffix(g.absRoot, string(filepath.Separator)) + string(filepath.Separator)
+	for _, mount := range mounts {
+		if !strings.HasPrefix(mount.Point, prefix) {
+			continue
+		}
+		if filter.OneFileSystem || MatchFilesystem(filter.SkipFilesystems, mount.Type) {
+			g.blocked[mount.Point] = struct{}{}
+		}
+	}
+	return g
+}
+
+// allows reports whether the walk may descend into the directory at path
+func (g *mountGuard) allows(path string) bool {
+	if g == nil {
+		return true
+	}
+
+	if len(g.blocked) > 0 {
+		abs := path
+		if rel, err := filepath.Rel(g.root, path); err == nil {
+			abs = filepath.Join(g.absRoot, rel)
+		}
+		if _, ok := g.blocked[abs]; ok {
+			return false
+		}
+	}
+	if g.checkDev {
+		// Stat, so a followed link is judged by the directory it points to
+		info, err := os.Stat(path)
+		if err != nil {
+			return false
+		}
+		if dev, ok := fileDevice(info); ok && dev != g.rootDev {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+//go:build linux
+// +build linux
+
+package filemanager
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// mountInfoPath is the mount table of the current process
+const mountInfoPath = "/proc/self/mountinfo"
+
+// ReadMounts returns the mounted filesystems listed in /proc/self/mountinfo
+func ReadMounts() ([]Mount, error) {
+	file, err := os.Open(mountInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var mounts []Mount
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		mount, err := parseMountInfoLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", mountInfoPath, err)
+		}
+		mounts = append(mounts, mount)
+	}
+	return mounts, scanner.Err()
+}
+
+// parseMountInfoLine parses a line such as
+//
+//	36 35 98:0 /mnt1 /mnt/parent rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+//
+// where a variable number of optional fields ends with a single "-"
+func parseMountInfoLine(line string) (Mount, error) {
+	fields := strings.Fields(line)
+	separator := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == "-" {
+			separator = i
+			break
+		}
+	}
+	if len(fields) < 6 || separator < 0 || separator+2 >= len(fields) {
+		return Mount{}, fmt.Errorf("malformed line %q", line)
+	}
+
+	return Mount{
+		Point:   unescapeMountField(fields[4]),
+		Type:    fields[separator+1],
+		Source:  unescapeMountField(fields[separator+2]),
+		Options: strings.Split(fields[5], ","),
+	}, nil
+}
+
+// unescapeMountField decodes the octal escapes the kernel uses for spaces,
+// tabs, newlines and backslashes in paths
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if code, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package filemanager
+
+import "errors"
+
+// ReadMounts is only implemented on top of /proc/self/mountinfo
+func ReadMounts() ([]Mount, error) {
+	return nil, errors.New("the mount table is only available on Linux")
+}
@@ -0,0 +1,102 @@
+package filemanager
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OpenFiles is a snapshot of the files that processes hold open or have
+// mapped into memory. Removing such a file only drops its name: the space
+// stays allocated until the last process closes it, and a daemon writing to
+// it keeps writing to a file nobody can see.
+type OpenFiles struct {
+	files      map[fileID]struct{}
+	Deleted    []DeletedOpenFile // Files already removed but still held open, largest first
+	Unreadable int               // Processes whose files could not be read, usually those of other users
+}
+
+// DeletedOpenFile is a removed file whose space a process still holds
+type DeletedOpenFile struct {
+	Path string // Path the file had when it was opened
+	PID  int    // One of the processes holding it
+	Size int64  // Size in bytes, 0 when only known from a memory mapping
+}
+
+// Contains reports whether the file described by info is held open. A nil
+// snapshot holds nothing.
+func (o *OpenFiles) Contains(info os.FileInfo) bool {
+	if o == nil {
+		return false
+	}
+	dev, ok := fileDevice(info)
+	ino := fileInode(info)
+	if !ok || ino == 0 {
+		return false
+	}
+	_, open := o.files[fileID{dev, ino}]
+	return open
+}
+
+// ContainsEntry reports whether the scanned file is held open
+func (o *OpenFiles) ContainsEntry(entry FileEntry) bool {
+	if o == nil || entry.Inode == 0 {
+		return false
+	}
+	_, open := o.files[fileID{entry.Device, entry.Inode}]
+	return open
+}
+
+// Len returns the number of distinct files held open
+func (o *OpenFiles) Len() int {
+	if o == nil {
+		return 0
+	}
+	return len(o.files)
+}
+
+// DeletedBelow returns the removed but still open files that were in dir
+// or below it, largest first
+func (o *OpenFiles) DeletedBelow(dir string) []DeletedOpenFile {
+	if o == nil {
+		return nil
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	prefix := strings.TrimSuffix(filepath.Clean(dir), string(filepath.Separator)) + string(filepath.Separator)
+	var below []DeletedOpenFile
+	for _, file := range o.Deleted {
+		if strings.HasPrefix(file.Path, prefix) {
+			below = append(below, file)
+		}
+	}
+	return below
+}
+
+// DeletedSize returns the space held by removed files that were in dir or
+// below it and are still open
+func (o *OpenFiles) DeletedSize(dir string) int64 {
+	var size int64
+	for _, file := range o.DeletedBelow(dir) {
+		size += file.Size
+	}
+	return size
+}
+
+// add records an open file, and its deleted copy when its name is gone
+func (o *OpenFiles) add(id fileID, deleted *DeletedOpenFile) {
+	if _, seen := o.files[id]; seen {
+		return
+	}
+	o.files[id] = struct{}{}
+	if deleted != nil {
+		o.Deleted = append(o.Deleted, *deleted)
+	}
+}
+
+// sortDeleted orders the deleted files by the space they hold
+func (o *OpenFiles) sortDeleted() {
+	sort.SliceStable(o.Deleted, func(i, j int) bool { return o.Deleted[i].Size > o.Deleted[j].Size })
+}
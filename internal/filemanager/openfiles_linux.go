@@ -0,0 +1,119 @@
+//go:build linux
+// +build linux
+
+package filemanager
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// deletedSuffix marks the link of an open file whose name was removed
+const deletedSuffix = " (deleted)"
+
+// ReadOpenFiles lists the files held open by every process it may inspect,
+// from the descriptors in /proc/PID/fd and the mappings in /proc/PID/maps.
+// Without root the files of other users' processes cannot be read; they are
+// counted in Unreadable.
+func ReadOpenFiles() (*OpenFiles, error) {
+	return readOpenFiles("/proc")
+}
+
+// readOpenFiles lists the open files of the processes below procRoot
+func readOpenFiles(procRoot string) (*OpenFiles, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	open := &OpenFiles{files: make(map[fileID]struct{})}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		procDir := filepath.Join(procRoot, entry.Name())
+		fdsRead := readDescriptors(open, procDir, pid)
+		mapsRead := readMappings(open, procDir, pid)
+		if !fdsRead && !mapsRead {
+			open.Unreadable++
+		}
+	}
+	open.sortDeleted()
+	return open, nil
+}
+
+// readDescriptors adds the files a process has open. Stat follows the
+// descriptor links to the open file itself, even when its name is gone.
+func readDescriptors(open *OpenFiles, procDir string, pid int) bool {
+	fdDir := filepath.Join(procDir, "fd")
+	fds, err := os.ReadDir(fdDir)
+	if err != nil {
+		return false
+	}
+	for _, fd := range fds {
+		link := filepath.Join(fdDir, fd.Name())
+		info, err := os.Stat(link)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			continue
+		}
+
+		var deleted *DeletedOpenFile
+		if target, err := os.Readlink(link); err == nil && stat.Nlink == 0 {
+			deleted = &DeletedOpenFile{Path: strings.TrimSuffix(target, deletedSuffix), PID: pid, Size: info.Size()}
+		}
+		open.add(fileID{uint64(stat.Dev), uint64(stat.Ino)}, deleted)
+	}
+	return true
+}
+
+// readMappings adds the files a process has mapped into memory, such as
+// libraries and memory-mapped databases. Each line of maps reads
+// "address perms offset major:minor inode path".
+func readMappings(open *OpenFiles, procDir string, pid int) bool {
+	f, err := os.Open(filepath.Join(procDir, "maps"))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 6)
+		if len(fields) < 6 {
+			continue
+		}
+		ino, err := strconv.ParseUint(fields[4], 10, 64)
+		if err != nil || ino == 0 {
+			continue
+		}
+		major, minor, ok := strings.Cut(fields[3], ":")
+		if !ok {
+			continue
+		}
+		majorNum, err1 := strconv.ParseUint(major, 16, 32)
+		minorNum, err2 := strconv.ParseUint(minor, 16, 32)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		var deleted *DeletedOpenFile
+		path := strings.TrimLeft(fields[5], " ")
+		if strings.HasSuffix(path, deletedSuffix) {
+			deleted = &DeletedOpenFile{Path: strings.TrimSuffix(path, deletedSuffix), PID: pid}
+		}
+		open.add(fileID{unix.Mkdev(uint32(majorNum), uint32(minorNum)), ino}, deleted)
+	}
+	// A process may exit while its maps are read, leaving them empty
+	return scanner.Err() == nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package filemanager
+
+import "errors"
+
+// ReadOpenFiles is only implemented on top of the Linux /proc filesystem
+func ReadOpenFiles() (*OpenFiles, error) {
+	return nil, errors.New("checking for open files needs /proc and is only supported on Linux")
+}
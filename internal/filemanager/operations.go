@@ -1,11 +1,11 @@
 package filemanager
 
 import (
+	"context"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"time"
 
@@ -13,51 +13,41 @@ import (
 	"github.com/pashkov256/deletor/internal/utils"
 )
 
-// WalkFilesWithFilter traverses files in a directory with concurrent processing
-// and applies the given filter to each file
-func (f *defaultFileManager) WalkFilesWithFilter(callback func(fi os.FileInfo, path string), dir string, filter *FileFilter) {
-	taskCh := make(chan struct{}, runtime.NumCPU())
-	var wg sync.WaitGroup
-
-	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if info == nil {
-			return nil
-		}
-
+// WalkFilesWithFilter traverses files in a directory on a fixed pool of
+// workers and applies the given filter to each entry. The walk stops as soon
+// as ctx is done; callbacks already running are waited for and ctx.Err() is
+// returned.
+func (f *defaultFileManager) WalkFilesWithFilter(ctx context.Context, callback func(fi os.FileInfo, path string), dir string, filter *FileFilter) error {
+	walkTree(ctx, dir, walkOptions{workers: walkWorkers, index: f.index, mounts: newMountGuard(dir, filter), visited: followLinks(filter), limits: walkLimits(filter)}, func(path string, d fs.DirEntry) bool {
+		info, err := d.Info()
 		if err != nil {
-			return nil
+			return false
 		}
-
-		wg.Add(1)
-		go func(path string, info os.FileInfo) {
-			defer wg.Done()
-			// Acquire token from channel first
-			taskCh <- struct{}{}
-			defer func() { <-taskCh }() // Release token when done
-			if filter.MatchesFilters(info, path) {
-				callback(info, path)
-			}
-		}(path, info)
-		return nil
+		if info, ok := matchFresh(filter, path, info); ok {
+			callback(info, path)
+		}
+		return true
 	})
-
-	wg.Wait()
+	return ctx.Err()
 }
 
 // DeleteFiles removes files matching the specified criteria from the given directory
-func (f *defaultFileManager) DeleteFiles(dir string, extensions []string, exclude []string, minSize, maxSize int64, olderThan, newerThan time.Time) {
+func (f *defaultFileManager) DeleteFiles(ctx context.Context, dir string, extensions []string, exclude []string, minSize, maxSize int64, olderThan, newerThan time.Time) error {
 	callback := func(fi os.FileInfo, path string) {
 		os.Remove(path)
 	}
 	fileFilter := f.NewFileFilter(minSize, maxSize, utils.ParseExtToMap(extensions), exclude, olderThan, newerThan)
-	f.WalkFilesWithFilter(callback, dir, fileFilter)
+	return f.WalkFilesWithFilter(ctx, callback, dir, fileFilter)
 }
 
 // DeleteEmptySubfolders removes all empty directories in the given path
-func (f *defaultFileManager) DeleteEmptySubfolders(dir string) {
+func (f *defaultFileManager) DeleteEmptySubfolders(ctx context.Context, dir string) error {
 	emptyDirs := make([]string, 0)
 
 	filepath.WalkDir(dir, func(path string, info os.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return filepath.SkipAll
+		}
 		if info == nil || !info.IsDir() {
 			return nil
 		}
@@ -70,13 +60,19 @@ func (f *defaultFileManager) DeleteEmptySubfolders(dir string) {
 	})
 
 	for i := len(emptyDirs) - 1; i >= 0; i-- {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		os.Remove(emptyDirs[i])
 	}
+	return ctx.Err()
 }
 
 // CalculateDirSize computes the total size of all files in a directory
-// Uses concurrent processing with limits to handle large directories efficiently
-func (f *defaultFileManager) CalculateDirSize(path string) int64 {
+// Uses a fixed pool of workers to handle large directories efficiently.
+// When ctx is done the walk stops and the size counted so far is returned.
+// Only files within the walk limits of the file manager are counted.
+func (f *defaultFileManager) CalculateDirSize(ctx context.Context, path string) int64 {
 	// For very large directories, return a placeholder value immediately
 	// to avoid blocking the UI
 	_, err := os.Stat(path)
@@ -91,68 +87,31 @@ func (f *defaultFileManager) CalculateDirSize(path string) int64 {
 	}
 
 	var totalSize int64 = 0
-
-	// Use a channel to limit concurrency
-	semaphore := make(chan struct{}, 10)
-	var wg sync.WaitGroup
-
-	// Create a function to process a directory
-	var processDir func(string) int64
-	processDir = func(dirPath string) int64 {
-		var size int64 = 0
-		entries, err := os.ReadDir(dirPath)
-		if err != nil {
-			return 0
+	walkTree(ctx, path, walkOptions{workers: walkWorkers, index: f.index, limits: f.limits}, func(path string, entry fs.DirEntry) bool {
+		// Skip hidden files and directories unless enabled
+		if strings.HasPrefix(entry.Name(), ".") {
+			return false
 		}
-
-		for _, entry := range entries {
-			// Skip hidden files and directories unless enabled
-			if strings.HasPrefix(entry.Name(), ".") {
-				continue
-			}
-
-			fullPath := filepath.Join(dirPath, entry.Name())
-			if entry.IsDir() {
-				// Process directories with concurrency limits
-				wg.Add(1)
-				go func(p string) {
-					semaphore <- struct{}{}
-					defer func() {
-						<-semaphore
-						wg.Done()
-					}()
-					dirSize := processDir(p)
-					atomic.AddInt64(&totalSize, dirSize)
-				}(fullPath)
-			} else {
-				// Process files directly
-				info, err := entry.Info()
-				if err == nil {
-					fileSize := info.Size()
-					atomic.AddInt64(&totalSize, fileSize)
-					size += fileSize
-				}
-			}
+		if entry.IsDir() {
+			return true
 		}
-		return size
-	}
-
-	// Start processing
-	processDir(path)
-
-	wg.Wait()
+		if info, err := entry.Info(); err == nil {
+			atomic.AddInt64(&totalSize, info.Size())
+		}
+		return false
+	})
 
 	return totalSize
 }
 
 // MoveFilesToTrash moves files matching the criteria to the system's recycle bin
-func (f *defaultFileManager) MoveFilesToTrash(dir string, extensions []string, exclude []string, minSize, maxSize int64, olderThan, newerThan time.Time) {
+func (f *defaultFileManager) MoveFilesToTrash(ctx context.Context, dir string, extensions []string, exclude []string, minSize, maxSize int64, olderThan, newerThan time.Time) error {
 	callback := func(fi os.FileInfo, path string) {
 		f.MoveFileToTrash(path)
 	}
 
 	fileFilter := f.NewFileFilter(minSize, maxSize, utils.ParseExtToMap(extensions), exclude, olderThan, newerThan)
-	f.WalkFilesWithFilter(callback, dir, fileFilter)
+	return f.WalkFilesWithFilter(ctx, callback, dir, fileFilter)
 }
 
 // MoveFileToTrash moves a single file to the system's recycle bin
@@ -0,0 +1,243 @@
+package filemanager
+
+import (
+	"fmt"
+	"io/fs"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LookupOwner resolves a user name or numeric user ID to the user ID
+func LookupOwner(name string) (int, error) {
+	name = strings.TrimSpace(name)
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return int(id), nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return -1, fmt.Errorf("unknown user %q", name)
+	}
+	id, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return -1, fmt.Errorf("user %q has no numeric ID", name)
+	}
+	return id, nil
+}
+
+// LookupGroup resolves a group name or numeric group ID to the group ID
+func LookupGroup(name string) (int, error) {
+	name = strings.TrimSpace(name)
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return int(id), nil
+	}
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return -1, fmt.Errorf("unknown group %q", name)
+	}
+	id, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return -1, fmt.Errorf("group %q has no numeric ID", name)
+	}
+	return id, nil
+}
+
+// LookupOwners resolves each user name or ID with LookupOwner
+func LookupOwners(names []string) ([]int, error) {
+	return lookupIDs(names, LookupOwner)
+}
+
+// LookupGroups resolves each group name or ID with LookupGroup
+func LookupGroups(names []string) ([]int, error) {
+	return lookupIDs(names, LookupGroup)
+}
+
+// lookupIDs resolves every name, stopping at the first unknown one
+func lookupIDs(names []string, lookup func(string) (int, error)) ([]int, error) {
+	var ids []int
+	for _, name := range names {
+		id, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ownerNames caches the user names shown next to scanned files
+var ownerNames sync.Map
+
+// OwnerName returns the name of the user with the given ID, the ID itself
+// when the user is unknown, or an empty string for a negative ID
+func OwnerName(uid int) string {
+	if uid < 0 {
+		return ""
+	}
+	if name, ok := ownerNames.Load(uid); ok {
+		return name.(string)
+	}
+	name := strconv.Itoa(uid)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	ownerNames.Store(uid, name)
+	return name
+}
+
+// PermMatch selects how a PermFilter compares permission bits
+type PermMatch int
+
+const (
+	PermExact PermMatch = iota // mode: the bits are exactly the mode
+	PermAll                    // -mode: all bits of the mode are set
+	PermAny                    // /mode: any bit of the mode is set
+)
+
+// PermFilter matches permission bits like find -perm. The mode is octal,
+// such as 644, or symbolic, such as o+w or u=rwx,g=rx, starting from no
+// bits set.
+type PermFilter struct {
+	Bits  uint32 // Permission bits including setuid, setgid and sticky (07777)
+	Match PermMatch
+	spec  string
+}
+
+// ParsePermFilter parses a find-style -perm argument: mode, -mode or /mode.
+// An empty spec returns nil, which matches every file.
+func ParsePermFilter(spec string) (*PermFilter, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	filter := &PermFilter{Match: PermExact, spec: spec}
+	mode := spec
+	switch spec[0] {
+	case '-':
+		filter.Match, mode = PermAll, spec[1:]
+	case '/':
+		filter.Match, mode = PermAny, spec[1:]
+	}
+	if mode == "" {
+		return nil, fmt.Errorf("invalid permission mode %q", spec)
+	}
+
+	if bits, err := strconv.ParseUint(mode, 8, 32); err == nil {
+		if bits > 07777 {
+			return nil, fmt.Errorf("invalid permission mode %q", spec)
+		}
+		filter.Bits = uint32(bits)
+		return filter, nil
+	}
+	bits, err := parseSymbolicMode(mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid permission mode %q: %w", spec, err)
+	}
+	filter.Bits = bits
+	return filter, nil
+}
+
+// parseSymbolicMode applies chmod-style clauses such as u+rw,go=r to a mode
+// with no bits set
+func parseSymbolicMode(mode string) (uint32, error) {
+	var bits uint32
+	for _, clause := range strings.Split(mode, ",") {
+		var who uint32
+		i := 0
+		for ; i < len(clause) && strings.IndexByte("ugoa", clause[i]) >= 0; i++ {
+			switch clause[i] {
+			case 'u':
+				who |= 04700
+			case 'g':
+				who |= 02070
+			case 'o':
+				who |= 01007
+			case 'a':
+				who |= 07777
+			}
+		}
+		if who == 0 {
+			who = 07777
+		}
+		if i == len(clause) {
+			return 0, fmt.Errorf("missing operator in %q", clause)
+		}
+		for i < len(clause) {
+			op := clause[i]
+			if op != '+' && op != '-' && op != '=' {
+				return 0, fmt.Errorf("unexpected %q in %q", op, clause)
+			}
+			i++
+
+			var perm uint32
+			for ; i < len(clause) && strings.IndexByte("+-=", clause[i]) < 0; i++ {
+				switch clause[i] {
+				case 'r':
+					perm |= 0444
+				case 'w':
+					perm |= 0222
+				case 'x', 'X':
+					perm |= 0111
+				case 's':
+					perm |= 06000
+				case 't':
+					perm |= 01000
+				default:
+					return 0, fmt.Errorf("unknown permission %q in %q", clause[i], clause)
+				}
+			}
+			perm &= who
+
+			switch op {
+			case '+':
+				bits |= perm
+			case '-':
+				bits &^= perm
+			case '=':
+				bits = bits&^who | perm
+			}
+		}
+	}
+	return bits, nil
+}
+
+// Matches reports whether a file mode passes the filter. A nil filter
+// matches every mode.
+func (p *PermFilter) Matches(mode fs.FileMode) bool {
+	if p == nil {
+		return true
+	}
+	bits := unixPerm(mode)
+	switch p.Match {
+	case PermAll:
+		return bits&p.Bits == p.Bits
+	case PermAny:
+		return p.Bits == 0 || bits&p.Bits != 0
+	}
+	return bits == p.Bits
+}
+
+// String returns the spec the filter was parsed from
+func (p *PermFilter) String() string {
+	if p == nil {
+		return ""
+	}
+	return p.spec
+}
+
+// unixPerm converts a file mode to the permission bits used by chmod
+func unixPerm(mode fs.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if mode&fs.ModeSticky != 0 {
+		bits |= 01000
+	}
+	return bits
+}
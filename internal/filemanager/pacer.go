@@ -0,0 +1,70 @@
+package filemanager
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Pacer spreads work out over time so it does not go faster than a rate,
+// such as files removed or bytes written per second. Idle time is not saved
+// up, so a pause is never followed by a burst. A nil Pacer never waits.
+type Pacer struct {
+	rate float64 // Units per second
+	mu   sync.Mutex
+	next time.Time // When the next unit may start
+}
+
+// NewPacer returns a pacer for rate units per second, or nil when rate is
+// not positive
+func NewPacer(rate float64) *Pacer {
+	if rate <= 0 {
+		return nil
+	}
+	return &Pacer{rate: rate}
+}
+
+// Wait blocks until n more units fit into the rate, or until ctx is done,
+// in which case ctx.Err() is returned
+func (p *Pacer) Wait(ctx context.Context, n int64) error {
+	if p == nil || n <= 0 {
+		return ctx.Err()
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	delay := p.next.Sub(now)
+	p.next = p.next.Add(time.Duration(float64(n) / p.rate * float64(time.Second)))
+	p.mu.Unlock()
+
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pacedReader paces the bytes read through it
+type pacedReader struct {
+	ctx   context.Context
+	r     io.Reader
+	pacer *Pacer
+}
+
+func (r *pacedReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	if waitErr := r.pacer.Wait(r.ctx, int64(n)); waitErr != nil && err == nil {
+		err = waitErr
+	}
+	return n, err
+}
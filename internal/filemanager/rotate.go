@@ -0,0 +1,157 @@
+package filemanager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// DefaultRotateKeep is the number of rotated generations kept when none is set
+const DefaultRotateKeep = 5
+
+// RotateOptions set how many generations a rotation keeps and how the live
+// file becomes the first of them
+type RotateOptions struct {
+	Keep         int  // Rotated generations kept, 0 for DefaultRotateKeep
+	CopyTruncate bool // Copy the file to the first generation and truncate it instead of renaming it
+}
+
+// Validate reports a negative number of generations
+func (o RotateOptions) Validate() error {
+	if o.Keep < 0 {
+		return fmt.Errorf("rotate keep must not be negative, got %d", o.Keep)
+	}
+	return nil
+}
+
+// String describes the options, such as "keep 5 generations, copy and
+// truncate open files"
+func (o RotateOptions) String() string {
+	o = o.withDefaults()
+	s := fmt.Sprintf("keep %d generations", o.Keep)
+	if o.CopyTruncate {
+		s += ", copy and truncate open files"
+	}
+	return s
+}
+
+// withDefaults fills in the number of generations left unset
+func (o RotateOptions) withDefaults() RotateOptions {
+	if o.Keep == 0 {
+		o.Keep = DefaultRotateKeep
+	}
+	return o
+}
+
+// RotatedPath returns the path of generation n of a rotated file: path.1
+// for the first, which is left uncompressed, and path.n.gz for older ones
+func RotatedPath(path string, n int) string {
+	if n == 1 {
+		return path + ".1"
+	}
+	return path + "." + strconv.Itoa(n) + ".gz"
+}
+
+// RotateFile rotates a file the way logrotate does with delaycompress:
+// older generations move up by one, path.1 is compressed to path.2.gz,
+// the file becomes path.1, and generations beyond Keep are removed. With
+// CopyTruncate the file is copied to path.1 and truncated in place, so a
+// process writing to it keeps writing to a file that can be seen. It
+// returns the path of the first generation.
+func RotateFile(path string, opts RotateOptions) (string, error) {
+	return RotateFilePaced(context.Background(), path, opts, nil)
+}
+
+// RotateFilePaced is RotateFile with the bytes compressed and copied paced
+// by pacer, which may be nil. It gives up when ctx is done.
+func RotateFilePaced(ctx context.Context, path string, opts RotateOptions, pacer *Pacer) (string, error) {
+	opts = opts.withDefaults()
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", path)
+	}
+
+	for n := opts.Keep - 1; n >= 1; n-- {
+		if err := shiftGeneration(ctx, path, n, pacer); err != nil {
+			return "", err
+		}
+	}
+
+	first := RotatedPath(path, 1)
+	if opts.CopyTruncate {
+		err = copyTruncate(ctx, path, first, info, pacer)
+	} else {
+		err = os.Rename(path, first)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	removeGenerations(path, opts.Keep+1)
+	return first, nil
+}
+
+// shiftGeneration moves generation n of path to n+1, compressing the first
+// generation on the way. A missing generation is not an error.
+func shiftGeneration(ctx context.Context, path string, n int, pacer *Pacer) error {
+	from, to := RotatedPath(path, n), RotatedPath(path, n+1)
+	if _, err := os.Lstat(from); os.IsNotExist(err) {
+		return nil
+	}
+	if n > 1 {
+		return os.Rename(from, to)
+	}
+
+	// A compressed copy left behind by an interrupted rotation is replaced
+	os.Remove(from + ".gz")
+	archive, err := ArchiveFilePaced(ctx, from, pacer)
+	if err != nil {
+		return err
+	}
+	return os.Rename(archive, to)
+}
+
+// copyTruncate copies path to target and truncates path to zero bytes. Lines
+// written between the copy and the truncation are lost.
+func copyTruncate(ctx context.Context, path, target string, info os.FileInfo, pacer *Pacer) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, &pacedReader{ctx: ctx, r: src, pacer: pacer})
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+		return err
+	}
+
+	os.Chtimes(target, info.ModTime(), info.ModTime())
+	return os.Truncate(path, 0)
+}
+
+// removeGenerations removes the generations of path numbered from and up,
+// including those left behind when Keep was lowered. Only names RotatedPath
+// gives are removed, up to the first one missing, so dated copies such as
+// app.log.20261018 and files the rotation never made are left alone.
+func removeGenerations(path string, from int) {
+	for n := from; ; n++ {
+		generation := RotatedPath(path, n)
+		info, err := os.Lstat(generation)
+		if err != nil || !info.Mode().IsRegular() || os.Remove(generation) != nil {
+			return
+		}
+	}
+}
@@ -1,14 +1,15 @@
 package filemanager
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"runtime"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/pashkov256/deletor/internal/utils"
 	"github.com/schollz/progressbar/v3"
 )
 
@@ -29,40 +30,31 @@ type FileScanner struct {
 	filter       *FileFilter // Filter criteria for files
 	ProgressChan chan int64  // Channel for progress updates
 	haveProgress bool        // Whether progress tracking is enabled
-	mutex        *sync.Mutex
-	wg           *sync.WaitGroup
+	index        *DirIndex   // Directory index of the file manager, nil if none
 }
 
-// NewFileScanner creates a new file scanner with the specified configuration
+// NewFileScanner creates a new file scanner with the specified configuration.
+// The scanner uses the directory index of the file manager if it has one.
 func NewFileScanner(fileManager FileManager, filter *FileFilter, haveProgress bool) *FileScanner {
-	return &FileScanner{
+	s := &FileScanner{
 		fileManager:  fileManager,
 		filter:       filter,
 		ProgressChan: make(chan int64),
 		haveProgress: haveProgress,
-		mutex:        &sync.Mutex{},
-		wg:           &sync.WaitGroup{},
 	}
+	if indexed, ok := fileManager.(indexedFileManager); ok {
+		s.index = indexed.Index()
+	}
+	return s
 }
 
-// ProgressBarScanner initializes and displays a progress bar for file scanning
+// ProgressBarScanner initializes and displays a progress bar for file
+// scanning. The bar counts the bytes of matched files as the scan reports
+// them, so the tree is not walked a second time to size it.
 func (s *FileScanner) ProgressBarScanner(dir string) {
-	var totalScanSize int64
-	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if info == nil {
-			return nil
-		}
-
-		if s.filter.MatchesFilters(info, path) {
-			totalScanSize += info.Size()
-		}
-
-		return nil
-	})
-
 	bar := progressbar.NewOptions64(
-		totalScanSize,
-		progressbar.OptionSetDescription("Scanning files..."),
+		-1,
+		progressbar.OptionSetDescription(fmt.Sprintf("Scanning %s...", dir)),
 		progressbar.OptionSetWriter(os.Stderr),
 		progressbar.OptionShowBytes(true),
 		progressbar.OptionSetWidth(10),
@@ -82,89 +74,188 @@ func (s *FileScanner) ProgressBarScanner(dir string) {
 	}()
 }
 
-// ScanFilesCurrentLevel scans files in the current directory level only
-func (s *FileScanner) ScanFilesCurrentLevel(dir string) (toDeleteMap map[string]string, totalClearSize int64) {
-	toDeleteMap = make(map[string]string)
-	entries, err := os.ReadDir(dir)
+// streamBuffer lets workers run ahead of a slow consumer without blocking
+const streamBuffer = 256
+
+// StreamFiles sends every file below dir that passes the filter to the
+// returned channel as soon as it is found, so callers can show results while
+// the scan runs. Without recursive only the files directly in dir are sent.
+// The channel is closed when the scan ends or ctx is done; unreadable
+// directories and files are skipped.
+func (s *FileScanner) StreamFiles(ctx context.Context, dir string, recursive bool) <-chan FileEntry {
+	out := make(chan FileEntry, streamBuffer)
+
+	emit := func(entry FileEntry) bool {
+		select {
+		case out <- entry:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	go func() {
+		defer close(out)
+		if recursive {
+			s.streamRecursively(ctx, dir, walkWorkers, emit)
+		} else {
+			s.streamCurrentLevel(ctx, dir, emit)
+		}
+	}()
+
+	return out
+}
+
+// EachFile calls fn for every file below dir that passes the filter, as
+// StreamFiles finds them, but reads one directory at a time on the calling
+// goroutine. Everything the scan does happens on the caller's thread, so a
+// thread with lowered priorities keeps them for the whole scan.
+func (s *FileScanner) EachFile(ctx context.Context, dir string, recursive bool, fn func(FileEntry)) {
+	emit := func(entry FileEntry) bool {
+		fn(entry)
+		return true
+	}
+	if recursive {
+		s.streamRecursively(ctx, dir, 1, emit)
+	} else {
+		s.streamCurrentLevel(ctx, dir, emit)
+	}
+}
+
+// send delivers a matched file through emit
+func (s *FileScanner) send(path string, info os.FileInfo, emit func(FileEntry) bool) {
+	entry := NewFileEntry(path, info)
+	if clause := s.filter.MatchedClause(info, path); clause != nil {
+		entry.MatchedRule, entry.Action = clause.Spec, clause.Action
+	} else {
+		entry.MatchedRule = s.filter.MatchedRule(info, path)
+	}
+	if s.filter.UsesContentTypes() {
+		entry.ContentType, _ = DetectContentType(path)
+	}
+	entry.InUse = s.filter.OpenFiles.Contains(info)
+
+	if !emit(entry) {
+		return
+	}
+
+	if s.haveProgress {
+		s.ProgressChan <- info.Size()
+	}
+}
+
+// sendBrokenLink delivers a dangling symbolic link under the SymlinkBroken
+// policy. Only the exclude patterns apply, since a missing target has no
+// extension, size or age to match.
+func (s *FileScanner) sendBrokenLink(path string, info os.FileInfo, emit func(FileEntry) bool) {
+	if !s.filter.ExcludeFilter(info, path) {
+		return
+	}
+	entry := NewFileEntry(path, info)
+	entry.BrokenLink = true
+	emit(entry)
+}
+
+// brokenLink reports whether a directory entry is a dangling link that the
+// scan reports as a broken link instead of matching it
+func (s *FileScanner) brokenLink(path string, d fs.DirEntry) bool {
+	return s.filter.Symlinks == SymlinkBroken && isSymlink(d) && isBrokenLink(path)
+}
+
+// streamCurrentLevel sends the matching files directly in dir
+func (s *FileScanner) streamCurrentLevel(ctx context.Context, dir string, emit func(FileEntry) bool) {
+	if !walkLimits(s.filter).reports(1) {
+		return
+	}
+	entries, err := s.index.ReadDir(dir)
 	if err != nil {
-		panic(err)
+		return
 	}
 
 	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
 		info, err := entry.Info()
-		if err != nil {
-			panic(err)
+		if err != nil || info.IsDir() {
+			continue
 		}
 
-		if info.IsDir() {
+		path := filepath.Join(dir, entry.Name())
+		if s.brokenLink(path, entry) {
+			s.sendBrokenLink(path, info, emit)
 			continue
 		}
-
-		if s.filter.MatchesFilters(info, filepath.Join(dir, entry.Name())) {
-			toDeleteMap[filepath.Join(dir, entry.Name())] = utils.FormatSize(info.Size())
-			totalClearSize += info.Size()
-
-			if s.haveProgress {
-				s.ProgressChan <- info.Size()
-			}
+		if info, ok := matchFresh(s.filter, path, info); ok {
+			s.send(path, info, emit)
 		}
-
 	}
-	return toDeleteMap, totalClearSize
 }
 
-// ScanFilesRecursively scans files in the directory and all subdirectories
-func (s *FileScanner) ScanFilesRecursively(dir string) (toDeleteMap map[string]string, totalClearSize int64) {
-	toDeleteMap = make(map[string]string)
-	taskCh := make(chan os.FileInfo, runtime.NumCPU())
-
-	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if info == nil || info.IsDir() {
-			return nil
+// streamRecursively sends the matching files in dir and all subdirectories,
+// reading directories and checking the filter on a fixed pool of workers.
+// Links are followed or reported as broken according to the symlink policy.
+func (s *FileScanner) streamRecursively(ctx context.Context, dir string, workers int, emit func(FileEntry) bool) {
+	walkTree(ctx, dir, walkOptions{workers: workers, index: s.index, mounts: newMountGuard(dir, s.filter), visited: followLinks(s.filter), limits: walkLimits(s.filter)}, func(path string, d fs.DirEntry) bool {
+		if d.IsDir() {
+			return true
 		}
-
+		info, err := d.Info()
 		if err != nil {
-			return nil
+			return false
 		}
-
-		s.wg.Add(1)
-		go func(path string, info os.FileInfo) {
-			// Acquire token from channel first
-			taskCh <- info
-			defer func() { <-taskCh }() // Release token when done
-			defer s.wg.Done()
-
-			if s.filter.MatchesFilters(info, path) {
-				s.mutex.Lock()
-
-				toDeleteMap[path] = utils.FormatSize(info.Size())
-				totalClearSize += info.Size()
-
-				s.mutex.Unlock()
-
-				if s.haveProgress {
-					s.ProgressChan <- info.Size()
-				}
-			}
-
-		}(path, info)
-
-		return nil
+		if s.brokenLink(path, d) {
+			s.sendBrokenLink(path, info, emit)
+			return false
+		}
+		if info, ok := matchFresh(s.filter, path, info); ok {
+			s.send(path, info, emit)
+		}
+		return false
 	})
+}
 
-	s.wg.Wait()
+// ScanFilesCurrentLevel collects the matching files in the current directory
+// level only. When ctx is done the files matched so far are returned.
+func (s *FileScanner) ScanFilesCurrentLevel(ctx context.Context, dir string) ScanResult {
+	return CollectEntries(s.StreamFiles(ctx, dir, false))
+}
 
-	return toDeleteMap, totalClearSize
+// ScanFilesRecursively collects the matching files in the directory and all
+// subdirectories. When ctx is done the files matched so far are returned.
+func (s *FileScanner) ScanFilesRecursively(ctx context.Context, dir string) ScanResult {
+	return CollectEntries(s.StreamFiles(ctx, dir, true))
 }
 
 // ScanEmptySubFolders finds all empty subdirectories in the given path
-func (s *FileScanner) ScanEmptySubFolders(dir string) []string {
+// within the walk limits of the filter
+func (s *FileScanner) ScanEmptySubFolders(ctx context.Context, dir string) []string {
 	emptyDirs := make([]string, 0)
+	mounts := newMountGuard(dir, s.filter)
+	limits := walkLimits(s.filter)
 
 	filepath.WalkDir(dir, func(path string, info os.DirEntry, err error) error {
-		if info == nil && !info.IsDir() {
+		if ctx.Err() != nil {
+			return filepath.SkipAll
+		}
+		if info == nil || !info.IsDir() {
 			return nil
 		}
+		if path != dir && info.IsDir() && !mounts.allows(path) {
+			return filepath.SkipDir
+		}
+		depth := pathDepth(dir, path)
+		if info.IsDir() && depth > 0 && limits.tooManyEntries(path) {
+			return filepath.SkipDir
+		}
+		if !limits.reports(depth) {
+			return nil
+		}
+		if info.IsDir() && !limits.descends(depth) {
+			if s.fileManager.IsEmptyDir(path) {
+				emptyDirs = append(emptyDirs, path)
+			}
+			return filepath.SkipDir
+		}
 		if s.fileManager.IsEmptyDir(path) {
 			emptyDirs = append(emptyDirs, path)
 		}
@@ -174,3 +265,80 @@ func (s *FileScanner) ScanEmptySubFolders(dir string) []string {
 
 	return emptyDirs
 }
+
+// ScanBrokenLinks finds the symbolic links in dir, and with recursive below
+// it, whose target does not exist. Only the exclude patterns of the filter
+// apply. The links are returned sorted by path with BrokenLink set.
+func (s *FileScanner) ScanBrokenLinks(ctx context.Context, dir string, recursive bool) []FileEntry {
+	return s.scanCategory(ctx, dir, recursive, func(path string, d fs.DirEntry, info os.FileInfo) bool {
+		return isSymlink(d) && isBrokenLink(path)
+	}, func(entry *FileEntry) { entry.BrokenLink = true })
+}
+
+// emptySentinels are the names of zero-byte files that matter by existing:
+// package markers, placeholders that keep a directory in version control,
+// and lock and PID files
+var emptySentinels = []string{
+	"__init__.py", "py.typed", ".gitkeep", ".keep", ".nojekyll", ".gitignore",
+	"LOCK", "lock", ".lock", "*.lock", "*.lck", "*.pid",
+}
+
+// isEmptySentinel reports whether a zero-byte file named name is a sentinel
+func isEmptySentinel(name string) bool {
+	for _, pattern := range emptySentinels {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanEmptyFiles finds the zero-byte regular files in dir, and with
+// recursive below it, leaving out sentinels such as __init__.py, .gitkeep
+// and lock files. Only the exclude patterns of the filter apply. The files
+// are returned sorted by path.
+func (s *FileScanner) ScanEmptyFiles(ctx context.Context, dir string, recursive bool) []FileEntry {
+	return s.scanCategory(ctx, dir, recursive, func(path string, d fs.DirEntry, info os.FileInfo) bool {
+		return info.Mode().IsRegular() && info.Size() == 0 && !isEmptySentinel(info.Name())
+	}, nil)
+}
+
+// scanCategory walks dir, and with recursive every directory below it,
+// within the mount guard and collects the entries that match, ignoring the
+// size, age and name filters that select regular scan results
+func (s *FileScanner) scanCategory(ctx context.Context, dir string, recursive bool, match func(path string, d fs.DirEntry, info os.FileInfo) bool, mark func(*FileEntry)) []FileEntry {
+	var mu sync.Mutex
+	found := make([]FileEntry, 0)
+
+	limits := walkLimits(s.filter)
+	if !recursive {
+		limits.MaxDepth = 1
+	}
+	walkTree(ctx, dir, walkOptions{workers: walkWorkers, mounts: newMountGuard(dir, s.filter), limits: limits}, func(path string, d fs.DirEntry) bool {
+		info, err := d.Info()
+		if err != nil {
+			return false
+		}
+		if s.filter != nil && !s.filter.ExcludeFilter(info, path) {
+			return false
+		}
+		if d.IsDir() {
+			return true
+		}
+		if !match(path, d, info) {
+			return false
+		}
+
+		entry := NewFileEntry(path, info)
+		if mark != nil {
+			mark(&entry)
+		}
+		mu.Lock()
+		found = append(found, entry)
+		mu.Unlock()
+		return false
+	})
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Path < found[j].Path })
+	return found
+}
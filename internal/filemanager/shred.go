@@ -0,0 +1,274 @@
+package filemanager
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ShredMethod selects the data written over a file before it is unlinked
+type ShredMethod string
+
+const (
+	// ShredZeros writes zero bytes on every pass
+	ShredZeros ShredMethod = "zeros"
+	// ShredRandom writes random bytes on every pass
+	ShredRandom ShredMethod = "random"
+	// ShredDoD writes zeros, ones and random bytes in turn, as DoD 5220.22-M does
+	ShredDoD ShredMethod = "dod"
+)
+
+// ErrHardLinked is returned for a file that still has hard links outside
+// what is shredded. Overwriting it would destroy the data behind those links
+// too, while unlinking one name would leave the data on disk.
+var ErrHardLinked = errors.New("file has other hard links")
+
+// DefaultShredPasses is the number of overwrite passes when none is set
+const DefaultShredPasses = 3
+
+// shredBlockSize is how much of a file each write overwrites
+const shredBlockSize = 64 * 1024
+
+// ShredMethods returns the accepted method names
+func ShredMethods() []string {
+	return []string{string(ShredZeros), string(ShredRandom), string(ShredDoD)}
+}
+
+// ParseShredMethod parses a method name. An empty name selects ShredRandom.
+func ParseShredMethod(name string) (ShredMethod, error) {
+	switch method := ShredMethod(strings.ToLower(strings.TrimSpace(name))); method {
+	case "":
+		return ShredRandom, nil
+	case ShredZeros, ShredRandom, ShredDoD:
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown shred method %q, want one of %s", name, strings.Join(ShredMethods(), ", "))
+}
+
+// ShredOptions set how files are overwritten before they are unlinked
+type ShredOptions struct {
+	Passes int         // Number of overwrite passes, 0 for DefaultShredPasses
+	Method ShredMethod // Data written on each pass, empty for ShredRandom
+}
+
+// Validate reports passes below zero and unknown methods
+func (o ShredOptions) Validate() error {
+	if o.Passes < 0 {
+		return fmt.Errorf("shred passes must not be negative, got %d", o.Passes)
+	}
+	_, err := ParseShredMethod(string(o.Method))
+	return err
+}
+
+// withDefaults fills in the passes and method left unset
+func (o ShredOptions) withDefaults() ShredOptions {
+	if o.Passes == 0 {
+		o.Passes = DefaultShredPasses
+	}
+	if o.Method == "" {
+		o.Method = ShredRandom
+	}
+	return o
+}
+
+// fill writes the data of a pass into buf
+func (m ShredMethod) fill(buf []byte, pass int) error {
+	var b byte
+	switch {
+	case m == ShredRandom, m == ShredDoD && pass%3 == 2:
+		_, err := rand.Read(buf)
+		return err
+	case m == ShredDoD && pass%3 == 1:
+		b = 0xff
+	}
+	for i := range buf {
+		buf[i] = b
+	}
+	return nil
+}
+
+// ShredFile overwrites the contents of a regular file in place, syncing it
+// to disk after every pass, then renames it to a random name in the same
+// directory and unlinks it, so neither the data nor the name stay in the
+// directory. Links and other special files are only removed. A file with
+// more than one hard link is left in place with ErrHardLinked. It returns
+// the number of bytes written.
+//
+// Overwriting in place only reaches the old data where the filesystem and
+// device write it back to the same blocks. Copy-on-write filesystems,
+// snapshots, journals and the wear levelling of SSDs keep earlier copies.
+func ShredFile(path string, opts ShredOptions) (int64, error) {
+	return ShredFilePaced(context.Background(), path, opts, nil)
+}
+
+// ShredFilePaced is ShredFile with the bytes written paced by pacer, which
+// may be nil. It gives up when ctx is done, leaving the file in place.
+func ShredFilePaced(ctx context.Context, path string, opts ShredOptions, pacer *Pacer) (int64, error) {
+	opts = opts.withDefaults()
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0, err
+	}
+	if !info.Mode().IsRegular() {
+		return 0, os.Remove(path)
+	}
+	if links := NewFileEntry(path, info).Links; links > 1 {
+		return 0, hardLinkError(path, links-1)
+	}
+
+	written, err := overwriteFile(ctx, path, info.Size(), opts, pacer)
+	if err != nil {
+		return written, err
+	}
+	return written, removeHidden(path)
+}
+
+// ShredDir shreds every regular file below dir and then removes the tree.
+// A file with several hard links is overwritten once when all of them are
+// in the tree, and is not shredded at all when some are outside it. It
+// stops at the first file that cannot be shredded, leaving the rest of the
+// tree in place, and returns the number of bytes written.
+func ShredDir(dir string, opts ShredOptions) (int64, error) {
+	return ShredDirPaced(context.Background(), dir, opts, nil)
+}
+
+// ShredDirPaced is ShredDir with the bytes written paced by pacer, which may
+// be nil
+func ShredDirPaced(ctx context.Context, dir string, opts ShredOptions, pacer *Pacer) (int64, error) {
+	var files []FileEntry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, NewFileEntry(path, info))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Count the links of each multiply linked file found in the tree
+	found := make(map[fileID]uint64)
+	for _, file := range files {
+		if file.Links > 1 && file.Inode != 0 {
+			found[fileID{dev: file.Device, ino: file.Inode}]++
+		}
+	}
+
+	opts = opts.withDefaults()
+	overwritten := make(map[fileID]bool)
+	var written int64
+	for _, file := range files {
+		if file.Links <= 1 || file.Inode == 0 {
+			n, err := ShredFilePaced(ctx, file.Path, opts, pacer)
+			written += n
+			if err != nil {
+				return written, err
+			}
+			continue
+		}
+
+		id := fileID{dev: file.Device, ino: file.Inode}
+		if found[id] < file.Links {
+			return written, hardLinkError(file.Path, file.Links-found[id])
+		}
+		if !overwritten[id] {
+			n, err := overwriteFile(ctx, file.Path, file.Size, opts, pacer)
+			written += n
+			if err != nil {
+				return written, err
+			}
+			overwritten[id] = true
+		}
+		if err := removeHidden(file.Path); err != nil {
+			return written, err
+		}
+	}
+	return written, os.RemoveAll(dir)
+}
+
+// removeHidden renames path to a random name in the same directory and
+// unlinks it, so the name does not stay in the directory either
+func removeHidden(path string) error {
+	hidden := filepath.Join(filepath.Dir(path), randomName())
+	if err := os.Rename(path, hidden); err != nil {
+		return err
+	}
+	return os.Remove(hidden)
+}
+
+// hardLinkError reports a file left alone because of its links outside
+// what is shredded
+func hardLinkError(path string, outside uint64) error {
+	return fmt.Errorf("%s has %d hard link(s) elsewhere, shredding it would destroy their data too: %w", path, outside, ErrHardLinked)
+}
+
+// overwriteFile runs the passes of opts over the first size bytes of path,
+// waiting on pacer before each block
+func overwriteFile(ctx context.Context, path string, size int64, opts ShredOptions, pacer *Pacer) (int64, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, min(size, shredBlockSize))
+	var written int64
+	for pass := 0; pass < opts.Passes; pass++ {
+		for offset := int64(0); offset < size; {
+			chunk := buf[:min(size-offset, int64(len(buf)))]
+			if err := pacer.Wait(ctx, int64(len(chunk))); err != nil {
+				return written, err
+			}
+			if err := opts.Method.fill(chunk, pass); err != nil {
+				return written, err
+			}
+			n, err := f.WriteAt(chunk, offset)
+			written += int64(n)
+			if err != nil {
+				return written, err
+			}
+			offset += int64(n)
+		}
+		if err := f.Sync(); err != nil {
+			return written, err
+		}
+	}
+	return written, f.Close()
+}
+
+// randomName returns a file name that gives nothing away about the file
+func randomName() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "." + hex.EncodeToString(b)
+}
+
+// copyOnWriteFilesystems write changed blocks to new places, so overwriting
+// a file leaves its old contents on disk
+var copyOnWriteFilesystems = []string{"btrfs", "zfs", "bcachefs", "apfs", "nilfs2", "f2fs"}
+
+// CopyOnWriteMount returns the mount holding path when its filesystem does
+// not overwrite files in place, where shredding cannot reach the old data
+func CopyOnWriteMount(path string) (Mount, bool) {
+	mount, ok := MountOf(path)
+	if !ok {
+		return Mount{}, false
+	}
+	for _, fsType := range copyOnWriteFilesystems {
+		if mount.Type == fsType {
+			return mount, true
+		}
+	}
+	return Mount{}, false
+}
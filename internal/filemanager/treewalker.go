@@ -0,0 +1,103 @@
+package filemanager
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// TreeWalker walks parts of a tree the way scans of its root with a filter
+// do: within the depth and directory size limits of the filter, on the
+// filesystems it allows and following links as its symlink policy says.
+// Watches use it to sweep and watch single directories of the tree.
+type TreeWalker struct {
+	root   string
+	limits WalkLimits
+	filter *FileFilter
+	mounts *mountGuard
+}
+
+// NewTreeWalker creates a walker for scans of root with filter, which may
+// be nil. The mount table is read once, when the walker is created.
+func NewTreeWalker(root string, filter *FileFilter) *TreeWalker {
+	return &TreeWalker{
+		root:   root,
+		limits: walkLimits(filter),
+		filter: filter,
+		mounts: newMountGuard(root, filter),
+	}
+}
+
+// Reads reports whether a scan of the root reads dir. Only dir itself is
+// checked; the directories between the root and dir are taken to be read.
+func (w *TreeWalker) Reads(dir string) bool {
+	depth := pathDepth(w.root, dir)
+	switch {
+	case depth < 0:
+		return false
+	case depth == 0:
+		return true
+	}
+	return w.limits.descends(depth) && w.mounts.allows(dir) && !w.limits.tooManyEntries(dir)
+}
+
+// Visits reports whether a scan of the root looks at the file at path: it
+// is deep enough and its directory is read
+func (w *TreeWalker) Visits(path string) bool {
+	depth := pathDepth(w.root, path)
+	return depth > 0 && w.limits.reports(depth) && w.Reads(filepath.Dir(path))
+}
+
+// Walk visits the files in dir that a scan of the root looks at, and with
+// recursive those in its subdirectories, whether or not they match the
+// filter. Nothing is visited when the scan does not read dir. Calls to
+// visit are serialised, so it need not be safe for concurrent use.
+func (w *TreeWalker) Walk(ctx context.Context, dir string, recursive bool, visit func(path string, info os.FileInfo)) {
+	if !w.Reads(dir) {
+		return
+	}
+	depth := pathDepth(w.root, dir)
+	limits := w.limits
+	if !recursive {
+		limits.MaxDepth = depth + 1
+	}
+
+	var mu sync.Mutex
+	opts := walkOptions{workers: walkWorkers, mounts: w.mounts, visited: followLinks(w.filter), limits: limits, depth: depth}
+	walkTree(ctx, dir, opts, func(path string, d fs.DirEntry) bool {
+		if d.IsDir() {
+			return true
+		}
+		if info, err := d.Info(); err == nil {
+			mu.Lock()
+			visit(path, info)
+			mu.Unlock()
+		}
+		return true
+	})
+}
+
+// Subdirs returns the directories below dir that a scan of the root reads,
+// in no particular order. It returns nil when the scan does not read dir.
+func (w *TreeWalker) Subdirs(ctx context.Context, dir string) []string {
+	if !w.Reads(dir) {
+		return nil
+	}
+
+	var (
+		mu   sync.Mutex
+		dirs []string
+	)
+	opts := walkOptions{workers: walkWorkers, mounts: w.mounts, visited: followLinks(w.filter), limits: w.limits, depth: pathDepth(w.root, dir)}
+	opts.read = func(path string) {
+		if path != dir {
+			mu.Lock()
+			dirs = append(dirs, path)
+			mu.Unlock()
+		}
+	}
+	walkTree(ctx, dir, opts, func(string, fs.DirEntry) bool { return true })
+	return dirs
+}
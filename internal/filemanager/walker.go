@@ -0,0 +1,172 @@
+package filemanager
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// walkWorkers is the number of goroutines reading directories in parallel.
+// Reading directories is mostly I/O, so more workers than CPUs pays off.
+var walkWorkers = 2 * runtime.GOMAXPROCS(0)
+
+// walkOptions configures a walkTree run
+type walkOptions struct {
+	workers int          // Number of goroutines reading directories
+	index   *DirIndex    // Directory index to list from, nil to read directly
+	mounts  *mountGuard  // Filesystem boundaries, nil to cross every mount
+	visited *visitedDirs // Directories already read, nil to not follow symbolic links
+	limits  WalkLimits   // Depth and directory size limits, zero for none
+	depth   int          // Depth of the walk root below the root the limits count from
+	read    func(string) // Called with each directory read, nil for none
+}
+
+// walkTree visits every entry below root, directories included, on a fixed
+// pool of workers. Each worker takes a directory from a shared queue, reads
+// it, from the index when one is given, and queues the subdirectories it finds, so large and
+// deep trees fan out across all workers without a goroutine per entry.
+// Returning false from visit for a directory skips its contents, and so does
+// the mount guard for directories on other filesystems. With a visited set,
+// links to directories are visited and walked like directories, and a
+// directory reached again through a link or a loop is not read twice.
+// Entries above the minimum depth are not visited, though directories among
+// them are still read, and nothing below the maximum depth or inside a
+// subdirectory with too many entries is read at all. Entries are visited in no particular order and visit must be safe for
+// concurrent use. Unreadable directories are skipped, and the walk stops
+// early once ctx is done. Depths count from the root at opts.depth, so a
+// subtree is walked with the limits of the whole tree. A single worker
+// reads every directory on the calling goroutine.
+func walkTree(ctx context.Context, root string, opts walkOptions, visit func(path string, d fs.DirEntry) bool) {
+	queue := newDirQueue()
+	queue.push(root, opts.depth)
+
+	work := func() {
+		for {
+			dir, ok := queue.pop()
+			if !ok {
+				return
+			}
+			if ctx.Err() == nil {
+				readDir(ctx, queue, opts, dir.path, dir.depth, visit)
+			}
+			queue.done()
+		}
+	}
+	if opts.workers <= 1 {
+		work()
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work()
+		}()
+	}
+	wg.Wait()
+}
+
+// readDir visits the entries of a single directory at the given depth and
+// queues its subdirectories
+func readDir(ctx context.Context, queue *dirQueue, opts walkOptions, dir string, depth int, visit func(path string, d fs.DirEntry) bool) {
+	if !opts.visited.claim(dir) {
+		return
+	}
+	entries, err := opts.index.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	// The entry limit applies to the listing, from the index or from disk
+	if depth > 0 && opts.limits.exceeds(len(entries)) {
+		return
+	}
+	if opts.read != nil {
+		opts.read(dir)
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		path := filepath.Join(dir, entry.Name())
+		if opts.visited != nil && isSymlink(entry) {
+			if target, err := os.Stat(path); err == nil && target.IsDir() {
+				entry = fs.FileInfoToDirEntry(target)
+			}
+		}
+		if !opts.limits.reports(depth + 1) {
+			if entry.IsDir() && opts.limits.descends(depth+1) && opts.mounts.allows(path) {
+				queue.push(path, depth+1)
+			}
+			continue
+		}
+		if visit(path, entry) && entry.IsDir() && opts.limits.descends(depth+1) && opts.mounts.allows(path) {
+			queue.push(path, depth+1)
+		}
+	}
+}
+
+// dirQueue is the work queue of directories still to read. It tracks the
+// directories queued or being read, so workers know when the walk is over.
+type dirQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	dirs    []queuedDir
+	pending int
+}
+
+// queuedDir is a directory to read with its depth below the walk root
+type queuedDir struct {
+	path  string
+	depth int
+}
+
+func newDirQueue() *dirQueue {
+	q := &dirQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds a directory to read
+func (q *dirQueue) push(dir string, depth int) {
+	q.mu.Lock()
+	q.dirs = append(q.dirs, queuedDir{path: dir, depth: depth})
+	q.pending++
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+// pop waits for a directory to read. It reports false once every queued
+// directory has been read and no more can appear.
+func (q *dirQueue) pop() (queuedDir, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.dirs) == 0 && q.pending > 0 {
+		q.cond.Wait()
+	}
+	if len(q.dirs) == 0 {
+		return queuedDir{}, false
+	}
+
+	// Reading the newest directory first keeps the queue short on deep trees
+	dir := q.dirs[len(q.dirs)-1]
+	q.dirs = q.dirs[:len(q.dirs)-1]
+	return dir, true
+}
+
+// done marks a popped directory as read
+func (q *dirQueue) done() {
+	q.mu.Lock()
+	q.pending--
+	finished := q.pending == 0
+	q.mu.Unlock()
+	if finished {
+		q.cond.Broadcast()
+	}
+}
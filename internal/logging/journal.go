@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// JournalStatus describes what a journal entry records
+type JournalStatus string
+
+const (
+	JournalStarted   JournalStatus = "started"   // Run began
+	JournalDone      JournalStatus = "done"      // Single operation finished
+	JournalCompleted JournalStatus = "completed" // Run finished normally
+	JournalCancelled JournalStatus = "cancelled" // Run was stopped by the user
+	JournalFailed    JournalStatus = "failed"    // Run stopped on an error
+)
+
+// JournalEntry is a single line of the operation journal
+type JournalEntry struct {
+	Timestamp time.Time      `json:"timestamp"`           // When the entry was written
+	RunID     string         `json:"run_id"`              // Groups the entries of one run
+	Status    JournalStatus  `json:"status"`              // What the entry records
+	Directory string         `json:"directory,omitempty"` // Target of the run
+	Operation *FileOperation `json:"operation,omitempty"` // Finished operation for done entries
+	Error     string         `json:"error,omitempty"`     // Reason a run failed
+}
+
+// Journal appends one JSON line per finished operation, so an interrupted
+// run leaves an exact record of what was already removed. All methods are
+// safe to call on a nil journal, which records nothing.
+type Journal struct {
+	mu        sync.Mutex
+	file      *os.File
+	runID     string
+	directory string
+}
+
+// OpenJournal opens the journal at path for appending and records the start
+// of a run against directory
+func OpenJournal(path, directory string) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+
+	j := &Journal{
+		file:      file,
+		runID:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		directory: directory,
+	}
+	if err := j.write(JournalEntry{Status: JournalStarted}); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// OpenDefaultJournal opens the journal in the application's config directory.
+// A journal that cannot be opened is reported as nil, so callers keep working
+// without one.
+func OpenDefaultJournal(directory string) *Journal {
+	j, err := OpenJournal(GetJournalFilePath(), directory)
+	if err != nil {
+		return nil
+	}
+	return j
+}
+
+// Record appends a finished operation to the journal
+func (j *Journal) Record(op *FileOperation) error {
+	if j == nil {
+		return nil
+	}
+	return j.write(JournalEntry{Status: JournalDone, Operation: op})
+}
+
+// Finish records how the run ended and closes the journal. A context.Canceled
+// error marks the run as cancelled, any other error as failed.
+func (j *Journal) Finish(runErr error) error {
+	if j == nil {
+		return nil
+	}
+
+	entry := JournalEntry{Status: JournalCompleted}
+	switch {
+	case errors.Is(runErr, context.Canceled):
+		entry.Status = JournalCancelled
+	case runErr != nil:
+		entry.Status = JournalFailed
+		entry.Error = runErr.Error()
+	}
+
+	err := j.write(entry)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if closeErr := j.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// write stamps an entry with the run details and appends it as one line
+func (j *Journal) write(entry JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry.Timestamp = time.Now()
+	entry.RunID = j.runID
+	entry.Directory = j.directory
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	return nil
+}
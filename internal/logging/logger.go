@@ -26,14 +26,41 @@ type ScanStatistics struct {
 	DeletedSize   int64     // Size of deleted files
 	TrashedFiles  int64     // Number of files moved to trash
 	TrashedSize   int64     // Size of trashed files
+	ShreddedFiles int64     // Number of deleted files overwritten before unlinking
+	ShreddedSize  int64     // Size of shredded files
+	ShredWritten  int64     // Bytes written by the overwrite passes
+	ShredNanos    int64     // Time spent shredding, in nanoseconds
 	IgnoredFiles  int64     // Number of ignored files
 	IgnoredSize   int64     // Size of ignored files
+	FreedSize     int64     // Space actually freed, a hard-linked file counted once
+	BrokenLinks   int64     // Number of broken symbolic links removed
+	EmptyFiles    int64     // Number of zero-byte files removed
+	InUseFiles    int64     // Number of matched files skipped because a process held them open
+	InUseSize     int64     // Size of the files in use
 	StartTime     time.Time // Operation start time
 	EndTime       time.Time // Operation end time
 	Directory     string    // Target directory
 	OperationType string    // Type of operation performed
 }
 
+// AddShred counts a shredded file of size bytes that took written bytes and
+// the given time to overwrite and unlink
+func (s *ScanStatistics) AddShred(size, written int64, took time.Duration) {
+	s.ShreddedFiles++
+	s.ShreddedSize += size
+	s.ShredWritten += written
+	s.ShredNanos += int64(took)
+}
+
+// ShredThroughput returns the bytes shredded per second, 0 before anything
+// was shredded
+func (s *ScanStatistics) ShredThroughput() float64 {
+	if s.ShredNanos <= 0 {
+		return 0
+	}
+	return float64(s.ShredWritten) / time.Duration(s.ShredNanos).Seconds()
+}
+
 // LogEntry represents a single log entry with metadata
 type LogEntry struct {
 	Timestamp time.Time       `json:"timestamp"`       // When the entry was created
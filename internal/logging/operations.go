@@ -8,11 +8,21 @@ import (
 type OperationType string
 
 const (
-	OperationDeleted OperationType = "deleted" // File was permanently deleted
-	OperationIgnored OperationType = "ignored" // File was skipped
-	OperationTrashed OperationType = "trashed" // File was moved to trash
+	OperationDeleted  OperationType = "deleted"  // File was permanently deleted
+	OperationIgnored  OperationType = "ignored"  // File was skipped
+	OperationTrashed  OperationType = "trashed"  // File was moved to trash
+	OperationArchived OperationType = "archived" // File was compressed in place
+	OperationShredded OperationType = "shredded" // File was overwritten and deleted
+	OperationRotated  OperationType = "rotated"  // File was renamed or copied to its first generation
 )
 
+// Removes reports whether the file is gone from its directory after the
+// operation, so its size counts as cleaned. Archived and rotated files keep
+// their data.
+func (t OperationType) Removes() bool {
+	return t == OperationDeleted || t == OperationTrashed || t == OperationShredded
+}
+
 // FileOperation records details about a single file operation
 type FileOperation struct {
 	Timestamp     time.Time     `json:"timestamp"`      // When the operation occurred
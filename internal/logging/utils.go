@@ -15,3 +15,10 @@ func GetLogFilePath() string {
 
 	return fileLogPath
 }
+
+// GetJournalFilePath returns the path to the operation journal, stored next
+// to the log file
+func GetJournalFilePath() string {
+	userConfigDir, _ := os.UserConfigDir()
+	return filepath.Join(userConfigDir, path.AppDirName, path.JournalFileName)
+}
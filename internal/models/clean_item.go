@@ -4,6 +4,8 @@ type CleanItem struct {
 	Path  string
 	Size  int64
 	IsDir bool
+	Owner string // Name of the owning user, empty when unknown
+	Type  string // MIME type sniffed from the contents, empty unless the filter matches types
 }
 
 // For list.Item bubble tea
@@ -1,7 +1,11 @@
 package path
 
 var (
-	AppDirName   = "deletor"
-	RuleFileName = "rule.json"
-	LogFileName  = "deletor.log"
+	AppDirName            = "deletor"
+	RuleFileName          = "rule.json"
+	LogFileName           = "deletor.log"
+	ProjectConfigFileName = ".deletor.json"
+	ProfilesDirName       = "profiles"
+	JournalFileName       = "journal.jsonl"
+	IndexFileName         = "index.gob"
 )
@@ -0,0 +1,121 @@
+package rules
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// RuleChange describes a single field that differs between two rule sets
+type RuleChange struct {
+	Field string // Name of the field as written in the rules file
+	Old   string // Current value, empty if unset
+	New   string // Incoming value, empty if unset
+}
+
+// LoadBundle reads a shared rules bundle from a local path or a file:// URL.
+// The bundle format is detected by extension and is checked with the same
+// migrations and validation as the saved rules.
+func LoadBundle(source string) (*defaultRules, error) {
+	filePath := source
+	if strings.Contains(source, "://") {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("parse bundle URL: %w", err)
+		}
+		if u.Scheme != "file" {
+			return nil, fmt.Errorf("unsupported bundle URL scheme %q: only local files can be imported", u.Scheme)
+		}
+		filePath = u.Path
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRules(filePath, data)
+}
+
+// ExportBundle encodes rules as an indented JSON bundle. Paths inside the home
+// directory are rewritten to start with "~" so the bundle works on any machine.
+func ExportBundle(rules *defaultRules) ([]byte, error) {
+	portable := rules.clone()
+	portable.Version = CurrentRulesVersion
+
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		portable.Path = collapseHome(portable.Path, home)
+		for i, pattern := range portable.Exclude {
+			portable.Exclude[i] = collapseHome(pattern, home)
+		}
+		for i, location := range portable.CacheLocations {
+			portable.CacheLocations[i].Path = collapseHome(location.Path, home)
+		}
+	}
+
+	doc, err := rulesToDocument(portable)
+	if err != nil {
+		return nil, err
+	}
+	return encodeDocument(formatJSON, doc)
+}
+
+// collapseHome replaces a leading home directory with "~"
+func collapseHome(p, home string) string {
+	home = filepath.Clean(home)
+	if p == home {
+		return "~"
+	}
+	if strings.HasPrefix(p, home+string(filepath.Separator)) {
+		return "~" + p[len(home):]
+	}
+	return p
+}
+
+// DiffRules lists the fields whose values differ between current and incoming,
+// in the order they appear in the rules file.
+func DiffRules(current, incoming *defaultRules) []RuleChange {
+	var changes []RuleChange
+
+	oldValue := reflect.ValueOf(current.clone()).Elem()
+	newValue := reflect.ValueOf(incoming.clone()).Elem()
+	t := oldValue.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Name == "Version" {
+			continue
+		}
+
+		oldText := formatRuleValue(oldValue.Field(i))
+		newText := formatRuleValue(newValue.Field(i))
+		if oldText != newText {
+			changes = append(changes, RuleChange{Field: field.Name, Old: oldText, New: newText})
+		}
+	}
+
+	return changes
+}
+
+// formatRuleValue renders a rules field for display, treating nil and empty
+// lists the same
+func formatRuleValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(items, ", ")
+	case reflect.Bool:
+		if v.Bool() {
+			return "true"
+		}
+		return "false"
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
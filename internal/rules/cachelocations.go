@@ -0,0 +1,171 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pashkov256/deletor/internal/cache"
+	"github.com/pashkov256/deletor/internal/utils"
+)
+
+// CacheRule is a cache directory of the user's own, such as
+// ~/.terraform.d/plugin-cache, offered next to the built-in cache locations.
+// A rule named like a built-in location replaces it.
+type CacheRule struct {
+	Name        string // Name used to select the location, such as terraform
+	Path        string // Directory of the cache; ~ and $VAR are expanded
+	Type        string `json:",omitempty"` // system, app, dev or browser; empty for app
+	Description string `json:",omitempty"` // What the location holds, shown on the Cache page
+	OlderThan   string `json:",omitempty"` // Only files not modified for this long are cleared, e.g. 30d
+	MinSize     string `json:",omitempty"` // Only files at least this large are cleared, e.g. 1mb
+	Confirm     bool   `json:",omitempty"` // Whether clearing the location is confirmed on its own
+}
+
+// Validate checks the name, path, type and limits of a cache location
+func (r CacheRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("cache location needs a name")
+	}
+	if strings.ContainsAny(r.Name, " \t,") || r.Name != strings.ToLower(r.Name) {
+		return fmt.Errorf("cache location name %q must be lower case without spaces or commas", r.Name)
+	}
+	if isCacheType(cache.CacheType(r.Name)) {
+		return fmt.Errorf("cache location name %q is a cache type", r.Name)
+	}
+	if strings.TrimSpace(r.Path) == "" {
+		return fmt.Errorf("cache location %q needs a path", r.Name)
+	}
+	if _, err := expandCachePath(r.Path); err != nil {
+		return fmt.Errorf("cache location %q: %w", r.Name, err)
+	}
+	if r.Type != "" && !isCacheType(cache.CacheType(r.Type)) {
+		return fmt.Errorf("cache location %q: unknown type %q, want system, app, dev or browser", r.Name, r.Type)
+	}
+	if r.OlderThan != "" {
+		if _, err := utils.ParseTimeDuration(r.OlderThan); err != nil {
+			return fmt.Errorf("older-than of cache location %q: %w", r.Name, err)
+		}
+	}
+	if r.MinSize != "" {
+		if _, err := utils.ToBytes(r.MinSize); err != nil {
+			return fmt.Errorf("size of cache location %q: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// Compile turns the rule into a cache location with its path expanded and
+// the age taken relative to the moment of the call
+func (r CacheRule) Compile() (cache.CacheLocation, error) {
+	if err := r.Validate(); err != nil {
+		return cache.CacheLocation{}, err
+	}
+	// Already checked by Validate
+	path, _ := expandCachePath(r.Path)
+	location := cache.CacheLocation{
+		Name:        r.Name,
+		Description: r.Description,
+		Path:        path,
+		Type:        cache.CacheType(r.Type),
+		Confirm:     r.Confirm,
+		Custom:      true,
+	}
+	if location.Type == "" {
+		location.Type = cache.AppCache
+	}
+	if location.Description == "" {
+		location.Description = "Custom cache"
+	}
+	location.OlderThan, _ = utils.ParseTimeDuration(r.OlderThan)
+	location.MinSize, _ = utils.ToBytes(r.MinSize)
+	return location, nil
+}
+
+// ValidateCacheLocations checks every cache location of a list and that
+// no two share a name
+func ValidateCacheLocations(locations []CacheRule) error {
+	seen := make(map[string]bool, len(locations))
+	for _, location := range locations {
+		if err := location.Validate(); err != nil {
+			return err
+		}
+		if seen[location.Name] {
+			return fmt.Errorf("cache location %q is defined twice", location.Name)
+		}
+		seen[location.Name] = true
+	}
+	return nil
+}
+
+// CompileCacheLocations compiles a list of cache locations in order
+func CompileCacheLocations(locations []CacheRule) ([]cache.CacheLocation, error) {
+	if err := ValidateCacheLocations(locations); err != nil {
+		return nil, err
+	}
+	compiled := make([]cache.CacheLocation, 0, len(locations))
+	for _, location := range locations {
+		c, err := location.Compile()
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// isCacheType reports whether t names a cache type
+func isCacheType(t cache.CacheType) bool {
+	switch t {
+	case cache.SystemCache, cache.AppCache, cache.DevCache, cache.BrowserCache:
+		return true
+	}
+	return false
+}
+
+// expandCachePath expands environment variables and a leading ~ in path.
+// Clearing a location removes everything below it, so the expanded path
+// must be absolute and neither a filesystem root nor the home directory or
+// one of its parents, and every variable it names must be set.
+func expandCachePath(path string) (string, error) {
+	var unset []string
+	path = os.Expand(strings.TrimSpace(path), func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			unset = append(unset, "$"+name)
+		}
+		return value
+	})
+	if len(unset) > 0 {
+		return "", fmt.Errorf("path names unset variables %s", strings.Join(unset, ", "))
+	}
+
+	home, homeErr := os.UserHomeDir()
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		if homeErr != nil {
+			return "", fmt.Errorf("expand ~: %w", homeErr)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	if strings.TrimSpace(path) == "" {
+		return "", fmt.Errorf("path is empty once expanded")
+	}
+
+	path = filepath.Clean(path)
+	switch {
+	case !filepath.IsAbs(path):
+		return "", fmt.Errorf("path %q is not absolute", path)
+	case filepath.Dir(path) == path:
+		return "", fmt.Errorf("path %q is a filesystem root", path)
+	case homeErr == nil && isParentOrSame(path, filepath.Clean(home)):
+		return "", fmt.Errorf("path %q holds the home directory", path)
+	}
+	return path, nil
+}
+
+// isParentOrSame reports whether dir is path or one of its parents
+func isParentOrSame(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,144 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/utils"
+)
+
+// Clause is one entry of a rule's ordered per-pattern policies, such as
+// *.log older than 7d archived while *.tmp older than 1d is deleted. The
+// first clause matching a file decides what happens to it.
+type Clause struct {
+	Pattern   string // Glob matched against the file name, or the path when it contains "/"
+	OlderThan string `json:",omitempty"` // Minimum age, e.g. 7d
+	MinSize   string `json:",omitempty"` // Minimum size, e.g. 10mb
+	MaxSize   string `json:",omitempty"` // Maximum size, e.g. 1gb
+	Action    string `json:",omitempty"` // delete, trash, shred, archive, rotate or skip; empty follows the run settings
+}
+
+// ParseClause parses the compact command-line form of a clause:
+// PATTERN[:older=AGE][:min-size=SIZE][:max-size=SIZE][:action=ACTION]
+func ParseClause(spec string) (Clause, error) {
+	parts := strings.Split(strings.TrimSpace(spec), ":")
+	clause := Clause{Pattern: strings.TrimSpace(parts[0])}
+
+	for _, part := range parts[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return Clause{}, fmt.Errorf("invalid clause limit %q in %q: expected key=value", part, spec)
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "older":
+			clause.OlderThan = value
+		case "min-size":
+			clause.MinSize = value
+		case "max-size":
+			clause.MaxSize = value
+		case "action":
+			clause.Action = strings.ToLower(value)
+		default:
+			return Clause{}, fmt.Errorf("unknown clause limit %q in %q: use older, min-size, max-size or action", key, spec)
+		}
+	}
+
+	if err := clause.Validate(); err != nil {
+		return Clause{}, err
+	}
+	return clause, nil
+}
+
+// String returns the compact form accepted by ParseClause
+func (c Clause) String() string {
+	s := c.Pattern
+	if c.OlderThan != "" {
+		s += ":older=" + c.OlderThan
+	}
+	if c.MinSize != "" {
+		s += ":min-size=" + c.MinSize
+	}
+	if c.MaxSize != "" {
+		s += ":max-size=" + c.MaxSize
+	}
+	if c.Action != "" {
+		s += ":action=" + c.Action
+	}
+	return s
+}
+
+// Validate checks the pattern, limits and action of a clause
+func (c Clause) Validate() error {
+	if c.Pattern == "" {
+		return fmt.Errorf("clause needs a pattern")
+	}
+	if _, err := filemanager.NewCompare("path", "~", c.Pattern); err != nil {
+		return fmt.Errorf("clause pattern %q: %w", c.Pattern, err)
+	}
+	if c.OlderThan != "" {
+		if _, err := utils.ParseTimeDuration(c.OlderThan); err != nil {
+			return fmt.Errorf("older-than of clause %q: %w", c.Pattern, err)
+		}
+	}
+	for _, size := range []string{c.MinSize, c.MaxSize} {
+		if size == "" {
+			continue
+		}
+		if _, err := utils.ToBytes(size); err != nil {
+			return fmt.Errorf("size of clause %q: %w", c.Pattern, err)
+		}
+	}
+	if _, err := filemanager.ParseFileAction(c.Action); err != nil {
+		return fmt.Errorf("clause %q: %w", c.Pattern, err)
+	}
+	return nil
+}
+
+// Compile turns a clause into the filter clause, with the age taken
+// relative to the moment of the call
+func (c Clause) Compile() (filemanager.Clause, error) {
+	if err := c.Validate(); err != nil {
+		return filemanager.Clause{}, err
+	}
+	compiled := filemanager.Clause{Spec: c.String(), Pattern: c.Pattern}
+	if c.OlderThan != "" {
+		compiled.OlderThan, _ = utils.ParseTimeDuration(c.OlderThan)
+	}
+	compiled.MinSize, _ = utils.ToBytes(c.MinSize)
+	compiled.MaxSize, _ = utils.ToBytes(c.MaxSize)
+	compiled.Action, _ = filemanager.ParseFileAction(c.Action)
+	return compiled, nil
+}
+
+// ValidateClauses checks every clause of a list and that the list selects
+// some files, which a list of only skip clauses never does
+func ValidateClauses(clauses []Clause) error {
+	selects := len(clauses) == 0
+	for _, clause := range clauses {
+		if err := clause.Validate(); err != nil {
+			return err
+		}
+		if !strings.EqualFold(clause.Action, string(filemanager.ActionSkip)) {
+			selects = true
+		}
+	}
+	if !selects {
+		return fmt.Errorf("every clause skips its files, so none would be cleaned")
+	}
+	return nil
+}
+
+// CompileClauses compiles a list of clauses in order
+func CompileClauses(clauses []Clause) ([]filemanager.Clause, error) {
+	compiled := make([]filemanager.Clause, 0, len(clauses))
+	for _, clause := range clauses {
+		c, err := clause.Compile()
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
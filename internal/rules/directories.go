@@ -0,0 +1,78 @@
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pashkov256/deletor/internal/utils"
+)
+
+// DirectoryRule selects whole directories, such as node_modules, that are
+// cleaned as a single item instead of file by file
+type DirectoryRule struct {
+	Pattern        string // Glob matched against the directory name
+	RequireSibling string `json:",omitempty"` // File that must exist next to the directory, e.g. package.json
+	UntouchedFor   string `json:",omitempty"` // Minimum age of the newest file in the subtree, e.g. 60d
+}
+
+// ParseDirectoryRule parses the compact command-line form of a directory
+// rule: PATTERN[:requires=FILE][:untouched=AGE]
+func ParseDirectoryRule(spec string) (DirectoryRule, error) {
+	parts := strings.Split(strings.TrimSpace(spec), ":")
+	rule := DirectoryRule{Pattern: strings.TrimSpace(parts[0])}
+
+	for _, part := range parts[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return DirectoryRule{}, fmt.Errorf("invalid directory guard %q in %q: expected key=value", part, spec)
+		}
+		switch strings.TrimSpace(key) {
+		case "requires":
+			rule.RequireSibling = strings.TrimSpace(value)
+		case "untouched":
+			rule.UntouchedFor = strings.TrimSpace(value)
+		default:
+			return DirectoryRule{}, fmt.Errorf("unknown directory guard %q in %q: use requires or untouched", key, spec)
+		}
+	}
+
+	if err := rule.Validate(); err != nil {
+		return DirectoryRule{}, err
+	}
+	return rule, nil
+}
+
+// String returns the compact form accepted by ParseDirectoryRule
+func (r DirectoryRule) String() string {
+	s := r.Pattern
+	if r.RequireSibling != "" {
+		s += ":requires=" + r.RequireSibling
+	}
+	if r.UntouchedFor != "" {
+		s += ":untouched=" + r.UntouchedFor
+	}
+	return s
+}
+
+// Validate checks the pattern and guards of a directory rule
+func (r DirectoryRule) Validate() error {
+	if r.Pattern == "" {
+		return fmt.Errorf("directory rule needs a pattern")
+	}
+	if strings.ContainsRune(r.Pattern, '/') || strings.ContainsRune(r.Pattern, filepath.Separator) {
+		return fmt.Errorf("directory pattern %q must match a single name", r.Pattern)
+	}
+	if _, err := filepath.Match(r.Pattern, ""); err != nil {
+		return fmt.Errorf("directory pattern %q: %w", r.Pattern, err)
+	}
+	if strings.ContainsRune(r.RequireSibling, '/') {
+		return fmt.Errorf("required sibling %q must be a file name", r.RequireSibling)
+	}
+	if r.UntouchedFor != "" {
+		if _, err := utils.ParseTimeDuration(r.UntouchedFor); err != nil {
+			return fmt.Errorf("untouched age of %q: %w", r.Pattern, err)
+		}
+	}
+	return nil
+}
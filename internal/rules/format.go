@@ -0,0 +1,201 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ruleFormat is the on-disk encoding of a rules file, detected by extension
+type ruleFormat string
+
+const (
+	formatJSON ruleFormat = "json"
+	formatYAML ruleFormat = "yaml"
+	formatTOML ruleFormat = "toml"
+)
+
+// ruleFileExtensions lists the supported rule file extensions in lookup order
+var ruleFileExtensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// formatForPath returns the rules format matching the file extension
+func formatForPath(filePath string) ruleFormat {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".toml":
+		return formatTOML
+	default:
+		return formatJSON
+	}
+}
+
+// ParseError reports a problem in a rules file together with its position
+type ParseError struct {
+	Path   string // Path of the rules file
+	Line   int    // 1-based line, 0 if unknown
+	Column int    // 1-based column, 0 if unknown
+	Err    error  // Underlying error
+}
+
+func (e *ParseError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %v", e.Path, e.Line, e.Column, e.Err)
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+	default:
+		return fmt.Sprintf("%s: %v", e.Path, e.Err)
+	}
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError reports an invalid value of a single rules field
+type FieldError struct {
+	Field string // Name of the field as written in the rules file
+	Err   error  // Why the value is invalid
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid %s: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// decodeDocument parses raw rules data into a generic document. Syntax errors
+// are returned as *ParseError with the position reported by the parser.
+func decodeDocument(format ruleFormat, filePath string, data []byte) (map[string]interface{}, error) {
+	doc := make(map[string]interface{})
+
+	switch format {
+	case formatYAML:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			parseErr := &ParseError{Path: filePath, Err: err}
+			if match := yamlLinePattern.FindStringSubmatch(err.Error()); match != nil {
+				parseErr.Line, _ = strconv.Atoi(match[1])
+			}
+			return nil, parseErr
+		}
+	case formatTOML:
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			parseErr := &ParseError{Path: filePath, Err: err}
+			var decodeErr *toml.DecodeError
+			if errors.As(err, &decodeErr) {
+				parseErr.Line, parseErr.Column = decodeErr.Position()
+			}
+			return nil, parseErr
+		}
+	default:
+		if err := json.Unmarshal(data, &doc); err != nil {
+			parseErr := &ParseError{Path: filePath, Err: err}
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) {
+				// Offset is just past the offending byte
+				parseErr.Line, parseErr.Column = offsetToPosition(data, syntaxErr.Offset-1)
+			} else if errors.As(err, &typeErr) {
+				parseErr.Line, parseErr.Column = offsetToPosition(data, typeErr.Offset)
+			}
+			return nil, parseErr
+		}
+	}
+
+	return doc, nil
+}
+
+// encodeDocument serializes a generic rules document in a hand-editable layout
+func encodeDocument(format ruleFormat, doc map[string]interface{}) ([]byte, error) {
+	switch format {
+	case formatYAML:
+		return yaml.Marshal(doc)
+	case formatTOML:
+		return toml.Marshal(doc)
+	default:
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+}
+
+// rulesToDocument converts rules into a generic document keyed by field name
+func rulesToDocument(rules *defaultRules) (map[string]interface{}, error) {
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]interface{})
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// documentToRules decodes a generic document on top of the default rule values
+func documentToRules(doc map[string]interface{}) (*defaultRules, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := defaultRuleValues()
+	if err := json.Unmarshal(data, rules); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			field := strings.SplitN(typeErr.Field, ".", 2)[0]
+			return nil, &FieldError{Field: field, Err: fmt.Errorf("expected %s, got %s", typeErr.Type, typeErr.Value)}
+		}
+		return nil, err
+	}
+	return rules, nil
+}
+
+// locateField returns the position of the first key named field in the raw
+// rules data, so errors found after parsing can still point at the source.
+func locateField(data []byte, field string) (line, column int) {
+	pattern, err := regexp.Compile(`["']?\b` + regexp.QuoteMeta(field) + `\b["']?\s*[:=]`)
+	if err != nil {
+		return 0, 0
+	}
+	loc := pattern.FindIndex(data)
+	if loc == nil {
+		return 0, 0
+	}
+	return offsetToPosition(data, int64(loc[0]))
+}
+
+// offsetToPosition converts a byte offset into a 1-based line and column
+func offsetToPosition(data []byte, offset int64) (line, column int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	line, column = 1, 1
+	for _, b := range data[:offset] {
+		if b == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
@@ -12,25 +12,61 @@ type Rules interface {
 
 // defaultRules holds the configuration for file operations.
 type defaultRules struct {
-	Path                  string        `json:",omitempty"` // Target directory path
-	Extensions            []string      `json:",omitempty"` // File extensions to process
-	Exclude               []string      `json:",omitempty"` // Patterns to exclude
-	MinSize               string        `json:",omitempty"` // Minimum file size
-	MaxSize               string        `json:",omitempty"` // Maximum file size
-	OlderThan             string        `json:",omitempty"` // Only process files older than
-	NewerThan             string        `json:",omitempty"` // Only process files newer than
-	ShowHiddenFiles       bool          `json:",omitempty"` // Whether to show hidden files
-	ConfirmDeletion       bool          `json:",omitempty"` // Whether to confirm deletions
-	IncludeSubfolders     bool          `json:",omitempty"` // Whether to process subfolders
-	DeleteEmptySubfolders bool          `json:",omitempty"` // Whether to remove empty folders
-	SendFilesToTrash      bool          `json:",omitempty"` // Whether to use trash instead of delete
-	LogOperations         bool          `json:",omitempty"` // Whether to log operations
-	LogToFile             bool          `json:",omitempty"` // Whether to write logs to file
-	ShowStatistics        bool          `json:",omitempty"` // Whether to display statistics
-	DisableEmoji          bool          `json:",omitempty"` // Whether to disable emoji
-	ExitAfterDeletion     bool          `json:",omitempty"` // Whether to exit after deletion
-	cached                *defaultRules `json:"-"`
-	mu                    sync.RWMutex  `json:"-"`
+	Version               int             // Schema version of the rules file
+	Path                  string          `json:",omitempty"` // Target directory path
+	Extensions            []string        `json:",omitempty"` // File extensions to process
+	Exclude               []string        `json:",omitempty"` // Patterns to exclude
+	Include               []string        `json:",omitempty"` // File or directory name globs to include
+	Presets               []string        `json:",omitempty"` // Built-in presets to apply
+	Directories           []DirectoryRule `json:",omitempty"` // Whole directories to clean as one item
+	Clauses               []Clause        `json:",omitempty"` // Ordered per-pattern policies, the first match decides a file's action
+	CacheLocations        []CacheRule     `json:",omitempty"` // Cache directories offered next to the built-in ones
+	MinSize               string          `json:",omitempty"` // Minimum file size
+	MaxSize               string          `json:",omitempty"` // Maximum file size
+	OlderThan             string          `json:",omitempty"` // Only process files older than
+	NewerThan             string          `json:",omitempty"` // Only process files newer than
+	AccessedBefore        string          `json:",omitempty"` // Only process files last read before
+	AccessedAfter         string          `json:",omitempty"` // Only process files last read after
+	ChangedBefore         string          `json:",omitempty"` // Only process files whose inode last changed before
+	ChangedAfter          string          `json:",omitempty"` // Only process files whose inode last changed after
+	Owners                []string        `json:",omitempty"` // Only process files owned by these users, by name or ID
+	NotOwners             []string        `json:",omitempty"` // Skip files owned by these users, by name or ID
+	UIDs                  []int           `json:",omitempty"` // Only process files owned by these numeric user IDs
+	Groups                []string        `json:",omitempty"` // Only process files whose group is one of these, by name or ID
+	Perm                  string          `json:",omitempty"` // Permission bits as in find -perm
+	Where                 string          `json:",omitempty"` // Filter expression files must also match
+	ContentTypes          []string        `json:",omitempty"` // MIME types or categories sniffed from file contents
+	ShowHiddenFiles       bool            `json:",omitempty"` // Whether to show hidden files
+	ConfirmDeletion       bool            `json:",omitempty"` // Whether to confirm deletions
+	IncludeSubfolders     bool            `json:",omitempty"` // Whether to process subfolders
+	MaxDepth              int             `json:",omitempty"` // Levels below the path to look at, 0 for no limit
+	MinDepth              int             `json:",omitempty"` // Levels below the path files must be at least, 0 for no limit
+	MaxDirEntries         int             `json:",omitempty"` // Subfolders holding more entries are not entered, 0 for no limit
+	DeleteEmptySubfolders bool            `json:",omitempty"` // Whether to remove empty folders
+	DeleteBrokenLinks     bool            `json:",omitempty"` // Whether to remove dangling symbolic links
+	DeleteEmptyFiles      bool            `json:",omitempty"` // Whether to remove zero-byte files
+	SendFilesToTrash      bool            `json:",omitempty"` // Whether to use trash instead of delete
+	Shred                 bool            `json:",omitempty"` // Whether to overwrite files before deleting them
+	ShredPasses           int             `json:",omitempty"` // Overwrite passes when shredding, 0 for the default of 3
+	ShredMethod           string          `json:",omitempty"` // Data written when shredding: zeros, random or dod
+	RotateKeep            int             `json:",omitempty"` // Generations kept by the rotate action, 0 for the default of 5
+	RotateCopyTruncate    bool            `json:",omitempty"` // Whether files held open are copied and truncated when rotated
+	IONice                string          `json:",omitempty"` // I/O class of scheduled runs: idle or best-effort[:LEVEL]
+	Nice                  int             `json:",omitempty"` // CPU niceness of scheduled runs from 1 to 19, 0 to keep it
+	RateLimit             string          `json:",omitempty"` // Files removed per time unit, such as 200/s
+	BWLimit               string          `json:",omitempty"` // Bytes archived or shredded per second, such as 20MB/s
+	SkipOpenFiles         bool            `json:",omitempty"` // Whether files held open by a process are left alone
+	LogOperations         bool            `json:",omitempty"` // Whether to log operations
+	LogToFile             bool            `json:",omitempty"` // Whether to write logs to file
+	ShowStatistics        bool            `json:",omitempty"` // Whether to display statistics
+	DisableEmoji          bool            `json:",omitempty"` // Whether to disable emoji
+	ExitAfterDeletion     bool            `json:",omitempty"` // Whether to exit after deletion
+	OneFileSystem         bool            `json:",omitempty"` // Whether to stay on the filesystem of Path
+	SkipFilesystems       []string        `json:",omitempty"` // Filesystem types or groups whose mounts are skipped
+	Symlinks              string          `json:",omitempty"` // Symbolic link policy: never, follow or broken
+	profile               string          `json:"-"`
+	cached                *defaultRules   `json:"-"`
+	mu                    *sync.RWMutex   `json:"-"` // Created by lock on first use
 }
 
 // NewRules creates a new instance of the default rules.
@@ -1,13 +1,14 @@
 package rules
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
+	"github.com/pashkov256/deletor/internal/filemanager"
 	"github.com/pashkov256/deletor/internal/path"
 	"github.com/pashkov256/deletor/internal/tui/options"
 	"github.com/pashkov256/deletor/internal/utils"
@@ -15,6 +16,7 @@ import (
 
 func defaultRuleValues() *defaultRules {
 	return &defaultRules{
+		Version:               CurrentRulesVersion,
 		Path:                  "",
 		Extensions:            []string{},
 		Exclude:               []string{},
@@ -26,7 +28,10 @@ func defaultRuleValues() *defaultRules {
 		ConfirmDeletion:       options.DefaultCleanOptionState[options.ConfirmDeletion],
 		IncludeSubfolders:     options.DefaultCleanOptionState[options.IncludeSubfolders],
 		DeleteEmptySubfolders: options.DefaultCleanOptionState[options.DeleteEmptySubfolders],
+		DeleteBrokenLinks:     options.DefaultCleanOptionState[options.DeleteBrokenLinks],
+		DeleteEmptyFiles:      options.DefaultCleanOptionState[options.DeleteEmptyFiles],
 		SendFilesToTrash:      options.DefaultCleanOptionState[options.SendFilesToTrash],
+		Shred:                 options.DefaultCleanOptionState[options.ShredFiles],
 		LogOperations:         options.DefaultCleanOptionState[options.LogOperations],
 		LogToFile:             options.DefaultCleanOptionState[options.LogToFile],
 		ShowStatistics:        options.DefaultCleanOptionState[options.ShowStatistics],
@@ -43,28 +48,76 @@ func (d *defaultRules) clone() *defaultRules {
 	clone := *d
 	clone.Extensions = append([]string(nil), d.Extensions...)
 	clone.Exclude = append([]string(nil), d.Exclude...)
+	clone.Include = append([]string(nil), d.Include...)
+	clone.Presets = append([]string(nil), d.Presets...)
+	clone.Directories = append([]DirectoryRule(nil), d.Directories...)
+	clone.Clauses = append([]Clause(nil), d.Clauses...)
+	clone.CacheLocations = append([]CacheRule(nil), d.CacheLocations...)
+	clone.SkipFilesystems = append([]string(nil), d.SkipFilesystems...)
+	clone.Owners = append([]string(nil), d.Owners...)
+	clone.NotOwners = append([]string(nil), d.NotOwners...)
+	clone.UIDs = append([]int(nil), d.UIDs...)
+	clone.Groups = append([]string(nil), d.Groups...)
+	clone.ContentTypes = append([]string(nil), d.ContentTypes...)
+	clone.profile = ""
 	clone.cached = nil
-	clone.mu = sync.RWMutex{}
+	clone.mu = nil
 	return &clone
 }
 
+// getRulesPath returns the rules file in the user config directory, or the
+// profile file when the rules are bound to a named profile. A hand-written YAML
+// or TOML variant is used when no file with the configured name exists.
 func (d *defaultRules) getRulesPath() (string, error) {
 	userConfigDir, err := os.UserConfigDir()
 	if err != nil {
 		return "", fmt.Errorf("get user config dir: %w", err)
 	}
-	return filepath.Join(userConfigDir, path.AppDirName, path.RuleFileName), nil
+
+	configured := filepath.Join(userConfigDir, path.AppDirName, path.RuleFileName)
+	if d.profile != "" {
+		configured = filepath.Join(userConfigDir, path.AppDirName, path.ProfilesDirName, d.profile+".json")
+	}
+	if _, err := os.Stat(configured); err == nil {
+		return configured, nil
+	}
+
+	base := strings.TrimSuffix(configured, filepath.Ext(configured))
+	for _, ext := range ruleFileExtensions {
+		candidate := base + ext
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return configured, nil
+}
+
+// lockInit guards the creation of the cache mutex of rules
+var lockInit sync.Mutex
+
+// lock returns the mutex guarding the cache, creating it on first use.
+// Clones and rules decoded from a file start without one, as a mutex must
+// not be copied, and are as usable as those made by NewRules.
+func (d *defaultRules) lock() *sync.RWMutex {
+	lockInit.Lock()
+	defer lockInit.Unlock()
+	if d.mu == nil {
+		d.mu = &sync.RWMutex{}
+	}
+	return d.mu
 }
 
 func (d *defaultRules) setCache(rules *defaultRules) {
-	d.mu.Lock()
-	defer d.mu.Unlock()
+	mu := d.lock()
+	mu.Lock()
+	defer mu.Unlock()
 	d.cached = rules.clone()
 }
 
 func (d *defaultRules) getCached() *defaultRules {
-	d.mu.RLock()
-	defer d.mu.RUnlock()
+	mu := d.lock()
+	mu.RLock()
+	defer mu.RUnlock()
 	if d.cached == nil {
 		return nil
 	}
@@ -74,27 +127,131 @@ func (d *defaultRules) getCached() *defaultRules {
 func (d *defaultRules) validate() error {
 	if d.MinSize != "" {
 		if _, err := utils.ToBytes(d.MinSize); err != nil {
-			return fmt.Errorf("invalid MinSize: %w", err)
+			return &FieldError{Field: "MinSize", Err: err}
 		}
 	}
 	if d.MaxSize != "" {
 		if _, err := utils.ToBytes(d.MaxSize); err != nil {
-			return fmt.Errorf("invalid MaxSize: %w", err)
+			return &FieldError{Field: "MaxSize", Err: err}
 		}
 	}
 	if d.OlderThan != "" {
 		if _, err := utils.ParseTimeDuration(d.OlderThan); err != nil {
-			return fmt.Errorf("invalid OlderThan: %w", err)
+			return &FieldError{Field: "OlderThan", Err: err}
 		}
 	}
 	if d.NewerThan != "" {
 		if _, err := utils.ParseTimeDuration(d.NewerThan); err != nil {
-			return fmt.Errorf("invalid NewerThan: %w", err)
+			return &FieldError{Field: "NewerThan", Err: err}
+		}
+	}
+	for _, age := range []struct{ field, value string }{
+		{"AccessedBefore", d.AccessedBefore},
+		{"AccessedAfter", d.AccessedAfter},
+		{"ChangedBefore", d.ChangedBefore},
+		{"ChangedAfter", d.ChangedAfter},
+	} {
+		if age.value == "" {
+			continue
+		}
+		if _, err := utils.ParseTimeDuration(age.value); err != nil {
+			return &FieldError{Field: age.field, Err: err}
+		}
+	}
+	for _, pattern := range d.Include {
+		if _, err := filepath.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+			return &FieldError{Field: "Include", Err: fmt.Errorf("%q: %w", pattern, err)}
+		}
+	}
+	for _, name := range d.Presets {
+		if _, ok := LookupPreset(name); !ok {
+			return &FieldError{Field: "Presets", Err: fmt.Errorf("unknown preset %q", name)}
+		}
+	}
+
+	for _, dir := range d.Directories {
+		if err := dir.Validate(); err != nil {
+			return &FieldError{Field: "Directories", Err: err}
+		}
+	}
+	if err := ValidateClauses(d.Clauses); err != nil {
+		return &FieldError{Field: "Clauses", Err: err}
+	}
+	if err := ValidateCacheLocations(d.CacheLocations); err != nil {
+		return &FieldError{Field: "CacheLocations", Err: err}
+	}
+	for _, pattern := range d.SkipFilesystems {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return &FieldError{Field: "SkipFilesystems", Err: fmt.Errorf("%q: %w", pattern, err)}
+		}
+	}
+	if _, err := filemanager.ParseSymlinkPolicy(d.Symlinks); err != nil {
+		return &FieldError{Field: "Symlinks", Err: err}
+	}
+	for _, uid := range d.UIDs {
+		if uid < 0 {
+			return &FieldError{Field: "UIDs", Err: fmt.Errorf("%d is not a user ID", uid)}
+		}
+	}
+	if _, err := filemanager.ParsePermFilter(d.Perm); err != nil {
+		return &FieldError{Field: "Perm", Err: err}
+	}
+	if err := filemanager.CheckExpr(d.Where); err != nil {
+		return &FieldError{Field: "Where", Err: err}
+	}
+	if _, err := filemanager.ParseContentTypes(d.ContentTypes); err != nil {
+		return &FieldError{Field: "ContentTypes", Err: err}
+	}
+	limits := filemanager.WalkLimits{MinDepth: d.MinDepth, MaxDepth: d.MaxDepth, MaxDirEntries: d.MaxDirEntries}
+	if err := limits.Validate(); err != nil {
+		field := "MinDepth"
+		if d.MaxDepth < 0 {
+			field = "MaxDepth"
+		} else if d.MaxDirEntries < 0 {
+			field = "MaxDirEntries"
+		}
+		return &FieldError{Field: field, Err: err}
+	}
+	shredding := filemanager.ShredOptions{Passes: d.ShredPasses, Method: filemanager.ShredMethod(d.ShredMethod)}
+	if err := shredding.Validate(); err != nil {
+		field := "ShredMethod"
+		if d.ShredPasses < 0 {
+			field = "ShredPasses"
 		}
+		return &FieldError{Field: field, Err: err}
+	}
+	if err := (filemanager.RotateOptions{Keep: d.RotateKeep}).Validate(); err != nil {
+		return &FieldError{Field: "RotateKeep", Err: err}
+	}
+	if d.Shred && d.SendFilesToTrash {
+		return &FieldError{Field: "Shred", Err: errors.New("files cannot be both shredded and sent to the trash")}
+	}
+	if _, err := utils.ParseIONice(d.IONice); err != nil {
+		return &FieldError{Field: "IONice", Err: err}
+	}
+	if d.Nice < 0 || d.Nice > 19 {
+		return &FieldError{Field: "Nice", Err: fmt.Errorf("nice must be between 0 and 19, got %d", d.Nice)}
+	}
+	if _, err := utils.ParseRate(d.RateLimit); err != nil {
+		return &FieldError{Field: "RateLimit", Err: err}
+	}
+	if _, err := utils.ParseByteRate(d.BWLimit); err != nil {
+		return &FieldError{Field: "BWLimit", Err: err}
 	}
 
 	d.Extensions = append([]string(nil), d.Extensions...)
 	d.Exclude = append([]string(nil), d.Exclude...)
+	d.Include = append([]string(nil), d.Include...)
+	d.Presets = append([]string(nil), d.Presets...)
+	d.Directories = append([]DirectoryRule(nil), d.Directories...)
+	d.Clauses = append([]Clause(nil), d.Clauses...)
+	d.CacheLocations = append([]CacheRule(nil), d.CacheLocations...)
+	d.SkipFilesystems = append([]string(nil), d.SkipFilesystems...)
+	d.Owners = append([]string(nil), d.Owners...)
+	d.NotOwners = append([]string(nil), d.NotOwners...)
+	d.UIDs = append([]int(nil), d.UIDs...)
+	d.Groups = append([]string(nil), d.Groups...)
+	d.ContentTypes = append([]string(nil), d.ContentTypes...)
 
 	return nil
 }
@@ -105,22 +262,50 @@ func (d *defaultRules) readRulesFromDisk() (*defaultRules, error) {
 		return nil, err
 	}
 
-	jsonRules, err := os.ReadFile(filePathRuleConfig)
+	data, err := os.ReadFile(filePathRuleConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	rules := defaultRuleValues()
-	if err := json.Unmarshal(jsonRules, rules); err != nil {
+	return parseRules(filePathRuleConfig, data)
+}
+
+// parseRules decodes, migrates and validates rules data. Problems are returned
+// as *ParseError pointing at the offending line and column where possible.
+func parseRules(filePath string, data []byte) (*defaultRules, error) {
+	doc, err := decodeDocument(formatForPath(filePath), filePath, data)
+	if err != nil {
 		return nil, err
 	}
+
+	// Field errors can only be located by searching for the key in the source
+	withPosition := func(err error) error {
+		parseErr := &ParseError{Path: filePath, Err: err}
+		var fieldErr *FieldError
+		if errors.As(err, &fieldErr) {
+			parseErr.Line, parseErr.Column = locateField(data, fieldErr.Field)
+		}
+		return parseErr
+	}
+
+	if _, err := migrateDocument(doc); err != nil {
+		return nil, withPosition(err)
+	}
+
+	rules, err := documentToRules(doc)
+	if err != nil {
+		return nil, withPosition(err)
+	}
 	if err := rules.validate(); err != nil {
-		return nil, err
+		return nil, withPosition(err)
 	}
 
 	return rules, nil
 }
 
+// readRulesForUpdate returns the saved rules, or the defaults if no rules
+// file exists yet. A malformed file is reported instead of being replaced, so
+// a bad hand edit is never silently overwritten.
 func (d *defaultRules) readRulesForUpdate() (*defaultRules, error) {
 	rules, err := d.readRulesFromDisk()
 	if err == nil {
@@ -131,12 +316,6 @@ func (d *defaultRules) readRulesForUpdate() (*defaultRules, error) {
 		return defaultRuleValues(), nil
 	}
 
-	var syntaxErr *json.SyntaxError
-	var typeErr *json.UnmarshalTypeError
-	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
-		return defaultRuleValues(), nil
-	}
-
 	return nil, err
 }
 
@@ -150,12 +329,18 @@ func (d *defaultRules) writeRules(rules *defaultRules) error {
 		return err
 	}
 
-	rulesJSON, err := json.Marshal(rules)
+	rules.Version = CurrentRulesVersion
+	doc, err := rulesToDocument(rules)
+	if err != nil {
+		return err
+	}
+
+	data, err := encodeDocument(formatForPath(filePathRuleConfig), doc)
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(filePathRuleConfig, rulesJSON, 0644); err != nil {
+	if err := os.WriteFile(filePathRuleConfig, data, 0644); err != nil {
 		return err
 	}
 
@@ -0,0 +1,99 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CurrentRulesVersion is the schema version written to new rules files.
+// Files without a Version field are treated as version 1.
+const CurrentRulesVersion = 2
+
+// migrations upgrade a rules document from the key version to the next one
+var migrations = map[int]func(doc map[string]interface{}) error{
+	1: migrateV1ToV2,
+}
+
+// documentVersion returns the schema version stored in a rules document
+func documentVersion(doc map[string]interface{}) (int, error) {
+	raw, ok := doc["Version"]
+	if !ok {
+		return 1, nil
+	}
+
+	switch v := raw.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case uint64:
+		return int(v), nil
+	case float64:
+		if v != float64(int(v)) {
+			return 0, &FieldError{Field: "Version", Err: fmt.Errorf("%v is not a whole number", v)}
+		}
+		return int(v), nil
+	default:
+		return 0, &FieldError{Field: "Version", Err: fmt.Errorf("expected a number, got %v", raw)}
+	}
+}
+
+// migrateDocument upgrades a rules document in place to CurrentRulesVersion
+// and returns the version it was stored with.
+func migrateDocument(doc map[string]interface{}) (int, error) {
+	version, err := documentVersion(doc)
+	if err != nil {
+		return 0, err
+	}
+	if version < 1 {
+		return 0, &FieldError{Field: "Version", Err: fmt.Errorf("unsupported version %d", version)}
+	}
+	if version > CurrentRulesVersion {
+		return 0, &FieldError{Field: "Version", Err: fmt.Errorf("version %d is newer than supported version %d", version, CurrentRulesVersion)}
+	}
+
+	for v := version; v < CurrentRulesVersion; v++ {
+		migrate, ok := migrations[v]
+		if !ok {
+			return 0, fmt.Errorf("no migration from rules version %d", v)
+		}
+		if err := migrate(doc); err != nil {
+			return 0, fmt.Errorf("migrate rules from version %d: %w", v, err)
+		}
+	}
+
+	doc["Version"] = CurrentRulesVersion
+	return version, nil
+}
+
+// migrateV1ToV2 normalizes extensions to the lowercase dotted form used by
+// the scanners, since version 1 files may contain hand-written "log" or "TMP".
+func migrateV1ToV2(doc map[string]interface{}) error {
+	raw, ok := doc["Extensions"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return &FieldError{Field: "Extensions", Err: fmt.Errorf("expected a list, got %v", raw)}
+	}
+
+	extensions := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		ext, ok := item.(string)
+		if !ok {
+			return &FieldError{Field: "Extensions", Err: fmt.Errorf("expected a string, got %v", item)}
+		}
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		extensions = append(extensions, ext)
+	}
+	doc["Extensions"] = extensions
+	return nil
+}
@@ -3,6 +3,17 @@ package rules
 // RuleOption is a function type that modifies rule settings
 type RuleOption func(*defaultRules)
 
+// WithRules replaces every rule setting with the values from src
+func WithRules(src *defaultRules) RuleOption {
+	return func(r *defaultRules) {
+		replacement := src.clone()
+		replacement.profile = r.profile
+		replacement.cached = r.cached
+		replacement.mu = r.mu
+		*r = *replacement
+	}
+}
+
 // WithPath sets the target directory path
 func WithPath(path string) RuleOption {
 	return func(r *defaultRules) {
@@ -31,6 +42,77 @@ func WithExtensions(extensions []string) RuleOption {
 	}
 }
 
+// WithInclude sets the file or directory name globs to include
+func WithInclude(include []string) RuleOption {
+	return func(r *defaultRules) {
+		r.Include = include
+	}
+}
+
+// WithPresets sets the built-in presets to apply
+func WithPresets(presets []string) RuleOption {
+	return func(r *defaultRules) {
+		r.Presets = presets
+	}
+}
+
+// WithDirectories sets the directories cleaned as a single item
+func WithDirectories(directories []DirectoryRule) RuleOption {
+	return func(r *defaultRules) {
+		r.Directories = directories
+	}
+}
+
+// WithClauses sets the ordered per-pattern policies of the rule
+func WithClauses(clauses []Clause) RuleOption {
+	return func(r *defaultRules) {
+		r.Clauses = clauses
+	}
+}
+
+// WithCacheLocations sets the cache directories offered next to the
+// built-in cache locations
+func WithCacheLocations(locations []CacheRule) RuleOption {
+	return func(r *defaultRules) {
+		r.CacheLocations = locations
+	}
+}
+
+// WithOneFileSystem keeps scans on the filesystem of the target path
+func WithOneFileSystem(oneFileSystem bool) RuleOption {
+	return func(r *defaultRules) {
+		r.OneFileSystem = oneFileSystem
+	}
+}
+
+// WithSkipFilesystems sets the filesystem types or groups whose mounts are skipped
+func WithSkipFilesystems(types []string) RuleOption {
+	return func(r *defaultRules) {
+		r.SkipFilesystems = types
+	}
+}
+
+// WithSymlinks sets the symbolic link policy of scans
+func WithSymlinks(policy string) RuleOption {
+	return func(r *defaultRules) {
+		r.Symlinks = policy
+	}
+}
+
+// WithDeleteBrokenLinks sets whether dangling symbolic links are removed
+func WithDeleteBrokenLinks(deleteBrokenLinks bool) RuleOption {
+	return func(r *defaultRules) {
+		r.DeleteBrokenLinks = deleteBrokenLinks
+	}
+}
+
+// WithDeleteEmptyFiles sets whether zero-byte files are removed
+func WithDeleteEmptyFiles(deleteEmptyFiles bool) RuleOption {
+	return func(r *defaultRules) {
+		r.DeleteEmptyFiles = deleteEmptyFiles
+	}
+}
+
 // WithExclude sets the patterns to exclude from processing
 func WithExclude(exclude []string) RuleOption {
 	return func(r *defaultRules) {
@@ -52,6 +134,136 @@ func WithNewerThan(time string) RuleOption {
 	}
 }
 
+// WithAccessedBefore sets the filter for files last read before an age
+func WithAccessedBefore(time string) RuleOption {
+	return func(r *defaultRules) {
+		r.AccessedBefore = time
+	}
+}
+
+// WithAccessedAfter sets the filter for files last read after an age
+func WithAccessedAfter(time string) RuleOption {
+	return func(r *defaultRules) {
+		r.AccessedAfter = time
+	}
+}
+
+// WithChangedBefore sets the filter for files whose inode changed before an age
+func WithChangedBefore(time string) RuleOption {
+	return func(r *defaultRules) {
+		r.ChangedBefore = time
+	}
+}
+
+// WithChangedAfter sets the filter for files whose inode changed after an age
+func WithChangedAfter(time string) RuleOption {
+	return func(r *defaultRules) {
+		r.ChangedAfter = time
+	}
+}
+
+// WithOwners sets the users whose files are processed
+func WithOwners(owners []string) RuleOption {
+	return func(r *defaultRules) {
+		r.Owners = owners
+	}
+}
+
+// WithNotOwners sets the users whose files are skipped
+func WithNotOwners(owners []string) RuleOption {
+	return func(r *defaultRules) {
+		r.NotOwners = owners
+	}
+}
+
+// WithUIDs sets the numeric user IDs whose files are processed
+func WithUIDs(uids []int) RuleOption {
+	return func(r *defaultRules) {
+		r.UIDs = uids
+	}
+}
+
+// WithGroups sets the groups whose files are processed
+func WithGroups(groups []string) RuleOption {
+	return func(r *defaultRules) {
+		r.Groups = groups
+	}
+}
+
+// WithPerm sets the find-style permission filter
+func WithPerm(perm string) RuleOption {
+	return func(r *defaultRules) {
+		r.Perm = perm
+	}
+}
+
+// WithWhere sets the filter expression files must also match
+func WithWhere(where string) RuleOption {
+	return func(r *defaultRules) {
+		r.Where = where
+	}
+}
+
+// WithContentTypes sets the MIME types or categories sniffed file contents
+// must match
+func WithContentTypes(types []string) RuleOption {
+	return func(r *defaultRules) {
+		r.ContentTypes = types
+	}
+}
+
+// WithWalkLimits sets the depth and directory size limits of recursive scans
+func WithWalkLimits(minDepth, maxDepth, maxDirEntries int) RuleOption {
+	return func(r *defaultRules) {
+		r.MinDepth = minDepth
+		r.MaxDepth = maxDepth
+		r.MaxDirEntries = maxDirEntries
+	}
+}
+
+// WithShred sets whether files are overwritten before they are deleted
+func WithShred(shred bool) RuleOption {
+	return func(r *defaultRules) {
+		r.Shred = shred
+	}
+}
+
+// WithShredOptions sets the number of overwrite passes and the data written
+// when shredding
+func WithShredOptions(passes int, method string) RuleOption {
+	return func(r *defaultRules) {
+		r.ShredPasses = passes
+		r.ShredMethod = method
+	}
+}
+
+// WithThrottle sets the I/O class, CPU niceness, file rate and bandwidth
+// limits of scheduled runs
+func WithThrottle(ionice string, nice int, rateLimit, bwLimit string) RuleOption {
+	return func(r *defaultRules) {
+		r.IONice = ionice
+		r.Nice = nice
+		r.RateLimit = rateLimit
+		r.BWLimit = bwLimit
+	}
+}
+
+// WithRotation sets the number of generations the rotate action keeps and
+// whether files held open are copied and truncated instead of renamed
+func WithRotation(keep int, copyTruncate bool) RuleOption {
+	return func(r *defaultRules) {
+		r.RotateKeep = keep
+		r.RotateCopyTruncate = copyTruncate
+	}
+}
+
+// WithSkipOpenFiles sets whether files held open by a process are left alone
+func WithSkipOpenFiles(skip bool) RuleOption {
+	return func(r *defaultRules) {
+		r.SkipOpenFiles = skip
+	}
+}
+
 // WithOptions sets multiple boolean options at once
 func WithOptions(showHidden, confirmDeletion, includeSubfolders, deleteEmptySubfolders, sendToTrash, logOps, logToFile, showStats, disableEmoji, exitAfterDeletion bool) RuleOption {
 	return func(r *defaultRules) {
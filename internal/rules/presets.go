@@ -0,0 +1,150 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/utils"
+)
+
+// PresetCatalogVersion is bumped whenever a built-in preset changes what it matches
+const PresetCatalogVersion = 2
+
+// Preset is a curated set of patterns for a common kind of junk
+type Preset struct {
+	Name        string          // Name used with --preset and in the rules file
+	Description string          // What the preset cleans
+	Include     []string        // Globs for file names, or parent directory names when ending in "/"
+	Exclude     []string        // Exclude patterns applied to the files the preset includes
+	OlderThan   string          // Minimum age of the included files, empty for any age
+	Directories []DirectoryRule // Whole directories the preset cleans, each as one item
+	SafetyNotes string          // What to check before deleting
+}
+
+// presets is the built-in catalog in display order
+var presets = []Preset{
+	{
+		Name:        "node",
+		Description: "npm and yarn dependency folders",
+		Directories: []DirectoryRule{{Pattern: "node_modules", RequireSibling: "package.json", UntouchedFor: "30d"}},
+		SafetyNotes: "Projects need `npm install` afterwards; only folders next to a package.json and untouched for 30 days are matched",
+	},
+	{
+		Name:        "python",
+		Description: "Python bytecode and test caches",
+		Include:     []string{"__pycache__/", ".pytest_cache/", "*.pyc", "*.pyo"},
+		Exclude:     []string{".git"},
+		SafetyNotes: "Caches are rebuilt on the next run; bytecode of installed packages may be read-only",
+	},
+	{
+		Name:        "rust",
+		Description: "Cargo build output",
+		Directories: []DirectoryRule{{Pattern: "target", RequireSibling: "Cargo.toml", UntouchedFor: "14d"}},
+		SafetyNotes: "Only target folders next to a Cargo.toml and untouched for 14 days are matched; the next build is slower",
+	},
+	{
+		Name:        "go",
+		Description: "Go build and test caches",
+		Include:     []string{"go-build/"},
+		SafetyNotes: "Point it at GOCACHE (see `go env GOCACHE`); the next build and test run are slower",
+	},
+	{
+		Name:        "os",
+		Description: "Operating system metadata files",
+		Include:     []string{".DS_Store", "._*", "Thumbs.db", "ehthumbs.db", "desktop.ini"},
+		SafetyNotes: "Folder view settings such as icon positions are reset",
+	},
+	{
+		Name:        "editor",
+		Description: "Editor swap and backup files",
+		Include:     []string{"*.swp", "*.swo", "*~", ".#*"},
+		OlderThan:   "1d",
+		SafetyNotes: "Swap files of open editors hold unsaved changes; only files older than a day are matched",
+	},
+	{
+		Name:        "patch",
+		Description: "Leftovers from patch and merge tools",
+		Include:     []string{"*.orig", "*.rej"},
+		Exclude:     []string{".git"},
+		SafetyNotes: "*.rej files list hunks that failed to apply; review them before deleting",
+	},
+	{
+		Name:        "coredump",
+		Description: "Process core dumps",
+		Include:     []string{"core", "core.[0-9]*", "*.core", "vgcore.*"},
+		OlderThan:   "7d",
+		SafetyNotes: "Core dumps are needed to debug crashes, and a file named core may be legitimate",
+	},
+}
+
+// Presets returns the built-in preset catalog in display order
+func Presets() []Preset {
+	catalog := make([]Preset, len(presets))
+	for i, p := range presets {
+		catalog[i] = p
+		catalog[i].Include = append([]string(nil), p.Include...)
+		catalog[i].Exclude = append([]string(nil), p.Exclude...)
+		catalog[i].Directories = append([]DirectoryRule(nil), p.Directories...)
+	}
+	return catalog
+}
+
+// PresetNames returns the names of all built-in presets
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for _, p := range presets {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// LookupPreset returns the built-in preset with the given name
+func LookupPreset(name string) (Preset, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, p := range Presets() {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// Term compiles the file patterns of the preset into one expression: a file
+// is selected when it matches an include pattern, none of the excludes and
+// the age limit, counted from now. ok is false for presets that only clean
+// directories.
+func (p Preset) Term() (term filemanager.PresetTerm, ok bool, err error) {
+	if len(p.Include) == 0 {
+		return filemanager.PresetTerm{}, false, nil
+	}
+	options := filemanager.FileFilterOptions{Include: p.Include, Exclude: p.Exclude}
+	if p.OlderThan != "" {
+		if options.OlderThan, err = utils.ParseTimeDuration(p.OlderThan); err != nil {
+			return filemanager.PresetTerm{}, false, fmt.Errorf("preset %s: %w", p.Name, err)
+		}
+	}
+	expr := filemanager.NewFileFilterWithOptions(options, nil).Expr()
+	return filemanager.PresetTerm{Name: p.Name, Expr: expr}, true, nil
+}
+
+// ExpandPresets compiles each named preset into its own term and collects
+// their directory rules. The terms select files independently, so the
+// excludes and age limit of one preset never apply to the files of another.
+func ExpandPresets(names []string) (terms []filemanager.PresetTerm, directories []DirectoryRule, err error) {
+	for _, name := range names {
+		p, ok := LookupPreset(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(PresetNames(), ", "))
+		}
+		term, ok, err := p.Term()
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			terms = append(terms, term)
+		}
+		directories = append(directories, p.Directories...)
+	}
+	return terms, directories, nil
+}
@@ -0,0 +1,23 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// DefaultProfile is the name of the profile stored in the main rules file
+const DefaultProfile = "default"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// NewProfileRules creates rules bound to the named profile. Profiles other
+// than DefaultProfile are stored as separate files in the profiles directory.
+func NewProfileRules(name string) (Rules, error) {
+	if name == "" || name == DefaultProfile {
+		return NewRules(), nil
+	}
+	if !profileNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid profile name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	return &defaultRules{profile: name}, nil
+}
@@ -1,109 +1,383 @@
 package runner
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 
+	"github.com/pashkov256/deletor/internal/cleanup"
 	"github.com/pashkov256/deletor/internal/cli/config"
 	"github.com/pashkov256/deletor/internal/cli/output"
 	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/logging"
 	"github.com/pashkov256/deletor/internal/rules"
 	"github.com/pashkov256/deletor/internal/utils"
 )
 
 const (
-	confirmMsgDlt   string = "Delete these files?"
-	confirmMsgTrash string = "Move files to trash?"
+	confirmMsgDlt      string = "Delete these files?"
+	confirmMsgTrash    string = "Move files to trash?"
+	confirmMsgShred    string = "Overwrite and delete these files?"
+	confirmMsgDirDlt   string = "Delete these directories?"
+	confirmMsgDirTrash string = "Move directories to trash?"
 )
 
+// RunCLI scans and cleans according to the resolved configuration. Cancelling
+// ctx, e.g. with Ctrl-C, stops scanning and deleting before the next file; the
+// files already removed are reported and kept in the operation journal.
 func RunCLI(
+	ctx context.Context,
 	fm filemanager.FileManager,
 	rules rules.Rules,
 	config *config.Config,
 ) {
-	// Get values from rules if --rules flag is set
-	if config.UseRules {
-		config = config.GetWithRules(rules)
+	printer := output.NewPrinter()
+
+	// Layer defaults, rules (if --rules flag is set), project file, env and flags
+	config, err := config.Resolve(rules)
+	if err != nil {
+		printer.PrintError("Invalid configuration: %v", err)
+		return
+	}
+
+	if err := cleanup.SetProcessPriority(config.Throttle); err != nil {
+		printer.PrintError("Failed to lower priority: %v", err)
+		return
 	}
 
+	printPresetNotes(printer, config.Presets)
+
 	filter := config.BuildFileFilter()
+	if config.SkipOpenFiles {
+		filter.OpenFiles = readOpenFiles(printer)
+	}
+	printAtimeWarning(printer, filter, config.Directory)
+	printShredWarning(printer, config)
 
 	fileScanner := filemanager.NewFileScanner(fm, filter, config.ShowProgress)
-	printer := output.NewPrinter()
 
-	if config.ShowProgress {
-		fileScanner.ProgressBarScanner(config.Directory)
+	// Directory rules clean whole folders. Files are scanned next to them
+	// only when the filter selects some, as it would otherwise match them all.
+	var toDelete filemanager.ScanResult
+	if len(config.Directories) > 0 {
+		cleanDirTargets(ctx, fm, printer, fileScanner, config)
 	}
+	if (len(config.Directories) == 0 || filter.SelectsFiles()) && ctx.Err() == nil {
+		toDelete = cleanFiles(ctx, fm, printer, fileScanner, filter, config)
+	}
+	printOpenFiles(printer, toDelete, filter.OpenFiles, config.Directory)
+	brokenLinks := toDelete.BrokenLinks
+	if config.DeleteBrokenLinks && config.Symlinks != filemanager.SymlinkBroken && ctx.Err() == nil {
+		printer.PrintInfo("Scan broken symbolic links")
+		brokenLinks = fileScanner.ScanBrokenLinks(ctx, config.Directory, config.IncludeSubdirs)
+		if len(brokenLinks) == 0 {
+			printer.PrintWarning("Broken links not found")
+		}
+	}
+	if len(brokenLinks) != 0 && ctx.Err() == nil {
+		printer.PrintBrokenLinks(brokenLinks)
+		cleanCategory(ctx, fm, printer, config, brokenLinks, "broken links")
+	}
+	if config.DeleteEmptyFiles && ctx.Err() == nil {
+		printer.PrintInfo("Scan empty files")
+		emptyFiles := fileScanner.ScanEmptyFiles(ctx, config.Directory, config.IncludeSubdirs)
+		if len(emptyFiles) != 0 {
+			printer.PrintEmptyFiles(emptyFiles)
+			cleanCategory(ctx, fm, printer, config, emptyFiles, "empty files")
+		} else {
+			printer.PrintWarning("Empty files not found")
+		}
+	}
+	if config.DeleteEmptyFolders && ctx.Err() == nil {
+		printer.PrintInfo("Scan empty subfolders")
+		toDeleteEmptyFolders := fileScanner.ScanEmptySubFolders(ctx, config.Directory)
+		if len(toDeleteEmptyFolders) != 0 {
+			printer.PrintEmptyDirs(toDeleteEmptyFolders)
+
+			actionIsEmptyDeleteFolders := true
 
-	var toDeleteMap map[string]string
-	var totalClearSize int64
+			if !config.SkipConfirm {
+				actionIsEmptyDeleteFolders = printer.AskForConfirmationContext(ctx, "Delete these empty folders?")
+			}
 
-	if config.IncludeSubdirs {
-		toDeleteMap, totalClearSize = fileScanner.ScanFilesRecursively(config.Directory)
+			if actionIsEmptyDeleteFolders {
+				removed := 0
+				for i := len(toDeleteEmptyFolders) - 1; i >= 0 && ctx.Err() == nil; i-- {
+					if os.Remove(toDeleteEmptyFolders[i]) == nil {
+						removed++
+					}
+				}
+				fmt.Println()
+				if ctx.Err() != nil {
+					printer.PrintWarning("Cancelled after deleting %d of %d empty folders", removed, len(toDeleteEmptyFolders))
+				} else {
+					printer.PrintSuccess("Number of deleted empty folders: %d", removed)
+				}
+			}
+		} else {
+			printer.PrintWarning("Empty folders not found")
+		}
+	}
+}
+
+// cleanFiles scans for the files matching the filter and removes them after
+// confirmation. It returns the scan result, which later passes reuse.
+func cleanFiles(
+	ctx context.Context,
+	fm filemanager.FileManager,
+	printer *output.Printer,
+	fileScanner *filemanager.FileScanner,
+	filter *filemanager.FileFilter,
+	cfg *config.Config,
+) filemanager.ScanResult {
+	if cfg.ShowProgress {
+		fileScanner.ProgressBarScanner(cfg.Directory)
+	}
+
+	var toDelete filemanager.ScanResult
+	if cfg.IncludeSubdirs {
+		toDelete = fileScanner.ScanFilesRecursively(ctx, cfg.Directory)
 	} else {
-		toDeleteMap, totalClearSize = fileScanner.ScanFilesCurrentLevel(config.Directory)
+		toDelete = fileScanner.ScanFilesCurrentLevel(ctx, cfg.Directory)
+	}
+	if ctx.Err() != nil {
+		printer.PrintWarning("Scan cancelled, nothing was deleted")
+		return filemanager.ScanResult{}
 	}
-	if len(toDeleteMap) != 0 {
-		printer.PrintFilesTable(toDeleteMap)
+	if toDelete.Len() != 0 {
+		printer.PrintFileEntries(toDelete.Entries)
 
 		actionIsDelete := true
 
 		fmt.Println() // This is required for formatting
-		if !config.SkipConfirm {
-			fmt.Println(utils.FormatSize(totalClearSize), "will be cleared.")
+		if !cfg.SkipConfirm {
+			printClearSize(toDelete)
 			var msg string
-			if config.MoveFileToTrash {
+			if cfg.MoveFileToTrash {
 				msg = confirmMsgTrash
+			} else if cfg.Shred {
+				msg = confirmMsgShred
 			} else {
 				msg = confirmMsgDlt
 			}
-			actionIsDelete = printer.AskForConfirmation(msg)
+			actionIsDelete = printer.AskForConfirmationContext(ctx, msg)
 		}
 
 		if actionIsDelete {
-			if config.MoveFileToTrash {
-				for path := range toDeleteMap {
-					fm.MoveFileToTrash(path)
-				}
-				printer.PrintSuccess("Moved to trash: %s", utils.FormatSize(totalClearSize))
-			} else {
-				for path := range toDeleteMap {
-					fm.DeleteFile(path)
-				}
-				printer.PrintSuccess("Deleted: %s", utils.FormatSize(totalClearSize))
+			openFiles := cleanup.RotationOpenFiles(cfg.Rotation, filter.Clauses, filter.OpenFiles)
+			removed, archived := removeFiles(ctx, fm, printer, cfg, toDelete, openFiles)
+
+			switch {
+			case ctx.Err() != nil:
+				printer.PrintWarning("Cancelled after %d of %d files, %s freed", removed.Len()+archived.Len(), toDelete.Len(), utils.FormatSize(removed.FreedSize()))
+			case len(cfg.Clauses) > 0:
+				printer.PrintSuccess("Cleaned: %s", utils.FormatSize(removed.FreedSize()))
+			case cfg.MoveFileToTrash:
+				printer.PrintSuccess("Moved to trash: %s", utils.FormatSize(removed.FreedSize()))
+			case cfg.Shred:
+				printer.PrintSuccess("Shredded: %s", utils.FormatSize(removed.FreedSize()))
+			default:
+				printer.PrintSuccess("Deleted: %s", utils.FormatSize(removed.FreedSize()))
+			}
+			if archived.Len() > 0 {
+				printer.PrintInfo("Archived or rotated %d files (%s), which keep their data", archived.Len(), utils.FormatSize(archived.TotalSize))
 			}
 
-			if config.JsonLogsEnabled {
-				utils.LogDeletionToFileAsJson(toDeleteMap, config.JsonLogsPath)
-			} else {
-				utils.LogDeletionToFile(toDeleteMap)
+			if removed.Len() > 0 {
+				logDeletions(cfg, removed.DeletionRecords())
 			}
 		}
 
 	} else {
 		printer.PrintWarning("File not found")
 	}
-	if config.DeleteEmptyFolders {
-		printer.PrintInfo("Scan empty subfolders")
-		toDeleteEmptyFolders := fileScanner.ScanEmptySubFolders(config.Directory)
-		if len(toDeleteEmptyFolders) != 0 {
-			printer.PrintEmptyDirs(toDeleteEmptyFolders)
+	return toDelete
+}
 
-			actionIsEmptyDeleteFolders := true
+// cleanCategory removes the listed entries of a separate scan category,
+// such as broken links or empty files, after its own confirmation
+func cleanCategory(
+	ctx context.Context,
+	fm filemanager.FileManager,
+	printer *output.Printer,
+	cfg *config.Config,
+	entries []filemanager.FileEntry,
+	name string,
+) {
+	if !cfg.SkipConfirm && !printer.AskForConfirmationContext(ctx, fmt.Sprintf("Delete these %s?", name)) {
+		return
+	}
 
-			if !config.SkipConfirm {
-				actionIsEmptyDeleteFolders = printer.AskForConfirmation("Delete these empty folders?")
+	removed, _ := removeFiles(ctx, fm, printer, cfg, filemanager.ScanResult{Entries: entries}, nil)
+	fmt.Println()
+	if ctx.Err() != nil {
+		printer.PrintWarning("Cancelled after deleting %d of %d %s", removed.Len(), len(entries), name)
+	} else {
+		printer.PrintSuccess("Number of deleted %s: %d", name, removed.Len())
+	}
+	if removed.Len() > 0 {
+		logDeletions(cfg, removed.DeletionRecords())
+	}
+}
+
+// cleanDirTargets removes the directories matched by the directory rules,
+// each subtree as a single item
+func cleanDirTargets(
+	ctx context.Context,
+	fm filemanager.FileManager,
+	printer *output.Printer,
+	fileScanner *filemanager.FileScanner,
+	cfg *config.Config,
+) {
+	matches, totalClearSize := fileScanner.ScanDirTargets(ctx, cfg.Directory, cfg.BuildDirTargets(), cfg.IncludeSubdirs)
+	if ctx.Err() != nil {
+		printer.PrintWarning("Scan cancelled, nothing was deleted")
+		return
+	}
+	if len(matches) == 0 {
+		printer.PrintWarning("Directories not found")
+		return
+	}
+
+	tableMap := make(map[string]string, len(matches))
+	for _, match := range matches {
+		tableMap[match.Path+string(filepath.Separator)] = fmt.Sprintf("%s (%d files)", utils.FormatSize(match.Size), match.Files)
+	}
+	printer.PrintFilesTable(tableMap)
+
+	fmt.Println() // This is required for formatting
+	if !cfg.SkipConfirm {
+		fmt.Println(utils.FormatSize(totalClearSize), "will be cleared.")
+		msg := confirmMsgDirDlt
+		if cfg.MoveFileToTrash {
+			msg = confirmMsgDirTrash
+		}
+		if !printer.AskForConfirmationContext(ctx, msg) {
+			return
+		}
+	}
+
+	journal := logging.OpenDefaultJournal(cfg.Directory)
+	opType := logging.OperationDeleted
+	if cfg.MoveFileToTrash {
+		opType = logging.OperationTrashed
+	} else if cfg.Shred {
+		opType = logging.OperationShredded
+	}
+
+	files, bytes := cfg.Throttle.Pacers()
+	var removed []utils.DeletionRecord
+	var removedSize int64
+	for _, match := range matches {
+		if files.Wait(ctx, 1) != nil {
+			break
+		}
+		if cfg.MoveFileToTrash {
+			if err := fm.TrashDir(match.Path); err != nil {
+				printer.PrintError("Failed to move %s to trash: %v", match.Path, err)
+				continue
+			}
+		} else if cfg.Shred {
+			if _, err := filemanager.ShredDirPaced(ctx, match.Path, cfg.ShredOptions, bytes); err != nil {
+				printer.PrintError("Failed to shred %s: %v", match.Path, err)
+				continue
 			}
+		} else if err := fm.DeleteDir(match.Path); err != nil {
+			printer.PrintError("Failed to delete %s: %v", match.Path, err)
+			continue
+		}
+		journal.Record(logging.NewFileOperation(match.Path, match.Size, opType, "directory rule", ""))
+		removed = append(removed, utils.DeletionRecord{Path: match.Path, Size: match.Size})
+		removedSize += match.Size
+	}
+	journal.Finish(ctx.Err())
 
-			if actionIsEmptyDeleteFolders {
-				for i := len(toDeleteEmptyFolders) - 1; i >= 0; i-- {
-					os.Remove(toDeleteEmptyFolders[i])
-				}
-				fmt.Println()
-				printer.PrintSuccess("Number of deleted empty folders: %d", len(toDeleteEmptyFolders))
+	if ctx.Err() != nil {
+		printer.PrintWarning("Cancelled after removing %d of %d directories (%s)", len(removed), len(matches), utils.FormatSize(removedSize))
+	} else if cfg.MoveFileToTrash {
+		printer.PrintSuccess("Moved to trash: %s in %d directories", utils.FormatSize(removedSize), len(removed))
+	} else if cfg.Shred {
+		printer.PrintSuccess("Shredded: %s in %d directories", utils.FormatSize(removedSize), len(removed))
+	} else {
+		printer.PrintSuccess("Deleted: %s in %d directories", utils.FormatSize(removedSize), len(removed))
+	}
+
+	logDeletions(cfg, removed)
+}
+
+// printClearSize prints how much space removing the scanned files frees, and
+// the listed size as well when hard links make the two differ
+func printClearSize(toDelete filemanager.ScanResult) {
+	freed := toDelete.FreedSize()
+	if freed == toDelete.TotalSize {
+		fmt.Println(utils.FormatSize(freed), "will be cleared.")
+		return
+	}
+	fmt.Printf("%s will be cleared (%s listed, hard-linked files are counted once and only when all their links are removed).\n",
+		utils.FormatSize(freed), utils.FormatSize(toDelete.TotalSize))
+}
+
+// logDeletions writes removed files to the deletion log, as JSON when enabled
+func logDeletions(cfg *config.Config, records []utils.DeletionRecord) {
+	if cfg.JsonLogsEnabled {
+		utils.LogDeletionToFileAsJson(records, cfg.JsonLogsPath)
+	} else {
+		utils.LogDeletionToFile(records)
+	}
+}
+
+// removeFiles deletes, trashes, shreds, archives or rotates the scanned
+// files one at a time, as their clause or the run settings say, stopping
+// before the next file once ctx is done. openFiles decides which rotated
+// files are copied and truncated. Every file is recorded in the operation
+// journal. The entries that were removed are returned apart from those
+// archived or rotated, which keep their data and stay out of the freed size
+// and the deletion log.
+func removeFiles(
+	ctx context.Context,
+	fm filemanager.FileManager,
+	printer *output.Printer,
+	cfg *config.Config,
+	toDelete filemanager.ScanResult,
+	openFiles *filemanager.OpenFiles,
+) (removed, archived filemanager.ScanResult) {
+	journal := logging.OpenDefaultJournal(cfg.Directory)
+	stats := &logging.ScanStatistics{}
+	opts := cleanup.ActionOptions{Trash: cfg.MoveFileToTrash, Shred: cfg.Shred, Shredding: cfg.ShredOptions, Stats: stats}
+	opts.Files, opts.Bytes = cfg.Throttle.Pacers()
+	opts.Rotation, opts.OpenFiles = cfg.Rotation, openFiles
+
+	for _, entry := range toDelete.Entries {
+		if ctx.Err() != nil {
+			break
+		}
+
+		opType, err := cleanup.ApplyAction(ctx, fm, entry, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
 			}
-		} else {
-			printer.PrintWarning("Empty folders not found")
+			printer.PrintError("Failed to %s %s: %v", opts.Resolve(entry.Action), entry.Path, err)
+			continue
+		}
+		journal.Record(logging.NewFileOperation(entry.Path, entry.Size, opType, "cli", entry.MatchedRule))
+		switch {
+		case opType.Removes():
+			// Appended as listed, so removed broken links stay in Entries
+			removed.Entries = append(removed.Entries, entry)
+			removed.TotalSize += entry.Size
+		case opType != logging.OperationIgnored:
+			archived.Entries = append(archived.Entries, entry)
+			archived.TotalSize += entry.Size
 		}
 	}
+	journal.Finish(ctx.Err())
+
+	if stats.ShreddedFiles > 0 {
+		printer.PrintInfo("Shredded %d files (%s), overwrote %s at %s/s",
+			stats.ShreddedFiles, utils.FormatSize(stats.ShreddedSize), utils.FormatSize(stats.ShredWritten), utils.FormatSize(int64(stats.ShredThroughput())))
+	}
+
+	return removed, archived
 }
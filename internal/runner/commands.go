@@ -0,0 +1,704 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/cache"
+	"github.com/pashkov256/deletor/internal/cleanup"
+	"github.com/pashkov256/deletor/internal/cli/config"
+	"github.com/pashkov256/deletor/internal/cli/output"
+	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/logging"
+	"github.com/pashkov256/deletor/internal/rules"
+	"github.com/pashkov256/deletor/internal/utils"
+)
+
+const commandsUsage = `Usage:
+  deletor [flags]                  Run the TUI, or the CLI with --cli
+  deletor config explain [flags]   Show effective settings and where they came from
+  deletor presets                  List the built-in rule presets for --preset
+  deletor rules export [--profile NAME]
+                                   Print a profile as a portable JSON bundle
+  deletor rules import [--profile NAME] [--yes] FILE|file://URL
+                                   Validate a bundle, show the diff and save it to a profile
+  deletor index rebuild [DIR...]   Index DIR, or the rules path or home directory, for --use-index
+  deletor index stats              Show the size and age of the scan index
+  deletor index clear              Remove the scan index
+  deletor mounts [--skip-fs LIST] [DIR]
+                                   List the mounts below DIR and which ones --skip-fs skips
+  deletor watch [--profile NAME] [--debounce 500ms]
+                                   Keep the profile's path clean as files appear (Linux)
+  deletor cache list|scan|clear [--yes] [--skip-open] [NAME|TYPE...]
+                                   List, size or clear cache locations, the system ones by default`
+
+// RunCommand dispatches a deletor subcommand and returns the process exit code
+func RunCommand(
+	fm filemanager.FileManager,
+	rules rules.Rules,
+	args []string,
+) int {
+	printer := output.NewPrinter()
+
+	if len(args) == 0 {
+		fmt.Println(commandsUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "config":
+		return runConfigCommand(printer, rules, args[1:])
+	case "presets":
+		return runPresetsCommand(printer)
+	case "rules":
+		return runRulesCommand(printer, args[1:])
+	case "index":
+		return runIndexCommand(printer, fm, rules, args[1:])
+	case "mounts":
+		return runMountsCommand(printer, fm, args[1:])
+	case "watch":
+		return runWatchCommand(printer, fm, args[1:])
+	case "cache":
+		return runCacheCommand(printer, fm, rules, args[1:])
+	case "help", "-h", "--help":
+		fmt.Println(commandsUsage)
+		return 0
+	default:
+		printer.PrintError("Unknown command %q", args[0])
+		fmt.Println(commandsUsage)
+		return 2
+	}
+}
+
+func runConfigCommand(printer *output.Printer, rules rules.Rules, args []string) int {
+	if len(args) == 0 || args[0] != "explain" {
+		printer.PrintError("Usage: deletor config explain [flags]")
+		return 2
+	}
+
+	cfg, err := config.ParseArgs("config explain", args[1:])
+	if err != nil {
+		return 2
+	}
+
+	resolved, err := cfg.Resolve(rules)
+	if err != nil {
+		printer.PrintError("Invalid configuration: %v", err)
+		return 1
+	}
+
+	rows := make([][]string, 0, len(config.SettingKeys()))
+	for _, key := range config.SettingKeys() {
+		origin := resolved.Origins[key]
+		source := string(origin.Source)
+		if origin.Detail != "" {
+			source = fmt.Sprintf("%s (%s)", origin.Source, origin.Detail)
+		}
+		rows = append(rows, []string{key, resolved.FormatValue(key), source})
+	}
+	printer.PrintSettings([]string{"SETTING", "VALUE", "SOURCE"}, rows)
+	if expr := resolved.BuildFileFilter().Expr(); expr != nil {
+		printer.PrintInfo("Files must match: %s", expr)
+	}
+	return 0
+}
+
+func runPresetsCommand(printer *output.Printer) int {
+	rows := make([][]string, 0, len(rules.Presets()))
+	for _, preset := range rules.Presets() {
+		matches := append([]string(nil), preset.Include...)
+		for _, dir := range preset.Directories {
+			matches = append(matches, dir.String())
+		}
+		rows = append(rows, []string{
+			preset.Name,
+			strings.Join(matches, " "),
+			preset.OlderThan,
+			preset.Description,
+		})
+	}
+	printer.PrintSettings([]string{"PRESET", "MATCHES", "OLDER", "DESCRIPTION"}, rows)
+	fmt.Println()
+	printer.PrintInfo("Catalog version %d. Use --preset NAME[,NAME] or the Presets field on the Rules page.", rules.PresetCatalogVersion)
+	return 0
+}
+
+// printPresetNotes shows the safety notes of the selected presets before a clean
+func printPresetNotes(printer *output.Printer, names []string) {
+	for _, name := range names {
+		if preset, ok := rules.LookupPreset(name); ok && preset.SafetyNotes != "" {
+			printer.PrintWarning("Preset %s: %s", preset.Name, preset.SafetyNotes)
+		}
+	}
+}
+
+// printAtimeWarning warns when access time filters are used on a directory
+// whose filesystem is mounted noatime and never records reads
+func printAtimeWarning(printer *output.Printer, filter *filemanager.FileFilter, dir string) {
+	if !filter.UsesAccessTimes() {
+		return
+	}
+	if mount, ok := filemanager.NoAtimeMount(dir); ok {
+		printer.PrintWarning("%s is mounted noatime, access times record when files were created, not when they were last read", mount.Point)
+	}
+}
+
+// readOpenFiles reads the files processes hold open, warning and returning
+// nil when they cannot be read, in which case no file is skipped
+func readOpenFiles(printer *output.Printer) *filemanager.OpenFiles {
+	openFiles, err := filemanager.ReadOpenFiles()
+	if err != nil {
+		printer.PrintWarning("Cannot check for open files, none will be skipped: %v", err)
+		return nil
+	}
+	return openFiles
+}
+
+// printOpenFiles lists the matched files that were skipped because they are
+// in use and the space still held by removed files below dir
+func printOpenFiles(printer *output.Printer, result filemanager.ScanResult, openFiles *filemanager.OpenFiles, dir string) {
+	if openFiles == nil {
+		return
+	}
+	if len(result.InUse) > 0 {
+		printer.PrintInUseFiles(result.InUse)
+		printer.PrintWarning("Skipped %d files in use (%s)", len(result.InUse), utils.FormatSize(result.InUseSize()))
+	}
+	if deleted := openFiles.DeletedBelow(dir); len(deleted) > 0 {
+		printer.PrintWarning("%s is held by %d deleted files that processes still have open", utils.FormatSize(openFiles.DeletedSize(dir)), len(deleted))
+		for _, file := range deleted {
+			printer.PrintInfo("PID %d holds %s (%s)", file.PID, file.Path, utils.FormatSize(file.Size))
+		}
+	}
+	if openFiles.Unreadable > 0 {
+		printer.PrintInfo("Open files of %d processes could not be read", openFiles.Unreadable)
+	}
+}
+
+// printShredWarning warns that shredding only overwrites the blocks a file
+// uses now, and names the mount when its filesystem is copy-on-write
+func printShredWarning(printer *output.Printer, cfg *config.Config) {
+	shreds := cfg.Shred
+	for _, clause := range cfg.Clauses {
+		if action, _ := filemanager.ParseFileAction(clause.Action); action == filemanager.ActionShred {
+			shreds = true
+		}
+	}
+	if !shreds {
+		return
+	}
+	if mount, ok := filemanager.CopyOnWriteMount(cfg.Directory); ok {
+		printer.PrintWarning("%s is %s, a copy-on-write filesystem: shredding cannot overwrite the old contents of files there", mount.Point, mount.Type)
+	}
+	printer.PrintWarning("Shredding does not reach copies kept by SSDs and flash storage, snapshots, journals or backups")
+}
+
+func runRulesCommand(printer *output.Printer, args []string) int {
+	if len(args) == 0 {
+		printer.PrintError("Usage: deletor rules export|import [flags]")
+		return 2
+	}
+
+	switch args[0] {
+	case "export":
+		return runRulesExport(args[1:])
+	case "import":
+		return runRulesImport(printer, args[1:])
+	default:
+		printer.PrintError("Unknown rules command %q", args[0])
+		return 2
+	}
+}
+
+// parseCommandArgs parses flags that may appear before or after positional
+// arguments and returns the positional ones
+func parseCommandArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func runRulesExport(args []string) int {
+	fs := flag.NewFlagSet("rules export", flag.ContinueOnError)
+	profile := fs.String("profile", rules.DefaultProfile, "Profile to export")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: deletor rules export [--profile NAME]")
+		return 2
+	}
+
+	// The bundle goes to stdout, so errors must not end up in the redirected file
+	profileRules, err := rules.NewProfileRules(*profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	current, err := profileRules.GetRules()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "Error: profile %q does not exist\n", *profile)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		return 1
+	}
+
+	data, err := rules.ExportBundle(current)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	os.Stdout.Write(data)
+	return 0
+}
+
+func runRulesImport(printer *output.Printer, args []string) int {
+	fs := flag.NewFlagSet("rules import", flag.ContinueOnError)
+	profile := fs.String("profile", rules.DefaultProfile, "Profile to import into")
+	yes := fs.Bool("yes", false, "Save without asking for confirmation")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		printer.PrintError("Usage: deletor rules import [--profile NAME] [--yes] FILE|file://URL")
+		return 2
+	}
+
+	profileRules, err := rules.NewProfileRules(*profile)
+	if err != nil {
+		printer.PrintError("%v", err)
+		return 2
+	}
+
+	incoming, err := rules.LoadBundle(positional[0])
+	if err != nil {
+		printer.PrintError("Invalid bundle: %v", err)
+		return 1
+	}
+
+	current, err := profileRules.GetRules()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		printer.PrintError("Cannot read profile %q: %v", *profile, err)
+		return 1
+	}
+
+	changes := rules.DiffRules(current, incoming)
+	if len(changes) == 0 {
+		printer.PrintInfo("Profile %q already matches %s", *profile, positional[0])
+		return 0
+	}
+
+	rows := make([][]string, 0, len(changes))
+	for _, change := range changes {
+		rows = append(rows, []string{change.Field, change.Old, change.New})
+	}
+	printer.PrintSettings([]string{"FIELD", "CURRENT", "IMPORTED"}, rows)
+	fmt.Println()
+
+	if !*yes && !printer.AskForConfirmation(fmt.Sprintf("Apply %d change(s) to profile %q?", len(changes), *profile)) {
+		printer.PrintWarning("Import cancelled")
+		return 1
+	}
+
+	if err := profileRules.UpdateRules(rules.WithRules(incoming)); err != nil {
+		printer.PrintError("Failed to save profile %q: %v", *profile, err)
+		return 1
+	}
+
+	printer.PrintSuccess("Imported %d change(s) into profile %q", len(changes), *profile)
+	return 0
+}
+
+func runIndexCommand(printer *output.Printer, fm filemanager.FileManager, rules rules.Rules, args []string) int {
+	if len(args) == 0 {
+		printer.PrintError("Usage: deletor index rebuild|stats|clear")
+		return 2
+	}
+
+	index, err := filemanager.OpenDirIndex(filemanager.DefaultIndexPath())
+	if err != nil {
+		printer.PrintError("Cannot open the scan index: %v", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "rebuild":
+		return runIndexRebuild(printer, fm, rules, index, args[1:])
+	case "stats":
+		if len(args) > 1 {
+			printer.PrintError("Usage: deletor index stats")
+			return 2
+		}
+		stats := index.Stats()
+		updated := "never"
+		if !stats.Updated.IsZero() {
+			updated = stats.Updated.Format("2006-01-02 15:04:05")
+		}
+		printer.PrintSettings([]string{"INDEX", "VALUE"}, [][]string{
+			{"path", stats.Path},
+			{"directories", fmt.Sprint(stats.Dirs)},
+			{"entries", fmt.Sprint(stats.Entries)},
+			{"size", utils.FormatSize(stats.FileSize)},
+			{"updated", updated},
+		})
+		return 0
+	case "clear":
+		if len(args) > 1 {
+			printer.PrintError("Usage: deletor index clear")
+			return 2
+		}
+		if err := index.Clear(); err != nil {
+			printer.PrintError("Failed to remove the scan index: %v", err)
+			return 1
+		}
+		printer.PrintSuccess("Removed the scan index %s", filemanager.DefaultIndexPath())
+		return 0
+	default:
+		printer.PrintError("Unknown index command %q", args[0])
+		return 2
+	}
+}
+
+// runIndexRebuild indexes the given directories from scratch. Without
+// arguments it indexes the path of the rules file, or the home directory.
+func runIndexRebuild(printer *output.Printer, fm filemanager.FileManager, rules rules.Rules, index *filemanager.DirIndex, roots []string) int {
+	if len(roots) == 0 {
+		if current, err := rules.GetRules(); err == nil && current.Path != "" {
+			roots = []string{current.Path}
+		} else if home, err := os.UserHomeDir(); err == nil {
+			roots = []string{home}
+		} else {
+			printer.PrintError("Usage: deletor index rebuild DIR...")
+			return 2
+		}
+	}
+	for i, root := range roots {
+		roots[i] = fm.ExpandTilde(root)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	printer.PrintInfo("Indexing %s", strings.Join(roots, ", "))
+	if err := index.Rebuild(ctx, roots); err != nil {
+		if ctx.Err() != nil {
+			printer.PrintWarning("Rebuild cancelled, the previous index is kept")
+		} else {
+			printer.PrintError("Rebuild failed: %v", err)
+		}
+		return 1
+	}
+	if err := index.Save(); err != nil {
+		printer.PrintError("Failed to save the scan index: %v", err)
+		return 1
+	}
+
+	stats := index.Stats()
+	printer.PrintSuccess("Indexed %d directories with %d entries (%s)", stats.Dirs, stats.Entries, utils.FormatSize(stats.FileSize))
+	return 0
+}
+
+func runWatchCommand(printer *output.Printer, fm filemanager.FileManager, args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	profile := fs.String("profile", rules.DefaultProfile, "Profile whose rules are applied")
+	debounce := fs.Duration("debounce", cleanup.DefaultWatchDebounce, "How long changes must settle before a file is checked")
+	ionice := fs.String("ionice", "", "I/O priority instead of the profile's: idle or best-effort:LEVEL")
+	nice := fs.Int("nice", 0, "CPU niceness from 1 to 19 instead of the profile's")
+	rateLimit := fs.String("rate-limit", "", "Files removed per time unit instead of the profile's (e.g. 200/s)")
+	bwLimit := fs.String("bwlimit", "", "Bytes archived or shredded per second instead of the profile's (e.g. 20MB/s)")
+	skipOpen := fs.Bool("skip-open", false, "Leave files a process holds open alone and retry them later")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) > 0 || *debounce <= 0 {
+		printer.PrintError("Usage: deletor watch [--profile NAME] [--debounce 500ms] [--ionice CLASS] [--nice N] [--rate-limit N/s] [--bwlimit SIZE/s] [--skip-open]")
+		return 2
+	}
+	override, err := cleanup.ParseThrottle(*ionice, *nice, *rateLimit, *bwLimit)
+	if err != nil {
+		printer.PrintError("%v", err)
+		return 2
+	}
+
+	profileRules, err := rules.NewProfileRules(*profile)
+	if err != nil {
+		printer.PrintError("%v", err)
+		return 2
+	}
+	spec, err := cleanup.LoadOneOffCleanSpec(profileRules)
+	if err != nil {
+		printer.PrintError("Cannot load profile %q: %v", *profile, err)
+		return 1
+	}
+	spec.Throttle.Override(override)
+	if *skipOpen {
+		spec.SkipOpenFiles = true
+	}
+	if err := cleanup.SetProcessPriority(spec.Throttle); err != nil {
+		printer.PrintError("Failed to lower priority: %v", err)
+		return 1
+	}
+
+	watcher, err := cleanup.NewWatcher(fm, spec)
+	if err != nil {
+		printer.PrintError("%v", err)
+		return 1
+	}
+	verbs := map[logging.OperationType]string{
+		logging.OperationDeleted:  "Deleted",
+		logging.OperationTrashed:  "Moved to trash",
+		logging.OperationShredded: "Shredded",
+		logging.OperationArchived: "Archived",
+		logging.OperationRotated:  "Rotated",
+	}
+	watcher.Debounce = *debounce
+	watcher.OnRemoved = func(entry filemanager.FileEntry, opType logging.OperationType) {
+		printer.PrintSuccess("%s %s (%s)", verbs[opType], entry.Path, utils.FormatSize(entry.Size))
+	}
+	watcher.OnScheduled = func(path string, due time.Time) {
+		printer.PrintInfo("Scheduled %s for %s", path, due.Format("2006-01-02 15:04:05"))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	printer.PrintInfo("Watching %s with profile %q, press Ctrl-C to stop", spec.Path, *profile)
+	if spec.Throttle != (cleanup.Throttle{}) {
+		printer.PrintInfo("Throttled: %s", spec.Throttle)
+	}
+	result, err := watcher.Run(ctx)
+	fmt.Println()
+	if err != nil {
+		printer.PrintError("Watch stopped: %v", err)
+	}
+	printer.PrintInfo("Cleaned %d files (%s), %d still waiting for their age limit", result.FilesCleaned, utils.FormatSize(result.BytesCleared), result.Scheduled)
+	if result.Archived > 0 {
+		printer.PrintInfo("Archived or rotated %d files, which keep their data", result.Archived)
+	}
+	if result.InUse > 0 {
+		printer.PrintInfo("Left %d files alone while processes held them open", result.InUse)
+	}
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+func runMountsCommand(printer *output.Printer, fm filemanager.FileManager, args []string) int {
+	fs := flag.NewFlagSet("mounts", flag.ContinueOnError)
+	skipFS := fs.String("skip-fs", "", "Filesystem types or groups to check (e.g. 'network,pseudo')")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) > 1 {
+		printer.PrintError("Usage: deletor mounts [--skip-fs LIST] [DIR]")
+		return 2
+	}
+
+	root := string(filepath.Separator)
+	if len(positional) == 1 {
+		root = fm.ExpandTilde(positional[0])
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	skip := utils.ParseExcludeToSlice(strings.ToLower(*skipFS))
+
+	mounts, err := filemanager.ReadMounts()
+	if err != nil {
+		printer.PrintError("Cannot read the mount table: %v", err)
+		return 1
+	}
+
+	prefix := strings.TrimSuffix(root, string(filepath.Separator)) + string(filepath.Separator)
+	rows := make([][]string, 0, len(mounts))
+	for _, mount := range mounts {
+		if mount.Point != root && !strings.HasPrefix(mount.Point, prefix) {
+			continue
+		}
+		scanned := "yes"
+		if mount.Point != root && filemanager.MatchFilesystem(skip, mount.Type) {
+			scanned = "skipped"
+		}
+		rows = append(rows, []string{mount.Point, mount.Type, mount.Source, scanned})
+	}
+	printer.PrintSettings([]string{"MOUNT", "TYPE", "SOURCE", "SCANNED"}, rows)
+	fmt.Println()
+	printer.PrintInfo("Groups for --skip-fs: %s. --one-file-system skips every mount below the directory.", strings.Join(filemanager.FilesystemGroups(), ", "))
+	return 0
+}
+
+// runCacheCommand lists, scans or clears the built-in cache locations and
+// those saved in the rules. Locations are selected by name or type, and the
+// system caches are used without any.
+func runCacheCommand(printer *output.Printer, fm filemanager.FileManager, ruleSet rules.Rules, args []string) int {
+	const usage = "Usage: deletor cache list|scan|clear [--yes] [--skip-open] [NAME|TYPE...]"
+	if len(args) == 0 {
+		printer.PrintError(usage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("cache "+args[0], flag.ContinueOnError)
+	yes := fs.Bool("yes", false, "Clear without asking for confirmation")
+	skipOpen := fs.Bool("skip-open", false, "Leave files a process holds open alone")
+	positional, err := parseCommandArgs(fs, args[1:])
+	if err != nil {
+		return 2
+	}
+
+	current, err := ruleSet.GetRules()
+	if err != nil {
+		printer.PrintError("Cannot load the rules: %v", err)
+		return 1
+	}
+	manager := cache.NewCacheManager(fm)
+	manager.SkipOpenFiles = current.SkipOpenFiles
+	if manager.Custom, err = rules.CompileCacheLocations(current.CacheLocations); err != nil {
+		printer.PrintError("Invalid cache location in the rules: %v", err)
+		return 1
+	}
+	if len(positional) == 0 {
+		positional = []string{string(cache.SystemCache)}
+	}
+	if err := manager.Select(positional...); err != nil {
+		printer.PrintError("%v", err)
+		return 2
+	}
+
+	switch args[0] {
+	case "list":
+		selected := make(map[string]bool, len(manager.Locations))
+		for _, location := range manager.Locations {
+			selected[location.Name] = true
+		}
+		rows := make([][]string, 0, len(manager.Known()))
+		for _, location := range manager.Known() {
+			rows = append(rows, []string{location.Name, string(location.Type), location.Description, location.Path, yesNo(location.Exists()), yesNo(selected[location.Name])})
+		}
+		printer.PrintSettings([]string{"NAME", "TYPE", "DESCRIPTION", "PATH", "EXISTS", "SELECTED"}, rows)
+		return 0
+	case "scan", "clear":
+	default:
+		printer.PrintError("Unknown cache command %q", args[0])
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	size := printCacheScan(printer, manager, manager.ScanAllLocations(ctx))
+	if ctx.Err() != nil {
+		printer.PrintWarning("Scan cancelled, sizes are partial")
+		return 1
+	}
+	if args[0] == "scan" {
+		return 0
+	}
+
+	if runtime.GOOS == "darwin" {
+		printer.PrintError("Currently only Windows and Linux is supported for cache clearing")
+		return 1
+	}
+	if !*yes && !printer.AskForConfirmationContext(ctx, fmt.Sprintf("Clear %s from %d cache location(s)?", utils.FormatSize(size), len(manager.Locations))) {
+		return 0
+	}
+	if manager.Locations = confirmCacheLocations(ctx, printer, manager.Locations); len(manager.Locations) == 0 {
+		return 0
+	}
+
+	if *skipOpen {
+		manager.SkipOpenFiles = true
+	}
+	journal := logging.OpenDefaultJournal("")
+	result, err := manager.ClearCache(ctx, journal)
+	journal.Finish(ctx.Err())
+
+	switch {
+	case ctx.Err() != nil:
+		printer.PrintWarning("Cache clearing cancelled after %d files", result.FilesDeleted)
+	case err != nil:
+		printer.PrintError("Not all files were successfully deleted: %v", err)
+	default:
+		printer.PrintSuccess("Removed %d cache files", result.FilesDeleted)
+	}
+	if result.InUseFiles > 0 {
+		printer.PrintInfo("Left %d files in use (%s) alone", result.InUseFiles, utils.FormatSize(result.InUseSize))
+	}
+	if result.DeletedOpenSize > 0 {
+		printer.PrintInfo("%s is still held by deleted files that processes have open", utils.FormatSize(result.DeletedOpenSize))
+	}
+	if ctx.Err() != nil || err != nil {
+		return 1
+	}
+	return 0
+}
+
+// confirmCacheLocations asks about each location that is only cleared once
+// confirmed, even with --yes, and returns the locations left to clear
+func confirmCacheLocations(ctx context.Context, printer *output.Printer, locations []cache.CacheLocation) []cache.CacheLocation {
+	confirmed := make([]cache.CacheLocation, 0, len(locations))
+	for _, location := range locations {
+		if location.Confirm && !printer.AskForConfirmationContext(ctx, fmt.Sprintf("Clear %s (%s)?", location.Name, location.Path)) {
+			continue
+		}
+		confirmed = append(confirmed, location)
+	}
+	return confirmed
+}
+
+// printCacheScan prints the scan results in the order of the selected
+// locations and returns their total size
+func printCacheScan(printer *output.Printer, manager *cache.Manager, results []cache.ScanResult) int64 {
+	byName := make(map[string]cache.ScanResult, len(results))
+	for _, result := range results {
+		byName[result.Name] = result
+	}
+
+	var totalSize, totalFiles int64
+	rows := make([][]string, 0, len(results)+1)
+	for _, location := range manager.Locations {
+		result, ok := byName[location.Name]
+		if !ok {
+			continue
+		}
+		rows = append(rows, []string{location.Name, result.Path, utils.FormatSize(result.Size), fmt.Sprint(result.FileCount)})
+		totalSize += result.Size
+		totalFiles += result.FileCount
+	}
+	rows = append(rows, []string{"total", "", utils.FormatSize(totalSize), fmt.Sprint(totalFiles)})
+	printer.PrintSettings([]string{"NAME", "PATH", "SIZE", "FILES"}, rows)
+	return totalSize
+}
+
+// yesNo formats a flag for a table column
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
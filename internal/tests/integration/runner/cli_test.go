@@ -1,6 +1,7 @@
 package runner_test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -142,7 +143,7 @@ func TestRunCLI_BasicFileOperations(t *testing.T) {
 			fm := filemanager.NewFileManager()
 			r := rules.NewRules()
 
-			runner.RunCLI(fm, r, tt.config)
+			runner.RunCLI(context.Background(), fm, r, tt.config)
 
 			fileCount, dirCount := countFilesAndDirs(testDir)
 			assert.Equal(t, tt.expectedFiles, fileCount, "File count mismatch")
@@ -201,7 +202,7 @@ func TestRunCLI_DirectoryOperations(t *testing.T) {
 			fm := filemanager.NewFileManager()
 			r := rules.NewRules()
 
-			runner.RunCLI(fm, r, tt.config)
+			runner.RunCLI(context.Background(), fm, r, tt.config)
 
 			fileCount, dirCount := countFilesAndDirs(testDir)
 			assert.Equal(t, tt.expectedFiles, fileCount, "File count mismatch")
@@ -225,7 +226,7 @@ func TestRunCLI_UserInteraction(t *testing.T) {
 		fm := filemanager.NewFileManager()
 		r := rules.NewRules()
 
-		runner.RunCLI(fm, r, config)
+		runner.RunCLI(context.Background(), fm, r, config)
 
 		fileCount, dirCount := countFilesAndDirs(testDir)
 		assert.Equal(t, 3, fileCount, "Should have 3 files remaining (.doc and .pdf files)")
@@ -261,10 +262,46 @@ func TestRunCLI_UserInteraction(t *testing.T) {
 		fm := filemanager.NewFileManager()
 		r := rules.NewRules()
 
-		runner.RunCLI(fm, r, config)
+		runner.RunCLI(context.Background(), fm, r, config)
 
 		fileCount, dirCount := countFilesAndDirs(testDir)
 		assert.Equal(t, 1, fileCount, "Should have 1 file remaining (.pdf file)")
 		assert.Equal(t, 3, dirCount, "Should have 3 directories remaining")
 	})
 }
+
+func TestRunCLI_DirectoryRulesWithFileFilters(t *testing.T) {
+	testDir, cleanup := setupTestDir(t)
+	defer cleanup()
+	assert.NoError(t, os.MkdirAll(filepath.Join(testDir, "build", "obj"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(testDir, "build", "obj", "main.o"), []byte("obj"), 0644))
+
+	fm := filemanager.NewFileManager()
+	r := rules.NewRules()
+
+	// Directory rules alone leave the other files alone
+	runner.RunCLI(context.Background(), fm, r, &config.Config{
+		Directory:      testDir,
+		Directories:    []rules.DirectoryRule{{Pattern: "build"}},
+		SkipConfirm:    true,
+		IncludeSubdirs: true,
+	})
+	_, err := os.Stat(filepath.Join(testDir, "build"))
+	assert.True(t, os.IsNotExist(err), "build directory should be removed")
+	fileCount, _ := countFilesAndDirs(testDir)
+	assert.Equal(t, 7, fileCount, "Files outside the directory rules should remain")
+
+	// File filters given with directory rules run as a second pass
+	assert.NoError(t, os.MkdirAll(filepath.Join(testDir, "build"), 0755))
+	runner.RunCLI(context.Background(), fm, r, &config.Config{
+		Directory:          testDir,
+		Directories:        []rules.DirectoryRule{{Pattern: "build"}},
+		Extensions:         []string{".txt"},
+		DeleteEmptyFolders: true,
+		SkipConfirm:        true,
+		IncludeSubdirs:     true,
+	})
+	fileCount, dirCount := countFilesAndDirs(testDir)
+	assert.Equal(t, 3, fileCount, "Only .doc and .pdf files should remain")
+	assert.Equal(t, 1, dirCount, "Directory rules and empty folders should both be cleaned")
+}
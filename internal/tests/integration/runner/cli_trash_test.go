@@ -1,13 +1,18 @@
 package runner_test
 
 import (
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
+	"runtime"
 	"time"
 
 	"testing"
 
 	"github.com/pashkov256/deletor/internal/cli/config"
 	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/logging"
 	"github.com/pashkov256/deletor/internal/rules"
 	"github.com/pashkov256/deletor/internal/runner"
 	"github.com/stretchr/testify/assert"
@@ -17,6 +22,9 @@ import (
 type mockFileManager struct {
 	deletedFiles []string
 	trashedFiles []string
+	deletedDirs  []string
+	trashedDirs  []string
+	trashErr     error // Returned by TrashDir
 }
 
 func (m *mockFileManager) DeleteFile(path string) {
@@ -27,6 +35,19 @@ func (m *mockFileManager) MoveFileToTrash(filePath string) {
 	m.trashedFiles = append(m.trashedFiles, filePath)
 }
 
+func (m *mockFileManager) DeleteDir(dir string) error {
+	m.deletedDirs = append(m.deletedDirs, dir)
+	return nil
+}
+
+func (m *mockFileManager) TrashDir(dir string) error {
+	if m.trashErr != nil {
+		return m.trashErr
+	}
+	m.trashedDirs = append(m.trashedDirs, dir)
+	return nil
+}
+
 func (m *mockFileManager) NewFileFilter(minSize, maxSize int64, extensions map[string]struct{}, exclude []string, olderThan, newerThan time.Time) *filemanager.FileFilter {
 	return &filemanager.FileFilter{
 		FileFilterOptions: filemanager.FileFilterOptions{
@@ -40,20 +61,24 @@ func (m *mockFileManager) NewFileFilter(minSize, maxSize int64, extensions map[s
 	}
 }
 
-func (m *mockFileManager) WalkFilesWithFilter(callback func(fi os.FileInfo, path string), dir string, filter *filemanager.FileFilter) {
+func (m *mockFileManager) WalkFilesWithFilter(ctx context.Context, callback func(fi os.FileInfo, path string), dir string, filter *filemanager.FileFilter) error {
 	// No operation for mock
+	return nil
 }
 
-func (m *mockFileManager) MoveFilesToTrash(dir string, extensions []string, exclude []string, minSize, maxSize int64, olderThan, newerThan time.Time) {
+func (m *mockFileManager) MoveFilesToTrash(ctx context.Context, dir string, extensions []string, exclude []string, minSize, maxSize int64, olderThan, newerThan time.Time) error {
 	// No operation for mock
+	return nil
 }
 
-func (m *mockFileManager) DeleteFiles(dir string, extensions []string, exclude []string, minSize, maxSize int64, olderThan, newerThan time.Time) {
+func (m *mockFileManager) DeleteFiles(ctx context.Context, dir string, extensions []string, exclude []string, minSize, maxSize int64, olderThan, newerThan time.Time) error {
 	// No operation for mock
+	return nil
 }
 
-func (m *mockFileManager) DeleteEmptySubfolders(dir string) {
+func (m *mockFileManager) DeleteEmptySubfolders(ctx context.Context, dir string) error {
 	// No operation for mock
+	return nil
 }
 
 func (m *mockFileManager) IsEmptyDir(dir string) bool {
@@ -64,7 +89,7 @@ func (m *mockFileManager) ExpandTilde(path string) string {
 	return path
 }
 
-func (m *mockFileManager) CalculateDirSize(path string) int64 {
+func (m *mockFileManager) CalculateDirSize(ctx context.Context, path string) int64 {
 	return 0
 }
 
@@ -146,7 +171,7 @@ func TestRunCLI_TrashFlag(t *testing.T) {
 			r := rules.NewRules()
 
 			// Run the CLI
-			runner.RunCLI(mockFm, r, tt.config)
+			runner.RunCLI(context.Background(), mockFm, r, tt.config)
 
 			if tt.expectDelete {
 				assert.Equal(t, len(mockFm.trashedFiles), 0, "No files should be moved to trash")
@@ -160,3 +185,30 @@ func TestRunCLI_TrashFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestRunCLI_TrashDirFailure(t *testing.T) {
+	testDir, cleanup := setupTestDir(t)
+	defer cleanup()
+	if runtime.GOOS != "linux" {
+		t.Skip("the journal location is only redirected through XDG_CONFIG_HOME on Linux")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	mockFm := &mockFileManager{trashErr: errors.New("trash unavailable")}
+	runner.RunCLI(context.Background(), mockFm, rules.NewRules(), &config.Config{
+		Directory:       testDir,
+		Directories:     []rules.DirectoryRule{{Pattern: "subdir"}},
+		IncludeSubdirs:  true,
+		SkipConfirm:     true,
+		MoveFileToTrash: true,
+	})
+
+	assert.Empty(t, mockFm.trashedDirs, "No directory should be moved to trash")
+	assert.DirExists(t, filepath.Join(testDir, "subdir"))
+
+	journal, err := os.ReadFile(logging.GetJournalFilePath())
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	assert.NotContains(t, string(journal), string(logging.OperationTrashed), "A directory that failed to move must not be journaled as trashed")
+}
@@ -328,6 +328,16 @@ func TestCleanFilesModel_Navigation(t *testing.T) {
 				{"tab", "maxSizeInput"},
 				{"tab", "olderInput"},
 				{"tab", "newerInput"},
+				{"tab", "accessedBeforeInput"},
+				{"tab", "accessedAfterInput"},
+				{"tab", "changedBeforeInput"},
+				{"tab", "changedAfterInput"},
+				{"tab", "ownerInput"},
+				{"tab", "notOwnerInput"},
+				{"tab", "uidInput"},
+				{"tab", "groupInput"},
+				{"tab", "permInput"},
+				{"tab", "whereInput"},
 				{"tab", "excludeInput"},
 			}
 
@@ -350,6 +360,16 @@ func TestCleanFilesModel_Navigation(t *testing.T) {
 				key      string
 				expected string
 			}{
+				{"shift+tab", "whereInput"},
+				{"shift+tab", "permInput"},
+				{"shift+tab", "groupInput"},
+				{"shift+tab", "uidInput"},
+				{"shift+tab", "notOwnerInput"},
+				{"shift+tab", "ownerInput"},
+				{"shift+tab", "changedAfterInput"},
+				{"shift+tab", "changedBeforeInput"},
+				{"shift+tab", "accessedAfterInput"},
+				{"shift+tab", "accessedBeforeInput"},
 				{"shift+tab", "newerInput"},
 				{"shift+tab", "olderInput"},
 				{"shift+tab", "maxSizeInput"},
@@ -435,9 +455,27 @@ func TestCleanFilesModel_Navigation(t *testing.T) {
 					expectedFocus: "clean_option_10",
 				},
 				{
-					name:          "Tab_wrap_to_option1",
+					name:          "Tab_to_option11",
 					initialFocus:  "clean_option_10",
 					key:           "tab",
+					expectedFocus: "clean_option_11",
+				},
+				{
+					name:          "Tab_to_option12",
+					initialFocus:  "clean_option_11",
+					key:           "tab",
+					expectedFocus: "clean_option_12",
+				},
+				{
+					name:          "Tab_to_option13",
+					initialFocus:  "clean_option_12",
+					key:           "tab",
+					expectedFocus: "clean_option_13",
+				},
+				{
+					name:          "Tab_wrap_to_option1",
+					initialFocus:  "clean_option_13",
+					key:           "tab",
 					expectedFocus: "clean_option_1",
 				},
 			}
@@ -857,9 +895,16 @@ func TestCleanFilesModel_OptionsAndSettings(t *testing.T) {
 
 		for i := 1; i <= len(options.DefaultCleanOption); i++ {
 			var optionKey string
-			if i == 10 {
+			switch i {
+			case 10:
 				optionKey = "alt+0" // ExitAfterDeletion uses alt+0, not alt+10
-			} else {
+			case 11:
+				optionKey = "alt+-"
+			case 12:
+				optionKey = "alt+="
+			case 13:
+				optionKey = "alt+\\"
+			default:
 				optionKey = fmt.Sprintf("alt+%d", i)
 			}
 			initialState := model.OptionState[options.DefaultCleanOption[i-1]]
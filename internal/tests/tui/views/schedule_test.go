@@ -116,3 +116,45 @@ func TestScheduleCleanModel_SchedulesAndRunsOneOffClean(t *testing.T) {
 		t.Fatal("delete.txt should be removed after scheduled clean")
 	}
 }
+
+func TestScheduleCleanModel_Limits(t *testing.T) {
+	model, _ := setupScheduleModel(t)
+	model.DelayInput.SetValue("1 hour")
+	model.IONiceInput.SetValue("realtime")
+	model.FocusedElement = "scheduleButton"
+
+	newModel, _ := model.Handle(tea.KeyMsg{Type: tea.KeyEnter})
+	scheduleModel := newModel.(*views.ScheduleCleanModel)
+	if scheduleModel.IsScheduled() {
+		t.Fatal("expected an unknown I/O class to block scheduling")
+	}
+
+	scheduleModel.IONiceInput.SetValue("idle")
+	scheduleModel.RateLimitInput.SetValue("100/s")
+	newModel, _ = scheduleModel.Handle(tea.KeyMsg{Type: tea.KeyEnter})
+	scheduleModel = newModel.(*views.ScheduleCleanModel)
+	if !scheduleModel.IsScheduled() {
+		t.Fatal("expected model to hold a scheduled run")
+	}
+	if status := scheduleModel.GetStatus(); !strings.Contains(status, "I/O idle, 100 files/s") {
+		t.Fatalf("status %q does not name the limits", status)
+	}
+}
+
+func TestScheduleCleanModel_TabCyclesInputs(t *testing.T) {
+	model, _ := setupScheduleModel(t)
+
+	want := []string{"ioniceInput", "niceInput", "rateLimitInput", "bwLimitInput", "scheduleButton", "delayInput"}
+	for _, focus := range want {
+		newModel, _ := model.Handle(tea.KeyMsg{Type: tea.KeyTab})
+		model = newModel.(*views.ScheduleCleanModel)
+		if model.FocusedElement != focus {
+			t.Fatalf("focus = %q, want %q", model.FocusedElement, focus)
+		}
+	}
+
+	newModel, _ := model.Handle(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if focus := newModel.(*views.ScheduleCleanModel).FocusedElement; focus != "scheduleButton" {
+		t.Errorf("shift+tab focus = %q, want scheduleButton", focus)
+	}
+}
@@ -1,10 +1,12 @@
 package cache_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/pashkov256/deletor/internal/cache"
@@ -35,16 +37,20 @@ func TestNewCacheManager_InitializesCorrectLocations(t *testing.T) {
 		if err != nil {
 			t.Error(err)
 		}
+		cacheHome := filepath.Join(homeDir, ".cache")
+		if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+			cacheHome = xdg
+		}
 		expectedLocations = []cache.CacheLocation{
 			{Path: "/tmp"},
 			{Path: "/var/tmp"},
-			{Path: filepath.Join(homeDir, ".cache")},
+			{Path: cacheHome},
 		}
 	default:
 		expectedLocations = nil
 	}
 
-	actualLocations := cm.ScanAllLocations()
+	actualLocations := cm.ScanAllLocations(context.Background())
 	if len(expectedLocations) != len(actualLocations) {
 		t.Error("Wrong number of locations initialized")
 	}
@@ -63,3 +69,73 @@ func TestNewCacheManager_InitializesCorrectLocations(t *testing.T) {
 		}
 	}
 }
+
+func TestRegistry_FollowsToolEnvironment(t *testing.T) {
+	t.Setenv("GOCACHE", "/custom/go-build")
+	t.Setenv("CARGO_HOME", "/custom/cargo")
+	t.Setenv("PIP_CACHE_DIR", "/custom/pip")
+
+	paths := map[string]string{}
+	for _, location := range cache.Registry(cache.Linux) {
+		paths[location.Name] = location.Path
+	}
+	want := map[string]string{
+		"tmp":      "/tmp",
+		"go-build": "/custom/go-build",
+		"cargo":    filepath.Join("/custom/cargo", "registry"),
+		"pip":      "/custom/pip",
+	}
+	for name, path := range want {
+		if paths[name] != path {
+			t.Errorf("Registry() path of %s = %q, want %q", name, paths[name], path)
+		}
+	}
+	if cache.Registry("plan9") != nil {
+		t.Error("Registry(plan9) != nil, want no locations")
+	}
+}
+
+func TestSelectLocations(t *testing.T) {
+	registry := cache.Registry(cache.Linux)
+
+	selected, err := cache.SelectLocations(registry, []string{"browser", "Go-Build"})
+	if err != nil {
+		t.Fatalf("SelectLocations() error = %v", err)
+	}
+	var names []string
+	for _, location := range selected {
+		names = append(names, location.Name)
+	}
+	if got := strings.Join(names, ","); got != "go-build,chrome,chromium,firefox" {
+		t.Errorf("SelectLocations(browser, Go-Build) = %s, want go-build and the browsers in registry order", got)
+	}
+
+	if _, err := cache.SelectLocations(registry, []string{"npm", "nope"}); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("SelectLocations(nope) error = %v, want an unknown location error", err)
+	}
+	if _, err := cache.SelectLocations(registry, nil); err == nil {
+		t.Error("SelectLocations() with no names error = nil, want an error")
+	}
+}
+
+func TestManagerSelect_KeepsLocationsOnError(t *testing.T) {
+	cm := cache.NewCacheManager(filemanager.NewFileManager())
+	before := len(cm.Locations)
+	if err := cm.Select("nope"); err == nil {
+		t.Fatal("Select(nope) error = nil, want an error")
+	}
+	if len(cm.Locations) != before {
+		t.Errorf("Select(nope) changed the locations to %+v", cm.Locations)
+	}
+	if cm.Os != cache.Linux {
+		return
+	}
+	if err := cm.Select("dev"); err != nil {
+		t.Fatalf("Select(dev) error = %v", err)
+	}
+	for _, location := range cm.Locations {
+		if location.Type != cache.DevCache {
+			t.Errorf("Select(dev) selected %s of type %s", location.Name, location.Type)
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package cache
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"runtime"
 	"slices"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/pashkov256/deletor/internal/cache"
 	"github.com/pashkov256/deletor/internal/filemanager"
@@ -27,7 +30,7 @@ func TestScanAllLocations(t *testing.T) {
 			Locations:   locs,
 			Filemanager: fm,
 		}
-		results := testManager.ScanAllLocations()
+		results := testManager.ScanAllLocations(context.Background())
 		infoTemp1, statErr1 := os.Stat(tempDir1)
 		if statErr1 != nil {
 			t.Fatal(statErr1)
@@ -85,7 +88,7 @@ func TestScanAllLocations(t *testing.T) {
 			Locations:   locs,
 			Filemanager: fm,
 		}
-		results := testManager.ScanAllLocations()
+		results := testManager.ScanAllLocations(context.Background())
 		infoTempA, statErr1 := os.Stat(tempDirA)
 		if statErr1 != nil {
 			t.Fatal(statErr1)
@@ -124,7 +127,7 @@ func TestScanAllLocations(t *testing.T) {
 	})
 	t.Run("successful cross-platform scanning", func(t *testing.T) {
 		m := cache.NewCacheManager(fm)
-		results := m.ScanAllLocations()
+		results := m.ScanAllLocations(context.Background())
 		var expectedPaths = []string{}
 		switch runtime.GOOS {
 		case "windows":
@@ -156,3 +159,169 @@ func TestScanAllLocations(t *testing.T) {
 		}
 	})
 }
+
+func TestClearCache_SkipsOpenFiles(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("open files are only read on Linux")
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"busy.bin", "stale.bin"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Open(filepath.Join(dir, "busy.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	testManager := &cache.Manager{
+		Os:            cache.OS(runtime.GOOS),
+		Locations:     []cache.CacheLocation{{Path: dir, Type: "system"}},
+		Filemanager:   filemanager.NewFileManager(),
+		SkipOpenFiles: true,
+	}
+	result, err := testManager.ClearCache(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ClearCache() error = %v", err)
+	}
+	if result.FilesDeleted != 1 || result.InUseFiles != 1 || result.InUseSize != int64(len("busy.bin")) {
+		t.Errorf("ClearCache() = %+v, want stale.bin deleted and busy.bin in use", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "busy.bin")); err != nil {
+		t.Errorf("open file was removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stale.bin")); !os.IsNotExist(err) {
+		t.Errorf("stale.bin still exists, stat error = %v", err)
+	}
+}
+
+func TestClearCache_ReadOnlyDirectories(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("directory permissions are only checked on Linux")
+	}
+	// The Go module cache leaves its directories read-only
+	dir := t.TempDir()
+	module := filepath.Join(dir, "example.com", "mod@v1.0.0")
+	if err := os.MkdirAll(module, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(module, "go.mod"), []byte("module example.com/mod\n"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(module, 0555); err != nil {
+		t.Fatal(err)
+	}
+
+	testManager := &cache.Manager{
+		Os:          cache.OS(runtime.GOOS),
+		Locations:   []cache.CacheLocation{{Name: "go-mod", Path: dir, Type: cache.DevCache}},
+		Filemanager: filemanager.NewFileManager(),
+	}
+	result, err := testManager.ClearCache(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ClearCache() error = %v", err)
+	}
+	if result.FilesDeleted != 1 {
+		t.Errorf("ClearCache() deleted %d files, want 1", result.FilesDeleted)
+	}
+	if _, err := os.Stat(filepath.Join(module, "go.mod")); !os.IsNotExist(err) {
+		t.Errorf("go.mod still exists, stat error = %v", err)
+	}
+}
+
+func TestClearCache_CustomLocationLimits(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]int{"old-large.bin": 2048, "old-small.bin": 10, "new-large.bin": 2048}
+	for name, size := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(name, "old") {
+			old := time.Now().Add(-48 * time.Hour)
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	testManager := &cache.Manager{
+		Os:          cache.OS(runtime.GOOS),
+		Filemanager: filemanager.NewFileManager(),
+		Custom: []cache.CacheLocation{
+			{Name: "tool", Path: dir, Type: cache.AppCache, OlderThan: time.Now().Add(-24 * time.Hour), MinSize: 1024, Custom: true},
+		},
+	}
+	if err := testManager.Select("tool"); err != nil {
+		t.Fatalf("Select(tool) error = %v", err)
+	}
+	result, err := testManager.ClearCache(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ClearCache() error = %v", err)
+	}
+	if result.FilesDeleted != 1 {
+		t.Errorf("ClearCache() deleted %d files, want only old-large.bin", result.FilesDeleted)
+	}
+	for name := range files {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if removed := os.IsNotExist(err); removed != (name == "old-large.bin") {
+			t.Errorf("%s removed = %v", name, removed)
+		}
+	}
+}
+
+func TestKnown_CustomReplacesBuiltIn(t *testing.T) {
+	testManager := &cache.Manager{
+		Os:     cache.Linux,
+		Custom: []cache.CacheLocation{{Name: "maven", Path: "/srv/m2", Type: cache.DevCache, Custom: true}},
+	}
+	var mavens []cache.CacheLocation
+	for _, location := range testManager.Known() {
+		if location.Name == "maven" {
+			mavens = append(mavens, location)
+		}
+	}
+	if len(mavens) != 1 || mavens[0].Path != "/srv/m2" {
+		t.Errorf("Known() maven locations = %+v, want only the custom one", mavens)
+	}
+}
+
+func TestClearCache_UserCacheSkipsNestedLocations(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	t.Setenv("PIP_CACHE_DIR", "")
+	files := []string{"thumbnail.png", filepath.Join("pip", "wheel.whl"), filepath.Join("google-chrome", "Cache", "data_0")}
+	for _, name := range files {
+		path := filepath.Join(cacheHome, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testManager := &cache.Manager{Os: cache.Linux, Filemanager: filemanager.NewFileManager()}
+	if err := testManager.Select("user-cache"); err != nil {
+		t.Fatalf("Select(user-cache) error = %v", err)
+	}
+	if len(testManager.Locations) != 1 || testManager.Locations[0].Path != cacheHome {
+		t.Fatalf("user-cache = %+v, want it at XDG_CACHE_HOME", testManager.Locations)
+	}
+
+	result, err := testManager.ClearCache(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ClearCache() error = %v", err)
+	}
+	if result.FilesDeleted != 1 {
+		t.Errorf("ClearCache() deleted %d files, want only thumbnail.png", result.FilesDeleted)
+	}
+	for _, name := range files {
+		_, err := os.Stat(filepath.Join(cacheHome, name))
+		if removed := os.IsNotExist(err); removed != (name == "thumbnail.png") {
+			t.Errorf("%s removed = %v", name, removed)
+		}
+	}
+}
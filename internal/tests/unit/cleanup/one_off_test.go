@@ -1,12 +1,18 @@
 package cleanup_test
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/pashkov256/deletor/internal/cleanup"
 	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/logging"
 	"github.com/pashkov256/deletor/internal/path"
 	"github.com/pashkov256/deletor/internal/rules"
 )
@@ -66,7 +72,7 @@ func TestRunOneOffClean_UsesSavedRulesRecursively(t *testing.T) {
 		t.Fatalf("LoadOneOffCleanSpec failed: %v", err)
 	}
 
-	result, err := cleanup.RunOneOffClean(filemanager.NewFileManager(), spec)
+	result, err := cleanup.RunOneOffClean(context.Background(), filemanager.NewFileManager(), spec)
 	if err != nil {
 		t.Fatalf("RunOneOffClean failed: %v", err)
 	}
@@ -131,7 +137,7 @@ func TestRunOneOffClean_RespectsCurrentLevelOnly(t *testing.T) {
 		t.Fatalf("LoadOneOffCleanSpec failed: %v", err)
 	}
 
-	result, err := cleanup.RunOneOffClean(filemanager.NewFileManager(), spec)
+	result, err := cleanup.RunOneOffClean(context.Background(), filemanager.NewFileManager(), spec)
 	if err != nil {
 		t.Fatalf("RunOneOffClean failed: %v", err)
 	}
@@ -143,3 +149,398 @@ func TestRunOneOffClean_RespectsCurrentLevelOnly(t *testing.T) {
 		t.Fatalf("nested file should remain when subfolders are disabled: %v", err)
 	}
 }
+
+func TestRunOneOffClean_PrunesOnlyScannedEmptyDirs(t *testing.T) {
+	cleanupConfig := setupCleanupRulesConfig(t)
+	defer cleanupConfig()
+
+	rootDir := t.TempDir()
+	shallow := filepath.Join(rootDir, "empty")
+	deep := filepath.Join(rootDir, "a", "b", "c")
+	for _, dir := range []string{shallow, deep} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	result, err := cleanup.RunOneOffClean(context.Background(), filemanager.NewFileManager(), &cleanup.OneOffCleanSpec{
+		Path:                  rootDir,
+		IncludeSubfolders:     true,
+		DeleteEmptySubfolders: true,
+		WalkLimits:            filemanager.WalkLimits{MaxDepth: 2},
+	})
+	if err != nil {
+		t.Fatalf("RunOneOffClean failed: %v", err)
+	}
+
+	if result.EmptyDirsDeleted != 1 {
+		t.Errorf("EmptyDirsDeleted = %d, want 1", result.EmptyDirsDeleted)
+	}
+	if _, err := os.Stat(shallow); !os.IsNotExist(err) {
+		t.Error("empty directory within the depth limit should be removed")
+	}
+	if _, err := os.Stat(deep); err != nil {
+		t.Errorf("empty directory below the depth limit should remain: %v", err)
+	}
+}
+
+func TestRunOneOffClean_AppliesClauses(t *testing.T) {
+	cleanupConfig := setupCleanupRulesConfig(t)
+	defer cleanupConfig()
+
+	rootDir := t.TempDir()
+	for _, name := range []string{"app.log", "x.tmp", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(rootDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	ruleManager := rules.NewRules()
+	if err := ruleManager.SetupRulesConfig(); err != nil {
+		t.Fatalf("Failed to setup rules: %v", err)
+	}
+	if err := ruleManager.UpdateRules(
+		rules.WithPath(rootDir),
+		rules.WithClauses([]rules.Clause{
+			{Pattern: "*.log", Action: "archive"},
+			{Pattern: "*.tmp", Action: "delete"},
+		}),
+	); err != nil {
+		t.Fatalf("Failed to update rules: %v", err)
+	}
+
+	spec, err := cleanup.LoadOneOffCleanSpec(ruleManager)
+	if err != nil {
+		t.Fatalf("LoadOneOffCleanSpec failed: %v", err)
+	}
+	result, err := cleanup.RunOneOffClean(context.Background(), filemanager.NewFileManager(), spec)
+	if err != nil {
+		t.Fatalf("RunOneOffClean failed: %v", err)
+	}
+	// The archive keeps the data of app.log, so only x.tmp counts as cleaned
+	if result.FilesCleaned != 1 || result.BytesCleared != int64(len("x.tmp")) || result.FilesArchived != 1 {
+		t.Fatalf("result = %+v, want x.tmp cleaned and app.log archived", result)
+	}
+
+	for name, exists := range map[string]bool{"app.log": false, "app.log.gz": true, "x.tmp": false, "notes.txt": true} {
+		if _, err := os.Stat(filepath.Join(rootDir, name)); (err == nil) != exists {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, exists)
+		}
+	}
+
+	journal, err := os.Open(logging.GetJournalFilePath())
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	defer journal.Close()
+
+	applied := make(map[string]*logging.FileOperation)
+	scanner := bufio.NewScanner(journal)
+	for scanner.Scan() {
+		var entry logging.JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to parse journal line: %v", err)
+		}
+		if entry.Operation != nil {
+			applied[filepath.Base(entry.Operation.FilePath)] = entry.Operation
+		}
+	}
+	if op := applied["app.log"]; op == nil || op.OperationType != logging.OperationArchived || op.RuleApplied != "*.log:action=archive" {
+		t.Errorf("app.log recorded as %+v, want archived by *.log:action=archive", op)
+	}
+	if op := applied["x.tmp"]; op == nil || op.OperationType != logging.OperationDeleted || op.RuleApplied != "*.tmp:action=delete" {
+		t.Errorf("x.tmp recorded as %+v, want deleted by *.tmp:action=delete", op)
+	}
+}
+
+func TestRunOneOffClean_RotatesLogs(t *testing.T) {
+	cleanupConfig := setupCleanupRulesConfig(t)
+	defer cleanupConfig()
+
+	rootDir := t.TempDir()
+	for name, contents := range map[string]string{"app.log": "today", "app.log.1": "yesterday", "app.log.2.gz": "old"} {
+		if err := os.WriteFile(filepath.Join(rootDir, name), []byte(contents), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	ruleManager := rules.NewRules()
+	if err := ruleManager.SetupRulesConfig(); err != nil {
+		t.Fatalf("Failed to setup rules: %v", err)
+	}
+	if err := ruleManager.UpdateRules(
+		rules.WithPath(rootDir),
+		rules.WithClauses([]rules.Clause{{Pattern: "*.log", Action: "rotate"}}),
+		rules.WithRotation(2, true),
+	); err != nil {
+		t.Fatalf("Failed to update rules: %v", err)
+	}
+
+	spec, err := cleanup.LoadOneOffCleanSpec(ruleManager)
+	if err != nil {
+		t.Fatalf("LoadOneOffCleanSpec failed: %v", err)
+	}
+	if want := (filemanager.RotateOptions{Keep: 2, CopyTruncate: true}); !spec.Rotates() || spec.Rotation != want {
+		t.Fatalf("spec rotation = %v %+v, want %+v", spec.Rotates(), spec.Rotation, want)
+	}
+
+	result, err := cleanup.RunOneOffClean(context.Background(), filemanager.NewFileManager(), spec)
+	if err != nil {
+		t.Fatalf("RunOneOffClean failed: %v", err)
+	}
+	if result.FilesCleaned != 0 || result.FilesArchived != 1 {
+		t.Errorf("result = %+v, want app.log rotated and nothing cleaned", result)
+	}
+
+	// Nothing holds app.log open, so it is renamed rather than truncated.
+	// Without /proc every rotated file is copied and truncated.
+	for name, exists := range map[string]bool{"app.log": runtime.GOOS != "linux", "app.log.1": true, "app.log.2.gz": true, "app.log.3.gz": false} {
+		if _, err := os.Stat(filepath.Join(rootDir, name)); (err == nil) != exists {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, exists)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(rootDir, "app.log.1")); string(data) != "today" {
+		t.Errorf("app.log.1 = %q, want the rotated live file", data)
+	}
+
+	if err := ruleManager.UpdateRules(rules.WithRotation(-1, false)); err == nil {
+		t.Error("UpdateRules() with negative keep error = nil, want an error")
+	}
+}
+
+func TestRunOneOffClean_Shreds(t *testing.T) {
+	cleanupConfig := setupCleanupRulesConfig(t)
+	defer cleanupConfig()
+
+	rootDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(rootDir, "export.csv"), []byte("id;email\n1;a@example.com\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	ruleManager := rules.NewRules()
+	if err := ruleManager.SetupRulesConfig(); err != nil {
+		t.Fatalf("Failed to setup rules: %v", err)
+	}
+	if err := ruleManager.UpdateRules(
+		rules.WithPath(rootDir),
+		rules.WithShred(true),
+		rules.WithShredOptions(2, "zeros"),
+	); err != nil {
+		t.Fatalf("Failed to update rules: %v", err)
+	}
+
+	spec, err := cleanup.LoadOneOffCleanSpec(ruleManager)
+	if err != nil {
+		t.Fatalf("LoadOneOffCleanSpec failed: %v", err)
+	}
+	if want := (filemanager.ShredOptions{Passes: 2, Method: filemanager.ShredZeros}); !spec.Shred || spec.ShredOptions != want {
+		t.Fatalf("spec shred = %v %+v, want true %+v", spec.Shred, spec.ShredOptions, want)
+	}
+
+	result, err := cleanup.RunOneOffClean(context.Background(), filemanager.NewFileManager(), spec)
+	if err != nil {
+		t.Fatalf("RunOneOffClean failed: %v", err)
+	}
+	if result.FilesCleaned != 1 || !result.UsedShred || result.ShredThroughput <= 0 {
+		t.Errorf("result = %+v, want one shredded file with its throughput", result)
+	}
+	if entries, _ := os.ReadDir(rootDir); len(entries) != 0 {
+		t.Errorf("directory still holds %v", entries)
+	}
+
+	if err := ruleManager.UpdateRules(rules.WithOptions(false, false, false, false, true, false, false, true, false, false)); err == nil {
+		t.Error("UpdateRules() with shred and trash error = nil, want an error")
+	}
+}
+
+func TestRunOneOffClean_Throttled(t *testing.T) {
+	cleanupConfig := setupCleanupRulesConfig(t)
+	defer cleanupConfig()
+
+	rootDir := t.TempDir()
+	for _, name := range []string{"a.tmp", "b.tmp", "c.tmp", "d.tmp"} {
+		if err := os.WriteFile(filepath.Join(rootDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	ruleManager := rules.NewRules()
+	if err := ruleManager.SetupRulesConfig(); err != nil {
+		t.Fatalf("Failed to setup rules: %v", err)
+	}
+	if err := ruleManager.UpdateRules(rules.WithPath(rootDir), rules.WithThrottle("be:7", 5, "50/s", "1mb/s")); err != nil {
+		t.Fatalf("Failed to update rules: %v", err)
+	}
+
+	spec, err := cleanup.LoadOneOffCleanSpec(ruleManager)
+	if err != nil {
+		t.Fatalf("LoadOneOffCleanSpec failed: %v", err)
+	}
+	want := cleanup.Throttle{IONice: "best-effort:7", Nice: 5, RateLimit: 50, BWLimit: 1024 * 1024}
+	if spec.Throttle != want {
+		t.Fatalf("spec throttle = %+v, want %+v", spec.Throttle, want)
+	}
+	if runtime.GOOS != "linux" {
+		spec.Throttle.IONice, spec.Throttle.Nice = "", 0
+	}
+
+	start := time.Now()
+	result, err := cleanup.RunOneOffClean(context.Background(), filemanager.NewFileManager(), spec)
+	if err != nil {
+		t.Fatalf("RunOneOffClean failed: %v", err)
+	}
+	if result.FilesCleaned != 4 {
+		t.Errorf("FilesCleaned = %d, want 4", result.FilesCleaned)
+	}
+	// The first file goes at once, the other three wait 20ms each
+	if took := time.Since(start); took < 55*time.Millisecond {
+		t.Errorf("4 files at 50/s took %v, want at least 60ms", took)
+	}
+
+	if err := ruleManager.UpdateRules(rules.WithThrottle("realtime", 0, "", "")); err == nil {
+		t.Error("UpdateRules() with an unknown I/O class error = nil, want an error")
+	}
+	if err := ruleManager.UpdateRules(rules.WithThrottle("", 0, "200/day", "")); err == nil {
+		t.Error("UpdateRules() with an unknown rate unit error = nil, want an error")
+	}
+}
+
+func TestRunOneOffClean_SkipsOpenFiles(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("open files are only read on Linux")
+	}
+	cleanupConfig := setupCleanupRulesConfig(t)
+	defer cleanupConfig()
+
+	rootDir := t.TempDir()
+	for _, name := range []string{"app.log", "old.log", "gone.log"} {
+		if err := os.WriteFile(filepath.Join(rootDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"app.log", "gone.log"} {
+		f, err := os.Open(filepath.Join(rootDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+	}
+	if err := os.Remove(filepath.Join(rootDir, "gone.log")); err != nil {
+		t.Fatal(err)
+	}
+
+	ruleManager := rules.NewRules()
+	if err := ruleManager.SetupRulesConfig(); err != nil {
+		t.Fatalf("Failed to setup rules: %v", err)
+	}
+	if err := ruleManager.UpdateRules(rules.WithPath(rootDir), rules.WithSkipOpenFiles(true)); err != nil {
+		t.Fatalf("Failed to update rules: %v", err)
+	}
+
+	spec, err := cleanup.LoadOneOffCleanSpec(ruleManager)
+	if err != nil {
+		t.Fatalf("LoadOneOffCleanSpec failed: %v", err)
+	}
+	if !spec.SkipOpenFiles {
+		t.Fatal("spec.SkipOpenFiles = false, want true")
+	}
+
+	result, err := cleanup.RunOneOffClean(context.Background(), filemanager.NewFileManager(), spec)
+	if err != nil {
+		t.Fatalf("RunOneOffClean failed: %v", err)
+	}
+	if result.FilesCleaned != 1 || result.InUseFiles != 1 || result.InUseSize != int64(len("app.log")) {
+		t.Errorf("result = %+v, want old.log cleaned and app.log in use", result)
+	}
+	if result.DeletedOpenSize != int64(len("gone.log")) {
+		t.Errorf("DeletedOpenSize = %d, want %d", result.DeletedOpenSize, len("gone.log"))
+	}
+	if _, err := os.Stat(filepath.Join(rootDir, "app.log")); err != nil {
+		t.Errorf("open file was removed: %v", err)
+	}
+}
+
+// cancellingFileManager cancels the run after the first deleted file
+type cancellingFileManager struct {
+	filemanager.FileManager
+	cancel context.CancelFunc
+}
+
+func (f *cancellingFileManager) DeleteFile(filePath string) {
+	f.FileManager.DeleteFile(filePath)
+	f.cancel()
+}
+
+func TestRunOneOffClean_CancelStopsBeforeNextFile(t *testing.T) {
+	cleanupConfig := setupCleanupRulesConfig(t)
+	defer cleanupConfig()
+
+	rootDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(rootDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fm := &cancellingFileManager{FileManager: filemanager.NewFileManager(), cancel: cancel}
+
+	result, err := cleanup.RunOneOffClean(ctx, fm, &cleanup.OneOffCleanSpec{Path: rootDir})
+	if err != nil {
+		t.Fatalf("RunOneOffClean failed: %v", err)
+	}
+	if !result.Cancelled || result.FilesCleaned != 1 || result.BytesCleared != 5 {
+		t.Fatalf("result = %+v, want cancelled after 1 file of 5 bytes", result)
+	}
+
+	entries, _ := os.ReadDir(rootDir)
+	if len(entries) != 2 {
+		t.Errorf("%d files remain, want 2", len(entries))
+	}
+
+	journal, err := os.Open(logging.GetJournalFilePath())
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	defer journal.Close()
+
+	var statuses []logging.JournalStatus
+	scanner := bufio.NewScanner(journal)
+	for scanner.Scan() {
+		var entry logging.JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to parse journal line: %v", err)
+		}
+		statuses = append(statuses, entry.Status)
+	}
+	want := []logging.JournalStatus{logging.JournalStarted, logging.JournalDone, logging.JournalCancelled}
+	if len(statuses) != len(want) {
+		t.Fatalf("journal statuses = %v, want %v", statuses, want)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Errorf("journal statuses = %v, want %v", statuses, want)
+			break
+		}
+	}
+}
+
+func TestActionOptions_Resolve(t *testing.T) {
+	tests := []struct {
+		opts   cleanup.ActionOptions
+		action filemanager.FileAction
+		want   filemanager.FileAction
+	}{
+		{cleanup.ActionOptions{}, filemanager.ActionDefault, filemanager.ActionDelete},
+		{cleanup.ActionOptions{Trash: true}, filemanager.ActionDefault, filemanager.ActionTrash},
+		{cleanup.ActionOptions{Shred: true}, filemanager.ActionDefault, filemanager.ActionShred},
+		{cleanup.ActionOptions{Shred: true}, filemanager.ActionArchive, filemanager.ActionArchive},
+		{cleanup.ActionOptions{Trash: true}, filemanager.ActionDelete, filemanager.ActionDelete},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.Resolve(tt.action); got != tt.want {
+			t.Errorf("%+v.Resolve(%q) = %q, want %q", tt.opts, tt.action, got, tt.want)
+		}
+	}
+}
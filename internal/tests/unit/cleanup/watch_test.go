@@ -0,0 +1,274 @@
+//go:build linux
+// +build linux
+
+package cleanup_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/cleanup"
+	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/logging"
+)
+
+// startWatcher runs a watcher in the background and reports removed paths
+func startWatcher(t *testing.T, spec *cleanup.OneOffCleanSpec) (removed <-chan string, stop func() *cleanup.WatchResult) {
+	t.Helper()
+
+	watcher, err := cleanup.NewWatcher(filemanager.NewFileManager(), spec)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	watcher.Debounce = 20 * time.Millisecond
+
+	removedCh := make(chan string, 16)
+	watcher.OnRemoved = func(entry filemanager.FileEntry, _ logging.OperationType) { removedCh <- entry.Path }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan *cleanup.WatchResult, 1)
+	go func() {
+		result, err := watcher.Run(ctx)
+		if err != nil {
+			t.Errorf("Run failed: %v", err)
+		}
+		done <- result
+	}()
+
+	return removedCh, func() *cleanup.WatchResult {
+		cancel()
+		return <-done
+	}
+}
+
+func waitRemoved(t *testing.T, removed <-chan string, want string) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case path := <-removed:
+			if path == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("%s was not removed", want)
+		}
+	}
+}
+
+func TestWatcher_RemovesNewAndExistingFiles(t *testing.T) {
+	cleanupConfig := setupCleanupRulesConfig(t)
+	defer cleanupConfig()
+
+	root := t.TempDir()
+	existing := filepath.Join(root, "existing.tmp")
+	keep := filepath.Join(root, "keep.log")
+	for _, file := range []string{existing, keep} {
+		if err := os.WriteFile(file, []byte("data"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", file, err)
+		}
+	}
+
+	removed, stop := startWatcher(t, &cleanup.OneOffCleanSpec{
+		Path:              root,
+		Extensions:        []string{".tmp"},
+		IncludeSubfolders: true,
+	})
+	waitRemoved(t, removed, existing)
+
+	// Files in directories created after the watch started are seen too
+	nested := filepath.Join(root, "spool", "in")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	incoming := filepath.Join(nested, "job.tmp")
+	if err := os.WriteFile(incoming, []byte("job"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	waitRemoved(t, removed, incoming)
+
+	result := stop()
+	if result.FilesCleaned != 2 || result.BytesCleared != int64(len("data")+len("job")) {
+		t.Errorf("result = %+v, want 2 files and 7 bytes", result)
+	}
+	if _, err := os.Stat(keep); err != nil {
+		t.Errorf("keep.log should not be removed: %v", err)
+	}
+}
+
+func TestWatcher_SchedulesFilesByAge(t *testing.T) {
+	cleanupConfig := setupCleanupRulesConfig(t)
+	defer cleanupConfig()
+
+	root := t.TempDir()
+	removed, stop := startWatcher(t, &cleanup.OneOffCleanSpec{
+		Path:         root,
+		Extensions:   []string{".tmp"},
+		OlderThanAge: 2 * time.Second,
+	})
+
+	// The file reaches the age limit about a second after it is written
+	aging := filepath.Join(root, "aging.tmp")
+	if err := os.WriteFile(aging, []byte("aging"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	written := time.Now().Add(-time.Second)
+	if err := os.Chtimes(aging, written, written); err != nil {
+		t.Fatalf("Failed to set times: %v", err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	if _, err := os.Stat(aging); err != nil {
+		t.Fatalf("file was removed before reaching the age limit: %v", err)
+	}
+	waitRemoved(t, removed, aging)
+
+	if result := stop(); result.FilesCleaned != 1 || result.Scheduled != 0 {
+		t.Errorf("result = %+v, want 1 file cleaned and none scheduled", result)
+	}
+}
+
+func TestWatcher_AppliesClauses(t *testing.T) {
+	cleanupConfig := setupCleanupRulesConfig(t)
+	defer cleanupConfig()
+
+	root := t.TempDir()
+	removed, stop := startWatcher(t, &cleanup.OneOffCleanSpec{
+		Path: root,
+		Clauses: []filemanager.Clause{
+			{Spec: "keep-*.log:action=skip", Pattern: "keep-*.log", Action: filemanager.ActionSkip},
+			{Spec: "*.csv:action=archive", Pattern: "*.csv", Action: filemanager.ActionArchive},
+			{Spec: "*.log", Pattern: "*.log"},
+		},
+	})
+
+	kept := filepath.Join(root, "keep-audit.log")
+	archived := filepath.Join(root, "export.csv")
+	deleted := filepath.Join(root, "app.log")
+	for _, file := range []string{kept, archived, deleted} {
+		if err := os.WriteFile(file, []byte("data"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", file, err)
+		}
+	}
+	// The two files may be handled in either order
+	pending := map[string]bool{deleted: true, archived: true}
+	timeout := time.After(5 * time.Second)
+	for len(pending) > 0 {
+		select {
+		case path := <-removed:
+			delete(pending, path)
+		case <-timeout:
+			t.Fatalf("%v were not handled", pending)
+		}
+	}
+	result := stop()
+
+	if result.FilesCleaned != 1 || result.Archived != 1 {
+		t.Errorf("result = %+v, want app.log cleaned and export.csv archived", result)
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("skipped file was removed: %v", err)
+	}
+	if _, err := os.Stat(archived + ".gz"); err != nil {
+		t.Errorf("archive clause did not archive export.csv: %v", err)
+	}
+}
+
+func TestWatcher_AppliesOneOffFilters(t *testing.T) {
+	cleanupConfig := setupCleanupRulesConfig(t)
+	defer cleanupConfig()
+
+	where, err := filemanager.ParseExpr(`not name ~ "keep*"`)
+	if err != nil {
+		t.Fatalf("ParseExpr failed: %v", err)
+	}
+	root := t.TempDir()
+	removed, stop := startWatcher(t, &cleanup.OneOffCleanSpec{
+		Path:       root,
+		Extensions: []string{".tmp"},
+		Where:      where,
+		NotOwners:  []int{os.Getuid() + 1},
+	})
+
+	kept := filepath.Join(root, "keep.tmp")
+	dropped := filepath.Join(root, "drop.tmp")
+	for _, file := range []string{kept, dropped} {
+		if err := os.WriteFile(file, []byte("data"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", file, err)
+		}
+	}
+	waitRemoved(t, removed, dropped)
+	stop()
+
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("file excluded by the filter expression was removed: %v", err)
+	}
+}
+
+func TestWatcher_HonorsWalkLimits(t *testing.T) {
+	cleanupConfig := setupCleanupRulesConfig(t)
+	defer cleanupConfig()
+
+	root := t.TempDir()
+	deep := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatalf("Failed to create %s: %v", deep, err)
+	}
+	existingDeep := filepath.Join(deep, "existing.tmp")
+	if err := os.WriteFile(existingDeep, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create %s: %v", existingDeep, err)
+	}
+
+	removed, stop := startWatcher(t, &cleanup.OneOffCleanSpec{
+		Path:              root,
+		Extensions:        []string{".tmp"},
+		IncludeSubfolders: true,
+		WalkLimits:        filemanager.WalkLimits{MaxDepth: 2},
+	})
+
+	// Files are written below the depth limit first, so the shallow file is
+	// only removed after the deep one would have been
+	newDeep := filepath.Join(deep, "new.tmp")
+	shallow := filepath.Join(root, "a", "shallow.tmp")
+	for _, file := range []string{newDeep, shallow} {
+		if err := os.WriteFile(file, []byte("data"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", file, err)
+		}
+	}
+	waitRemoved(t, removed, shallow)
+	stop()
+
+	for _, file := range []string{existingDeep, newDeep} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("file below the depth limit was removed: %v", err)
+		}
+	}
+}
+
+func TestWatcher_SkipsOtherOwners(t *testing.T) {
+	cleanupConfig := setupCleanupRulesConfig(t)
+	defer cleanupConfig()
+
+	root := t.TempDir()
+	owned := filepath.Join(root, "owned.tmp")
+	if err := os.WriteFile(owned, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	_, stop := startWatcher(t, &cleanup.OneOffCleanSpec{
+		Path:       root,
+		Extensions: []string{".tmp"},
+		NotOwners:  []int{os.Getuid()},
+	})
+	time.Sleep(200 * time.Millisecond)
+
+	if result := stop(); result.FilesCleaned != 0 {
+		t.Errorf("result = %+v, want no files of the excluded owner cleaned", result)
+	}
+	if _, err := os.Stat(owned); err != nil {
+		t.Errorf("file of an excluded owner was removed: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package filemanager_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+func TestScanEmptyFiles(t *testing.T) {
+	root := t.TempDir()
+	createDirStructure(t, root, []string{"empty-dir"}, map[string]string{
+		"crash.dmp":         "",
+		"nested/deep/a.tmp": "",
+		"data.txt":          "content",
+		"vendor/empty.go":   "",
+		"app.pid":           "",
+		"pkg/__init__.py":   "",
+		"logs/.gitkeep":     "",
+		"yarn.lock":         "",
+	}, nil)
+
+	fm := filemanager.NewFileManager()
+	// Size and extension filters select regular results, not this category
+	filter := fm.NewFileFilter(1024, 0, map[string]struct{}{".log": {}}, []string{"vendor"}, time.Time{}, time.Time{})
+	found := filemanager.NewFileScanner(fm, filter, false).ScanEmptyFiles(context.Background(), root, true)
+
+	want := []string{filepath.Join(root, "crash.dmp"), filepath.Join(root, "nested", "deep", "a.tmp")}
+	if len(found) != len(want) {
+		t.Fatalf("ScanEmptyFiles() found %d files, want %d: %+v", len(found), len(want), found)
+	}
+	for i, entry := range found {
+		if entry.Path != want[i] || entry.Size != 0 {
+			t.Errorf("entry %d = %s (%d bytes), want %s", i, entry.Path, entry.Size, want[i])
+		}
+	}
+}
+
+func TestScanEmptyFiles_NotRecursive(t *testing.T) {
+	root := t.TempDir()
+	createDirStructure(t, root, nil, map[string]string{
+		"top.tmp":        "",
+		"nested/low.tmp": "",
+	}, nil)
+
+	fm := filemanager.NewFileManager()
+	filter := fm.NewFileFilter(0, 0, nil, nil, time.Time{}, time.Time{})
+	found := filemanager.NewFileScanner(fm, filter, false).ScanEmptyFiles(context.Background(), root, false)
+
+	if len(found) != 1 || found[0].Path != filepath.Join(root, "top.tmp") {
+		t.Errorf("ScanEmptyFiles() without subdirectories = %+v, want only top.tmp", found)
+	}
+}
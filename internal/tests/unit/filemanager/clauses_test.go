@@ -0,0 +1,169 @@
+package filemanager_test
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+func TestFileFilter_Clauses(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	createTestFilesWithTimes(t, root, map[string]struct {
+		size    int64
+		modTime time.Time
+	}{
+		"app.log":        {10, now.Add(-10 * 24 * time.Hour)},
+		"new.log":        {10, now.Add(-24 * time.Hour)},
+		"x.tmp":          {10, now.Add(-2 * 24 * time.Hour)},
+		"keep/old.tmp":   {10, now.Add(-2 * 24 * time.Hour)},
+		"disk.iso":       {10, now.Add(-100 * 24 * time.Hour)},
+		"recent.iso":     {10, now.Add(-10 * 24 * time.Hour)},
+		"notes/readme.a": {10, now.Add(-100 * 24 * time.Hour)},
+	})
+
+	filter := filemanager.NewFileFilterWithOptions(filemanager.FileFilterOptions{
+		Clauses: []filemanager.Clause{
+			{Spec: "keep/**:action=skip", Pattern: "keep/**", Action: filemanager.ActionSkip},
+			{Spec: "*.log:older=7d:action=archive", Pattern: "*.log", OlderThan: now.Add(-7 * 24 * time.Hour), Action: filemanager.ActionArchive},
+			{Spec: "*.tmp:older=1d:action=delete", Pattern: "*.tmp", OlderThan: now.Add(-24 * time.Hour), Action: filemanager.ActionDelete},
+			{Spec: "*.iso:older=90d:action=trash", Pattern: "*.iso", OlderThan: now.Add(-90 * 24 * time.Hour), Action: filemanager.ActionTrash},
+		},
+	}, nil)
+
+	scanner := filemanager.NewFileScanner(filemanager.NewFileManager(), filter, false)
+	result := scanner.ScanFilesRecursively(context.Background(), root)
+
+	want := map[string]struct {
+		rule   string
+		action filemanager.FileAction
+	}{
+		"app.log":  {"*.log:older=7d:action=archive", filemanager.ActionArchive},
+		"x.tmp":    {"*.tmp:older=1d:action=delete", filemanager.ActionDelete},
+		"disk.iso": {"*.iso:older=90d:action=trash", filemanager.ActionTrash},
+	}
+	if len(result.Entries) != len(want) {
+		t.Errorf("matched %d files, want %d: %+v", len(result.Entries), len(want), result.Entries)
+	}
+	for _, entry := range result.Entries {
+		rel, _ := filepath.Rel(root, entry.Path)
+		expected, ok := want[filepath.ToSlash(rel)]
+		if !ok {
+			t.Errorf("%s should not match", rel)
+			continue
+		}
+		if entry.MatchedRule != expected.rule || entry.Action != expected.action {
+			t.Errorf("%s matched %q with %q, want %q with %q", rel, entry.MatchedRule, entry.Action, expected.rule, expected.action)
+		}
+	}
+}
+
+func TestFileFilter_ClausesFirstMatchWins(t *testing.T) {
+	root := t.TempDir()
+	createTestFilesWithTimes(t, root, map[string]struct {
+		size    int64
+		modTime time.Time
+	}{
+		"big.log":   {4096, time.Now()},
+		"small.log": {10, time.Now()},
+	})
+
+	filter := filemanager.NewFileFilterWithOptions(filemanager.FileFilterOptions{
+		Clauses: []filemanager.Clause{
+			{Spec: "*.log:min-size=1kb:action=archive", Pattern: "*.log", MinSize: 1024, Action: filemanager.ActionArchive},
+			{Spec: "*.log", Pattern: "*.log"},
+		},
+	}, nil)
+
+	for name, action := range map[string]filemanager.FileAction{"big.log": filemanager.ActionArchive, "small.log": filemanager.ActionDefault} {
+		path := filepath.Join(root, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", name, err)
+		}
+		clause := filter.MatchedClause(info, path)
+		if clause == nil || clause.Action != action {
+			t.Errorf("MatchedClause(%s) = %+v, want action %q", name, clause, action)
+		}
+	}
+
+	onlySkip := filemanager.NewFileFilterWithOptions(filemanager.FileFilterOptions{
+		Clauses: []filemanager.Clause{{Spec: "*:action=skip", Pattern: "*", Action: filemanager.ActionSkip}},
+	}, nil)
+	info, _ := os.Stat(filepath.Join(root, "big.log"))
+	if onlySkip.MatchesFilters(info, filepath.Join(root, "big.log")) {
+		t.Error("a list of only skip clauses should select nothing")
+	}
+}
+
+func TestParseFileAction(t *testing.T) {
+	for _, name := range []string{"", "delete", "Trash", "archive", "skip"} {
+		if _, err := filemanager.ParseFileAction(name); err != nil {
+			t.Errorf("ParseFileAction(%q) unexpected error: %v", name, err)
+		}
+	}
+	if _, err := filemanager.ParseFileAction("shred-it"); err == nil {
+		t.Error("ParseFileAction should reject unknown actions")
+	}
+	if got := filemanager.ActionDefault.Resolve(true); got != filemanager.ActionTrash {
+		t.Errorf("Resolve(true) = %q, want trash", got)
+	}
+	if got := filemanager.ActionArchive.Resolve(true); got != filemanager.ActionArchive {
+		t.Errorf("Resolve(true) = %q, want archive", got)
+	}
+}
+
+func TestArchiveFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	modTime := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	if err := os.WriteFile(path, []byte("line one\nline two\n"), 0640); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	os.Chtimes(path, modTime, modTime)
+
+	target, err := filemanager.ArchiveFile(path)
+	if err != nil {
+		t.Fatalf("ArchiveFile failed: %v", err)
+	}
+	if target != path+".gz" {
+		t.Errorf("ArchiveFile() = %s, want %s.gz", target, path)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("the original file should be removed")
+	}
+
+	f, err := os.Open(target)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	data, _ := io.ReadAll(zr)
+	if string(data) != "line one\nline two\n" || zr.Name != "app.log" {
+		t.Errorf("archive holds %q named %q", data, zr.Name)
+	}
+	if info, _ := os.Stat(target); !info.ModTime().Equal(modTime) {
+		t.Errorf("archive modified at %v, want %v", info.ModTime(), modTime)
+	}
+
+	// An existing archive is never overwritten
+	if err := os.WriteFile(path, []byte("again"), 0640); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if _, err := filemanager.ArchiveFile(path); err == nil {
+		t.Error("ArchiveFile should fail when the archive exists")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("the original should remain when archiving fails: %v", err)
+	}
+}
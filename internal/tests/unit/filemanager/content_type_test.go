@@ -0,0 +1,165 @@
+package filemanager_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+// createContentTestFiles writes files whose extensions do not tell their type
+func createContentTestFiles(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+
+	elf := make([]byte, 64)
+	copy(elf, "\x7fELF\x02\x01\x01")
+	core := append([]byte(nil), elf...)
+	elf[16] = 2  // ET_EXEC
+	core[16] = 4 // ET_CORE
+
+	tar := make([]byte, 512)
+	copy(tar, "notes.txt")
+	copy(tar[257:], "ustar\x0000")
+
+	iso := make([]byte, 0x8001+5)
+	copy(iso[0x8001:], "CD001")
+
+	files := map[string][]byte{
+		"download.bin":  []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+		"photo":         []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00"),
+		"backup.dat":    []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00"),
+		"bundle":        tar,
+		"disk.img":      iso,
+		"tool":          elf,
+		"core.1234":     core,
+		"app.log.3":     []byte("2024-01-01 started\n"),
+		"empty.txt":     nil,
+		"report.bin":    []byte("%PDF-1.7\n"),
+		"archive.7zbak": []byte("7z\xbc\xaf\x27\x1c\x00\x04"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(root, name), data, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return root
+}
+
+func TestDetectContentType(t *testing.T) {
+	root := createContentTestFiles(t)
+
+	tests := map[string]string{
+		"download.bin":  "image/png",
+		"photo":         "image/jpeg",
+		"backup.dat":    "application/gzip",
+		"bundle":        "application/x-tar",
+		"disk.img":      "application/x-iso9660-image",
+		"tool":          "application/x-executable",
+		"core.1234":     "application/x-coredump",
+		"app.log.3":     "text/plain",
+		"empty.txt":     "inode/x-empty",
+		"report.bin":    "application/pdf",
+		"archive.7zbak": "application/x-7z-compressed",
+	}
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := filemanager.DetectContentType(filepath.Join(root, name))
+			if err != nil {
+				t.Fatalf("DetectContentType() unexpected error: %v", err)
+			}
+			if got != want {
+				t.Errorf("DetectContentType() = %q, want %q", got, want)
+			}
+		})
+	}
+
+	if _, err := filemanager.DetectContentType(root); err == nil {
+		t.Error("DetectContentType(dir) error = nil, want an error")
+	}
+}
+
+func TestParseContentTypes(t *testing.T) {
+	got, err := filemanager.ParseContentTypes([]string{" Image/* ", "archive", "", "application/pdf"})
+	if err != nil {
+		t.Fatalf("ParseContentTypes() unexpected error: %v", err)
+	}
+	want := []string{"image/*", "archive", "application/pdf"}
+	if len(got) != len(want) {
+		t.Fatalf("ParseContentTypes() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ParseContentTypes()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"pictures", "image/", "/png", "a/b/c"} {
+		if _, err := filemanager.ParseContentTypes([]string{bad}); err == nil {
+			t.Errorf("ParseContentTypes(%q) error = nil, want an error", bad)
+		}
+	}
+}
+
+func TestContentTypeFilter(t *testing.T) {
+	root := createContentTestFiles(t)
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{`type in (image/*, archive)`, []string{"archive.7zbak", "backup.dat", "bundle", "download.bin", "photo"}},
+		{`type = core or type = disk-image`, []string{"core.1234", "disk.img"}},
+		{`type = executable and name = tool`, []string{"tool"}},
+		{`type != text/* and ext = .bin`, []string{"download.bin", "report.bin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := filemanager.ParseExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseExpr() unexpected error: %v", err)
+			}
+			got := matchingFiles(t, root, expr)
+			if len(got) != len(tt.want) {
+				t.Errorf("matched %v, want %v", got, tt.want)
+			}
+			for _, name := range tt.want {
+				if !got[name] {
+					t.Errorf("%s not matched, matched %v", name, got)
+				}
+			}
+		})
+	}
+
+	if _, err := filemanager.ParseExpr(`type ~ "image/*"`); err == nil {
+		t.Error("ParseExpr(type ~) error = nil, want an error")
+	}
+}
+
+func TestScanner_ReportsContentType(t *testing.T) {
+	root := createContentTestFiles(t)
+	filter := filemanager.NewFileFilterWithOptions(filemanager.FileFilterOptions{
+		MinSize:      1,
+		ContentTypes: []string{"image/*"},
+	}, nil)
+
+	// Sniffing comes after the cheap terms of the filter
+	expr := filter.Expr().(*filemanager.AndExpr)
+	if last := expr.Terms[len(expr.Terms)-1].String(); last != "type in (image/*)" {
+		t.Errorf("last term = %q, want the type term", last)
+	}
+
+	scanner := filemanager.NewFileScanner(filemanager.NewFileManager(), filter, false)
+	result := scanner.ScanFilesCurrentLevel(context.Background(), root)
+	want := map[string]string{"download.bin": "image/png", "photo": "image/jpeg"}
+	if result.Len() != len(want) {
+		t.Fatalf("scan found %d files, want %d", result.Len(), len(want))
+	}
+	for _, entry := range result.Entries {
+		if got := entry.ContentType; got != want[filepath.Base(entry.Path)] {
+			t.Errorf("%s ContentType = %q, want %q", entry.Path, got, want[filepath.Base(entry.Path)])
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package filemanager_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+func TestScanDirTargets_Guards(t *testing.T) {
+	now := time.Now()
+	longAgo := now.Add(-90 * 24 * time.Hour)
+
+	root := t.TempDir()
+	createTestFilesWithTimes(t, root, map[string]struct {
+		size    int64
+		modTime time.Time
+	}{
+		"web/package.json":                  {2, now},
+		"web/node_modules/react/index.js":   {300, longAgo},
+		"web/node_modules/react/README.md":  {200, longAgo},
+		"stale/package.json":                {2, now},
+		"stale/node_modules/lib/main.js":    {100, now},
+		"orphan/node_modules/lib/main.js":   {100, longAgo},
+		"web/node_modules/a/node_modules/b": {50, longAgo},
+	})
+	// Directory times are bumped by creating files in them, so age them afterwards
+	for _, dir := range []string{"web/node_modules", "web/node_modules/react", "web/node_modules/a", "web/node_modules/a/node_modules", "orphan/node_modules", "orphan/node_modules/lib"} {
+		os.Chtimes(filepath.Join(root, dir), longAgo, longAgo)
+	}
+
+	fm := filemanager.NewFileManager()
+	scanner := filemanager.NewFileScanner(fm, &filemanager.FileFilter{}, false)
+	targets := []filemanager.DirTarget{{
+		Pattern:        "node_modules",
+		RequireSibling: "package.json",
+		UntouchedSince: now.Add(-60 * 24 * time.Hour),
+	}}
+
+	matches, totalSize := scanner.ScanDirTargets(context.Background(), root, targets, true)
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d: %+v", len(matches), matches)
+	}
+	if matches[0].Path != filepath.Join(root, "web", "node_modules") {
+		t.Errorf("unexpected match %s", matches[0].Path)
+	}
+	if matches[0].Files != 3 || totalSize != 550 {
+		t.Errorf("expected 3 files and 550 bytes, got %d files and %d bytes", matches[0].Files, totalSize)
+	}
+
+	// Without recursion only direct children of the root are considered
+	matches, _ = scanner.ScanDirTargets(context.Background(), filepath.Join(root, "web"), []filemanager.DirTarget{{Pattern: "node_*"}}, false)
+	if len(matches) != 1 {
+		t.Errorf("expected the direct child to match, got %+v", matches)
+	}
+	matches, _ = scanner.ScanDirTargets(context.Background(), root, []filemanager.DirTarget{{Pattern: "node_*"}}, false)
+	if len(matches) != 0 {
+		t.Errorf("expected no matches below the first level, got %+v", matches)
+	}
+}
+
+func TestDeleteDir(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "build")
+	if err := os.MkdirAll(filepath.Join(target, "nested"), 0755); err != nil {
+		t.Fatalf("failed to create dirs: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "nested", "out.o"), []byte("obj"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	if err := filemanager.NewFileManager().DeleteDir(target); err != nil {
+		t.Fatalf("DeleteDir failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("failed to read root: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected parent to be empty, found %d entries", len(entries))
+	}
+	if err := filemanager.NewFileManager().DeleteDir(target); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestScanDirTargets_WalkLimits(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	files := map[string]struct {
+		size    int64
+		modTime time.Time
+	}{}
+	for _, path := range []string{
+		"app/node_modules/lib/main.js",
+		"deep/nested/app/node_modules/x.js",
+		"wide/node_modules/a.js",
+		"wide/node_modules/b.js",
+		"wide/node_modules/c.js",
+		"wide/node_modules/d.js",
+		"linked/real/index.js",
+	} {
+		files[path] = struct {
+			size    int64
+			modTime time.Time
+		}{10, now}
+	}
+	createTestFilesWithTimes(t, root, files)
+	if err := os.Symlink(filepath.Join(root, "linked", "real"), filepath.Join(root, "linked", "node_modules")); err != nil {
+		t.Skipf("symbolic links unavailable: %v", err)
+	}
+
+	fm := filemanager.NewFileManager()
+	targets := []filemanager.DirTarget{{Pattern: "node_modules"}}
+	paths := func(filter *filemanager.FileFilter) []string {
+		matches, _ := filemanager.NewFileScanner(fm, filter, false).ScanDirTargets(context.Background(), root, targets, true)
+		var paths []string
+		for _, match := range matches {
+			rel, _ := filepath.Rel(root, match.Path)
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return paths
+	}
+
+	// Links to directories are followed as the policy says but never matched
+	filter := &filemanager.FileFilter{}
+	filter.Symlinks = filemanager.SymlinkFollow
+	if got := paths(filter); len(got) != 3 {
+		t.Errorf("expected the three real node_modules, got %v", got)
+	}
+
+	filter = &filemanager.FileFilter{}
+	filter.MaxDepth = 2
+	if got := paths(filter); len(got) != 2 || got[0] != "app/node_modules" || got[1] != "wide/node_modules" {
+		t.Errorf("expected matches within depth 2, got %v", got)
+	}
+
+	// A target holding more entries than the limit cannot be measured and is left alone
+	filter = &filemanager.FileFilter{}
+	filter.MaxDirEntries = 3
+	if got := paths(filter); len(got) != 2 || got[0] != "app/node_modules" || got[1] != "deep/nested/app/node_modules" {
+		t.Errorf("expected the wide target to be skipped, got %v", got)
+	}
+}
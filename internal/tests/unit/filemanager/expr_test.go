@@ -0,0 +1,186 @@
+package filemanager_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+// matchingFiles returns the files under root an expression matches, by path
+// relative to root
+func matchingFiles(t *testing.T, root string, expr filemanager.Expr) map[string]bool {
+	t.Helper()
+	matched := make(map[string]bool)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if expr.Match(info, path) {
+			rel, _ := filepath.Rel(root, path)
+			matched[filepath.ToSlash(rel)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to walk %s: %v", root, err)
+	}
+	return matched
+}
+
+func createExprTestFiles(t *testing.T) string {
+	root := t.TempDir()
+	now := time.Now()
+	createTestFilesWithTimes(t, root, map[string]struct {
+		size    int64
+		modTime time.Time
+	}{
+		"logs/app.log": {2048, now.Add(-10 * 24 * time.Hour)},
+		"logs/new.log": {10, now.Add(-24 * time.Hour)},
+		"tmp/x.tmp":    {10, now.Add(-2 * 24 * time.Hour)},
+		"keep/old.log": {10, now.Add(-10 * 24 * time.Hour)},
+		"a.txt":        {10, now},
+	})
+	return root
+}
+
+func TestParseExpr_Matches(t *testing.T) {
+	root := createExprTestFiles(t)
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{`ext in (log,tmp) and age > 7d and not path ~ "keep/**"`, []string{"logs/app.log"}},
+		{`(ext = log and age > 7d) or (ext = tmp and age > 1d)`, []string{"keep/old.log", "logs/app.log", "tmp/x.tmp"}},
+		{`size >= 1kb`, []string{"logs/app.log"}},
+		{`size < 2048 and age <= 1d`, []string{"a.txt"}},
+		{`name ~ "*.log" && !(dir = keep)`, []string{"logs/app.log", "logs/new.log"}},
+		{`ext not in (.log)`, []string{"a.txt", "tmp/x.tmp"}},
+		{`NAME = a.txt OR name = 'x.tmp'`, []string{"a.txt", "tmp/x.tmp"}},
+		{`path ~ "logs/*.log" and name != new.log`, []string{"logs/app.log"}},
+		{`path ~ "**/*.log" and not dir ~ "l?gs"`, []string{"keep/old.log"}},
+		{`name ~ "[!a-m]*" and name ~ "[m-o]*"`, []string{"keep/old.log", "logs/new.log"}},
+		{`perm = -u+r and mtime > 5d`, []string{"keep/old.log", "logs/app.log"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := filemanager.ParseExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseExpr() unexpected error: %v", err)
+			}
+			got := matchingFiles(t, root, expr)
+			if len(got) != len(tt.want) {
+				t.Errorf("matched %v, want %v", got, tt.want)
+			}
+			for _, name := range tt.want {
+				if !got[name] {
+					t.Errorf("%s not matched, matched %v", name, got)
+				}
+			}
+
+			// The printed expression parses back to the same matches
+			again, err := filemanager.ParseExpr(expr.String())
+			if err != nil {
+				t.Fatalf("ParseExpr(%q) unexpected error: %v", expr.String(), err)
+			}
+			if again.String() != expr.String() || len(matchingFiles(t, root, again)) != len(got) {
+				t.Errorf("reparsed %q as %q", expr.String(), again.String())
+			}
+		})
+	}
+
+	if expr, err := filemanager.ParseExpr("  "); expr != nil || err != nil {
+		t.Errorf("ParseExpr(blank) = %v, %v, want nil, nil", expr, err)
+	}
+}
+
+func TestParseExpr_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`agee > 7d`, `column 1: unknown field "agee"`},
+		{`ext in (log`, `column 12: expected , or ) in the list, found end of expression`},
+		{`ext = log and`, `column 14: expected a field, found end of expression`},
+		{`(ext = log`, `column 11: expected ) to close the ( at column 1`},
+		{`ext = log ext = tmp`, `column 11: unexpected "ext"`},
+		{`ext log`, `column 5: expected an operator after ext, found "log"`},
+		{`name = "half`, `column 8: unterminated string`},
+		{`name = and`, `column 8: expected a value, found "and"`},
+		{`size > lots`, `column 1: invalid size "lots"`},
+		{`age = 7d`, `column 1: age does not support =, use <, <=, > or >=`},
+		{`age > soon`, `invalid age "soon"`},
+		{`name < b`, `name does not support <`},
+		{`name ~ "[a"`, `syntax error in pattern`},
+		{`uid = alice`, `not a numeric user ID`},
+		{`owner = no-such-user-here`, `unknown user`},
+		{`perm = o+q`, `invalid permission mode`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := filemanager.ParseExpr(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseExpr(%q) error = %v, want it to contain %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckExpr_KeepsNames(t *testing.T) {
+	if err := filemanager.CheckExpr(`owner in (no-such-user-here) or group != no-such-group`); err != nil {
+		t.Errorf("CheckExpr() unexpected error: %v", err)
+	}
+	if err := filemanager.CheckExpr(`owner > root`); err == nil {
+		t.Error("CheckExpr() should reject operators owners do not support")
+	}
+	if err := filemanager.CheckExpr(`age > 7d and`); err == nil {
+		t.Error("CheckExpr() should report syntax errors")
+	}
+}
+
+func TestFileFilter_Expr(t *testing.T) {
+	root := createExprTestFiles(t)
+	now := time.Now()
+
+	where, err := filemanager.ParseExpr(`not name = new.log`)
+	if err != nil {
+		t.Fatalf("ParseExpr() unexpected error: %v", err)
+	}
+	filter := filemanager.NewFileFilterWithOptions(filemanager.FileFilterOptions{
+		Exclude:   []string{"keep"},
+		MaxSize:   4096,
+		OlderThan: now.Add(-12 * time.Hour),
+		Where:     where,
+	}, map[string]struct{}{".log": {}, ".tmp": {}})
+
+	expr := filter.Expr()
+	want := `not (path ~ **keep/** or name ~ keep*) and ext in (.log, .tmp) and size <= 4096 and age > 12h and not name = new.log`
+	if expr.String() != want {
+		t.Errorf("Expr() = %q, want %q", expr.String(), want)
+	}
+
+	// The flags and the expression printed from them select the same files
+	parsed, err := filemanager.ParseExpr(expr.String())
+	if err != nil {
+		t.Fatalf("ParseExpr(%q) unexpected error: %v", expr.String(), err)
+	}
+	byFlags, byExpr := matchingFiles(t, root, expr), matchingFiles(t, root, parsed)
+	if len(byFlags) != 2 || !byFlags["logs/app.log"] || !byFlags["tmp/x.tmp"] {
+		t.Errorf("flags matched %v, want logs/app.log and tmp/x.tmp", byFlags)
+	}
+	for name := range byFlags {
+		if !byExpr[name] {
+			t.Errorf("%s matched by the flags but not by %q", name, expr.String())
+		}
+	}
+
+	if filemanager.NewFileFilterWithOptions(filemanager.FileFilterOptions{}, nil).Expr() != nil {
+		t.Error("an empty filter should compile to nil")
+	}
+}
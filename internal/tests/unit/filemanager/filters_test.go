@@ -43,6 +43,7 @@ func TestFileFilter_FullRequirements(t *testing.T) {
 			modTime time.Time
 		}
 		exclude     []string
+		include     []string
 		extensions  map[string]struct{}
 		minSize     int64
 		maxSize     int64
@@ -101,6 +102,25 @@ func TestFileFilter_FullRequirements(t *testing.T) {
 				"now.log": false,
 			},
 		},
+		{
+			name: "IncludePatterns",
+			files: map[string]struct {
+				size    int64
+				modTime time.Time
+			}{
+				"app/node_modules/lib/index.js": {100, now},
+				"app/src/index.js":              {100, now},
+				"notes.txt.swp":                 {100, now},
+				"notes.txt":                     {100, now},
+			},
+			include: []string{"node_modules/", "*.swp"},
+			expectMatch: map[string]bool{
+				"app/node_modules/lib/index.js": true,
+				"app/src/index.js":              false,
+				"notes.txt.swp":                 true,
+				"notes.txt":                     false,
+			},
+		},
 		{
 			name: "CombinedFilters",
 			files: map[string]struct {
@@ -130,6 +150,7 @@ func TestFileFilter_FullRequirements(t *testing.T) {
 					MinSize:   tt.minSize,
 					MaxSize:   tt.maxSize,
 					Exclude:   tt.exclude,
+					Include:   tt.include,
 					OlderThan: tt.olderThan,
 					NewerThan: tt.newerThan,
 				},
@@ -0,0 +1,185 @@
+package filemanager_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+// backdate moves the mtime of every directory below root into the past, so
+// the index does not treat them as modified moments ago
+func backdate(t *testing.T, root string) {
+	t.Helper()
+
+	past := time.Now().Add(-time.Hour)
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.IsDir() {
+			os.Chtimes(path, past, past)
+		}
+		return nil
+	})
+}
+
+func openTestIndex(t *testing.T, indexPath string) *filemanager.DirIndex {
+	t.Helper()
+
+	index, err := filemanager.OpenDirIndex(indexPath)
+	if err != nil {
+		t.Fatalf("OpenDirIndex failed: %v", err)
+	}
+	return index
+}
+
+func TestDirIndex_ServesUnchangedDirectories(t *testing.T) {
+	root := t.TempDir()
+	indexPath := filepath.Join(t.TempDir(), "index.gob")
+	buildTree(t, root, 200)
+	backdate(t, root)
+
+	index := openTestIndex(t, indexPath)
+	if err := index.Rebuild(context.Background(), []string{root}); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+	if err := index.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A new process reads the listings back from disk
+	reopened := openTestIndex(t, indexPath)
+	stats := reopened.Stats()
+	if stats.Dirs != 21 || stats.Entries != 220 || stats.FileSize == 0 || stats.Updated.IsZero() {
+		t.Fatalf("Stats() = %+v, want 21 dirs and 220 entries", stats)
+	}
+
+	scanner := filemanager.NewFileScanner(filemanager.NewFileManagerWithIndex(reopened), &filemanager.FileFilter{}, false)
+	result := scanner.ScanFilesRecursively(context.Background(), root)
+	if result.Len() != 200 {
+		t.Errorf("indexed scan found %d files, want 200", result.Len())
+	}
+	if stats := reopened.Stats(); stats.Hits != 21 || stats.Misses != 0 {
+		t.Errorf("hits/misses = %d/%d, want 21/0", stats.Hits, stats.Misses)
+	}
+}
+
+func TestDirIndex_InvalidatesChangedDirectories(t *testing.T) {
+	root := t.TempDir()
+	buildTree(t, root, 30)
+	if err := os.MkdirAll(filepath.Join(root, "gone", "deeper"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	backdate(t, root)
+
+	index := openTestIndex(t, filepath.Join(t.TempDir(), "index.gob"))
+	if err := index.Rebuild(context.Background(), []string{root}); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+	before := index.Stats().Dirs
+
+	// Adding a file and removing a subtree both change the mtime of root
+	if err := os.WriteFile(filepath.Join(root, "new.log"), []byte("new"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(root, "gone")); err != nil {
+		t.Fatalf("Failed to remove dir: %v", err)
+	}
+
+	scanner := filemanager.NewFileScanner(filemanager.NewFileManagerWithIndex(index), &filemanager.FileFilter{}, false)
+	result := scanner.ScanFilesRecursively(context.Background(), root)
+	if result.Len() != 31 {
+		t.Errorf("scan found %d files, want 31", result.Len())
+	}
+
+	// root was just modified, so it is read again but not cached yet
+	if stats := index.Stats(); stats.Dirs != before-3 {
+		t.Errorf("Stats().Dirs = %d, want %d", stats.Dirs, before-3)
+	}
+}
+
+func TestDirIndex_RereadsMatchedFiles(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "app.log")
+	if err := os.WriteFile(target, []byte("small"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	backdate(t, root)
+
+	index := openTestIndex(t, filepath.Join(t.TempDir(), "index.gob"))
+	if err := index.Rebuild(context.Background(), []string{root}); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+
+	// Rewriting a file in place leaves the directory mtime untouched
+	if err := os.WriteFile(target, []byte("grown much larger"), 0644); err != nil {
+		t.Fatalf("Failed to rewrite file: %v", err)
+	}
+
+	fm := filemanager.NewFileManagerWithIndex(index)
+	scanner := filemanager.NewFileScanner(fm, &filemanager.FileFilter{}, false)
+	result := scanner.ScanFilesRecursively(context.Background(), root)
+	if result.Len() != 1 || result.Entries[0].Size != int64(len("grown much larger")) {
+		t.Errorf("scan reported %+v, want the current size of app.log", result.Entries)
+	}
+	if index.Stats().Hits == 0 {
+		t.Error("expected the directory to be served from the index")
+	}
+}
+
+func TestDirIndex_MatchesGrownFiles(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "app.log")
+	if err := os.WriteFile(target, []byte("small"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	past := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(target, past, past)
+	backdate(t, root)
+
+	index := openTestIndex(t, filepath.Join(t.TempDir(), "index.gob"))
+	if err := index.Rebuild(context.Background(), []string{root}); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+
+	// The file grows past the size limit and is modified now, which the
+	// listing in the index does not know about
+	if err := os.WriteFile(target, []byte("grown much larger"), 0644); err != nil {
+		t.Fatalf("Failed to rewrite file: %v", err)
+	}
+
+	fm := filemanager.NewFileManagerWithIndex(index)
+	for name, options := range map[string]filemanager.FileFilterOptions{
+		"min size": {MinSize: 10},
+		"newer":    {NewerThan: time.Now().Add(-time.Hour)},
+	} {
+		filter := filemanager.NewFileFilterWithOptions(options, nil)
+		result := filemanager.NewFileScanner(fm, filter, false).ScanFilesRecursively(context.Background(), root)
+		if result.Len() != 1 {
+			t.Errorf("%s: scan found %d files, want the rewritten app.log", name, result.Len())
+		}
+	}
+	if index.Stats().Hits == 0 {
+		t.Error("expected the directory to be served from the index")
+	}
+}
+
+func TestDirIndex_DiscardsOutdatedFile(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "index.gob")
+	if err := os.WriteFile(indexPath, []byte("not an index"), 0644); err != nil {
+		t.Fatalf("Failed to write index: %v", err)
+	}
+
+	index := openTestIndex(t, indexPath)
+	if stats := index.Stats(); stats.Dirs != 0 {
+		t.Errorf("Stats().Dirs = %d, want 0 for an unreadable index", stats.Dirs)
+	}
+
+	if err := index.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if _, err := os.Stat(indexPath); !os.IsNotExist(err) {
+		t.Errorf("Clear should remove the index file, stat err = %v", err)
+	}
+}
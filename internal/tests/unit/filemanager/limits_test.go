@@ -0,0 +1,197 @@
+package filemanager_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+// createDepthTestTree lays out files and empty folders at known depths,
+// with one folder holding many entries
+func createDepthTestTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := []string{"a.log", "sub/b.log", "sub/deep/c.log", "sub/deep/deeper/d.log"}
+	for i := 0; i < 5; i++ {
+		files = append(files, fmt.Sprintf("big/f%d.log", i))
+	}
+	for _, name := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	for _, name := range []string{"empty1", "sub/empty2", "sub/deep/empty3"} {
+		if err := os.MkdirAll(filepath.Join(root, name), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+	}
+	return root
+}
+
+// relPaths turns paths below root into sorted slash-separated relative paths
+func relPaths(root string, paths []string) []string {
+	rel := make([]string, 0, len(paths))
+	for _, path := range paths {
+		r, _ := filepath.Rel(root, path)
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	sort.Strings(rel)
+	return rel
+}
+
+func TestWalkLimits_Scanners(t *testing.T) {
+	root := createDepthTestTree(t)
+
+	tests := []struct {
+		name   string
+		limits filemanager.WalkLimits
+		files  []string
+		empty  []string
+	}{
+		{"no limits", filemanager.WalkLimits{},
+			[]string{"a.log", "big/f0.log", "big/f1.log", "big/f2.log", "big/f3.log", "big/f4.log", "sub/b.log", "sub/deep/c.log", "sub/deep/deeper/d.log"},
+			[]string{"empty1", "sub/deep/empty3", "sub/empty2"}},
+		{"max depth 2", filemanager.WalkLimits{MaxDepth: 2},
+			[]string{"a.log", "big/f0.log", "big/f1.log", "big/f2.log", "big/f3.log", "big/f4.log", "sub/b.log"},
+			[]string{"empty1", "sub/empty2"}},
+		{"min depth 3", filemanager.WalkLimits{MinDepth: 3},
+			[]string{"sub/deep/c.log", "sub/deep/deeper/d.log"},
+			[]string{"sub/deep/empty3"}},
+		{"depth 2 to 3", filemanager.WalkLimits{MinDepth: 2, MaxDepth: 3},
+			[]string{"big/f0.log", "big/f1.log", "big/f2.log", "big/f3.log", "big/f4.log", "sub/b.log", "sub/deep/c.log"},
+			[]string{"sub/deep/empty3", "sub/empty2"}},
+		{"max dir entries", filemanager.WalkLimits{MaxDirEntries: 4},
+			[]string{"a.log", "sub/b.log", "sub/deep/c.log", "sub/deep/deeper/d.log"},
+			[]string{"empty1", "sub/deep/empty3", "sub/empty2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := filemanager.NewFileManager()
+			filter := fm.NewFileFilter(0, 0, nil, nil, time.Time{}, time.Time{})
+			filter.WalkLimits = tt.limits
+			scanner := filemanager.NewFileScanner(fm, filter, false)
+
+			var scanned []string
+			for _, entry := range scanner.ScanFilesRecursively(context.Background(), root).Entries {
+				scanned = append(scanned, entry.Path)
+			}
+			assertPaths(t, "ScanFilesRecursively", relPaths(root, scanned), tt.files)
+
+			// The limits apply the same to directories listed from the index
+			index, err := filemanager.OpenDirIndex(filepath.Join(t.TempDir(), "index.json"))
+			if err != nil {
+				t.Fatalf("OpenDirIndex() unexpected error: %v", err)
+			}
+			indexed := filemanager.NewFileManagerWithIndex(index)
+			for pass := 0; pass < 2; pass++ {
+				var listed []string
+				for _, entry := range filemanager.NewFileScanner(indexed, filter, false).ScanFilesRecursively(context.Background(), root).Entries {
+					listed = append(listed, entry.Path)
+				}
+				assertPaths(t, fmt.Sprintf("indexed ScanFilesRecursively pass %d", pass+1), relPaths(root, listed), tt.files)
+			}
+
+			var walkerFiles []string
+			filemanager.NewTreeWalker(root, filter).Walk(context.Background(), root, true, func(path string, info os.FileInfo) {
+				walkerFiles = append(walkerFiles, path)
+			})
+			assertPaths(t, "TreeWalker.Walk", relPaths(root, walkerFiles), tt.files)
+
+			var mu sync.Mutex
+			var walked []string
+			err = fm.WalkFilesWithFilter(context.Background(), func(fi os.FileInfo, path string) {
+				if !fi.IsDir() {
+					mu.Lock()
+					walked = append(walked, path)
+					mu.Unlock()
+				}
+			}, root, filter)
+			if err != nil {
+				t.Fatalf("WalkFilesWithFilter() unexpected error: %v", err)
+			}
+			assertPaths(t, "WalkFilesWithFilter", relPaths(root, walked), tt.files)
+
+			assertPaths(t, "ScanEmptySubFolders", relPaths(root, scanner.ScanEmptySubFolders(context.Background(), root)), tt.empty)
+
+			sized := filemanager.WithWalkLimits(fm, tt.limits).CalculateDirSize(context.Background(), root)
+			if sized != int64(len(tt.files)) {
+				t.Errorf("CalculateDirSize() = %d, want %d", sized, len(tt.files))
+			}
+		})
+	}
+}
+
+func TestTreeWalker_Subtrees(t *testing.T) {
+	root := createDepthTestTree(t)
+	fm := filemanager.NewFileManager()
+	filter := fm.NewFileFilter(0, 0, nil, nil, time.Time{}, time.Time{})
+	filter.WalkLimits = filemanager.WalkLimits{MaxDepth: 3, MaxDirEntries: 4}
+	walker := filemanager.NewTreeWalker(root, filter)
+
+	assertPaths(t, "Subdirs", relPaths(root, walker.Subdirs(context.Background(), root)), []string{"empty1", "sub", "sub/deep", "sub/empty2"})
+	for dir, reads := range map[string]bool{"sub/deep": true, "sub/deep/deeper": false, "big": false} {
+		if got := walker.Reads(filepath.Join(root, dir)); got != reads {
+			t.Errorf("Reads(%s) = %v, want %v", dir, got, reads)
+		}
+	}
+
+	// A subtree is walked with the depths of the whole tree
+	var files []string
+	walker.Walk(context.Background(), filepath.Join(root, "sub"), true, func(path string, info os.FileInfo) {
+		files = append(files, path)
+	})
+	assertPaths(t, "Walk(sub)", relPaths(root, files), []string{"sub/b.log", "sub/deep/c.log"})
+}
+
+func TestScanEmptySubFolders_MissingDir(t *testing.T) {
+	fm := filemanager.NewFileManager()
+	scanner := filemanager.NewFileScanner(fm, fm.NewFileFilter(0, 0, nil, nil, time.Time{}, time.Time{}), false)
+	if empty := scanner.ScanEmptySubFolders(context.Background(), filepath.Join(t.TempDir(), "missing")); len(empty) != 0 {
+		t.Errorf("ScanEmptySubFolders() of a missing dir = %v, want none", empty)
+	}
+}
+
+func TestWalkLimits_CurrentLevel(t *testing.T) {
+	root := createDepthTestTree(t)
+	fm := filemanager.NewFileManager()
+	filter := fm.NewFileFilter(0, 0, nil, nil, time.Time{}, time.Time{})
+
+	filter.WalkLimits = filemanager.WalkLimits{MinDepth: 2}
+	if result := filemanager.NewFileScanner(fm, filter, false).ScanFilesCurrentLevel(context.Background(), root); result.Len() != 0 {
+		t.Errorf("ScanFilesCurrentLevel() with min depth 2 found %d files, want 0", result.Len())
+	}
+}
+
+func TestWalkLimits_Validate(t *testing.T) {
+	valid := []filemanager.WalkLimits{{}, {MinDepth: 2, MaxDepth: 2}, {MinDepth: 3}, {MaxDirEntries: 1000}}
+	for _, limits := range valid {
+		if err := limits.Validate(); err != nil {
+			t.Errorf("%+v.Validate() unexpected error: %v", limits, err)
+		}
+	}
+	invalid := []filemanager.WalkLimits{{MinDepth: -1}, {MaxDepth: -1}, {MaxDirEntries: -1}, {MinDepth: 3, MaxDepth: 2}}
+	for _, limits := range invalid {
+		if err := limits.Validate(); err == nil {
+			t.Errorf("%+v.Validate() error = nil, want an error", limits)
+		}
+	}
+}
+
+func assertPaths(t *testing.T, name string, got, want []string) {
+	t.Helper()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
@@ -0,0 +1,151 @@
+//go:build linux
+// +build linux
+
+package filemanager_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+// scanWithPolicy scans root recursively for every file under a symlink policy
+func scanWithPolicy(root string, policy filemanager.SymlinkPolicy, extensions ...string) filemanager.ScanResult {
+	fm := filemanager.NewFileManager()
+	exts := make(map[string]struct{})
+	for _, ext := range extensions {
+		exts[ext] = struct{}{}
+	}
+	filter := fm.NewFileFilter(0, 0, exts, nil, time.Time{}, time.Time{})
+	filter.Symlinks = policy
+	return filemanager.NewFileScanner(fm, filter, false).ScanFilesRecursively(context.Background(), root)
+}
+
+func TestParseSymlinkPolicy(t *testing.T) {
+	for _, name := range []string{"", "never", "Follow", " broken "} {
+		if _, err := filemanager.ParseSymlinkPolicy(name); err != nil {
+			t.Errorf("ParseSymlinkPolicy(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := filemanager.ParseSymlinkPolicy("always"); err == nil {
+		t.Error("ParseSymlinkPolicy(\"always\") should fail")
+	}
+}
+
+func TestScan_FollowsSymlinksOnce(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	createDirStructure(t, root, []string{"sub"}, map[string]string{"sub/a.log": "aaaa"}, nil)
+	createDirStructure(t, outside, nil, map[string]string{"b.log": "bb"}, nil)
+
+	// A link out of the tree, a link back to the root and a second path to sub
+	for link, target := range map[string]string{"out": outside, "sub/loop": root, "again": filepath.Join(root, "sub")} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Fatalf("Failed to create link: %v", err)
+		}
+	}
+
+	never := scanWithPolicy(root, filemanager.SymlinkNever, ".log")
+	if never.Len() != 1 {
+		t.Errorf("never policy found %d files, want 1", never.Len())
+	}
+
+	follow := scanWithPolicy(root, filemanager.SymlinkFollow, ".log")
+	if follow.Len() != 2 || follow.TotalSize != 6 {
+		t.Errorf("follow policy found %d files of %d bytes, want 2 files of 6 bytes: %+v", follow.Len(), follow.TotalSize, follow.Entries)
+	}
+}
+
+func TestScan_ReportsBrokenLinks(t *testing.T) {
+	root := t.TempDir()
+	createDirStructure(t, root, nil, map[string]string{"a.log": "a"}, nil)
+	if err := os.Symlink(filepath.Join(root, "missing.log"), filepath.Join(root, "dangling")); err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "a.log"), filepath.Join(root, "alive")); err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+
+	result := scanWithPolicy(root, filemanager.SymlinkBroken, ".log")
+	if result.Len() != 1 || len(result.BrokenLinks) != 1 {
+		t.Fatalf("found %d files and %d broken links, want 1 and 1", result.Len(), len(result.BrokenLinks))
+	}
+	if link := result.BrokenLinks[0]; link.Path != filepath.Join(root, "dangling") || !link.BrokenLink {
+		t.Errorf("broken link = %+v, want %s", link, filepath.Join(root, "dangling"))
+	}
+
+	if never := scanWithPolicy(root, filemanager.SymlinkNever); len(never.BrokenLinks) != 0 {
+		t.Errorf("never policy reported broken links: %+v", never.BrokenLinks)
+	}
+}
+
+func TestScanBrokenLinks(t *testing.T) {
+	root := t.TempDir()
+	createDirStructure(t, root, []string{"a/b", "cache"}, map[string]string{"a/file.txt": "x"}, nil)
+	links := map[string]string{
+		"a/b/dangling":   filepath.Join(root, "gone"),
+		"a/alive":        filepath.Join(root, "a/file.txt"),
+		"cache/dangling": filepath.Join(root, "gone"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Fatalf("Failed to create link: %v", err)
+		}
+	}
+
+	fm := filemanager.NewFileManager()
+	scanner := filemanager.NewFileScanner(fm, fm.NewFileFilter(0, 0, nil, []string{"cache"}, time.Time{}, time.Time{}), false)
+	found := scanner.ScanBrokenLinks(context.Background(), root, true)
+	if len(found) != 1 || found[0].Path != filepath.Join(root, "a/b/dangling") || !found[0].BrokenLink {
+		t.Errorf("ScanBrokenLinks() = %+v, want only a/b/dangling", found)
+	}
+}
+
+func TestScanResult_FreedSizeCountsHardLinksOnce(t *testing.T) {
+	root := t.TempDir()
+	createDirStructure(t, root, []string{"keep"}, map[string]string{"a.log": "12345678", "c.log": "xy"}, nil)
+	for _, link := range []string{"b.log", "keep/d.txt"} {
+		if err := os.Link(filepath.Join(root, "a.log"), filepath.Join(root, link)); err != nil {
+			t.Fatalf("Failed to create hard link: %v", err)
+		}
+	}
+
+	// keep/d.txt is not matched, so removing a.log and b.log frees nothing
+	partial := scanWithPolicy(root, filemanager.SymlinkNever, ".log")
+	if partial.TotalSize != 18 || partial.FreedSize() != 2 {
+		t.Errorf("TotalSize = %d, FreedSize = %d, want 18 and 2", partial.TotalSize, partial.FreedSize())
+	}
+
+	all := scanWithPolicy(root, filemanager.SymlinkNever)
+	if all.TotalSize != 26 || all.FreedSize() != 10 {
+		t.Errorf("TotalSize = %d, FreedSize = %d, want 26 and 10", all.TotalSize, all.FreedSize())
+	}
+
+	paths := []string{filepath.Join(root, "a.log"), filepath.Join(root, "b.log"), filepath.Join(root, "keep", "d.txt")}
+	if freed := filemanager.FreedSizeOf(paths); freed != 8 {
+		t.Errorf("FreedSizeOf() = %d, want 8", freed)
+	}
+}
+
+func TestScanDirTargets_HardLinksAcrossMatches(t *testing.T) {
+	root := t.TempDir()
+	createDirStructure(t, root, []string{"one/node_modules", "two/node_modules"}, map[string]string{"one/node_modules/pkg.js": "1234567890"}, nil)
+	if err := os.Link(filepath.Join(root, "one/node_modules/pkg.js"), filepath.Join(root, "two/node_modules/pkg.js")); err != nil {
+		t.Fatalf("Failed to create hard link: %v", err)
+	}
+
+	fm := filemanager.NewFileManager()
+	scanner := filemanager.NewFileScanner(fm, fm.NewFileFilter(0, 0, nil, nil, time.Time{}, time.Time{}), false)
+	matches, total := scanner.ScanDirTargets(context.Background(), root, []filemanager.DirTarget{{Pattern: "node_modules"}}, true)
+	if len(matches) != 2 {
+		t.Fatalf("found %d directories, want 2", len(matches))
+	}
+	// Each directory alone frees nothing, both together free the file once
+	if matches[0].Size != 0 || matches[1].Size != 0 || total != 10 {
+		t.Errorf("sizes = %d, %d, total %d, want 0, 0 and 10", matches[0].Size, matches[1].Size, total)
+	}
+}
@@ -0,0 +1,68 @@
+//go:build linux
+// +build linux
+
+package filemanager_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+	"golang.org/x/sys/unix"
+)
+
+func TestReadMounts_ListsRoot(t *testing.T) {
+	mounts, err := filemanager.ReadMounts()
+	if err != nil {
+		t.Fatalf("ReadMounts failed: %v", err)
+	}
+	for _, mount := range mounts {
+		if mount.Point == "/" && mount.Type != "" {
+			return
+		}
+	}
+	t.Errorf("ReadMounts() = %+v, want an entry for /", mounts)
+}
+
+// TestScan_StaysOnFilesystem mounts a tmpfs inside the scanned tree, which
+// needs privileges the test run usually does not have
+func TestScan_StaysOnFilesystem(t *testing.T) {
+	root := t.TempDir()
+	mountPoint := filepath.Join(root, "mnt")
+	if err := os.Mkdir(mountPoint, 0755); err != nil {
+		t.Fatalf("Failed to create mount point: %v", err)
+	}
+	if err := unix.Mount("tmpfs", mountPoint, "tmpfs", 0, "size=1m"); err != nil {
+		t.Skipf("cannot mount tmpfs: %v", err)
+	}
+	defer unix.Unmount(mountPoint, 0)
+
+	for _, file := range []string{filepath.Join(root, "local.log"), filepath.Join(mountPoint, "mounted.log")} {
+		if err := os.WriteFile(file, []byte("data"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", file, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options filemanager.FileFilterOptions
+		want    int
+	}{
+		{"crosses mounts by default", filemanager.FileFilterOptions{}, 2},
+		{"one file system", filemanager.FileFilterOptions{OneFileSystem: true}, 1},
+		{"skipped type", filemanager.FileFilterOptions{SkipFilesystems: []string{"tmpfs"}}, 1},
+		{"other type", filemanager.FileFilterOptions{SkipFilesystems: []string{"network"}}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := filemanager.NewFileFilterWithOptions(tt.options, nil)
+			scanner := filemanager.NewFileScanner(filemanager.NewFileManager(), filter, false)
+			if got := scanner.ScanFilesRecursively(context.Background(), root).Len(); got != tt.want {
+				t.Errorf("scan found %d files, want %d", got, tt.want)
+			}
+		})
+	}
+}
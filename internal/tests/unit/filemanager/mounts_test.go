@@ -0,0 +1,30 @@
+package filemanager_test
+
+import (
+	"testing"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+func TestMatchFilesystem(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		fsType   string
+		want     bool
+	}{
+		{[]string{"network"}, "nfs4", true},
+		{[]string{"network"}, "ext4", false},
+		{[]string{"pseudo"}, "proc", true},
+		{[]string{"pseudo"}, "tmpfs", false},
+		{[]string{"fuse.*"}, "fuse.sshfs", true},
+		{[]string{"NFS*"}, "nfs", true},
+		{[]string{"btrfs", "xfs"}, "xfs", true},
+		{nil, "proc", false},
+	}
+
+	for _, tt := range tests {
+		if got := filemanager.MatchFilesystem(tt.patterns, tt.fsType); got != tt.want {
+			t.Errorf("MatchFilesystem(%v, %q) = %v, want %v", tt.patterns, tt.fsType, got, tt.want)
+		}
+	}
+}
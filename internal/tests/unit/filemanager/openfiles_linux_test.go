@@ -0,0 +1,107 @@
+//go:build linux
+// +build linux
+
+package filemanager_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+func TestReadOpenFiles_FindsOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	openPath := filepath.Join(dir, "open.log")
+	closedPath := filepath.Join(dir, "closed.log")
+	for _, path := range []string{openPath, closedPath} {
+		if err := os.WriteFile(path, []byte("line\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Open(openPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	openFiles, err := filemanager.ReadOpenFiles()
+	if err != nil {
+		t.Fatalf("ReadOpenFiles() error = %v", err)
+	}
+	openInfo, _ := os.Stat(openPath)
+	closedInfo, _ := os.Stat(closedPath)
+	if !openFiles.Contains(openInfo) {
+		t.Errorf("Contains(%s) = false, want true", openPath)
+	}
+	if openFiles.Contains(closedInfo) {
+		t.Errorf("Contains(%s) = true, want false", closedPath)
+	}
+
+	var none *filemanager.OpenFiles
+	if none.Contains(openInfo) || none.Len() != 0 || none.DeletedSize(dir) != 0 {
+		t.Error("nil OpenFiles holds files, want none")
+	}
+}
+
+func TestReadOpenFiles_DeletedButOpen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rotated.log")
+	if err := os.WriteFile(path, make([]byte, 4096), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	openFiles, err := filemanager.ReadOpenFiles()
+	if err != nil {
+		t.Fatalf("ReadOpenFiles() error = %v", err)
+	}
+	deleted := openFiles.DeletedBelow(dir)
+	if len(deleted) != 1 || deleted[0].Path != path || deleted[0].PID != os.Getpid() || deleted[0].Size != 4096 {
+		t.Fatalf("DeletedBelow() = %+v, want %s of 4096 bytes held by this process", deleted, path)
+	}
+	if size := openFiles.DeletedSize(dir); size != 4096 {
+		t.Errorf("DeletedSize() = %d, want 4096", size)
+	}
+	if below := openFiles.DeletedBelow(filepath.Join(dir, "other")); len(below) != 0 {
+		t.Errorf("DeletedBelow(other) = %+v, want none", below)
+	}
+}
+
+func TestFileScanner_MarksFilesInUse(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"busy.log", "idle.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Open(filepath.Join(dir, "busy.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	fm := filemanager.NewFileManager()
+	filter := fm.NewFileFilter(0, 0, nil, nil, time.Time{}, time.Time{})
+	if filter.OpenFiles, err = filemanager.ReadOpenFiles(); err != nil {
+		t.Fatal(err)
+	}
+	result := filemanager.NewFileScanner(fm, filter, false).ScanFilesRecursively(context.Background(), dir)
+
+	if result.Len() != 1 || filepath.Base(result.Entries[0].Path) != "idle.log" {
+		t.Errorf("Entries = %+v, want only idle.log", result.Entries)
+	}
+	if len(result.InUse) != 1 || filepath.Base(result.InUse[0].Path) != "busy.log" || result.InUseSize() != int64(len("busy.log")) {
+		t.Errorf("InUse = %+v, want busy.log", result.InUse)
+	}
+}
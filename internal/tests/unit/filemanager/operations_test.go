@@ -1,11 +1,11 @@
 package filemanager_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"reflect"
 	"sort"
-	"strings"
 	"testing"
 	"time"
 
@@ -396,7 +396,7 @@ func TestDeleteFiles(t *testing.T) {
 			fm := filemanager.NewFileManager()
 
 			// Execute DeleteFiles
-			fm.DeleteFiles(root, tt.extensions, tt.exclude, tt.minSize, tt.maxSize, tt.olderThan, tt.newerThan)
+			fm.DeleteFiles(context.Background(), root, tt.extensions, tt.exclude, tt.minSize, tt.maxSize, tt.olderThan, tt.newerThan)
 
 			// Verify files that should not exist
 			for _, path := range tt.shouldNotExist {
@@ -573,29 +573,26 @@ func TestScanFilesCurrentLevel(t *testing.T) {
 			fm := filemanager.NewFileManager()
 			filter := fm.NewFileFilter(tt.minSize, tt.maxSize, utils.ParseExtToMap(tt.extensions), tt.exclude, tt.olderThan, tt.newerThan)
 			scanner := filemanager.NewFileScanner(fm, filter, false)
-			files, totalSize := scanner.ScanFilesCurrentLevel(root)
+			result := scanner.ScanFilesCurrentLevel(context.Background(), root)
 
-			if totalSize != tt.expectedSize {
-				t.Errorf("expected total size %d, got %d", tt.expectedSize, totalSize)
+			if result.TotalSize != tt.expectedSize {
+				t.Errorf("expected total size %d, got %d", tt.expectedSize, result.TotalSize)
 			}
 
-			if len(files) != len(tt.expectedFiles) {
-				t.Errorf("expected %d files, got %d", len(tt.expectedFiles), len(files))
+			if result.Len() != len(tt.expectedFiles) {
+				t.Errorf("expected %d files, got %d", len(tt.expectedFiles), result.Len())
 			}
 
+			sizes := make(map[string]int64, result.Len())
+			for _, entry := range result.Entries {
+				sizes[entry.Path] = entry.Size
+			}
 			for path, expectedSize := range tt.expectedFiles {
 				fullPath := filepath.Join(root, path)
-				if formattedSize, exists := files[fullPath]; !exists {
+				if size, exists := sizes[fullPath]; !exists {
 					t.Errorf("expected file %s to be found", path)
-				} else {
-					// Remove the space before the unit
-					formattedSize = strings.ReplaceAll(formattedSize, " ", "")
-					parsedSize, err := utils.ToBytes(formattedSize)
-					if err != nil {
-						t.Errorf("failed to parse size %s: %v", formattedSize, err)
-					} else if parsedSize != expectedSize {
-						t.Errorf("expected size %d for file %s, got %d", expectedSize, path, parsedSize)
-					}
+				} else if size != expectedSize {
+					t.Errorf("expected size %d for file %s, got %d", expectedSize, path, size)
 				}
 			}
 		})
@@ -800,28 +797,26 @@ func TestScanFilesRecursively(t *testing.T) {
 			fm := filemanager.NewFileManager()
 			filter := fm.NewFileFilter(tt.minSize, tt.maxSize, utils.ParseExtToMap(tt.extensions), tt.exclude, tt.olderThan, tt.newerThan)
 			scanner := filemanager.NewFileScanner(fm, filter, false)
-			files, totalSize := scanner.ScanFilesRecursively(root)
+			result := scanner.ScanFilesRecursively(context.Background(), root)
 
-			if totalSize != tt.expectedSize {
-				t.Errorf("expected total size %d, got %d", tt.expectedSize, totalSize)
+			if result.TotalSize != tt.expectedSize {
+				t.Errorf("expected total size %d, got %d", tt.expectedSize, result.TotalSize)
 			}
 
-			if len(files) != len(tt.expectedFiles) {
-				t.Errorf("expected %d files, got %d", len(tt.expectedFiles), len(files))
+			if result.Len() != len(tt.expectedFiles) {
+				t.Errorf("expected %d files, got %d", len(tt.expectedFiles), result.Len())
 			}
 
+			sizes := make(map[string]int64, result.Len())
+			for _, entry := range result.Entries {
+				sizes[entry.Path] = entry.Size
+			}
 			for path, expectedSize := range tt.expectedFiles {
 				fullPath := filepath.Join(root, path)
-				if formattedSize, exists := files[fullPath]; !exists {
+				if size, exists := sizes[fullPath]; !exists {
 					t.Errorf("expected file %s to be found", path)
-				} else {
-					formattedSize = strings.ReplaceAll(formattedSize, " ", "")
-					parsedSize, err := utils.ToBytes(formattedSize)
-					if err != nil {
-						t.Errorf("failed to parse size %s: %v", formattedSize, err)
-					} else if parsedSize != expectedSize {
-						t.Errorf("expected size %d for file %s, got %d", expectedSize, path, parsedSize)
-					}
+				} else if size != expectedSize {
+					t.Errorf("expected size %d for file %s, got %d", expectedSize, path, size)
 				}
 			}
 		})
@@ -887,7 +882,7 @@ func TestScanEmptySubFolders(t *testing.T) {
 			fm := filemanager.NewFileManager()
 			filter := fm.NewFileFilter(0, 0, nil, nil, time.Time{}, time.Time{})
 			scanner := filemanager.NewFileScanner(fm, filter, false)
-			emptyDirs := scanner.ScanEmptySubFolders(root)
+			emptyDirs := scanner.ScanEmptySubFolders(context.Background(), root)
 
 			// Convert expected paths to full paths
 			expectedEmptyFull := make([]string, len(tt.expectedEmpty))
@@ -917,3 +912,105 @@ func TestScanEmptySubFolders(t *testing.T) {
 		})
 	}
 }
+
+func TestWalkFilesWithFilter_Cancelled(t *testing.T) {
+	root := t.TempDir()
+	createDirStructure(t, root, []string{"sub"}, map[string]string{
+		"a.txt":     "a",
+		"sub/b.txt": "b",
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fm := filemanager.NewFileManager()
+	called := false
+	err := fm.WalkFilesWithFilter(ctx, func(fi os.FileInfo, path string) {
+		called = true
+	}, root, fm.NewFileFilter(0, 0, nil, nil, time.Time{}, time.Time{}))
+
+	if err != context.Canceled {
+		t.Errorf("WalkFilesWithFilter() error = %v, want context.Canceled", err)
+	}
+	if called {
+		t.Error("callback should not run after cancellation")
+	}
+	if err := fm.DeleteFiles(ctx, root, nil, nil, 0, 0, time.Time{}, time.Time{}); err != context.Canceled {
+		t.Errorf("DeleteFiles() error = %v, want context.Canceled", err)
+	}
+	verifyFileContents(t, filepath.Join(root, "a.txt"), "a")
+}
+
+func TestStreamFiles_Entries(t *testing.T) {
+	root := t.TempDir()
+	modTime := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	createDirStructure(t, root, []string{"cache"}, map[string]string{
+		"b.log":       "bb",
+		"a.log":       "a",
+		"notes.txt":   "text",
+		"cache/c.tmp": "ccc",
+	}, map[string]time.Time{"a.log": modTime})
+
+	fm := filemanager.NewFileManager()
+	filter := fm.NewFileFilter(0, 0, utils.ParseExtToMap([]string{".log"}), nil, time.Time{}, time.Time{})
+	filter.Include = []string{"cache/", "*.log"}
+	scanner := filemanager.NewFileScanner(fm, filter, false)
+
+	streamed := 0
+	for entry := range scanner.StreamFiles(context.Background(), root, true) {
+		streamed++
+		if entry.Mode.IsDir() || entry.Size == 0 {
+			t.Errorf("unexpected entry %+v", entry)
+		}
+	}
+	if streamed != 2 {
+		t.Errorf("streamed %d entries, want 2", streamed)
+	}
+
+	result := scanner.ScanFilesRecursively(context.Background(), root)
+	if result.Len() != 2 || result.TotalSize != 3 {
+		t.Fatalf("result = %+v, want a.log and b.log totalling 3 bytes", result)
+	}
+	first := result.Entries[0]
+	if first.Path != filepath.Join(root, "a.log") || result.Entries[1].Path != filepath.Join(root, "b.log") {
+		t.Errorf("entries are not sorted by path: %s, %s", first.Path, result.Entries[1].Path)
+	}
+	if !first.ModTime.Equal(modTime) || first.MatchedRule != "*.log" {
+		t.Errorf("entry = %+v, want mtime %v and rule *.log", first, modTime)
+	}
+
+	records := result.DeletionRecords()
+	if len(records) != 2 || records[1].Size != 2 {
+		t.Errorf("DeletionRecords() = %+v, want raw byte sizes", records)
+	}
+}
+
+func TestEachFile_MatchesStreamFiles(t *testing.T) {
+	root := t.TempDir()
+	createDirStructure(t, root, []string{"a", "a/b"}, map[string]string{
+		"top.log":     "1",
+		"a/mid.log":   "22",
+		"a/b/low.log": "333",
+		"a/b/low.txt": "4444",
+	}, nil)
+
+	fm := filemanager.NewFileManager()
+	filter := fm.NewFileFilter(0, 0, utils.ParseExtToMap([]string{".log"}), nil, time.Time{}, time.Time{})
+	scanner := filemanager.NewFileScanner(fm, filter, false)
+
+	for _, recursive := range []bool{true, false} {
+		var got []string
+		scanner.EachFile(context.Background(), root, recursive, func(entry filemanager.FileEntry) {
+			got = append(got, entry.Path)
+		})
+		var want []string
+		for entry := range scanner.StreamFiles(context.Background(), root, recursive) {
+			want = append(want, entry.Path)
+		}
+		sort.Strings(got)
+		sort.Strings(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("EachFile(recursive=%v) = %v, want %v", recursive, got, want)
+		}
+	}
+}
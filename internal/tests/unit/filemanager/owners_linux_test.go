@@ -0,0 +1,77 @@
+//go:build linux
+// +build linux
+
+package filemanager_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+func TestFileFilter_Owners(t *testing.T) {
+	root := t.TempDir()
+	uid, gid := os.Getuid(), os.Getgid()
+	other := uid + 1
+
+	path := filepath.Join(root, "owned.bin")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	// The umask may clear bits on create
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatalf("Failed to chmod file: %v", err)
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+
+	worldWritable, _ := filemanager.ParsePermFilter("-o+w")
+	readable, _ := filemanager.ParsePermFilter("-u+r")
+
+	tests := []struct {
+		name    string
+		options filemanager.FileFilterOptions
+		want    bool
+	}{
+		{"no limits", filemanager.FileFilterOptions{}, true},
+		{"owner", filemanager.FileFilterOptions{Owners: []int{uid}}, true},
+		{"other owner", filemanager.FileFilterOptions{Owners: []int{other}}, false},
+		{"uid", filemanager.FileFilterOptions{UIDs: []int{other, uid}}, true},
+		{"not owner", filemanager.FileFilterOptions{NotOwners: []int{uid}}, false},
+		{"not other owner", filemanager.FileFilterOptions{NotOwners: []int{other}}, true},
+		{"group", filemanager.FileFilterOptions{Groups: []int{gid}}, true},
+		{"other group", filemanager.FileFilterOptions{Groups: []int{gid + 1}}, false},
+		{"perm", filemanager.FileFilterOptions{Perm: readable}, true},
+		{"perm not set", filemanager.FileFilterOptions{Perm: worldWritable}, false},
+		{"where owner", filemanager.FileFilterOptions{Where: mustParseExpr(t, fmt.Sprintf("uid = %d and group != %d", uid, gid+1))}, true},
+		{"where not owner", filemanager.FileFilterOptions{Where: mustParseExpr(t, fmt.Sprintf("uid not in (%d)", uid))}, false},
+		{"where changed", filemanager.FileFilterOptions{Where: mustParseExpr(t, "ctime < 1h and atime < 1h")}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := filemanager.NewFileFilterWithOptions(tt.options, nil)
+			if got := filter.MatchesFilters(info, path); got != tt.want {
+				t.Errorf("MatchesFilters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if name := filemanager.OwnerName(uid); name == "" {
+		t.Errorf("OwnerName(%d) is empty", uid)
+	}
+}
+
+func mustParseExpr(t *testing.T, input string) filemanager.Expr {
+	t.Helper()
+	expr, err := filemanager.ParseExpr(input)
+	if err != nil {
+		t.Fatalf("ParseExpr(%q) unexpected error: %v", input, err)
+	}
+	return expr
+}
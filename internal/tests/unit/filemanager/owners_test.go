@@ -0,0 +1,89 @@
+package filemanager_test
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+func TestParsePermFilter(t *testing.T) {
+	tests := []struct {
+		spec    string
+		bits    uint32
+		match   filemanager.PermMatch
+		wantErr bool
+	}{
+		{"644", 0644, filemanager.PermExact, false},
+		{"-o+w", 0002, filemanager.PermAll, false},
+		{"/u+x,g+x", 0110, filemanager.PermAny, false},
+		{"u=rwx,go=rx", 0755, filemanager.PermExact, false},
+		{"-4000", 04000, filemanager.PermAll, false},
+		{"a+r,o-r", 0440, filemanager.PermExact, false},
+		{"-", 0, 0, true},
+		{"99", 0, 0, true},
+		{"o+q", 0, 0, true},
+		{"u", 0, 0, true},
+		{"17777", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			filter, err := filemanager.ParsePermFilter(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParsePermFilter(%q) error = nil, want an error", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePermFilter(%q) unexpected error: %v", tt.spec, err)
+			}
+			if filter.Bits != tt.bits || filter.Match != tt.match {
+				t.Errorf("ParsePermFilter(%q) = %o/%d, want %o/%d", tt.spec, filter.Bits, filter.Match, tt.bits, tt.match)
+			}
+			if filter.String() != tt.spec {
+				t.Errorf("String() = %q, want %q", filter.String(), tt.spec)
+			}
+		})
+	}
+
+	if filter, err := filemanager.ParsePermFilter(""); filter != nil || err != nil {
+		t.Errorf("ParsePermFilter(\"\") = %v, %v, want nil, nil", filter, err)
+	}
+}
+
+func TestPermFilter_Matches(t *testing.T) {
+	tests := []struct {
+		spec string
+		mode fs.FileMode
+		want bool
+	}{
+		{"644", 0644, true},
+		{"644", 0664, false},
+		{"-o+w", 0666, true},
+		{"-o+w", 0644, false},
+		{"-u+rw", 0600, true},
+		{"-u+rw", 0400, false},
+		{"/o+w,g+w", 0620, true},
+		{"/o+w,g+w", 0600, false},
+		{"-u+s", 0755 | fs.ModeSetuid, true},
+		{"-u+s", 0755, false},
+		{"-o+t", 0777 | fs.ModeSticky | fs.ModeDir, true},
+	}
+
+	for _, tt := range tests {
+		filter, err := filemanager.ParsePermFilter(tt.spec)
+		if err != nil {
+			t.Fatalf("ParsePermFilter(%q) unexpected error: %v", tt.spec, err)
+		}
+		if got := filter.Matches(tt.mode); got != tt.want {
+			t.Errorf("%q.Matches(%v) = %v, want %v", tt.spec, tt.mode, got, tt.want)
+		}
+	}
+
+	var none *filemanager.PermFilter
+	if !none.Matches(0) {
+		t.Error("a nil filter should match every mode")
+	}
+}
@@ -0,0 +1,60 @@
+package filemanager_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+func TestPacer_Wait(t *testing.T) {
+	pacer := filemanager.NewPacer(100)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 11; i++ {
+		if err := pacer.Wait(ctx, 1); err != nil {
+			t.Fatalf("Wait() unexpected error: %v", err)
+		}
+	}
+	// The first unit starts at once, the other ten take 10ms each
+	if took := time.Since(start); took < 90*time.Millisecond {
+		t.Errorf("11 units at 100/s took %v, want about 100ms", took)
+	}
+}
+
+func TestPacer_Unlimited(t *testing.T) {
+	if pacer := filemanager.NewPacer(0); pacer != nil {
+		t.Fatalf("NewPacer(0) = %v, want nil", pacer)
+	}
+
+	var pacer *filemanager.Pacer
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		if err := pacer.Wait(context.Background(), 1<<20); err != nil {
+			t.Fatalf("Wait() unexpected error: %v", err)
+		}
+	}
+	if took := time.Since(start); took > 50*time.Millisecond {
+		t.Errorf("nil pacer took %v, want no wait", took)
+	}
+}
+
+func TestPacer_Cancel(t *testing.T) {
+	pacer := filemanager.NewPacer(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := pacer.Wait(ctx, 1); err != nil {
+		t.Fatalf("first Wait() unexpected error: %v", err)
+	}
+	start := time.Now()
+	if err := pacer.Wait(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() = %v, want context.DeadlineExceeded", err)
+	}
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("cancelled Wait() took %v, want it to return with ctx", took)
+	}
+}
@@ -0,0 +1,150 @@
+package filemanager_test
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+// readGeneration returns the contents of a rotated generation, unpacking
+// compressed ones
+func readGeneration(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if filepath.Ext(path) == ".gz" {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("gzip %s: %v", path, err)
+		}
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestRotatedPath(t *testing.T) {
+	for n, want := range map[int]string{1: "app.log.1", 2: "app.log.2.gz", 10: "app.log.10.gz"} {
+		if got := filemanager.RotatedPath("app.log", n); got != want {
+			t.Errorf("RotatedPath(app.log, %d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestRotateFile_ShiftsAndCompressesGenerations(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	opts := filemanager.RotateOptions{Keep: 3}
+
+	// Each rotation turns the live file into the first generation
+	for _, contents := range []string{"first", "second", "third", "fourth"} {
+		if err := os.WriteFile(path, []byte(contents), 0640); err != nil {
+			t.Fatal(err)
+		}
+		first, err := filemanager.RotateFile(path, opts)
+		if err != nil {
+			t.Fatalf("RotateFile() error = %v", err)
+		}
+		if first != path+".1" {
+			t.Errorf("RotateFile() = %q, want %q", first, path+".1")
+		}
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("live file still exists after rotation, stat error = %v", err)
+	}
+	for n, want := range map[int]string{1: "fourth", 2: "third", 3: "second"} {
+		if got := readGeneration(t, filemanager.RotatedPath(path, n)); got != want {
+			t.Errorf("generation %d = %q, want %q", n, got, want)
+		}
+	}
+	if _, err := os.Stat(filemanager.RotatedPath(path, 4)); !os.IsNotExist(err) {
+		t.Errorf("generation 4 kept beyond the limit, stat error = %v", err)
+	}
+	if info, err := os.Stat(path + ".1"); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("first generation = %v, %v, want mode 0640", info, err)
+	}
+}
+
+func TestRotateFile_RemovesGenerationsBeyondKeep(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	files := []string{"app.log", "app.log.1", "app.log.2.gz", "app.log.3.gz", "app.log.4", "app.log.6.gz", "app.log.20261018", "app.log.2024.gz"}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := filemanager.RotateFile(path, filemanager.RotateOptions{Keep: 1}); err != nil {
+		t.Fatalf("RotateFile() error = %v", err)
+	}
+
+	// Only the generations the rotation made, up to the first gap, are
+	// removed; dated copies and other numbered files stay
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	want := []string{"app.log.1", "app.log.2024.gz", "app.log.20261018", "app.log.4", "app.log.6.gz"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Fatalf("directory holds %v, want %v", names, want)
+	}
+	if got := readGeneration(t, path+".1"); got != "app.log" {
+		t.Errorf("app.log.1 = %q, want the rotated live file", got)
+	}
+}
+
+func TestRotateFile_CopyTruncate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "daemon.log")
+	if err := os.WriteFile(path, []byte("before\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writer, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	if _, err := filemanager.RotateFile(path, filemanager.RotateOptions{CopyTruncate: true}); err != nil {
+		t.Fatalf("RotateFile() error = %v", err)
+	}
+	if got := readGeneration(t, path+".1"); got != "before\n" {
+		t.Errorf("first generation = %q, want the copied contents", got)
+	}
+
+	// The writer keeps appending to the file that is still in place
+	if _, err := writer.WriteString("after\n"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readGeneration(t, path); got != "after\n" {
+		t.Errorf("live file = %q, want only what was written after the rotation", got)
+	}
+}
+
+func TestRotateOptions(t *testing.T) {
+	if err := (filemanager.RotateOptions{Keep: -1}).Validate(); err == nil {
+		t.Error("Validate() with negative keep error = nil, want an error")
+	}
+	if got := (filemanager.RotateOptions{CopyTruncate: true}).String(); got != "keep 5 generations, copy and truncate open files" {
+		t.Errorf("String() = %q", got)
+	}
+	if _, err := filemanager.ParseFileAction("Rotate"); err != nil {
+		t.Errorf("ParseFileAction(Rotate) error = %v", err)
+	}
+}
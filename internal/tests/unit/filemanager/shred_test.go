@@ -0,0 +1,190 @@
+package filemanager_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+func TestShredFile(t *testing.T) {
+	for _, method := range []filemanager.ShredMethod{filemanager.ShredZeros, filemanager.ShredRandom, filemanager.ShredDoD} {
+		t.Run(string(method), func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "customers.csv")
+			data := bytes.Repeat([]byte("alice@example.com;"), 10000)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+
+			written, err := filemanager.ShredFile(path, filemanager.ShredOptions{Passes: 2, Method: method})
+			if err != nil {
+				t.Fatalf("ShredFile() unexpected error: %v", err)
+			}
+			if want := int64(2 * len(data)); written != want {
+				t.Errorf("ShredFile() wrote %d bytes, want %d", written, want)
+			}
+
+			// Neither the file nor its renamed copy stay behind
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("Failed to read dir: %v", err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("directory still holds %v", entries)
+			}
+		})
+	}
+}
+
+func TestShredFile_KeepsHardLinkedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "export.json")
+	if err := os.WriteFile(path, []byte("secret"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Link(path, link); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+	skipWithoutLinkCounts(t, path)
+
+	_, err := filemanager.ShredFile(path, filemanager.ShredOptions{Passes: 3, Method: filemanager.ShredDoD})
+	if !errors.Is(err, filemanager.ErrHardLinked) {
+		t.Fatalf("ShredFile() error = %v, want ErrHardLinked", err)
+	}
+	for _, name := range []string{path, link} {
+		if data, err := os.ReadFile(name); err != nil || string(data) != "secret" {
+			t.Errorf("%s = %q, %v, want it untouched", name, data, err)
+		}
+	}
+}
+
+func TestShredDir_HardLinks(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "exports")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	path := filepath.Join(dir, "a.json")
+	if err := os.WriteFile(path, []byte("secret"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Link(path, filepath.Join(dir, "b.json")); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+	skipWithoutLinkCounts(t, path)
+
+	// A link outside the tree keeps the file from being shredded
+	outside := filepath.Join(root, "outside.json")
+	if err := os.Link(path, outside); err != nil {
+		t.Fatalf("Failed to link file: %v", err)
+	}
+	if _, err := filemanager.ShredDir(dir, filemanager.ShredOptions{Passes: 1}); !errors.Is(err, filemanager.ErrHardLinked) {
+		t.Fatalf("ShredDir() error = %v, want ErrHardLinked", err)
+	}
+	if data, err := os.ReadFile(outside); err != nil || string(data) != "secret" {
+		t.Fatalf("link outside the tree = %q, %v, want it untouched", data, err)
+	}
+
+	// With every link in the tree the data is overwritten once
+	if err := os.Remove(outside); err != nil {
+		t.Fatalf("Failed to remove link: %v", err)
+	}
+	written, err := filemanager.ShredDir(dir, filemanager.ShredOptions{Passes: 1})
+	if err != nil {
+		t.Fatalf("ShredDir() unexpected error: %v", err)
+	}
+	if written != int64(len("secret")) {
+		t.Errorf("ShredDir() wrote %d bytes, want %d", written, len("secret"))
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("shredded directory still exists: %v", err)
+	}
+}
+
+// skipWithoutLinkCounts skips a test where stat does not report hard link
+// counts
+func skipWithoutLinkCounts(t *testing.T, path string) {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat %s: %v", path, err)
+	}
+	if filemanager.NewFileEntry(path, info).Links < 2 {
+		t.Skip("hard link counts not reported on this platform")
+	}
+}
+
+func TestShredFile_Symlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	if err := os.WriteFile(target, []byte("keep"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	if written, err := filemanager.ShredFile(link, filemanager.ShredOptions{}); err != nil || written != 0 {
+		t.Fatalf("ShredFile(symlink) = %d, %v, want 0, nil", written, err)
+	}
+	if data, err := os.ReadFile(target); err != nil || string(data) != "keep" {
+		t.Errorf("link target = %q, %v, want it untouched", data, err)
+	}
+}
+
+func TestShredDir(t *testing.T) {
+	root := createDepthTestTree(t)
+	written, err := filemanager.ShredDir(filepath.Join(root, "sub"), filemanager.ShredOptions{Passes: 1})
+	if err != nil {
+		t.Fatalf("ShredDir() unexpected error: %v", err)
+	}
+	if written != 3 {
+		t.Errorf("ShredDir() wrote %d bytes, want 3", written)
+	}
+	if _, err := os.Stat(filepath.Join(root, "sub")); !os.IsNotExist(err) {
+		t.Errorf("shredded directory still exists: %v", err)
+	}
+}
+
+func TestParseShredMethod(t *testing.T) {
+	tests := map[string]filemanager.ShredMethod{"": filemanager.ShredRandom, " Zeros ": filemanager.ShredZeros, "DOD": filemanager.ShredDoD}
+	for name, want := range tests {
+		if got, err := filemanager.ParseShredMethod(name); err != nil || got != want {
+			t.Errorf("ParseShredMethod(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := filemanager.ParseShredMethod("gutmann"); err == nil {
+		t.Error("ParseShredMethod(gutmann) error = nil, want an error")
+	}
+	if err := (filemanager.ShredOptions{Passes: -1}).Validate(); err == nil {
+		t.Error("Validate() with negative passes error = nil, want an error")
+	}
+}
+
+func TestShredFilePaced(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.sql")
+	if err := os.WriteFile(path, bytes.Repeat([]byte{'x'}, 4096), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	start := time.Now()
+	written, err := filemanager.ShredFilePaced(context.Background(), path, filemanager.ShredOptions{Passes: 2}, filemanager.NewPacer(64*1024))
+	if err != nil {
+		t.Fatalf("ShredFilePaced() unexpected error: %v", err)
+	}
+	if written != 8192 {
+		t.Errorf("ShredFilePaced() wrote %d bytes, want 8192", written)
+	}
+	// The second pass waits for the 4096 bytes of the first at 64KiB/s
+	if took := time.Since(start); took < 50*time.Millisecond {
+		t.Errorf("8KiB at 64KiB/s took %v, want about 62ms", took)
+	}
+}
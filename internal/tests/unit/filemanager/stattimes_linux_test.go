@@ -0,0 +1,73 @@
+//go:build linux
+// +build linux
+
+package filemanager_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+func TestFileFilter_StatTimes(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	monthAgo := now.Add(-30 * 24 * time.Hour)
+
+	// Chtimes sets the access time directly, the change time is always now
+	files := map[string]time.Time{"stale.bin": now.Add(-120 * 24 * time.Hour), "read.bin": now.Add(-time.Hour)}
+	for name, atime := range files {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+		if err := os.Chtimes(path, atime, monthAgo); err != nil {
+			t.Fatalf("Failed to set times of %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options filemanager.FileFilterOptions
+		want    map[string]bool
+	}{
+		{"no limits", filemanager.FileFilterOptions{}, map[string]bool{"stale.bin": true, "read.bin": true}},
+		{"accessed before", filemanager.FileFilterOptions{AccessedBefore: monthAgo}, map[string]bool{"stale.bin": true, "read.bin": false}},
+		{"accessed after", filemanager.FileFilterOptions{AccessedAfter: now.Add(-24 * time.Hour)}, map[string]bool{"stale.bin": false, "read.bin": true}},
+		{"accessed between", filemanager.FileFilterOptions{AccessedBefore: now, AccessedAfter: monthAgo}, map[string]bool{"stale.bin": false, "read.bin": true}},
+		{"changed before", filemanager.FileFilterOptions{ChangedBefore: now.Add(-time.Hour)}, map[string]bool{"stale.bin": false, "read.bin": false}},
+		{"changed after", filemanager.FileFilterOptions{ChangedAfter: now.Add(-time.Hour)}, map[string]bool{"stale.bin": true, "read.bin": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := filemanager.NewFileFilterWithOptions(tt.options, nil)
+			for name, want := range tt.want {
+				path := filepath.Join(root, name)
+				info, err := os.Lstat(path)
+				if err != nil {
+					t.Fatalf("Failed to stat %s: %v", name, err)
+				}
+				if got := filter.MatchesFilters(info, path); got != want {
+					t.Errorf("MatchesFilters(%s) = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNoAtimeMount(t *testing.T) {
+	mount, ok := filemanager.MountOf(t.TempDir())
+	if !ok {
+		t.Skip("the mount table is not readable")
+	}
+	if _, noatime := filemanager.NoAtimeMount(t.TempDir()); noatime != mount.HasOption("noatime") {
+		t.Errorf("NoAtimeMount() = %v, but %s has options %v", noatime, mount.Point, mount.Options)
+	}
+	if len(mount.Options) == 0 || (!mount.HasOption("rw") && !mount.HasOption("ro")) {
+		t.Errorf("mount %s has options %v, want rw or ro among them", mount.Point, mount.Options)
+	}
+}
@@ -0,0 +1,103 @@
+package filemanager_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+// legacyScanRecursively is the previous goroutine-per-file scanner, kept
+// here as the baseline for the walker benchmarks
+func legacyScanRecursively(dir string, filter *filemanager.FileFilter) (files int, totalSize int64) {
+	taskCh := make(chan os.FileInfo, runtime.NumCPU())
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if info == nil || info.IsDir() || err != nil {
+			return nil
+		}
+
+		wg.Add(1)
+		go func(path string, info os.FileInfo) {
+			taskCh <- info
+			defer func() { <-taskCh }()
+			defer wg.Done()
+
+			if filter.MatchesFilters(info, path) {
+				mu.Lock()
+				files++
+				totalSize += info.Size()
+				mu.Unlock()
+			}
+		}(path, info)
+		return nil
+	})
+
+	wg.Wait()
+	return files, totalSize
+}
+
+// BenchmarkScanRecursively compares the worker-pool walker with the legacy
+// scanner on synthetic trees. pool-walk counts matches like the legacy code,
+// pool-collect also builds and sorts the FileEntry results. Building the
+// 10^6 tree takes a while, so it is skipped with -short.
+//
+//	go test -run '^$' -bench ScanRecursively -benchmem ./internal/tests/unit/filemanager/
+func BenchmarkScanRecursively(b *testing.B) {
+	for _, size := range []int{100_000, 1_000_000} {
+		b.Run(fmt.Sprintf("files=%d", size), func(b *testing.B) {
+			if size > 100_000 && testing.Short() {
+				b.Skip("skipping large tree in short mode")
+			}
+
+			root := b.TempDir()
+			buildTree(b, root, size)
+
+			fm := filemanager.NewFileManager()
+			filter := fm.NewFileFilter(0, 0, map[string]struct{}{".log": {}}, nil, time.Time{}, time.Time{})
+
+			b.Run("legacy", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if files, _ := legacyScanRecursively(root, filter); files != size {
+						b.Fatalf("found %d files, want %d", files, size)
+					}
+				}
+			})
+
+			b.Run("pool-walk", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					var mu sync.Mutex
+					files := 0
+					fm.WalkFilesWithFilter(context.Background(), func(fi os.FileInfo, path string) {
+						mu.Lock()
+						files++
+						mu.Unlock()
+					}, root, filter)
+					if files != size {
+						b.Fatalf("found %d files, want %d", files, size)
+					}
+				}
+			})
+
+			b.Run("pool-collect", func(b *testing.B) {
+				b.ReportAllocs()
+				scanner := filemanager.NewFileScanner(fm, filter, false)
+				for i := 0; i < b.N; i++ {
+					if result := scanner.ScanFilesRecursively(context.Background(), root); result.Len() != size {
+						b.Fatalf("found %d files, want %d", result.Len(), size)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package filemanager_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pashkov256/deletor/internal/filemanager"
+)
+
+// buildTree creates files spread over nested directories, ten files per
+// directory and ten directories per level
+func buildTree(tb testing.TB, root string, files int) {
+	tb.Helper()
+
+	for i := 0; i < files; i++ {
+		dir := root
+		for n := i / 10; n > 0; n /= 10 {
+			dir = filepath.Join(dir, fmt.Sprintf("d%d", n%10))
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			tb.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.log", i)), []byte("x"), 0644); err != nil {
+			tb.Fatalf("Failed to create file: %v", err)
+		}
+	}
+}
+
+func TestScanFilesRecursively_DeepTree(t *testing.T) {
+	root := t.TempDir()
+	buildTree(t, root, 2000)
+
+	// A narrow chain deeper than the worker count must not stall the pool
+	deep := root
+	for i := 0; i < 64; i++ {
+		deep = filepath.Join(deep, "deep")
+	}
+	createDirStructure(t, deep, nil, map[string]string{"bottom.log": "x"}, nil)
+
+	fm := filemanager.NewFileManager()
+	filter := fm.NewFileFilter(0, 0, nil, nil, time.Time{}, time.Time{})
+	scanner := filemanager.NewFileScanner(fm, filter, false)
+
+	result := scanner.ScanFilesRecursively(context.Background(), root)
+	if result.Len() != 2001 || result.TotalSize != 2001 {
+		t.Fatalf("found %d files of %d bytes, want 2001", result.Len(), result.TotalSize)
+	}
+
+	var walked atomic.Int64
+	err := fm.WalkFilesWithFilter(context.Background(), func(fi os.FileInfo, path string) {
+		if !fi.IsDir() {
+			walked.Add(1)
+		}
+	}, root, filter)
+	if err != nil || walked.Load() != 2001 {
+		t.Errorf("WalkFilesWithFilter visited %d files (err %v), want 2001", walked.Load(), err)
+	}
+
+	if size := fm.CalculateDirSize(context.Background(), root); size != 2001 {
+		t.Errorf("CalculateDirSize() = %d, want 2001", size)
+	}
+}
+
+func TestScanFilesRecursively_BoundedGoroutines(t *testing.T) {
+	root := t.TempDir()
+	buildTree(t, root, 5000)
+
+	fm := filemanager.NewFileManager()
+	scanner := filemanager.NewFileScanner(fm, fm.NewFileFilter(0, 0, nil, nil, time.Time{}, time.Time{}), false)
+
+	before := runtime.NumGoroutine()
+	var peak int
+	var mu sync.Mutex
+	for range scanner.StreamFiles(context.Background(), root, true) {
+		mu.Lock()
+		if n := runtime.NumGoroutine(); n > peak {
+			peak = n
+		}
+		mu.Unlock()
+	}
+
+	// The pool size plus the stream goroutine, far below one per file
+	if limit := before + 4*runtime.GOMAXPROCS(0) + 4; peak > limit {
+		t.Errorf("peak goroutines = %d, want at most %d", peak, limit)
+	}
+}
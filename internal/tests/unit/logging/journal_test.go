@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pashkov256/deletor/internal/logging"
+)
+
+// readJournal parses every line of a journal file
+func readJournal(t *testing.T, journalPath string) []logging.JournalEntry {
+	t.Helper()
+
+	file, err := os.Open(journalPath)
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	defer file.Close()
+
+	var entries []logging.JournalEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry logging.JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to parse journal line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestJournal_RecordsCancelledRun(t *testing.T) {
+	journalPath := filepath.Join(t.TempDir(), "nested", "journal.jsonl")
+
+	journal, err := logging.OpenJournal(journalPath, "/data")
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	journal.Record(logging.NewFileOperation("/data/a.log", 10, logging.OperationDeleted, "cli", ""))
+	journal.Record(logging.NewFileOperation("/data/b.log", 20, logging.OperationTrashed, "cli", ""))
+	if err := journal.Finish(context.Canceled); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+
+	entries := readJournal(t, journalPath)
+	wantStatuses := []logging.JournalStatus{logging.JournalStarted, logging.JournalDone, logging.JournalDone, logging.JournalCancelled}
+	if len(entries) != len(wantStatuses) {
+		t.Fatalf("journal has %d entries, want %d", len(entries), len(wantStatuses))
+	}
+	for i, entry := range entries {
+		if entry.Status != wantStatuses[i] {
+			t.Errorf("entry %d status = %s, want %s", i, entry.Status, wantStatuses[i])
+		}
+		if entry.RunID != entries[0].RunID || entry.Directory != "/data" {
+			t.Errorf("entry %d = %+v, want run %s in /data", i, entry, entries[0].RunID)
+		}
+	}
+	if op := entries[2].Operation; op == nil || op.FilePath != "/data/b.log" || op.OperationType != logging.OperationTrashed {
+		t.Errorf("entry 2 operation = %+v, want trashed /data/b.log", op)
+	}
+}
+
+func TestJournal_FinishStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status logging.JournalStatus
+	}{
+		{"completed", nil, logging.JournalCompleted},
+		{"cancelled", context.Canceled, logging.JournalCancelled},
+		{"failed", errors.New("disk full"), logging.JournalFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			journalPath := filepath.Join(t.TempDir(), "journal.jsonl")
+			journal, err := logging.OpenJournal(journalPath, "")
+			if err != nil {
+				t.Fatalf("OpenJournal failed: %v", err)
+			}
+			journal.Finish(tt.err)
+
+			entries := readJournal(t, journalPath)
+			last := entries[len(entries)-1]
+			if last.Status != tt.status {
+				t.Errorf("final status = %s, want %s", last.Status, tt.status)
+			}
+			if tt.status == logging.JournalFailed && last.Error != "disk full" {
+				t.Errorf("final error = %q, want disk full", last.Error)
+			}
+		})
+	}
+}
+
+func TestJournal_NilIsNoop(t *testing.T) {
+	var journal *logging.Journal
+	if err := journal.Record(logging.NewFileOperation("/tmp/x", 1, logging.OperationDeleted, "", "")); err != nil {
+		t.Errorf("Record on nil journal = %v", err)
+	}
+	if err := journal.Finish(nil); err != nil {
+		t.Errorf("Finish on nil journal = %v", err)
+	}
+}
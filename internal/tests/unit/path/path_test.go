@@ -14,6 +14,8 @@ func TestPathVariables(t *testing.T) {
 		{"AppDirName", path.AppDirName, "deletor"},
 		{"RuleFileName", path.RuleFileName, "rule.json"},
 		{"LogFileName", path.LogFileName, "deletor.log"},
+		{"JournalFileName", path.JournalFileName, "journal.jsonl"},
+		{"IndexFileName", path.IndexFileName, "index.gob"},
 	}
 
 	for _, tt := range tests {
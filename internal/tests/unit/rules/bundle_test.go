@@ -0,0 +1,126 @@
+package rules_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pashkov256/deletor/internal/path"
+	"github.com/pashkov256/deletor/internal/rules"
+)
+
+func TestExportBundle_CollapsesHome(t *testing.T) {
+	cleanup := setupTempConfigDir()
+	defer cleanup()
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	rs := rules.NewRules()
+	if err := rs.UpdateRules(
+		rules.WithPath(filepath.Join(home, "Downloads")),
+		rules.WithExclude([]string{filepath.Join(home, "Downloads", "keep"), "node_modules"}),
+	); err != nil {
+		t.Fatalf("UpdateRules failed: %v", err)
+	}
+
+	current, err := rs.GetRules()
+	if err != nil {
+		t.Fatalf("GetRules failed: %v", err)
+	}
+	data, err := rules.ExportBundle(current)
+	if err != nil {
+		t.Fatalf("ExportBundle failed: %v", err)
+	}
+
+	bundle := string(data)
+	for _, want := range []string{`"Path": "~/Downloads"`, `"~/Downloads/keep"`, `"node_modules"`, `"Version": 2`} {
+		if !strings.Contains(bundle, want) {
+			t.Errorf("bundle does not contain %s:\n%s", want, bundle)
+		}
+	}
+	if strings.Contains(bundle, home) {
+		t.Errorf("bundle still contains home directory:\n%s", bundle)
+	}
+}
+
+func TestImportBundle_IntoProfile(t *testing.T) {
+	cleanup := setupTempConfigDir()
+	defer cleanup()
+
+	bundlePath := filepath.Join(t.TempDir(), "team-rules.yaml")
+	if err := os.WriteFile(bundlePath, []byte("Path: ~/spool\nExtensions: [LOG]\nMinSize: 1kb\n"), 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+
+	incoming, err := rules.LoadBundle("file://" + bundlePath)
+	if err != nil {
+		t.Fatalf("LoadBundle failed: %v", err)
+	}
+
+	team, err := rules.NewProfileRules("team")
+	if err != nil {
+		t.Fatalf("NewProfileRules failed: %v", err)
+	}
+	current, err := team.GetRules()
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("GetRules on a new profile = %v, want ErrNotExist", err)
+	}
+
+	changes := rules.DiffRules(current, incoming)
+	fields := make([]string, 0, len(changes))
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+	}
+	if got := strings.Join(fields, ","); got != "Path,Extensions,MinSize" {
+		t.Errorf("DiffRules fields = %s, want Path,Extensions,MinSize", got)
+	}
+
+	if err := team.UpdateRules(rules.WithRules(incoming)); err != nil {
+		t.Fatalf("UpdateRules failed: %v", err)
+	}
+
+	userConfigDir, _ := os.UserConfigDir()
+	profilesDir := filepath.Join(userConfigDir, path.AppDirName, path.ProfilesDirName)
+	defer os.RemoveAll(profilesDir)
+	profilePath := filepath.Join(profilesDir, "team.json")
+	if team.GetRulesPath() != profilePath {
+		t.Errorf("GetRulesPath() = %s, want %s", team.GetRulesPath(), profilePath)
+	}
+
+	saved, err := team.GetRules()
+	if err != nil {
+		t.Fatalf("GetRules failed: %v", err)
+	}
+	if len(rules.DiffRules(saved, incoming)) != 0 {
+		t.Errorf("saved profile differs from bundle: %+v", rules.DiffRules(saved, incoming))
+	}
+
+	// The default profile is untouched
+	if _, err := os.Stat(rules.NewRules().GetRulesPath()); !os.IsNotExist(err) {
+		t.Errorf("default rules file should not be created by a profile import")
+	}
+}
+
+func TestImportBundle_Rejected(t *testing.T) {
+	dir := t.TempDir()
+	badPath := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(badPath, []byte("{\n  \"OlderThan\": \"7 fortnights\"\n}"), 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+
+	var parseErr *rules.ParseError
+	if _, err := rules.LoadBundle(badPath); !errors.As(err, &parseErr) || parseErr.Line != 2 {
+		t.Errorf("LoadBundle(invalid) = %v, want ParseError on line 2", err)
+	}
+	if _, err := rules.LoadBundle("https://example.com/rules.json"); err == nil {
+		t.Error("LoadBundle should reject remote URLs")
+	}
+	if _, err := rules.NewProfileRules("../escape"); err == nil {
+		t.Error("NewProfileRules should reject path separators")
+	}
+}
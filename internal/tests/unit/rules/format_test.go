@@ -0,0 +1,254 @@
+package rules_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pashkov256/deletor/internal/path"
+	"github.com/pashkov256/deletor/internal/rules"
+)
+
+// writeRulesFile writes a rules file with the given name into a temporary config dir
+func writeRulesFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	origAppDirName := path.AppDirName
+	path.AppDirName = "deletor_format_test"
+	userConfigDir, _ := os.UserConfigDir()
+	dir := filepath.Join(userConfigDir, path.AppDirName)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+		path.AppDirName = origAppDirName
+	})
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	filePath := filepath.Join(dir, name)
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rules file: %v", err)
+	}
+	return filePath
+}
+
+func TestGetRules_YAML(t *testing.T) {
+	filePath := writeRulesFile(t, "rule.yaml", "Version: 2\nPath: /data\nExtensions:\n  - .log\nMinSize: 10kb\nIncludeSubfolders: true\n")
+
+	rs := rules.NewRules()
+	if got := rs.GetRulesPath(); got != filePath {
+		t.Fatalf("GetRulesPath() = %v, want %v", got, filePath)
+	}
+
+	loaded, err := rs.GetRules()
+	if err != nil {
+		t.Fatalf("GetRules failed: %v", err)
+	}
+	if loaded.Path != "/data" || loaded.MinSize != "10kb" || !loaded.IncludeSubfolders {
+		t.Errorf("Unexpected rules: %+v", loaded)
+	}
+
+	// Saving keeps the YAML format
+	if err := rs.UpdateRules(rules.WithMaxSize("1gb")); err != nil {
+		t.Fatalf("UpdateRules failed: %v", err)
+	}
+	data, _ := os.ReadFile(filePath)
+	if !strings.Contains(string(data), "MaxSize: 1gb") {
+		t.Errorf("Saved YAML does not contain MaxSize:\n%s", data)
+	}
+}
+
+func TestGetRules_TOML(t *testing.T) {
+	writeRulesFile(t, "rule.toml", "Version = 2\nPath = \"/data\"\nExtensions = [\".tmp\"]\nSendFilesToTrash = true\n")
+
+	loaded, err := rules.NewRules().GetRules()
+	if err != nil {
+		t.Fatalf("GetRules failed: %v", err)
+	}
+	if loaded.Path != "/data" || len(loaded.Extensions) != 1 || !loaded.SendFilesToTrash {
+		t.Errorf("Unexpected rules: %+v", loaded)
+	}
+}
+
+func TestGetRules_MigratesVersion1(t *testing.T) {
+	writeRulesFile(t, "rule.json", `{"Path":"/data","Extensions":["LOG","tmp",".Bak"]}`)
+
+	loaded, err := rules.NewRules().GetRules()
+	if err != nil {
+		t.Fatalf("GetRules failed: %v", err)
+	}
+	if loaded.Version != rules.CurrentRulesVersion {
+		t.Errorf("Version = %d, want %d", loaded.Version, rules.CurrentRulesVersion)
+	}
+	want := []string{".log", ".tmp", ".bak"}
+	if strings.Join(loaded.Extensions, ",") != strings.Join(want, ",") {
+		t.Errorf("Extensions = %v, want %v", loaded.Extensions, want)
+	}
+}
+
+func TestGetRules_ErrorPositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		line    int
+		column  int
+	}{
+		{"json syntax", "rule.json", "{\n  \"Path\": \"/data\",\n  oops\n}", 3, 3},
+		{"json invalid value", "rule.json", "{\n  \"Version\": 2,\n  \"MinSize\": \"ten\"\n}", 3, 3},
+		{"json wrong type", "rule.json", "{\n  \"Version\": 2,\n  \"IncludeSubfolders\": \"yes\"\n}", 3, 3},
+		{"yaml syntax", "rule.yaml", "Path: /data\nExtensions: [.log\n", 1, 0},
+		{"toml invalid value", "rule.toml", "Version = 2\nOlderThan = \"7 fortnights\"\n", 2, 1},
+		{"newer version", "rule.json", "{\"Version\": 99}", 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeRulesFile(t, tt.file, tt.content)
+
+			_, err := rules.NewRules().GetRules()
+			var parseErr *rules.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("GetRules error = %v, want *rules.ParseError", err)
+			}
+			if parseErr.Line != tt.line || parseErr.Column != tt.column {
+				t.Errorf("position = %d:%d, want %d:%d (%v)", parseErr.Line, parseErr.Column, tt.line, tt.column, err)
+			}
+		})
+	}
+}
+
+func TestParseDirectoryRule(t *testing.T) {
+	rule, err := rules.ParseDirectoryRule("node_modules:requires=package.json:untouched=60d")
+	if err != nil {
+		t.Fatalf("ParseDirectoryRule failed: %v", err)
+	}
+	want := rules.DirectoryRule{Pattern: "node_modules", RequireSibling: "package.json", UntouchedFor: "60d"}
+	if rule != want {
+		t.Errorf("ParseDirectoryRule = %+v, want %+v", rule, want)
+	}
+	if rule.String() != "node_modules:requires=package.json:untouched=60d" {
+		t.Errorf("String() = %s", rule.String())
+	}
+
+	for _, spec := range []string{"", "a/b", "target:owner=me", "target:untouched=2 fortnights", "[:requires=x"} {
+		if _, err := rules.ParseDirectoryRule(spec); err == nil {
+			t.Errorf("ParseDirectoryRule(%q) should fail", spec)
+		}
+	}
+}
+
+func TestParseClause(t *testing.T) {
+	clause, err := rules.ParseClause("*.log:older=7d:max-size=1gb:action=Archive")
+	if err != nil {
+		t.Fatalf("ParseClause failed: %v", err)
+	}
+	want := rules.Clause{Pattern: "*.log", OlderThan: "7d", MaxSize: "1gb", Action: "archive"}
+	if clause != want {
+		t.Errorf("ParseClause = %+v, want %+v", clause, want)
+	}
+	if clause.String() != "*.log:older=7d:max-size=1gb:action=archive" {
+		t.Errorf("String() = %s", clause.String())
+	}
+
+	for _, spec := range []string{"", ":action=delete", "*.log:when=7d", "*.log:older=2 fortnights", "*.log:min-size=lots", "*.log:action=burn", "[:action=skip"} {
+		if _, err := rules.ParseClause(spec); err == nil {
+			t.Errorf("ParseClause(%q) should fail", spec)
+		}
+	}
+
+	if err := rules.ValidateClauses([]rules.Clause{{Pattern: "*.iso", Action: "skip"}}); err == nil {
+		t.Error("ValidateClauses should reject a list that only skips")
+	}
+}
+
+func TestGetRules_ClausesTOML(t *testing.T) {
+	writeRulesFile(t, "rule.toml", "Version = 2\n\n[[Clauses]]\nPattern = \"*.log\"\nOlderThan = \"7d\"\nAction = \"archive\"\n\n[[Clauses]]\nPattern = \"*.tmp\"\n")
+
+	loaded, err := rules.NewRules().GetRules()
+	if err != nil {
+		t.Fatalf("GetRules failed: %v", err)
+	}
+	if len(loaded.Clauses) != 2 || loaded.Clauses[0].Action != "archive" || loaded.Clauses[1].Pattern != "*.tmp" {
+		t.Errorf("Clauses = %+v", loaded.Clauses)
+	}
+}
+
+func TestGetRules_DirectoriesTOML(t *testing.T) {
+	writeRulesFile(t, "rule.toml", "Version = 2\n\n[[Directories]]\nPattern = \"target\"\nRequireSibling = \"Cargo.toml\"\n")
+
+	loaded, err := rules.NewRules().GetRules()
+	if err != nil {
+		t.Fatalf("GetRules failed: %v", err)
+	}
+	if len(loaded.Directories) != 1 || loaded.Directories[0].RequireSibling != "Cargo.toml" {
+		t.Errorf("Directories = %+v", loaded.Directories)
+	}
+}
+
+func TestGetRules_CacheLocationsTOML(t *testing.T) {
+	writeRulesFile(t, "rule.toml", "Version = 2\n\n[[CacheLocations]]\nName = \"terraform\"\nPath = \"~/.terraform.d/plugin-cache\"\nType = \"dev\"\nOlderThan = \"30d\"\nConfirm = true\n")
+
+	loaded, err := rules.NewRules().GetRules()
+	if err != nil {
+		t.Fatalf("GetRules failed: %v", err)
+	}
+	if len(loaded.CacheLocations) != 1 || loaded.CacheLocations[0].Name != "terraform" || !loaded.CacheLocations[0].Confirm {
+		t.Errorf("CacheLocations = %+v", loaded.CacheLocations)
+	}
+
+	writeRulesFile(t, "rule.toml", "Version = 2\n\n[[CacheLocations]]\nName = \"dev\"\nPath = \"/tmp/cache\"\n")
+	_, err = rules.NewRules().GetRules()
+	var fieldErr *rules.FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "CacheLocations" {
+		t.Errorf("GetRules with a location named like a type error = %v, want a CacheLocations field error", err)
+	}
+}
+
+func TestCacheRule_Compile(t *testing.T) {
+	t.Setenv("TEAM_CACHE", "/srv/team")
+	t.Setenv("DELETOR_TEST_EMPTY", "")
+	os.Unsetenv("DELETOR_TEST_UNSET")
+	home, _ := os.UserHomeDir()
+
+	location, err := rules.CacheRule{Name: "tool", Path: "$TEAM_CACHE/tool", MinSize: "1kb", Confirm: true}.Compile()
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if location.Path != filepath.Join("/srv/team", "tool") || location.Type != "app" || location.MinSize != 1024 || !location.Confirm || !location.Custom {
+		t.Errorf("Compile = %+v", location)
+	}
+
+	location, err = rules.CacheRule{Name: "terraform", Path: "~/.terraform.d/plugin-cache", OlderThan: "7d"}.Compile()
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if location.Path != filepath.Join(home, ".terraform.d", "plugin-cache") || location.OlderThan.IsZero() {
+		t.Errorf("Compile = %+v", location)
+	}
+
+	for _, rule := range []rules.CacheRule{
+		{Path: "/tmp/cache"},
+		{Name: "My Cache", Path: "/tmp/cache"},
+		{Name: "tool"},
+		{Name: "tool", Path: "/tmp/cache", Type: "network"},
+		{Name: "tool", Path: "/tmp/cache", MinSize: "huge"},
+		// Paths that would clear far more than a cache once expanded
+		{Name: "tool", Path: "$DELETOR_TEST_UNSET"},
+		{Name: "tool", Path: "$DELETOR_TEST_UNSET/cache"},
+		{Name: "tool", Path: "$DELETOR_TEST_EMPTY"},
+		{Name: "tool", Path: "cache/tool"},
+		{Name: "tool", Path: "/"},
+		{Name: "tool", Path: "~"},
+		{Name: "tool", Path: "$HOME/.."},
+	} {
+		if err := rule.Validate(); err == nil {
+			t.Errorf("Validate(%+v) should fail", rule)
+		}
+	}
+	if err := rules.ValidateCacheLocations([]rules.CacheRule{{Name: "tool", Path: "/a"}, {Name: "tool", Path: "/b"}}); err == nil {
+		t.Error("ValidateCacheLocations should reject a name defined twice")
+	}
+}
@@ -2,6 +2,7 @@ package rules_test
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -436,6 +437,29 @@ func TestGetRules_UsesCache(t *testing.T) {
 	}
 }
 
+func TestGetRules_ReturnedRulesAreUsable(t *testing.T) {
+	cleanup := setupTempConfigDir()
+	defer cleanup()
+
+	rs := rules.NewRules()
+	if err := rs.SetupRulesConfig(); err != nil {
+		t.Fatalf("SetupRulesConfig failed: %v", err)
+	}
+	copied, err := rs.GetRules()
+	if err != nil {
+		t.Fatalf("GetRules failed: %v", err)
+	}
+
+	// The returned rules satisfy Rules and must not panic when used as such
+	var again rules.Rules = copied
+	if _, err := again.GetRules(); err != nil {
+		t.Fatalf("GetRules on returned rules failed: %v", err)
+	}
+	if err := again.UpdateRules(rules.WithPath("/from/copy")); err != nil {
+		t.Fatalf("UpdateRules on returned rules failed: %v", err)
+	}
+}
+
 func TestUpdateRules_InvalidJSON(t *testing.T) {
 	// Setup temporary test directory
 	cleanup := setupTempConfigDir()
@@ -456,27 +480,27 @@ func TestUpdateRules_InvalidJSON(t *testing.T) {
 		t.Fatalf("Failed to write test config: %v", err)
 	}
 
-	// Attempt to update rules - this should succeed since UpdateRules doesn't read the existing file
+	// Updating must fail with a positioned error instead of resetting the file
 	err = rs.UpdateRules(rules.WithPath("/new/path"))
-	if err != nil {
-		t.Errorf("UpdateRules failed but should have succeeded: %v", err)
+	if err == nil {
+		t.Fatal("UpdateRules succeeded but should have reported the malformed file")
 	}
 
-	// Verify the file was updated with valid JSON
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		t.Fatalf("Failed to read updated config: %v", err)
+	var parseErr *rules.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("UpdateRules error = %T, want *rules.ParseError", err)
+	}
+	if parseErr.Line != 1 || parseErr.Column != 2 {
+		t.Errorf("ParseError position = %d:%d, want 1:2", parseErr.Line, parseErr.Column)
 	}
 
-	var config map[string]interface{}
-	err = json.Unmarshal(data, &config)
+	// Verify the malformed file was left untouched
+	data, err := os.ReadFile(configPath)
 	if err != nil {
-		t.Errorf("Updated config contains invalid JSON: %v", err)
+		t.Fatalf("Failed to read config: %v", err)
 	}
-
-	// Verify the path was updated
-	if path, ok := config["Path"].(string); !ok || path != "/new/path" {
-		t.Errorf("Path = %v, want %v", config["Path"], "/new/path")
+	if string(data) != string(invalidJSON) {
+		t.Errorf("Config was overwritten: %q", data)
 	}
 }
 
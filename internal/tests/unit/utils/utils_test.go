@@ -2,9 +2,11 @@ package utils
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"math"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -241,3 +243,112 @@ func TestParseTimeDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"200", 200, false},
+		{"200/s", 200, false},
+		{"120/min", 2, false},
+		{"30 / m", 0.5, false},
+		{"3600/h", 1, false},
+		{"10/day", 0, true},
+		{"fast", 0, true},
+		{"-5/s", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := utils.ParseRate(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRate(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseByteRate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"20MB/s", 20 * 1024 * 1024, false},
+		{"512kb", 512 * 1024, false},
+		{"1.00 MB/s", 1024 * 1024, false},
+		{"20MB/min", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := utils.ParseByteRate(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseByteRate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseByteRate(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIONice(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"idle", "idle", false},
+		{"IDLE", "idle", false},
+		{"best-effort", "best-effort", false},
+		{"be:7", "best-effort:7", false},
+		{"best-effort:0", "best-effort:0", false},
+		{"best-effort:8", "", true},
+		{"idle:3", "", true},
+		{"realtime", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := utils.ParseIONice(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIONice(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseIONice(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogDeletionToFileAsJson_KeepsBytes(t *testing.T) {
+	dir := t.TempDir()
+	utils.LogDeletionToFileAsJson([]utils.DeletionRecord{
+		{Path: "/tmp/big.iso", Size: 1288490189},
+	}, dir)
+
+	data, err := os.ReadFile(filepath.Join(dir, "deletor.json"))
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	var logs []struct {
+		Path  string `json:"path"`
+		Size  string `json:"size"`
+		Bytes int64  `json:"bytes"`
+	}
+	if err := json.Unmarshal(data, &logs); err != nil {
+		t.Fatalf("Failed to parse log: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Bytes != 1288490189 || logs[0].Size != utils.FormatSize(1288490189) {
+		t.Errorf("logs = %+v, want exact bytes 1288490189", logs)
+	}
+}
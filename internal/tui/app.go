@@ -64,9 +64,14 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "q":
+			a.cleanFilesModel.CancelOperations()
+			a.cacheModel.CancelOperations()
 			return a, tea.Quit
 		case "esc":
 			if a.page != menuPage {
+				// Scans and deletes belong to the page being left
+				a.cleanFilesModel.CancelOperations()
+				a.cacheModel.CancelOperations()
 				if a.page == rulesPage {
 					a.cleanFilesModel = views.InitialCleanModel(a.rules, a.filemanager, a.validator)
 					cmds = append(cmds, a.cleanFilesModel.Init())
@@ -1,7 +1,8 @@
 package help
 
 var (
-	CleanHelpText    = "Ctrl+R: refresh • Ctrl+D: delete files • Ctrl+S: toogle show dirs/files • Ctrl+O: open in explorer"
+	CleanHelpText    = "Ctrl+R: refresh • Ctrl+D: delete files • Ctrl+S: toogle show dirs/files • Ctrl+O: open in explorer • Ctrl+X: cancel running operation"
 	NavigateHelpText = "Tab: cycle focus • Shift+Tab: focus back • Enter: select/confirm/update • Esc: back to menu\n"
+	CancelHelpText   = "Ctrl+X: cancel running scan or clear"
 	ListHelpText     = "⬇/⬆: navigate in files • Shift+↑/↓: select file • Alt+↑/↓: deselect file • Space: toggle selection • Ctrl+A: select all files"
 )
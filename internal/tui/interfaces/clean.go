@@ -13,6 +13,7 @@ import (
 type CleanModel interface {
 	// Getters
 	GetCurrentPath() string
+	GetNoAtimeMount() string
 	GetExtensions() []string
 	GetMinSize() int64
 	GetExclude() []string
@@ -36,6 +37,16 @@ type CleanModel interface {
 	GetExcludeInput() textinput.Model
 	GetOlderInput() textinput.Model
 	GetNewerInput() textinput.Model
+	GetAccessedBeforeInput() textinput.Model
+	GetAccessedAfterInput() textinput.Model
+	GetChangedBeforeInput() textinput.Model
+	GetChangedAfterInput() textinput.Model
+	GetOwnerInput() textinput.Model
+	GetNotOwnerInput() textinput.Model
+	GetUIDInput() textinput.Model
+	GetGroupInput() textinput.Model
+	GetPermInput() textinput.Model
+	GetWhereInput() textinput.Model
 	GetSelectedFiles() map[string]bool
 	GetSelectedCount() int
 	GetSelectedSize() int64
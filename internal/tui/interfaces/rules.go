@@ -14,6 +14,17 @@ type RulesModel interface {
 	GetExcludeInput() textinput.Model
 	GetOlderInput() textinput.Model
 	GetNewerInput() textinput.Model
+	GetAccessedBeforeInput() textinput.Model
+	GetAccessedAfterInput() textinput.Model
+	GetChangedBeforeInput() textinput.Model
+	GetChangedAfterInput() textinput.Model
+	GetOwnerInput() textinput.Model
+	GetNotOwnerInput() textinput.Model
+	GetUIDInput() textinput.Model
+	GetGroupInput() textinput.Model
+	GetPermInput() textinput.Model
+	GetWhereInput() textinput.Model
+	GetPresetsInput() textinput.Model
 	GetFocusedElement() string
 	GetOptionState() map[string]bool
 	GetRulesPath() string
@@ -7,14 +7,15 @@ const (
 	ConfirmDeletion       = "Confirm deletion"
 	IncludeSubfolders     = "Include subfolders"
 	DeleteEmptySubfolders = "Delete empty subfolders"
+	DeleteBrokenLinks     = "Delete broken links"
+	DeleteEmptyFiles      = "Delete empty files"
 	SendFilesToTrash      = "Send files to trash"
 	LogOperations         = "Log operations"
 	LogToFile             = "Log to file"
 	ShowStatistics        = "Show statistics"
 	DisableEmoji          = "Disable Emoji"
 	ExitAfterDeletion     = "Exit after deletion"
-	//options for cache view
-	SystemCache = "System cache"
+	ShredFiles            = "Shred files"
 )
 
 // If you change the bool in these options, you must also change the values in the default rules json (rules/manager.go).
@@ -23,12 +24,15 @@ var DefaultCleanOptionState = map[string]bool{
 	ConfirmDeletion:       false,
 	IncludeSubfolders:     false,
 	DeleteEmptySubfolders: false,
+	DeleteBrokenLinks:     false,
+	DeleteEmptyFiles:      false,
 	SendFilesToTrash:      false,
 	LogOperations:         false,
 	LogToFile:             false,
 	ShowStatistics:        true,
 	DisableEmoji:          false,
 	ExitAfterDeletion:     false,
+	ShredFiles:            false,
 }
 
 var DefaultCleanOption = []string{
@@ -42,12 +46,7 @@ var DefaultCleanOption = []string{
 	ShowStatistics,
 	DisableEmoji,
 	ExitAfterDeletion,
-}
-
-var DefaultCacheOptionState = map[string]bool{
-	SystemCache: true,
-}
-
-var DefaultCacheOption = []string{
-	SystemCache,
+	DeleteBrokenLinks,
+	DeleteEmptyFiles,
+	ShredFiles,
 }
@@ -18,6 +18,10 @@ func GetEmojiByCleanOption(optionName string) string {
 		emoji = "📁"
 	case DeleteEmptySubfolders:
 		emoji = "🗑️"
+	case DeleteBrokenLinks:
+		emoji = "🔗"
+	case DeleteEmptyFiles:
+		emoji = "📭"
 	case SendFilesToTrash:
 		emoji = "♻️"
 	case LogOperations:
@@ -30,6 +34,8 @@ func GetEmojiByCleanOption(optionName string) string {
 		emoji = "🚫"
 	case ExitAfterDeletion:
 		emoji = "🚪"
+	case ShredFiles:
+		emoji = "🔥"
 	}
 
 	return emoji
@@ -1,6 +1,7 @@
 package clean
 
 import (
+	"fmt"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -55,6 +56,84 @@ func (t *FiltersTab) View() string {
 		newerStyle = styles.StandardInputFocusedStyle
 	}
 	content.WriteString(zone.Mark("filters_newer_input", newerStyle.Render("Newer than: "+t.model.GetNewerInput().View())))
+	content.WriteString("\n")
+
+	// Access and change time filters
+	accessedBeforeStyle := styles.StandardInputStyle
+	if t.model.GetFocusedElement() == "accessedBeforeInput" {
+		accessedBeforeStyle = styles.StandardInputFocusedStyle
+	}
+	content.WriteString(zone.Mark("filters_accessed_before_input", accessedBeforeStyle.Render("Accessed before: "+t.model.GetAccessedBeforeInput().View())))
+	content.WriteString("\n")
+
+	accessedAfterStyle := styles.StandardInputStyle
+	if t.model.GetFocusedElement() == "accessedAfterInput" {
+		accessedAfterStyle = styles.StandardInputFocusedStyle
+	}
+	content.WriteString(zone.Mark("filters_accessed_after_input", accessedAfterStyle.Render("Accessed after: "+t.model.GetAccessedAfterInput().View())))
+	content.WriteString("\n")
+
+	changedBeforeStyle := styles.StandardInputStyle
+	if t.model.GetFocusedElement() == "changedBeforeInput" {
+		changedBeforeStyle = styles.StandardInputFocusedStyle
+	}
+	content.WriteString(zone.Mark("filters_changed_before_input", changedBeforeStyle.Render("Changed before: "+t.model.GetChangedBeforeInput().View())))
+	content.WriteString("\n")
+
+	changedAfterStyle := styles.StandardInputStyle
+	if t.model.GetFocusedElement() == "changedAfterInput" {
+		changedAfterStyle = styles.StandardInputFocusedStyle
+	}
+	content.WriteString(zone.Mark("filters_changed_after_input", changedAfterStyle.Render("Changed after: "+t.model.GetChangedAfterInput().View())))
+
+	if mount := t.model.GetNoAtimeMount(); mount != "" {
+		content.WriteString("\n")
+		content.WriteString(styles.InfoStyle.Render(fmt.Sprintf("Warning: %s is mounted noatime: access times show when files were created, not when they were last read", mount)))
+	}
+	content.WriteString("\n")
+
+	// Owner, group and permission filters
+	ownerStyle := styles.StandardInputStyle
+	if t.model.GetFocusedElement() == "ownerInput" {
+		ownerStyle = styles.StandardInputFocusedStyle
+	}
+	content.WriteString(zone.Mark("filters_owner_input", ownerStyle.Render("Owner: "+t.model.GetOwnerInput().View())))
+	content.WriteString("\n")
+
+	notOwnerStyle := styles.StandardInputStyle
+	if t.model.GetFocusedElement() == "notOwnerInput" {
+		notOwnerStyle = styles.StandardInputFocusedStyle
+	}
+	content.WriteString(zone.Mark("filters_not_owner_input", notOwnerStyle.Render("Not owner: "+t.model.GetNotOwnerInput().View())))
+	content.WriteString("\n")
+
+	uidStyle := styles.StandardInputStyle
+	if t.model.GetFocusedElement() == "uidInput" {
+		uidStyle = styles.StandardInputFocusedStyle
+	}
+	content.WriteString(zone.Mark("filters_uid_input", uidStyle.Render("UID: "+t.model.GetUIDInput().View())))
+	content.WriteString("\n")
+
+	groupStyle := styles.StandardInputStyle
+	if t.model.GetFocusedElement() == "groupInput" {
+		groupStyle = styles.StandardInputFocusedStyle
+	}
+	content.WriteString(zone.Mark("filters_group_input", groupStyle.Render("Group: "+t.model.GetGroupInput().View())))
+	content.WriteString("\n")
+
+	permStyle := styles.StandardInputStyle
+	if t.model.GetFocusedElement() == "permInput" {
+		permStyle = styles.StandardInputFocusedStyle
+	}
+	content.WriteString(zone.Mark("filters_perm_input", permStyle.Render("Permissions: "+t.model.GetPermInput().View())))
+	content.WriteString("\n")
+
+	// Filter expression
+	whereStyle := styles.StandardInputStyle
+	if t.model.GetFocusedElement() == "whereInput" {
+		whereStyle = styles.StandardInputFocusedStyle
+	}
+	content.WriteString(zone.Mark("filters_where_input", whereStyle.Render("Where: "+t.model.GetWhereInput().View())))
 
 	return content.String()
 }
@@ -44,6 +44,9 @@ func (t *HelpTab) View() string {
 	content.WriteString("  Alt+2    - Toggle confirm deletion\n")
 	content.WriteString("  Alt+3    - Toggle include subfolders\n")
 	content.WriteString("  Alt+4    - Toggle delete empty subfolders\n")
+	content.WriteString("  Alt+-    - Toggle delete broken links\n")
+	content.WriteString("  Alt+=    - Toggle delete empty files\n")
+	content.WriteString("  Alt+\\    - Toggle shred files\n")
 
 	return content.String()
 }
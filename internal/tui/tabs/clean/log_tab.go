@@ -104,13 +104,21 @@ func (t *LogTab) View() string {
 		{"⏰", "Start Time", timeStr, false},
 		{"⏱️", "Program lifetime", durationStr, true},
 		{"📝", "Total Files", fmt.Sprintf("%d", t.totalStats.TotalFiles), false},
-		{"💾", "Total Size", utils.FormatSize(t.totalStats.TotalSize), true},
+		{"💾", "Total Size", utils.FormatSize(t.totalStats.TotalSize), false},
+		{"💽", "Freed Size", utils.FormatSize(t.totalStats.FreedSize), true},
 		{"🗑️", "Deleted Files", fmt.Sprintf("%d", t.totalStats.DeletedFiles), false},
 		{"📈", "Deleted Size", utils.FormatSize(t.totalStats.DeletedSize), true},
 		{"♻️", "Trashed Files", fmt.Sprintf("%d", t.totalStats.TrashedFiles), false},
 		{"📈", "Trashed Size", utils.FormatSize(t.totalStats.TrashedSize), true},
 		{"🚫", "Ignored Files", fmt.Sprintf("%d", t.totalStats.IgnoredFiles), false},
-		{"📈", "Ignored Size", utils.FormatSize(t.totalStats.IgnoredSize), false},
+		{"📈", "Ignored Size", utils.FormatSize(t.totalStats.IgnoredSize), true},
+		{"🔥", "Shredded Files", fmt.Sprintf("%d", t.totalStats.ShreddedFiles), false},
+		{"📈", "Shredded Size", utils.FormatSize(t.totalStats.ShreddedSize), false},
+		{"⚡", "Shred Throughput", utils.FormatSize(int64(t.totalStats.ShredThroughput())) + "/s", true},
+		{"🔗", "Broken Links", fmt.Sprintf("%d", t.totalStats.BrokenLinks), false},
+		{"📭", "Empty Files", fmt.Sprintf("%d", t.totalStats.EmptyFiles), true},
+		{"🔒", "In Use Files", fmt.Sprintf("%d", t.totalStats.InUseFiles), false},
+		{"📈", "In Use Size", utils.FormatSize(t.totalStats.InUseSize), false},
 	}
 	// Create table content
 	var tableContent strings.Builder
@@ -148,6 +156,15 @@ func (t *LogTab) UpdateStats(stats *logging.ScanStatistics) {
 		t.totalStats.TrashedSize += stats.TrashedSize
 		t.totalStats.IgnoredFiles += stats.IgnoredFiles
 		t.totalStats.IgnoredSize += stats.IgnoredSize
+		t.totalStats.ShreddedFiles += stats.ShreddedFiles
+		t.totalStats.ShreddedSize += stats.ShreddedSize
+		t.totalStats.ShredWritten += stats.ShredWritten
+		t.totalStats.ShredNanos += stats.ShredNanos
+		t.totalStats.FreedSize += stats.FreedSize
+		t.totalStats.BrokenLinks += stats.BrokenLinks
+		t.totalStats.EmptyFiles += stats.EmptyFiles
+		t.totalStats.InUseFiles += stats.InUseFiles
+		t.totalStats.InUseSize += stats.InUseSize
 
 		// Force a redraw by sending a nil message to the model
 		t.model.Update(nil)
@@ -153,6 +153,7 @@ func (t *MainTab) View() string {
 				const iconWidth = 3
 				const filenameWidth = 70
 				const sizeWidth = 10
+				const ownerWidth = 12
 
 				iconDisplay := fmt.Sprintf("%-*s", iconWidth, icon)
 				displayName := filename
@@ -160,12 +161,21 @@ func (t *MainTab) View() string {
 					displayName = displayName[:filenameWidth-3] + "..."
 				}
 				sizeDisplay := fmt.Sprintf("%-*s", sizeWidth, sizeStr)
+				owner := item.Owner
+				if len(owner) > ownerWidth {
+					owner = owner[:ownerWidth-3] + "..."
+				}
+
+				if item.Type != "" {
+					owner = fmt.Sprintf("%-*s %s", ownerWidth, owner, item.Type)
+				}
 
-				line := fmt.Sprintf("%s%s%-*s%s",
+				line := fmt.Sprintf("%s%s%-*s%s%s",
 					prefix,
 					iconDisplay,
 					filenameWidth, displayName,
-					sizeDisplay)
+					sizeDisplay,
+					owner)
 
 				listContent.WriteString(style.Render(line))
 				listContent.WriteString("\n")
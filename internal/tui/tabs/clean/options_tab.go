@@ -40,6 +40,11 @@ func (t *OptionsTab) View() string {
 		content.WriteString("\n")
 	}
 
+	if t.model.GetOptionState()[options.ShredFiles] {
+		content.WriteString(styles.InfoStyle.Render("\nShredding overwrites files in place before deleting them. Copy-on-write\nfilesystems (btrfs, ZFS, APFS), SSDs and snapshots keep earlier copies,\nso the data may still be recoverable there. Trash takes precedence."))
+		content.WriteString("\n")
+	}
+
 	return content.String()
 }
 
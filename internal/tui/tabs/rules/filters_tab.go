@@ -32,6 +32,17 @@ func (t *FiltersTab) View() string {
 		{"Exclude", t.model.GetExcludeInput(), "excludeInput"},
 		{"Older Than", t.model.GetOlderInput(), "olderInput"},
 		{"Newer Than", t.model.GetNewerInput(), "newerInput"},
+		{"Accessed Before", t.model.GetAccessedBeforeInput(), "accessedBeforeInput"},
+		{"Accessed After", t.model.GetAccessedAfterInput(), "accessedAfterInput"},
+		{"Changed Before", t.model.GetChangedBeforeInput(), "changedBeforeInput"},
+		{"Changed After", t.model.GetChangedAfterInput(), "changedAfterInput"},
+		{"Owner", t.model.GetOwnerInput(), "ownerInput"},
+		{"Not Owner", t.model.GetNotOwnerInput(), "notOwnerInput"},
+		{"UID", t.model.GetUIDInput(), "uidInput"},
+		{"Group", t.model.GetGroupInput(), "groupInput"},
+		{"Permissions", t.model.GetPermInput(), "permInput"},
+		{"Where", t.model.GetWhereInput(), "whereInput"},
+		{"Presets", t.model.GetPresetsInput(), "presetsInput"},
 	}
 
 	for _, input := range inputs {
@@ -1,6 +1,7 @@
 package views
 
 import (
+	"context"
 	"fmt"
 	"runtime"
 	"strconv"
@@ -12,6 +13,7 @@ import (
 	zone "github.com/lrstanley/bubblezone"
 	"github.com/pashkov256/deletor/internal/cache"
 	"github.com/pashkov256/deletor/internal/filemanager"
+	"github.com/pashkov256/deletor/internal/logging"
 	rules "github.com/pashkov256/deletor/internal/rules"
 	"github.com/pashkov256/deletor/internal/tui/errors"
 	"github.com/pashkov256/deletor/internal/tui/help"
@@ -21,17 +23,33 @@ import (
 )
 
 type CacheModel struct {
-	OptionState      map[string]bool
+	OptionState      map[string]bool       // Selection of each location, keyed by its name
+	locations        []cache.CacheLocation // Locations offered for selection
 	FocusedElement   string
 	cacheManager     cache.Manager
 	filemanager      filemanager.FileManager
 	scanResults      []cache.ScanResult
 	isScanning       bool
+	isClearing       bool
+	confirmClear     bool               // Whether the next delete also clears locations that ask first
+	cancel           context.CancelFunc // Stops the running scan or clear
 	rulesOptionState map[string]bool
 	status           string
 	Error            *errors.Error
 }
 
+// CacheScanDoneMsg carries the results of a background cache scan
+type CacheScanDoneMsg struct {
+	Results []cache.ScanResult
+	Err     error // context.Canceled when the scan was cancelled
+}
+
+// CacheClearDoneMsg is sent when a background cache clear finishes
+type CacheClearDoneMsg struct {
+	Result cache.ClearResult
+	Err    error // context.Canceled when clearing was cancelled
+}
+
 type CachePath struct {
 	Path string
 	Size string
@@ -39,27 +57,48 @@ type CachePath struct {
 
 func InitialCacheModel(fm filemanager.FileManager, rules rules.Rules) *CacheModel {
 	latestRules, _ := rules.GetRules()
+	cacheManager := cache.NewCacheManager(fm)
+	cacheManager.SkipOpenFiles = latestRules.SkipOpenFiles
+	cacheManager.Custom = customCacheLocations(latestRules.CacheLocations)
+
+	// Only the system locations are selected until the user picks others
+	locations := cacheManager.Known()
+	optionState := make(map[string]bool, len(locations))
+	for _, location := range locations {
+		optionState[location.Name] = location.Type == cache.SystemCache
+	}
 	return &CacheModel{
-		cacheManager:   *cache.NewCacheManager(fm),
+		cacheManager:   *cacheManager,
 		filemanager:    fm,
-		OptionState:    options.DefaultCacheOptionState,
+		OptionState:    optionState,
+		locations:      locations,
 		FocusedElement: "option1",
 		rulesOptionState: map[string]bool{
 			options.ShowHiddenFiles:       latestRules.ShowHiddenFiles,
 			options.ConfirmDeletion:       latestRules.ConfirmDeletion,
 			options.IncludeSubfolders:     latestRules.IncludeSubfolders,
 			options.DeleteEmptySubfolders: latestRules.DeleteEmptySubfolders,
+			options.DeleteBrokenLinks:     latestRules.DeleteBrokenLinks,
+			options.DeleteEmptyFiles:      latestRules.DeleteEmptyFiles,
 			options.SendFilesToTrash:      latestRules.SendFilesToTrash,
 			options.LogOperations:         latestRules.LogOperations,
 			options.LogToFile:             latestRules.LogToFile,
 			options.ShowStatistics:        latestRules.ShowStatistics,
 			options.DisableEmoji:          latestRules.DisableEmoji,
 			options.ExitAfterDeletion:     latestRules.ExitAfterDeletion,
+			options.ShredFiles:            latestRules.Shred,
 		},
 		status: "",
 	}
 }
 
+// customCacheLocations compiles the cache locations saved in the rules.
+// The rules are validated when loaded, so none are dropped in practice.
+func customCacheLocations(saved []rules.CacheRule) []cache.CacheLocation {
+	locations, _ := rules.CompileCacheLocations(saved)
+	return locations
+}
+
 const pathWidth = 60
 const sizeWidth = 15
 const filesWidth = 10
@@ -72,8 +111,9 @@ func (m *CacheModel) View() string {
 	var content strings.Builder
 	disableEmoji := m.GetRulesOptionState()[options.DisableEmoji]
 	content.WriteString("\n")
-	content.WriteString("Select cache types to clear:\n")
-	for optionIndex, name := range options.DefaultCacheOption {
+	content.WriteString("Select cache locations to clear:\n")
+	for optionIndex, location := range m.locations {
+		name := location.Name
 		style := styles.OptionStyle
 		if m.OptionState[name] {
 			style = styles.SelectedOptionStyle
@@ -85,13 +125,22 @@ func (m *CacheModel) View() string {
 
 		emoji := ""
 		if !disableEmoji {
-			switch name {
-			case options.SystemCache:
+			switch location.Type {
+			case cache.SystemCache:
 				emoji = "💻"
+			case cache.DevCache:
+				emoji = "🛠️"
+			case cache.BrowserCache:
+				emoji = "🌐"
+			case cache.AppCache:
+				emoji = "📦"
 			}
 		}
 
-		optionContent := fmt.Sprintf("[%s] %s %-20s", map[bool]string{true: "✓", false: "○"}[m.OptionState[name]], emoji, name)
+		optionContent := fmt.Sprintf("[%s] %s %-12s %-26s", map[bool]string{true: "✓", false: "○"}[m.OptionState[name]], emoji, name, location.Description)
+		if location.Confirm {
+			optionContent += " (asks first)"
+		}
 		content.WriteString(zone.Mark(fmt.Sprintf("cache_option_%d", optionIndex+1), style.Render(optionContent)))
 		content.WriteString("\n")
 	}
@@ -194,6 +243,9 @@ func (m *CacheModel) View() string {
 	content.WriteString(zone.Mark("cache_delete_button", deleteBtn))
 	content.WriteString("\n\n")
 	content.WriteString("\n" + help.NavigateHelpText)
+	if m.isScanning || m.isClearing {
+		content.WriteString(help.CancelHelpText + "\n")
+	}
 	return zone.Scan(content.String())
 }
 
@@ -207,12 +259,42 @@ func (m *CacheModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleShiftTab()
 		case "enter", " ":
 			return m.handleSpace()
+		case "ctrl+x":
+			m.CancelOperations()
+			return m, nil
+		}
+	case CacheScanDoneMsg:
+		m.isScanning = false
+		m.cancel = nil
+		m.scanResults = msg.Results
+		if msg.Err == context.Canceled {
+			m.status = "Scan cancelled, sizes are partial"
+		}
+		return m, nil
+	case CacheClearDoneMsg:
+		m.isClearing = false
+		m.cancel = nil
+		switch {
+		case msg.Err == context.Canceled:
+			m.status = "Cache clearing cancelled"
+		case msg.Err != nil:
+			m.Error = errors.New(errors.ErrorTypeFileSystem, "Not all files were successfully deleted")
+		default:
+			m.scanResults = []cache.ScanResult{}
+			m.status = "Cache clearing completed"
+			if msg.Result.InUseFiles > 0 {
+				m.status += fmt.Sprintf(", %d files in use (%s) skipped", msg.Result.InUseFiles, utils.FormatSize(msg.Result.InUseSize))
+			}
+			if msg.Result.DeletedOpenSize > 0 {
+				m.status += fmt.Sprintf(", %s held by deleted files still open", utils.FormatSize(msg.Result.DeletedOpenSize))
+			}
 		}
+		return m, nil
 	case tea.MouseMsg:
 		// nolint:staticcheck
 		if msg.Type == tea.MouseLeft && msg.Action == tea.MouseActionPress {
 			// Handle option clicks
-			for i := range options.DefaultCacheOption {
+			for i := range m.locations {
 				if zone.Get(fmt.Sprintf("cache_option_%d", i+1)).InBounds(msg) {
 					m.FocusedElement = fmt.Sprintf("option%d", i+1)
 					return m.handleSpace()
@@ -236,33 +318,49 @@ func (m *CacheModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *CacheModel) handleTab() (tea.Model, tea.Cmd) {
-	switch m.FocusedElement {
-	case "option1":
-		m.FocusedElement = "scanButton"
-	case "scanButton":
-		m.FocusedElement = "deleteButton"
-	case "deleteButton":
-		m.FocusedElement = "option1"
-	default:
-		m.FocusedElement = "option1"
-	}
+	m.moveFocus(1)
 	return m, nil
 }
 
 func (m *CacheModel) handleShiftTab() (tea.Model, tea.Cmd) {
-	switch m.FocusedElement {
-	case "option1":
-		m.FocusedElement = "deleteButton"
-	case "scanButton":
-		m.FocusedElement = "option1"
-	case "deleteButton":
-		m.FocusedElement = "scanButton"
-	default:
-		m.FocusedElement = "option1"
-	}
+	m.moveFocus(-1)
 	return m, nil
 }
 
+// moveFocus moves the focus by step through the location options followed
+// by the scan and delete buttons, wrapping around at either end
+func (m *CacheModel) moveFocus(step int) {
+	order := make([]string, 0, len(m.locations)+2)
+	for i := range m.locations {
+		order = append(order, fmt.Sprintf("option%d", i+1))
+	}
+	order = append(order, "scanButton", "deleteButton")
+
+	current := -1
+	for i, element := range order {
+		if element == m.FocusedElement {
+			current = i
+		}
+	}
+	if current < 0 {
+		m.FocusedElement = order[0]
+		return
+	}
+	m.FocusedElement = order[(current+step+len(order))%len(order)]
+}
+
+// selectLocations points the cache manager at the locations selected on
+// the page and reports when none is
+func (m *CacheModel) selectLocations() error {
+	var names []string
+	for _, location := range m.locations {
+		if m.OptionState[location.Name] {
+			names = append(names, location.Name)
+		}
+	}
+	return m.cacheManager.Select(names...)
+}
+
 func (m *CacheModel) handleSpace() (tea.Model, tea.Cmd) {
 	if strings.HasPrefix(m.FocusedElement, "option") {
 		optionNum := strings.TrimPrefix(m.FocusedElement, "option")
@@ -270,47 +368,98 @@ func (m *CacheModel) handleSpace() (tea.Model, tea.Cmd) {
 		if err != nil {
 			return m, nil
 		}
-		if idx < 1 || idx > len(options.DefaultCacheOption) {
+		if idx < 1 || idx > len(m.locations) {
 			return m, nil
 		}
 		idx--
 
-		optName := options.DefaultCacheOption[idx]
+		optName := m.locations[idx].Name
 		m.OptionState[optName] = !m.OptionState[optName]
+		m.confirmClear = false
 
 		m.FocusedElement = "option" + optionNum
 
 		return m, nil
 	} else if m.FocusedElement == "scanButton" {
-		m.isScanning = true
+		if m.isScanning || m.isClearing {
+			return m, nil
+		}
 		m.scanResults = nil
 		m.status = ""
 		m.Error = nil
+		m.confirmClear = false
+		if err := m.selectLocations(); err != nil {
+			m.Error = errors.New(errors.ErrorTypeValidation, "Select at least one cache location to scan")
+			return m, nil
+		}
+		m.isScanning = true
 
-		results := m.cacheManager.ScanAllLocations()
-		m.scanResults = results
-		m.isScanning = false
-
-		return m, nil
+		ctx := m.startOperation()
+		return m, func() tea.Msg {
+			results := m.cacheManager.ScanAllLocations(ctx)
+			return CacheScanDoneMsg{Results: results, Err: ctx.Err()}
+		}
 	} else if m.FocusedElement == "deleteButton" {
+		if m.isScanning || m.isClearing {
+			return m, nil
+		}
 		m.Error = nil
 		m.status = ""
 
 		if runtime.GOOS == "darwin" {
 			m.Error = errors.New(errors.ErrorTypeFileSystem, "Currently only Windows and Linux is supported for cache clearing")
-		} else {
-			if err := m.cacheManager.ClearCache(); err != nil {
-				m.Error = errors.New(errors.ErrorTypeFileSystem, "Not all files were successfully deleted")
-			} else {
-				m.scanResults = []cache.ScanResult{}
-				m.status = "Cache clearing completed"
-			}
+			return m, nil
+		}
+		if err := m.selectLocations(); err != nil {
+			m.Error = errors.New(errors.ErrorTypeValidation, "Select at least one cache location to clear")
+			return m, nil
+		}
+		if names := m.confirmNames(); len(names) > 0 && !m.confirmClear {
+			m.confirmClear = true
+			m.status = fmt.Sprintf("Clearing %s needs confirmation, press Delete again to clear", strings.Join(names, ", "))
+			return m, nil
+		}
+		m.confirmClear = false
+
+		m.isClearing = true
+		ctx := m.startOperation()
+		return m, func() tea.Msg {
+			journal := logging.OpenDefaultJournal("")
+			result, err := m.cacheManager.ClearCache(ctx, journal)
+			journal.Finish(ctx.Err())
+			return CacheClearDoneMsg{Result: result, Err: err}
 		}
-		return m, nil
 	}
 	return m, nil
 }
 
+// confirmNames returns the names of the selected locations that are only
+// cleared once the delete is confirmed
+func (m *CacheModel) confirmNames() []string {
+	var names []string
+	for _, location := range m.cacheManager.Locations {
+		if location.Confirm {
+			names = append(names, location.Name)
+		}
+	}
+	return names
+}
+
+// startOperation returns the context for a new scan or clear
+func (m *CacheModel) startOperation() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	return ctx
+}
+
+// CancelOperations stops the running scan or clear, if any
+func (m *CacheModel) CancelOperations() {
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+}
+
 func (m *CacheModel) GetRulesOptionState() map[string]bool {
 	return m.rulesOptionState
 }
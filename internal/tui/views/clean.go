@@ -1,6 +1,7 @@
 package views
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,6 +10,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/bubbles/list"
@@ -30,40 +32,62 @@ import (
 )
 
 type CleanFilesModel struct {
-	List              list.Model
-	ExtInput          textinput.Model
-	MinSizeInput      textinput.Model
-	MaxSizeInput      textinput.Model
-	PathInput         textinput.Model
-	ExcludeInput      textinput.Model
-	OlderInput        textinput.Model
-	NewerInput        textinput.Model
-	CurrentPath       string
-	Extensions        []string
-	MinSize           int64
-	MaxSize           int64
-	Exclude           []string
-	Options           []string
-	OptionState       map[string]bool
-	FocusedElement    string // "pathInput", "extInput","excludeInput","olderInput","newerInput", "minSizeInput","maxSizeInput", "deleteButton","dirButton", "clean_option_1", "clean_option_2", "clean_option_3"
-	FileToDelete      *models.CleanItem
-	ShowDirs          bool
-	DirList           list.Model
-	DirSize           int64 // Cached directory size
-	CalculatingSize   bool  // Flag to indicate size calculation in progress
-	FilteredSize      int64 // Total size of filtered files
-	FilteredCount     int   // Count of filtered files
-	Rules             rules.Rules
-	Filemanager       filemanager.FileManager
-	TabManager        *clean.CleanTabManager
-	Validator         *validation.Validator
-	Logger            *logging.Logger
-	Error             *errors.Error
-	IsLaunched        bool            // Track if the app has been launched
-	SelectedFiles     map[string]bool // Track selected files by path
-	SelectedSize      int64           // Track selected files size
-	SelectedCount     int             // Track selected files count
-	LastSelectedIndex int             // Track last selected index for range selection
+	List                list.Model
+	ExtInput            textinput.Model
+	MinSizeInput        textinput.Model
+	MaxSizeInput        textinput.Model
+	PathInput           textinput.Model
+	ExcludeInput        textinput.Model
+	OlderInput          textinput.Model
+	NewerInput          textinput.Model
+	AccessedBeforeInput textinput.Model
+	AccessedAfterInput  textinput.Model
+	ChangedBeforeInput  textinput.Model
+	ChangedAfterInput   textinput.Model
+	OwnerInput          textinput.Model
+	NotOwnerInput       textinput.Model
+	UIDInput            textinput.Model
+	GroupInput          textinput.Model
+	PermInput           textinput.Model
+	WhereInput          textinput.Model
+	CurrentPath         string
+	Extensions          []string
+	MinSize             int64
+	MaxSize             int64
+	Exclude             []string
+	Include             []string // Include globs from the rules
+	OneFileSystem       bool     // Whether scans stay on the filesystem of the path
+	SkipFilesystems     []string // Filesystem types or groups whose mounts are skipped
+	Symlinks            string   // Symbolic link policy of scans
+	SkipOpenFiles       bool     // Whether files held open by a process are left alone
+	NoAtimeMount        string   // Mount point of the path when access filters are set and it is mounted noatime
+	Options             []string
+	OptionState         map[string]bool
+	FocusedElement      string // "pathInput", "extInput","excludeInput","olderInput","newerInput", "minSizeInput","maxSizeInput", "deleteButton","dirButton", "clean_option_1", "clean_option_2", "clean_option_3"
+	FileToDelete        *models.CleanItem
+	ShowDirs            bool
+	DirList             list.Model
+	DirSize             int64 // Cached directory size
+	CalculatingSize     bool  // Flag to indicate size calculation in progress
+	FilteredSize        int64 // Total size of filtered files
+	FilteredCount       int   // Count of filtered files
+	Rules               rules.Rules
+	Filemanager         filemanager.FileManager
+	WalkLimits          filemanager.WalkLimits
+	PresetTerms         []filemanager.PresetTerm // Presets of the rules; their directory rules are cleaned by the CLI only
+	ShredOptions        filemanager.ShredOptions
+	TabManager          *clean.CleanTabManager
+	Validator           *validation.Validator
+	Logger              *logging.Logger
+	Error               *errors.Error
+	IsLaunched          bool            // Track if the app has been launched
+	SelectedFiles       map[string]bool // Track selected files by path
+	SelectedSize        int64           // Track selected files size
+	SelectedCount       int             // Track selected files count
+	LastSelectedIndex   int             // Track last selected index for range selection
+	Deleting            bool            // Flag to indicate a bulk delete in progress
+	cancelSize          context.CancelFunc
+	cancelDelete        context.CancelFunc
 }
 
 // Message for directory size updates
@@ -71,6 +95,13 @@ type DirSizeMsg struct {
 	Size int64
 }
 
+// Message sent when a background bulk delete finishes or is cancelled
+type BulkDeleteDoneMsg struct {
+	Removed int                     // Number of files removed before the delete ended
+	Err     error                   // context.Canceled when the delete was cancelled
+	Stats   *logging.ScanStatistics // Counters of the delete, nil if not collected
+}
+
 func InitialCleanModel(rules rules.Rules, fileManager filemanager.FileManager, validator *validation.Validator) *CleanFilesModel {
 	// Create a temporary model to get rules
 	lastestRules, _ := rules.GetRules()
@@ -81,6 +112,7 @@ func InitialCleanModel(rules rules.Rules, fileManager filemanager.FileManager, v
 	latestExclude := lastestRules.Exclude
 	latestOlderThan := lastestRules.OlderThan
 	latestNewerThan := lastestRules.NewerThan
+	presetTerms := expandRulePresets(lastestRules.Presets)
 
 	// Initialize inputs
 	extInput := textinput.New()
@@ -126,12 +158,82 @@ func InitialCleanModel(rules rules.Rules, fileManager filemanager.FileManager, v
 	newerInput.TextStyle = styles.TextInputTextStyle
 	newerInput.Cursor.Style = styles.TextInputCursorStyle
 
+	accessedBeforeInput := textinput.New()
+	accessedBeforeInput.SetValue(lastestRules.AccessedBefore)
+	accessedBeforeInput.PromptStyle = styles.TextInputPromptStyle
+	accessedBeforeInput.TextStyle = styles.TextInputTextStyle
+	accessedBeforeInput.Cursor.Style = styles.TextInputCursorStyle
+
+	accessedAfterInput := textinput.New()
+	accessedAfterInput.SetValue(lastestRules.AccessedAfter)
+	accessedAfterInput.PromptStyle = styles.TextInputPromptStyle
+	accessedAfterInput.TextStyle = styles.TextInputTextStyle
+	accessedAfterInput.Cursor.Style = styles.TextInputCursorStyle
+
+	changedBeforeInput := textinput.New()
+	changedBeforeInput.SetValue(lastestRules.ChangedBefore)
+	changedBeforeInput.PromptStyle = styles.TextInputPromptStyle
+	changedBeforeInput.TextStyle = styles.TextInputTextStyle
+	changedBeforeInput.Cursor.Style = styles.TextInputCursorStyle
+
+	changedAfterInput := textinput.New()
+	changedAfterInput.SetValue(lastestRules.ChangedAfter)
+	changedAfterInput.PromptStyle = styles.TextInputPromptStyle
+	changedAfterInput.TextStyle = styles.TextInputTextStyle
+	changedAfterInput.Cursor.Style = styles.TextInputCursorStyle
+
+	ownerInput := textinput.New()
+	ownerInput.SetValue(strings.Join(lastestRules.Owners, ","))
+	ownerInput.PromptStyle = styles.TextInputPromptStyle
+	ownerInput.TextStyle = styles.TextInputTextStyle
+	ownerInput.Cursor.Style = styles.TextInputCursorStyle
+
+	notOwnerInput := textinput.New()
+	notOwnerInput.SetValue(strings.Join(lastestRules.NotOwners, ","))
+	notOwnerInput.PromptStyle = styles.TextInputPromptStyle
+	notOwnerInput.TextStyle = styles.TextInputTextStyle
+	notOwnerInput.Cursor.Style = styles.TextInputCursorStyle
+
+	uidInput := textinput.New()
+	uidInput.SetValue(formatIDs(lastestRules.UIDs))
+	uidInput.PromptStyle = styles.TextInputPromptStyle
+	uidInput.TextStyle = styles.TextInputTextStyle
+	uidInput.Cursor.Style = styles.TextInputCursorStyle
+
+	groupInput := textinput.New()
+	groupInput.SetValue(strings.Join(lastestRules.Groups, ","))
+	groupInput.PromptStyle = styles.TextInputPromptStyle
+	groupInput.TextStyle = styles.TextInputTextStyle
+	groupInput.Cursor.Style = styles.TextInputCursorStyle
+
+	permInput := textinput.New()
+	permInput.SetValue(lastestRules.Perm)
+	permInput.PromptStyle = styles.TextInputPromptStyle
+	permInput.TextStyle = styles.TextInputTextStyle
+	permInput.Cursor.Style = styles.TextInputCursorStyle
+
+	whereInput := textinput.New()
+	whereInput.SetValue(lastestRules.Where)
+	whereInput.PromptStyle = styles.TextInputPromptStyle
+	whereInput.TextStyle = styles.TextInputTextStyle
+	whereInput.Cursor.Style = styles.TextInputCursorStyle
+
 	extInput.Placeholder = "e.g. js,png,zip"
 	minSizeInput.Placeholder = "e.g. 10b,10kb,10mb,10gb,10tb"
 	maxSizeInput.Placeholder = "e.g. 10b,10kb,10mb,10gb,10tb"
 	excludeInput.Placeholder = "specific files/paths (e.g. data,backup)"
 	olderInput.Placeholder = "e.g. 60 min, 1 hour, 7 days, 1 month"
 	newerInput.Placeholder = "e.g. 60 min, 1 hour, 7 days, 1 month"
+	accessedBeforeInput.Placeholder = "not read for, e.g. 90 days"
+	accessedAfterInput.Placeholder = "read within, e.g. 7 days"
+	changedBeforeInput.Placeholder = "e.g. 60 min, 1 hour, 7 days, 1 month"
+	changedAfterInput.Placeholder = "e.g. 60 min, 1 hour, 7 days, 1 month"
+	ownerInput.Placeholder = "user names or IDs (e.g. root,www-data)"
+	notOwnerInput.Placeholder = "skip files of these users (e.g. root)"
+	uidInput.Placeholder = "numeric user IDs (e.g. 1000,1001)"
+	groupInput.Placeholder = "group names or IDs (e.g. staff)"
+	permInput.Placeholder = "mode, -mode or /mode (e.g. -o+w, 644)"
+	whereInput.Placeholder = `e.g. ext in (log,tmp) and age > 7d and not path ~ "keep/**"`
 
 	// Create a proper delegate with visible height
 	delegate := list.NewDefaultDelegate()
@@ -176,31 +278,63 @@ func InitialCleanModel(rules rules.Rules, fileManager filemanager.FileManager, v
 	// Expand the path if it contains tilde
 	expandedPath := utils.ExpandTilde(latestDir)
 
+	// Depth and directory size limits only come from the rules
+	walkLimits := filemanager.WalkLimits{
+		MinDepth:      lastestRules.MinDepth,
+		MaxDepth:      lastestRules.MaxDepth,
+		MaxDirEntries: lastestRules.MaxDirEntries,
+	}
+	shredOptions := filemanager.ShredOptions{
+		Passes: lastestRules.ShredPasses,
+		Method: filemanager.ShredMethod(lastestRules.ShredMethod),
+	}
+
 	// Create model first
 	model := &CleanFilesModel{
-		List:         l,
-		ExtInput:     extInput,
-		MinSizeInput: minSizeInput,
-		MaxSizeInput: maxSizeInput,
-		PathInput:    pathInput,
-		ExcludeInput: excludeInput,
-		OlderInput:   olderInput,
-		NewerInput:   newerInput,
-		CurrentPath:  expandedPath,
-		Extensions:   latestExtensions,
-		MinSize:      minSize,
-		Exclude:      latestExclude,
+		List:                l,
+		ExtInput:            extInput,
+		MinSizeInput:        minSizeInput,
+		MaxSizeInput:        maxSizeInput,
+		PathInput:           pathInput,
+		ExcludeInput:        excludeInput,
+		OlderInput:          olderInput,
+		NewerInput:          newerInput,
+		AccessedBeforeInput: accessedBeforeInput,
+		AccessedAfterInput:  accessedAfterInput,
+		ChangedBeforeInput:  changedBeforeInput,
+		ChangedAfterInput:   changedAfterInput,
+		OwnerInput:          ownerInput,
+		NotOwnerInput:       notOwnerInput,
+		UIDInput:            uidInput,
+		GroupInput:          groupInput,
+		PermInput:           permInput,
+		WhereInput:          whereInput,
+		CurrentPath:         expandedPath,
+		Extensions:          latestExtensions,
+		MinSize:             minSize,
+		Exclude:             latestExclude,
+		Include:             lastestRules.Include,
+		PresetTerms:         presetTerms,
+		OneFileSystem:       lastestRules.OneFileSystem,
+		SkipFilesystems:     lastestRules.SkipFilesystems,
+		WalkLimits:          walkLimits,
+		ShredOptions:        shredOptions,
+		Symlinks:            lastestRules.Symlinks,
+		SkipOpenFiles:       lastestRules.SkipOpenFiles,
 		OptionState: map[string]bool{
 			options.ShowHiddenFiles:       lastestRules.ShowHiddenFiles,
 			options.ConfirmDeletion:       lastestRules.ConfirmDeletion,
 			options.IncludeSubfolders:     lastestRules.IncludeSubfolders,
 			options.DeleteEmptySubfolders: lastestRules.DeleteEmptySubfolders,
+			options.DeleteBrokenLinks:     lastestRules.DeleteBrokenLinks,
+			options.DeleteEmptyFiles:      lastestRules.DeleteEmptyFiles,
 			options.SendFilesToTrash:      lastestRules.SendFilesToTrash,
 			options.LogOperations:         lastestRules.LogOperations,
 			options.LogToFile:             lastestRules.LogToFile,
 			options.ShowStatistics:        lastestRules.ShowStatistics,
 			options.DisableEmoji:          lastestRules.DisableEmoji,
 			options.ExitAfterDeletion:     lastestRules.ExitAfterDeletion,
+			options.ShredFiles:            lastestRules.Shred,
 		},
 		FocusedElement:    "list",
 		ShowDirs:          false,
@@ -292,6 +426,11 @@ func (m *CleanFilesModel) View() string {
 	// Render active tab content
 	content.WriteString(m.TabManager.GetActiveTab().View())
 
+	if m.Deleting {
+		content.WriteString("\n")
+		content.WriteString(styles.InfoStyle.Render("Deleting files... (Ctrl+X to cancel)"))
+	}
+
 	// Add error message if there is one
 	if m.Error != nil && m.Error.IsVisible() {
 		errorStyle := errors.GetStyle(m.Error.GetType())
@@ -423,6 +562,76 @@ func (m *CleanFilesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
+			if zone.Get("filters_accessed_before_input").InBounds(msg) {
+				m.blurAllInputs()
+				m.FocusedElement = "accessedBeforeInput"
+				m.AccessedBeforeInput.Focus()
+				return m, nil
+			}
+
+			if zone.Get("filters_accessed_after_input").InBounds(msg) {
+				m.blurAllInputs()
+				m.FocusedElement = "accessedAfterInput"
+				m.AccessedAfterInput.Focus()
+				return m, nil
+			}
+
+			if zone.Get("filters_changed_before_input").InBounds(msg) {
+				m.blurAllInputs()
+				m.FocusedElement = "changedBeforeInput"
+				m.ChangedBeforeInput.Focus()
+				return m, nil
+			}
+
+			if zone.Get("filters_changed_after_input").InBounds(msg) {
+				m.blurAllInputs()
+				m.FocusedElement = "changedAfterInput"
+				m.ChangedAfterInput.Focus()
+				return m, nil
+			}
+
+			if zone.Get("filters_owner_input").InBounds(msg) {
+				m.blurAllInputs()
+				m.FocusedElement = "ownerInput"
+				m.OwnerInput.Focus()
+				return m, nil
+			}
+
+			if zone.Get("filters_not_owner_input").InBounds(msg) {
+				m.blurAllInputs()
+				m.FocusedElement = "notOwnerInput"
+				m.NotOwnerInput.Focus()
+				return m, nil
+			}
+
+			if zone.Get("filters_uid_input").InBounds(msg) {
+				m.blurAllInputs()
+				m.FocusedElement = "uidInput"
+				m.UIDInput.Focus()
+				return m, nil
+			}
+
+			if zone.Get("filters_group_input").InBounds(msg) {
+				m.blurAllInputs()
+				m.FocusedElement = "groupInput"
+				m.GroupInput.Focus()
+				return m, nil
+			}
+
+			if zone.Get("filters_perm_input").InBounds(msg) {
+				m.blurAllInputs()
+				m.FocusedElement = "permInput"
+				m.PermInput.Focus()
+				return m, nil
+			}
+
+			if zone.Get("filters_where_input").InBounds(msg) {
+				m.blurAllInputs()
+				m.FocusedElement = "whereInput"
+				m.WhereInput.Focus()
+				return m, nil
+			}
+
 			// Handle options tab clicks
 			for i, option := range options.DefaultCleanOption {
 				if zone.Get(fmt.Sprintf("clean_option_%d", i+1)).InBounds(msg) {
@@ -457,6 +666,17 @@ func (m *CleanFilesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.DirSize = msg.Size
 		return m, nil
 
+	case BulkDeleteDoneMsg:
+		m.Deleting = false
+		m.cancelDelete = nil
+		if msg.Stats != nil {
+			m.publishStats(msg.Stats)
+		}
+		if msg.Err == context.Canceled {
+			m.Error = errors.New(errors.ErrorTypeFileSystem, fmt.Sprintf("Delete cancelled after removing %d files", msg.Removed))
+		}
+		return m, m.LoadFiles()
+
 	case []list.Item:
 		if m.ShowDirs {
 			m.DirList.SetItems(msg)
@@ -501,6 +721,36 @@ func (m *CleanFilesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case "newerInput":
 		m.NewerInput, cmd = m.NewerInput.Update(msg)
 		cmds = append(cmds, cmd)
+	case "accessedBeforeInput":
+		m.AccessedBeforeInput, cmd = m.AccessedBeforeInput.Update(msg)
+		cmds = append(cmds, cmd)
+	case "accessedAfterInput":
+		m.AccessedAfterInput, cmd = m.AccessedAfterInput.Update(msg)
+		cmds = append(cmds, cmd)
+	case "changedBeforeInput":
+		m.ChangedBeforeInput, cmd = m.ChangedBeforeInput.Update(msg)
+		cmds = append(cmds, cmd)
+	case "changedAfterInput":
+		m.ChangedAfterInput, cmd = m.ChangedAfterInput.Update(msg)
+		cmds = append(cmds, cmd)
+	case "ownerInput":
+		m.OwnerInput, cmd = m.OwnerInput.Update(msg)
+		cmds = append(cmds, cmd)
+	case "notOwnerInput":
+		m.NotOwnerInput, cmd = m.NotOwnerInput.Update(msg)
+		cmds = append(cmds, cmd)
+	case "uidInput":
+		m.UIDInput, cmd = m.UIDInput.Update(msg)
+		cmds = append(cmds, cmd)
+	case "groupInput":
+		m.GroupInput, cmd = m.GroupInput.Update(msg)
+		cmds = append(cmds, cmd)
+	case "permInput":
+		m.PermInput, cmd = m.PermInput.Update(msg)
+		cmds = append(cmds, cmd)
+	case "whereInput":
+		m.WhereInput, cmd = m.WhereInput.Update(msg)
+		cmds = append(cmds, cmd)
 	}
 
 	return m, tea.Batch(cmd, tea.Batch(cmds...))
@@ -542,6 +792,23 @@ func (m *CleanFilesModel) LoadFiles() tea.Cmd {
 			}
 		}
 
+		limits, err := m.statTimeLimits()
+		if err != nil {
+			return errors.New(errors.ErrorTypeValidation, err.Error())
+		}
+		if _, err := m.ownerLimits(); err != nil {
+			return errors.New(errors.ErrorTypeValidation, err.Error())
+		}
+		if limits.Where, err = filemanager.ParseExpr(m.WhereInput.Value()); err != nil {
+			return errors.New(errors.ErrorTypeValidation, fmt.Sprintf("Invalid filter expression: %v", err))
+		}
+		m.NoAtimeMount = ""
+		if filemanager.NewFileFilterWithOptions(limits, nil).UsesAccessTimes() {
+			if mount, ok := filemanager.NoAtimeMount(currentDir); ok {
+				m.NoAtimeMount = mount.Point
+			}
+		}
+
 		minSizeStr := m.MinSizeInput.Value()
 		if minSizeStr != "" {
 			minSize, err := utils.ToBytes(minSizeStr)
@@ -583,7 +850,7 @@ func (m *CleanFilesModel) LoadFiles() tea.Cmd {
 				})
 			}
 
-			filter := m.Filemanager.NewFileFilter(m.MinSize, m.MaxSize, utils.ParseExtToMap(m.Extensions), m.Exclude, olderDuration, newerDuration)
+			filter := m.newFileFilter(m.MinSize, m.MaxSize, olderDuration, newerDuration)
 
 			// Then collect files
 			for _, fileInfo := range fileInfos {
@@ -609,7 +876,7 @@ func (m *CleanFilesModel) LoadFiles() tea.Cmd {
 					continue
 				}
 
-				if !filter.MatchesFilters(fi, currentDir) {
+				if !filter.MatchesFilters(fi, path) || filter.OpenFiles.Contains(fi) {
 					continue
 				}
 
@@ -617,11 +884,16 @@ func (m *CleanFilesModel) LoadFiles() tea.Cmd {
 				totalFilteredSize += size
 				filteredCount++
 
-				items = append(items, models.CleanItem{
+				item := models.CleanItem{
 					Path:  path,
 					Size:  size,
 					IsDir: false,
-				})
+					Owner: filemanager.OwnerName(filemanager.NewFileEntry(path, info).UID),
+				}
+				if filter.UsesContentTypes() {
+					item.Type, _ = filemanager.DetectContentType(path)
+				}
+				items = append(items, item)
 			}
 		}
 
@@ -632,6 +904,116 @@ func (m *CleanFilesModel) LoadFiles() tea.Cmd {
 	}
 }
 
+// newFileFilter builds the filter for the current inputs together with the
+// terms of the selected presets
+func (m *CleanFilesModel) newFileFilter(minSize, maxSize int64, olderThan, newerThan time.Time) *filemanager.FileFilter {
+	filter := m.Filemanager.NewFileFilter(minSize, maxSize, utils.ParseExtToMap(m.Extensions), m.Exclude, olderThan, newerThan)
+	filter.Include = m.Include
+	filter.PresetTerms = m.PresetTerms
+	filter.OneFileSystem = m.OneFileSystem
+	filter.SkipFilesystems = m.SkipFilesystems
+	filter.WalkLimits = m.WalkLimits
+	filter.Symlinks, _ = filemanager.ParseSymlinkPolicy(m.Symlinks)
+	if m.SkipOpenFiles {
+		// Without a snapshot no file is skipped, as before the option
+		filter.OpenFiles, _ = filemanager.ReadOpenFiles()
+	}
+
+	// Invalid values are reported before a filter is built
+	limits, _ := m.statTimeLimits()
+	filter.AccessedBefore = limits.AccessedBefore
+	filter.AccessedAfter = limits.AccessedAfter
+	filter.ChangedBefore = limits.ChangedBefore
+	filter.ChangedAfter = limits.ChangedAfter
+
+	owners, _ := m.ownerLimits()
+	filter.Owners = owners.Owners
+	filter.NotOwners = owners.NotOwners
+	filter.UIDs = owners.UIDs
+	filter.Groups = owners.Groups
+	filter.Perm = owners.Perm
+	filter.Where, _ = filemanager.ParseExpr(m.WhereInput.Value())
+	return filter
+}
+
+// statTimeLimits parses the access and change time inputs into the matching
+// fields of the filter options
+func (m *CleanFilesModel) statTimeLimits() (filemanager.FileFilterOptions, error) {
+	var limits filemanager.FileFilterOptions
+	for _, input := range []struct {
+		name  string
+		value string
+		limit *time.Time
+	}{
+		{"accessed before", m.AccessedBeforeInput.Value(), &limits.AccessedBefore},
+		{"accessed after", m.AccessedAfterInput.Value(), &limits.AccessedAfter},
+		{"changed before", m.ChangedBeforeInput.Value(), &limits.ChangedBefore},
+		{"changed after", m.ChangedAfterInput.Value(), &limits.ChangedAfter},
+	} {
+		if input.value == "" {
+			continue
+		}
+		t, err := utils.ParseTimeDuration(input.value)
+		if err != nil {
+			return limits, fmt.Errorf("Invalid %s time: %v", input.name, err)
+		}
+		*input.limit = t
+	}
+	return limits, nil
+}
+
+// ownerLimits resolves the owner, group and permission inputs into the
+// matching fields of the filter options
+func (m *CleanFilesModel) ownerLimits() (filemanager.FileFilterOptions, error) {
+	var limits filemanager.FileFilterOptions
+	var err error
+	if limits.Owners, err = filemanager.LookupOwners(utils.ParseExcludeToSlice(m.OwnerInput.Value())); err != nil {
+		return limits, fmt.Errorf("Invalid owner: %v", err)
+	}
+	if limits.NotOwners, err = filemanager.LookupOwners(utils.ParseExcludeToSlice(m.NotOwnerInput.Value())); err != nil {
+		return limits, fmt.Errorf("Invalid not owner: %v", err)
+	}
+	if limits.Groups, err = filemanager.LookupGroups(utils.ParseExcludeToSlice(m.GroupInput.Value())); err != nil {
+		return limits, fmt.Errorf("Invalid group: %v", err)
+	}
+	if limits.UIDs, err = parseIDs(m.UIDInput.Value()); err != nil {
+		return limits, fmt.Errorf("Invalid uid: %v", err)
+	}
+	if limits.Perm, err = filemanager.ParsePermFilter(m.PermInput.Value()); err != nil {
+		return limits, fmt.Errorf("Invalid permissions: %v", err)
+	}
+	return limits, nil
+}
+
+// parseIDs parses a comma separated list of numeric user IDs
+func parseIDs(value string) ([]int, error) {
+	var ids []int
+	for _, field := range utils.ParseExcludeToSlice(value) {
+		id, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a numeric user ID", field)
+		}
+		ids = append(ids, int(id))
+	}
+	return ids, nil
+}
+
+// formatIDs joins numeric user IDs for display in an input
+func formatIDs(ids []int) string {
+	fields := make([]string, len(ids))
+	for i, id := range ids {
+		fields[i] = strconv.Itoa(id)
+	}
+	return strings.Join(fields, ",")
+}
+
+// expandRulePresets returns the terms of the presets saved in the rules.
+// Unknown names are rejected when the rules are saved, so errors are ignored.
+func expandRulePresets(names []string) []filemanager.PresetTerm {
+	terms, _, _ := rules.ExpandPresets(names)
+	return terms
+}
+
 func (m *CleanFilesModel) LoadDirs() tea.Cmd {
 	return func() tea.Msg {
 		// Reset selection state when loading directories
@@ -703,16 +1085,169 @@ func (m *CleanFilesModel) LoadDirs() tea.Cmd {
 	}
 }
 
-// Asynchronous directory size calculation
+// Asynchronous directory size calculation. A calculation still running for
+// the previous directory is cancelled.
 func (m *CleanFilesModel) CalculateDirSizeAsync() tea.Cmd {
+	if m.cancelSize != nil {
+		m.cancelSize()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelSize = cancel
+	path := m.CurrentPath
+
 	return func() tea.Msg {
 		m.CalculatingSize = true
-		size := m.Filemanager.CalculateDirSize(m.CurrentPath)
+		size := filemanager.WithWalkLimits(m.Filemanager, m.WalkLimits).CalculateDirSize(ctx, path)
 		m.CalculatingSize = false
+		if ctx.Err() != nil {
+			return nil
+		}
 		return DirSizeMsg{Size: size}
 	}
 }
 
+// deleteAllAsync removes every file below the current directory that matches
+// filter, recording each one in the operation journal. Broken links and
+// empty files are removed as well when their options are on. It runs in the
+// background so the cancel key stays responsive.
+func (m *CleanFilesModel) deleteAllAsync(filter *filemanager.FileFilter) tea.Cmd {
+	if m.cancelDelete != nil {
+		m.cancelDelete()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelDelete = cancel
+	m.Deleting = true
+
+	dir := m.CurrentPath
+	moveToTrash := m.OptionState[options.SendFilesToTrash]
+	deleteEmpty := m.OptionState[options.DeleteEmptySubfolders]
+	deleteBrokenLinks := m.OptionState[options.DeleteBrokenLinks]
+	deleteEmptyFiles := m.OptionState[options.DeleteEmptyFiles]
+	shred := m.OptionState[options.ShredFiles] && !moveToTrash
+	shredding := m.ShredOptions
+	opType := logging.OperationDeleted
+	if moveToTrash {
+		opType = logging.OperationTrashed
+	} else if shred {
+		opType = logging.OperationShredded
+	}
+
+	return func() tea.Msg {
+		journal := logging.OpenDefaultJournal(dir)
+		stats := &logging.ScanStatistics{
+			StartTime:     time.Now(),
+			Directory:     dir,
+			OperationType: "bulk delete",
+		}
+
+		var mu sync.Mutex
+		var removed filemanager.ScanResult
+		remove := func(entry filemanager.FileEntry, source string) {
+			if moveToTrash {
+				m.Filemanager.MoveFileToTrash(entry.Path)
+			} else if shred {
+				start := time.Now()
+				written, err := filemanager.ShredFile(entry.Path, shredding)
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				stats.AddShred(entry.Size, written, time.Since(start))
+				mu.Unlock()
+			} else {
+				m.Filemanager.DeleteFile(entry.Path)
+			}
+			journal.Record(logging.NewFileOperation(entry.Path, entry.Size, opType, source, ""))
+			mu.Lock()
+			removed.Entries = append(removed.Entries, entry)
+			removed.TotalSize += entry.Size
+			mu.Unlock()
+		}
+
+		err := m.Filemanager.WalkFilesWithFilter(ctx, func(fi os.FileInfo, path string) {
+			if fi.IsDir() || ctx.Err() != nil {
+				return
+			}
+			if filter.OpenFiles.Contains(fi) {
+				mu.Lock()
+				stats.InUseFiles++
+				stats.InUseSize += fi.Size()
+				mu.Unlock()
+				return
+			}
+			remove(filemanager.NewFileEntry(path, fi), "bulk delete")
+		}, dir, filter)
+
+		scanner := filemanager.NewFileScanner(m.Filemanager, filter, false)
+		if err == nil && deleteBrokenLinks {
+			for _, link := range scanner.ScanBrokenLinks(ctx, dir, true) {
+				if ctx.Err() != nil {
+					break
+				}
+				remove(link, "broken link")
+				stats.BrokenLinks++
+			}
+			err = ctx.Err()
+		}
+		if err == nil && deleteEmptyFiles {
+			for _, file := range scanner.ScanEmptyFiles(ctx, dir, true) {
+				if ctx.Err() != nil {
+					break
+				}
+				remove(file, "empty file")
+				stats.EmptyFiles++
+			}
+			err = ctx.Err()
+		}
+
+		if err == nil && deleteEmpty {
+			err = m.Filemanager.DeleteEmptySubfolders(ctx, dir)
+		}
+		journal.Finish(err)
+
+		stats.TotalFiles = int64(removed.Len())
+		stats.TotalSize = removed.TotalSize
+		stats.FreedSize = removed.FreedSize()
+		if moveToTrash {
+			stats.TrashedFiles, stats.TrashedSize = stats.TotalFiles, stats.TotalSize
+		} else {
+			stats.DeletedFiles, stats.DeletedSize = stats.TotalFiles, stats.TotalSize
+		}
+		stats.EndTime = time.Now()
+
+		return BulkDeleteDoneMsg{Removed: removed.Len(), Err: err, Stats: stats}
+	}
+}
+
+// publishStats logs the statistics of a finished operation and shows them
+// in the log tabs
+func (m *CleanFilesModel) publishStats(stats *logging.ScanStatistics) {
+	if m.Logger != nil {
+		m.Logger.Log(logging.INFO, fmt.Sprintf("%s operation completed. Statistics: %+v", stats.OperationType, stats))
+		m.Logger.UpdateStats(stats)
+	}
+	if m.TabManager != nil {
+		for _, tab := range m.TabManager.GetAllTabs() {
+			if logTab, ok := tab.(*clean.LogTab); ok {
+				logTab.UpdateStats(stats)
+			}
+		}
+	}
+}
+
+// CancelOperations stops the size calculation and bulk delete running for
+// this view, if any
+func (m *CleanFilesModel) CancelOperations() {
+	if m.cancelSize != nil {
+		m.cancelSize()
+		m.cancelSize = nil
+	}
+	if m.cancelDelete != nil {
+		m.cancelDelete()
+		m.cancelDelete = nil
+	}
+}
+
 func (m *CleanFilesModel) OnDelete() (tea.Model, tea.Cmd) {
 	// Create statistics for this operation
 	stats := &logging.ScanStatistics{
@@ -732,6 +1267,7 @@ func (m *CleanFilesModel) OnDelete() (tea.Model, tea.Cmd) {
 	if len(m.SelectedFiles) > 0 {
 		stats.TotalFiles = int64(m.SelectedCount)
 		stats.TotalSize = m.SelectedSize
+		stats.FreedSize = filemanager.FreedSizeOf(m.selectedPaths())
 
 		if m.OptionState[options.SendFilesToTrash] {
 			for filePath := range m.SelectedFiles {
@@ -750,7 +1286,7 @@ func (m *CleanFilesModel) OnDelete() (tea.Model, tea.Cmd) {
 				if strings.HasSuffix(filePath, ".log") {
 					continue
 				}
-				os.Remove(filePath)
+				m.removeFile(filePath, stats)
 				delete(m.SelectedFiles, filePath)
 			}
 			stats.DeletedFiles = int64(m.SelectedCount)
@@ -802,18 +1338,26 @@ func (m *CleanFilesModel) OnDelete() (tea.Model, tea.Cmd) {
 			}
 		}
 
-		if m.OptionState[options.SendFilesToTrash] {
-			m.Filemanager.MoveFilesToTrash(m.CurrentPath, m.Extensions, m.Exclude, utils.ToBytesOrDefault(m.MinSizeInput.Value()), utils.ToBytesOrDefault(m.MaxSizeInput.Value()), olderDuration, newerDuration)
-		} else {
-			// Delete all files in the current directory and all subfolders
-			m.Filemanager.DeleteFiles(m.CurrentPath, m.Extensions, m.Exclude, utils.ToBytesOrDefault(m.MinSizeInput.Value()), utils.ToBytesOrDefault(m.MaxSizeInput.Value()), olderDuration, newerDuration)
+		if _, err := m.statTimeLimits(); err != nil {
+			return m, func() tea.Msg {
+				return errors.New(errors.ErrorTypeValidation, err.Error())
+			}
 		}
-
-		if m.OptionState[options.DeleteEmptySubfolders] {
-			m.Filemanager.DeleteEmptySubfolders(m.CurrentPath)
+		if _, err := m.ownerLimits(); err != nil {
+			return m, func() tea.Msg {
+				return errors.New(errors.ErrorTypeValidation, err.Error())
+			}
+		}
+		if _, err := filemanager.ParseExpr(m.WhereInput.Value()); err != nil {
+			return m, func() tea.Msg {
+				return errors.New(errors.ErrorTypeValidation, fmt.Sprintf("Invalid filter expression: %v", err))
+			}
 		}
 
-		return m, m.LoadFiles()
+		minSize := utils.ToBytesOrDefault(m.MinSizeInput.Value())
+		maxSize := utils.ToBytesOrDefault(m.MaxSizeInput.Value())
+
+		return m, m.deleteAllAsync(m.newFileFilter(minSize, maxSize, olderDuration, newerDuration))
 	}
 
 	// Process files based on Confirm deletion option
@@ -835,6 +1379,7 @@ func (m *CleanFilesModel) OnDelete() (tea.Model, tea.Cmd) {
 
 		stats.TotalFiles = 1
 		stats.TotalSize = item.Size
+		stats.FreedSize = filemanager.FreedSizeOf([]string{item.Path})
 
 		if m.OptionState[options.SendFilesToTrash] {
 			// Move to trash
@@ -847,7 +1392,7 @@ func (m *CleanFilesModel) OnDelete() (tea.Model, tea.Cmd) {
 			}
 		} else {
 			// Permanent deletion
-			if err := os.Remove(item.Path); err != nil {
+			if err := m.removeFile(item.Path, stats); err != nil {
 				if m.Logger != nil {
 					m.Logger.Log(logging.ERROR, fmt.Sprintf("Failed to delete file: %v", err))
 				}
@@ -890,6 +1435,14 @@ func (m *CleanFilesModel) OnDelete() (tea.Model, tea.Cmd) {
 
 		stats.TotalFiles = int64(selectedCount)
 
+		paths := make([]string, 0, selectedCount)
+		for _, item := range items {
+			if cleanItem := item.(models.CleanItem); cleanItem.Size != -1 && !strings.HasSuffix(cleanItem.Path, ".log") {
+				paths = append(paths, cleanItem.Path)
+			}
+		}
+		stats.FreedSize = filemanager.FreedSizeOf(paths)
+
 		for _, item := range items {
 			cleanItem := item.(models.CleanItem)
 			// Skip parent directory entry, log files and unselected files
@@ -910,7 +1463,7 @@ func (m *CleanFilesModel) OnDelete() (tea.Model, tea.Cmd) {
 				}
 			} else {
 				// Permanent deletion
-				if err := os.Remove(cleanItem.Path); err != nil {
+				if err := m.removeFile(cleanItem.Path, stats); err != nil {
 					if m.Logger != nil {
 						m.Logger.Log(logging.ERROR, fmt.Sprintf("Failed to delete file: %v", err))
 					}
@@ -953,11 +1506,39 @@ func (m *CleanFilesModel) OnDelete() (tea.Model, tea.Cmd) {
 	return m, m.LoadFiles()
 }
 
+// removeFile deletes a file for good, overwriting it first when the shred
+// option is on. Shredded files are counted in stats.
+func (m *CleanFilesModel) removeFile(path string, stats *logging.ScanStatistics) error {
+	if !m.OptionState[options.ShredFiles] {
+		return os.Remove(path)
+	}
+	var size int64
+	if info, err := os.Lstat(path); err == nil {
+		size = info.Size()
+	}
+	start := time.Now()
+	written, err := filemanager.ShredFile(path, m.ShredOptions)
+	if err == nil {
+		stats.AddShred(size, written, time.Since(start))
+	}
+	return err
+}
+
+// selectedPaths returns the paths of the selected files
+func (m *CleanFilesModel) selectedPaths() []string {
+	paths := make([]string, 0, len(m.SelectedFiles))
+	for filePath := range m.SelectedFiles {
+		paths = append(paths, filePath)
+	}
+	return paths
+}
+
 func (m *CleanFilesModel) DeleteUserSelectedFiles(stats *logging.ScanStatistics) (tea.Model, tea.Cmd) {
 
 	if len(m.SelectedFiles) > 0 {
 		stats.TotalFiles = int64(m.SelectedCount)
 		stats.TotalSize = m.SelectedSize
+		stats.FreedSize = filemanager.FreedSizeOf(m.selectedPaths())
 
 		if m.OptionState[options.SendFilesToTrash] {
 			for filePath := range m.SelectedFiles {
@@ -967,7 +1548,7 @@ func (m *CleanFilesModel) DeleteUserSelectedFiles(stats *logging.ScanStatistics)
 			stats.TrashedSize = m.SelectedSize
 		} else {
 			for filePath := range m.SelectedFiles {
-				os.Remove(filePath)
+				m.removeFile(filePath, stats)
 			}
 			stats.DeletedFiles = int64(m.SelectedCount)
 			stats.DeletedSize = m.SelectedSize
@@ -1132,6 +1713,9 @@ func (m *CleanFilesModel) Handle(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.OnDelete()
 	case "ctrl+o":
 		return m, m.OpenFileExplorer(m.CurrentPath)
+	case "ctrl+x":
+		m.CancelOperations()
+		return m, nil
 	case "alt+c":
 		return m.handleAltC()
 	case "alt+1": // Toggle hidden files
@@ -1164,6 +1748,15 @@ func (m *CleanFilesModel) Handle(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "alt+0": // Toggle exit after deletion
 		m.OptionState[options.ExitAfterDeletion] = !m.OptionState[options.ExitAfterDeletion]
 		return m, nil
+	case "alt+-": // Toggle delete broken links
+		m.OptionState[options.DeleteBrokenLinks] = !m.OptionState[options.DeleteBrokenLinks]
+		return m, nil
+	case "alt+=": // Toggle delete empty files
+		m.OptionState[options.DeleteEmptyFiles] = !m.OptionState[options.DeleteEmptyFiles]
+		return m, nil
+	case "alt+\\": // Toggle shred files
+		m.OptionState[options.ShredFiles] = !m.OptionState[options.ShredFiles]
+		return m, nil
 	case "enter":
 		return m.handleEnter()
 	case " ":
@@ -1298,6 +1891,66 @@ func (m *CleanFilesModel) UpdateInputs(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.NewerInput, cmd = m.NewerInput.Update(msg)
 		cmds = append(cmds, cmd)
 		return m, tea.Batch(cmds...)
+	case "accessedBeforeInput":
+		var cmd tea.Cmd
+		var cmds []tea.Cmd
+		m.AccessedBeforeInput, cmd = m.AccessedBeforeInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	case "accessedAfterInput":
+		var cmd tea.Cmd
+		var cmds []tea.Cmd
+		m.AccessedAfterInput, cmd = m.AccessedAfterInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	case "changedBeforeInput":
+		var cmd tea.Cmd
+		var cmds []tea.Cmd
+		m.ChangedBeforeInput, cmd = m.ChangedBeforeInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	case "changedAfterInput":
+		var cmd tea.Cmd
+		var cmds []tea.Cmd
+		m.ChangedAfterInput, cmd = m.ChangedAfterInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	case "ownerInput":
+		var cmd tea.Cmd
+		var cmds []tea.Cmd
+		m.OwnerInput, cmd = m.OwnerInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	case "notOwnerInput":
+		var cmd tea.Cmd
+		var cmds []tea.Cmd
+		m.NotOwnerInput, cmd = m.NotOwnerInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	case "uidInput":
+		var cmd tea.Cmd
+		var cmds []tea.Cmd
+		m.UIDInput, cmd = m.UIDInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	case "groupInput":
+		var cmd tea.Cmd
+		var cmds []tea.Cmd
+		m.GroupInput, cmd = m.GroupInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	case "permInput":
+		var cmd tea.Cmd
+		var cmds []tea.Cmd
+		m.PermInput, cmd = m.PermInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+	case "whereInput":
+		var cmd tea.Cmd
+		var cmds []tea.Cmd
+		m.WhereInput, cmd = m.WhereInput.Update(msg)
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
 	default:
 		return m, nil
 	}
@@ -1356,6 +2009,46 @@ func (m *CleanFilesModel) handleTab() (tea.Model, tea.Cmd) {
 			m.NewerInput.Focus()
 		case "newerInput":
 			m.NewerInput.Blur()
+			m.FocusedElement = "accessedBeforeInput"
+			m.AccessedBeforeInput.Focus()
+		case "accessedBeforeInput":
+			m.AccessedBeforeInput.Blur()
+			m.FocusedElement = "accessedAfterInput"
+			m.AccessedAfterInput.Focus()
+		case "accessedAfterInput":
+			m.AccessedAfterInput.Blur()
+			m.FocusedElement = "changedBeforeInput"
+			m.ChangedBeforeInput.Focus()
+		case "changedBeforeInput":
+			m.ChangedBeforeInput.Blur()
+			m.FocusedElement = "changedAfterInput"
+			m.ChangedAfterInput.Focus()
+		case "changedAfterInput":
+			m.ChangedAfterInput.Blur()
+			m.FocusedElement = "ownerInput"
+			m.OwnerInput.Focus()
+		case "ownerInput":
+			m.OwnerInput.Blur()
+			m.FocusedElement = "notOwnerInput"
+			m.NotOwnerInput.Focus()
+		case "notOwnerInput":
+			m.NotOwnerInput.Blur()
+			m.FocusedElement = "uidInput"
+			m.UIDInput.Focus()
+		case "uidInput":
+			m.UIDInput.Blur()
+			m.FocusedElement = "groupInput"
+			m.GroupInput.Focus()
+		case "groupInput":
+			m.GroupInput.Blur()
+			m.FocusedElement = "permInput"
+			m.PermInput.Focus()
+		case "permInput":
+			m.PermInput.Blur()
+			m.FocusedElement = "whereInput"
+			m.WhereInput.Focus()
+		case "whereInput":
+			m.WhereInput.Blur()
 			m.FocusedElement = "excludeInput"
 			m.ExcludeInput.Focus()
 		}
@@ -1437,8 +2130,8 @@ func (m *CleanFilesModel) handleShiftTab() (tea.Model, tea.Cmd) {
 		switch m.FocusedElement {
 		case "excludeInput":
 			m.ExcludeInput.Blur()
-			m.FocusedElement = "newerInput"
-			m.NewerInput.Focus()
+			m.FocusedElement = "whereInput"
+			m.WhereInput.Focus()
 		case "minSizeInput":
 			m.MinSizeInput.Blur()
 			m.FocusedElement = "excludeInput"
@@ -1455,6 +2148,46 @@ func (m *CleanFilesModel) handleShiftTab() (tea.Model, tea.Cmd) {
 			m.NewerInput.Blur()
 			m.FocusedElement = "olderInput"
 			m.OlderInput.Focus()
+		case "accessedBeforeInput":
+			m.AccessedBeforeInput.Blur()
+			m.FocusedElement = "newerInput"
+			m.NewerInput.Focus()
+		case "accessedAfterInput":
+			m.AccessedAfterInput.Blur()
+			m.FocusedElement = "accessedBeforeInput"
+			m.AccessedBeforeInput.Focus()
+		case "changedBeforeInput":
+			m.ChangedBeforeInput.Blur()
+			m.FocusedElement = "accessedAfterInput"
+			m.AccessedAfterInput.Focus()
+		case "changedAfterInput":
+			m.ChangedAfterInput.Blur()
+			m.FocusedElement = "changedBeforeInput"
+			m.ChangedBeforeInput.Focus()
+		case "ownerInput":
+			m.OwnerInput.Blur()
+			m.FocusedElement = "changedAfterInput"
+			m.ChangedAfterInput.Focus()
+		case "notOwnerInput":
+			m.NotOwnerInput.Blur()
+			m.FocusedElement = "ownerInput"
+			m.OwnerInput.Focus()
+		case "uidInput":
+			m.UIDInput.Blur()
+			m.FocusedElement = "notOwnerInput"
+			m.NotOwnerInput.Focus()
+		case "groupInput":
+			m.GroupInput.Blur()
+			m.FocusedElement = "uidInput"
+			m.UIDInput.Focus()
+		case "permInput":
+			m.PermInput.Blur()
+			m.FocusedElement = "groupInput"
+			m.GroupInput.Focus()
+		case "whereInput":
+			m.WhereInput.Blur()
+			m.FocusedElement = "permInput"
+			m.PermInput.Focus()
 		}
 	case 2: // Tab navigation for Options tab
 		m.FocusedElement = options.GetNextOption(m.FocusedElement, "clean_option_", len(options.DefaultCleanOption), false)
@@ -1548,7 +2281,9 @@ func (m *CleanFilesModel) handleEnter() (tea.Model, tea.Cmd) {
 					}
 				}
 			}
-		case "extInput", "minSizeInput", "maxSizeInput", "excludeInput", "olderInput", "newerInput":
+		case "extInput", "minSizeInput", "maxSizeInput", "excludeInput", "olderInput", "newerInput",
+			"accessedBeforeInput", "accessedAfterInput", "changedBeforeInput", "changedAfterInput",
+			"ownerInput", "notOwnerInput", "uidInput", "groupInput", "permInput", "whereInput":
 			// Validate input values before updating
 			var err error
 			switch m.FocusedElement {
@@ -1568,7 +2303,26 @@ func (m *CleanFilesModel) handleEnter() (tea.Model, tea.Cmd) {
 				if m.NewerInput.Value() != "" {
 					err = m.Validator.ValidateTimeDuration(m.NewerInput.Value())
 				}
-
+			case "accessedBeforeInput":
+				if m.AccessedBeforeInput.Value() != "" {
+					err = m.Validator.ValidateTimeDuration(m.AccessedBeforeInput.Value())
+				}
+			case "accessedAfterInput":
+				if m.AccessedAfterInput.Value() != "" {
+					err = m.Validator.ValidateTimeDuration(m.AccessedAfterInput.Value())
+				}
+			case "changedBeforeInput":
+				if m.ChangedBeforeInput.Value() != "" {
+					err = m.Validator.ValidateTimeDuration(m.ChangedBeforeInput.Value())
+				}
+			case "changedAfterInput":
+				if m.ChangedAfterInput.Value() != "" {
+					err = m.Validator.ValidateTimeDuration(m.ChangedAfterInput.Value())
+				}
+			case "ownerInput", "notOwnerInput", "uidInput", "groupInput", "permInput":
+				_, err = m.ownerLimits()
+			case "whereInput":
+				_, err = filemanager.ParseExpr(m.WhereInput.Value())
 			}
 
 			if err != nil {
@@ -1691,12 +2445,26 @@ func (m *CleanFilesModel) blurAllInputs() {
 	m.ExcludeInput.Blur()
 	m.OlderInput.Blur()
 	m.NewerInput.Blur()
+	m.AccessedBeforeInput.Blur()
+	m.AccessedAfterInput.Blur()
+	m.ChangedBeforeInput.Blur()
+	m.ChangedAfterInput.Blur()
+	m.OwnerInput.Blur()
+	m.NotOwnerInput.Blur()
+	m.UIDInput.Blur()
+	m.GroupInput.Blur()
+	m.PermInput.Blur()
+	m.WhereInput.Blur()
 }
 
 func (m *CleanFilesModel) GetCurrentPath() string {
 	return m.CurrentPath
 }
 
+func (m *CleanFilesModel) GetNoAtimeMount() string {
+	return m.NoAtimeMount
+}
+
 func (m *CleanFilesModel) GetExtensions() []string {
 	return m.Extensions
 }
@@ -1788,6 +2556,46 @@ func (m *CleanFilesModel) GetNewerInput() textinput.Model {
 	return m.NewerInput
 }
 
+func (m *CleanFilesModel) GetAccessedBeforeInput() textinput.Model {
+	return m.AccessedBeforeInput
+}
+
+func (m *CleanFilesModel) GetAccessedAfterInput() textinput.Model {
+	return m.AccessedAfterInput
+}
+
+func (m *CleanFilesModel) GetChangedBeforeInput() textinput.Model {
+	return m.ChangedBeforeInput
+}
+
+func (m *CleanFilesModel) GetChangedAfterInput() textinput.Model {
+	return m.ChangedAfterInput
+}
+
+func (m *CleanFilesModel) GetOwnerInput() textinput.Model {
+	return m.OwnerInput
+}
+
+func (m *CleanFilesModel) GetNotOwnerInput() textinput.Model {
+	return m.NotOwnerInput
+}
+
+func (m *CleanFilesModel) GetUIDInput() textinput.Model {
+	return m.UIDInput
+}
+
+func (m *CleanFilesModel) GetGroupInput() textinput.Model {
+	return m.GroupInput
+}
+
+func (m *CleanFilesModel) GetPermInput() textinput.Model {
+	return m.PermInput
+}
+
+func (m *CleanFilesModel) GetWhereInput() textinput.Model {
+	return m.WhereInput
+}
+
 func (m *CleanFilesModel) GetSelectedFiles() map[string]bool {
 	return m.SelectedFiles
 }
@@ -28,12 +28,15 @@ func NewMainMenu(rules rules.Rules) *MainMenu {
 			options.ConfirmDeletion:       latestRules.ConfirmDeletion,
 			options.IncludeSubfolders:     latestRules.IncludeSubfolders,
 			options.DeleteEmptySubfolders: latestRules.DeleteEmptySubfolders,
+			options.DeleteBrokenLinks:     latestRules.DeleteBrokenLinks,
+			options.DeleteEmptyFiles:      latestRules.DeleteEmptyFiles,
 			options.SendFilesToTrash:      latestRules.SendFilesToTrash,
 			options.LogOperations:         latestRules.LogOperations,
 			options.LogToFile:             latestRules.LogToFile,
 			options.ShowStatistics:        latestRules.ShowStatistics,
 			options.DisableEmoji:          latestRules.DisableEmoji,
 			options.ExitAfterDeletion:     latestRules.ExitAfterDeletion,
+			options.ShredFiles:            latestRules.Shred,
 		},
 	}
 }
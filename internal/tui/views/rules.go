@@ -11,6 +11,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	zone "github.com/lrstanley/bubblezone"
+	"github.com/pashkov256/deletor/internal/filemanager"
 	rules "github.com/pashkov256/deletor/internal/rules"
 	"github.com/pashkov256/deletor/internal/tui/errors"
 	"github.com/pashkov256/deletor/internal/tui/options"
@@ -26,19 +27,30 @@ type RulesModel struct {
 	LocationInput textinput.Model
 
 	// Filters tab fields
-	ExtensionsInput textinput.Model
-	MinSizeInput    textinput.Model
-	MaxSizeInput    textinput.Model
-	ExcludeInput    textinput.Model
-	OlderInput      textinput.Model
-	NewerInput      textinput.Model
+	ExtensionsInput     textinput.Model
+	MinSizeInput        textinput.Model
+	MaxSizeInput        textinput.Model
+	ExcludeInput        textinput.Model
+	OlderInput          textinput.Model
+	NewerInput          textinput.Model
+	AccessedBeforeInput textinput.Model
+	AccessedAfterInput  textinput.Model
+	ChangedBeforeInput  textinput.Model
+	ChangedAfterInput   textinput.Model
+	OwnerInput          textinput.Model
+	NotOwnerInput       textinput.Model
+	UIDInput            textinput.Model
+	GroupInput          textinput.Model
+	PermInput           textinput.Model
+	WhereInput          textinput.Model
+	PresetsInput        textinput.Model
 
 	// Options tab fields
 	OptionState map[string]bool
 
 	// Common fields
 	rules           rules.Rules
-	FocusedElement  string // "locationInput", "saveButton", "extensionsInput", "minSizeInput", "maxSizeInput", "excludeInput", "olderInput", "newerInput", "rules_option_1", "rules_option_2", etc.
+	FocusedElement  string // "locationInput", "saveButton", "extensionsInput", "minSizeInput", "maxSizeInput", "excludeInput", "olderInput", "newerInput", "accessedBeforeInput", "accessedAfterInput", "changedBeforeInput", "changedAfterInput", "ownerInput", "notOwnerInput", "uidInput", "groupInput", "permInput", "whereInput", "presetsInput", "rules_option_1", "rules_option_2", etc.
 	rulesPath       string
 	SuccessSaveText string
 	Error           *errors.Error
@@ -102,28 +114,119 @@ func NewRulesModel(rules rules.Rules, validator *validation.Validator) *RulesMod
 	newerInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6666"))
 	newerInput.SetValue(lastestRules.NewerThan)
 
+	accessedBeforeInput := textinput.New()
+	accessedBeforeInput.Placeholder = "Accessed before (e.g. 90 days without a read)"
+	accessedBeforeInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#1E90FF"))
+	accessedBeforeInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	accessedBeforeInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6666"))
+	accessedBeforeInput.SetValue(lastestRules.AccessedBefore)
+
+	accessedAfterInput := textinput.New()
+	accessedAfterInput.Placeholder = "Accessed after (e.g. read within 7 days)"
+	accessedAfterInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#1E90FF"))
+	accessedAfterInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	accessedAfterInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6666"))
+	accessedAfterInput.SetValue(lastestRules.AccessedAfter)
+
+	changedBeforeInput := textinput.New()
+	changedBeforeInput.Placeholder = "Changed before (e.g. 60 min, 1 hour, 7 days, 1 month)"
+	changedBeforeInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#1E90FF"))
+	changedBeforeInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	changedBeforeInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6666"))
+	changedBeforeInput.SetValue(lastestRules.ChangedBefore)
+
+	changedAfterInput := textinput.New()
+	changedAfterInput.Placeholder = "Changed after (e.g. 60 min, 1 hour, 7 days, 1 month)"
+	changedAfterInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#1E90FF"))
+	changedAfterInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	changedAfterInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6666"))
+	changedAfterInput.SetValue(lastestRules.ChangedAfter)
+
+	ownerInput := textinput.New()
+	ownerInput.Placeholder = "Owners (user names or IDs, e.g. root,www-data)"
+	ownerInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#1E90FF"))
+	ownerInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	ownerInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6666"))
+	ownerInput.SetValue(strings.Join(lastestRules.Owners, ","))
+
+	notOwnerInput := textinput.New()
+	notOwnerInput.Placeholder = "Not owners (skip files of these users, e.g. root)"
+	notOwnerInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#1E90FF"))
+	notOwnerInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	notOwnerInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6666"))
+	notOwnerInput.SetValue(strings.Join(lastestRules.NotOwners, ","))
+
+	uidInput := textinput.New()
+	uidInput.Placeholder = "UIDs (numeric user IDs, e.g. 1000,1001)"
+	uidInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#1E90FF"))
+	uidInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	uidInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6666"))
+	uidInput.SetValue(formatIDs(lastestRules.UIDs))
+
+	groupInput := textinput.New()
+	groupInput.Placeholder = "Groups (group names or IDs, e.g. staff)"
+	groupInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#1E90FF"))
+	groupInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	groupInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6666"))
+	groupInput.SetValue(strings.Join(lastestRules.Groups, ","))
+
+	permInput := textinput.New()
+	permInput.Placeholder = "Permissions (mode, -mode or /mode, e.g. -o+w)"
+	permInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#1E90FF"))
+	permInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	permInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6666"))
+	permInput.SetValue(lastestRules.Perm)
+
+	whereInput := textinput.New()
+	whereInput.Placeholder = `Where (e.g. ext in (log,tmp) and age > 7d and not path ~ "keep/**")`
+	whereInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#1E90FF"))
+	whereInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	whereInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6666"))
+	whereInput.SetValue(lastestRules.Where)
+
+	presetsInput := textinput.New()
+	presetsInput.Placeholder = "Built-in presets (e.g. node,python,editor)"
+	presetsInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#1E90FF"))
+	presetsInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	presetsInput.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6666"))
+	presetsInput.SetValue(strings.Join(lastestRules.Presets, ","))
+
 	// Get AppData path
 	rulesPath := filepath.Join(os.Getenv("APPDATA"), rules.GetRulesPath())
 
 	return &RulesModel{
-		LocationInput:   locationInput,
-		ExtensionsInput: extensionsInput,
-		MinSizeInput:    minSizeInput,
-		MaxSizeInput:    maxSizeInput,
-		ExcludeInput:    excludeInput,
-		OlderInput:      olderInput,
-		NewerInput:      newerInput,
+		LocationInput:       locationInput,
+		ExtensionsInput:     extensionsInput,
+		MinSizeInput:        minSizeInput,
+		MaxSizeInput:        maxSizeInput,
+		ExcludeInput:        excludeInput,
+		OlderInput:          olderInput,
+		NewerInput:          newerInput,
+		AccessedBeforeInput: accessedBeforeInput,
+		AccessedAfterInput:  accessedAfterInput,
+		ChangedBeforeInput:  changedBeforeInput,
+		ChangedAfterInput:   changedAfterInput,
+		OwnerInput:          ownerInput,
+		NotOwnerInput:       notOwnerInput,
+		UIDInput:            uidInput,
+		GroupInput:          groupInput,
+		PermInput:           permInput,
+		WhereInput:          whereInput,
+		PresetsInput:        presetsInput,
 		OptionState: map[string]bool{
 			options.ShowHiddenFiles:       lastestRules.ShowHiddenFiles,
 			options.ConfirmDeletion:       lastestRules.ConfirmDeletion,
 			options.IncludeSubfolders:     lastestRules.IncludeSubfolders,
 			options.DeleteEmptySubfolders: lastestRules.DeleteEmptySubfolders,
+			options.DeleteBrokenLinks:     lastestRules.DeleteBrokenLinks,
+			options.DeleteEmptyFiles:      lastestRules.DeleteEmptyFiles,
 			options.SendFilesToTrash:      lastestRules.SendFilesToTrash,
 			options.LogOperations:         lastestRules.LogOperations,
 			options.LogToFile:             lastestRules.LogToFile,
 			options.ShowStatistics:        lastestRules.ShowStatistics,
 			options.DisableEmoji:          lastestRules.DisableEmoji,
 			options.ExitAfterDeletion:     lastestRules.ExitAfterDeletion,
+			options.ShredFiles:            lastestRules.Shred,
 		},
 		rules:           rules,
 		rulesPath:       rulesPath,
@@ -161,6 +264,17 @@ func (m *RulesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.ExcludeInput.Blur()
 					m.OlderInput.Blur()
 					m.NewerInput.Blur()
+					m.AccessedBeforeInput.Blur()
+					m.AccessedAfterInput.Blur()
+					m.ChangedBeforeInput.Blur()
+					m.ChangedAfterInput.Blur()
+					m.OwnerInput.Blur()
+					m.NotOwnerInput.Blur()
+					m.UIDInput.Blur()
+					m.GroupInput.Blur()
+					m.PermInput.Blur()
+					m.WhereInput.Blur()
+					m.PresetsInput.Blur()
 
 					switch i {
 					case 0:
@@ -186,6 +300,17 @@ func (m *RulesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.ExcludeInput.Blur()
 					m.OlderInput.Blur()
 					m.NewerInput.Blur()
+					m.AccessedBeforeInput.Blur()
+					m.AccessedAfterInput.Blur()
+					m.ChangedBeforeInput.Blur()
+					m.ChangedAfterInput.Blur()
+					m.OwnerInput.Blur()
+					m.NotOwnerInput.Blur()
+					m.UIDInput.Blur()
+					m.GroupInput.Blur()
+					m.PermInput.Blur()
+					m.WhereInput.Blur()
+					m.PresetsInput.Blur()
 
 					m.FocusedElement = "locationInput"
 					m.LocationInput.Focus()
@@ -198,6 +323,17 @@ func (m *RulesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.ExcludeInput.Blur()
 					m.OlderInput.Blur()
 					m.NewerInput.Blur()
+					m.AccessedBeforeInput.Blur()
+					m.AccessedAfterInput.Blur()
+					m.ChangedBeforeInput.Blur()
+					m.ChangedAfterInput.Blur()
+					m.OwnerInput.Blur()
+					m.NotOwnerInput.Blur()
+					m.UIDInput.Blur()
+					m.GroupInput.Blur()
+					m.PermInput.Blur()
+					m.WhereInput.Blur()
+					m.PresetsInput.Blur()
 
 					m.FocusedElement = "saveButton"
 					return m.handleEnter()
@@ -206,7 +342,9 @@ func (m *RulesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			// Handle filters tab elements
 			if m.TabManager.GetActiveTabIndex() == 1 {
-				for _, key := range []string{"extensionsInput", "minSizeInput", "maxSizeInput", "excludeInput", "olderInput", "newerInput"} {
+				for _, key := range []string{"extensionsInput", "minSizeInput", "maxSizeInput", "excludeInput", "olderInput", "newerInput",
+					"accessedBeforeInput", "accessedAfterInput", "changedBeforeInput", "changedAfterInput",
+					"ownerInput", "notOwnerInput", "uidInput", "groupInput", "permInput", "whereInput", "presetsInput"} {
 					if zone.Get(fmt.Sprintf("rules_%s", key)).InBounds(msg) {
 						// Blur all inputs
 						m.LocationInput.Blur()
@@ -216,6 +354,17 @@ func (m *RulesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.ExcludeInput.Blur()
 						m.OlderInput.Blur()
 						m.NewerInput.Blur()
+						m.AccessedBeforeInput.Blur()
+						m.AccessedAfterInput.Blur()
+						m.ChangedBeforeInput.Blur()
+						m.ChangedAfterInput.Blur()
+						m.OwnerInput.Blur()
+						m.NotOwnerInput.Blur()
+						m.UIDInput.Blur()
+						m.GroupInput.Blur()
+						m.PermInput.Blur()
+						m.WhereInput.Blur()
+						m.PresetsInput.Blur()
 
 						m.FocusedElement = key
 						switch key {
@@ -231,6 +380,28 @@ func (m *RulesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							m.OlderInput.Focus()
 						case "newerInput":
 							m.NewerInput.Focus()
+						case "accessedBeforeInput":
+							m.AccessedBeforeInput.Focus()
+						case "accessedAfterInput":
+							m.AccessedAfterInput.Focus()
+						case "changedBeforeInput":
+							m.ChangedBeforeInput.Focus()
+						case "changedAfterInput":
+							m.ChangedAfterInput.Focus()
+						case "ownerInput":
+							m.OwnerInput.Focus()
+						case "notOwnerInput":
+							m.NotOwnerInput.Focus()
+						case "uidInput":
+							m.UIDInput.Focus()
+						case "groupInput":
+							m.GroupInput.Focus()
+						case "permInput":
+							m.PermInput.Focus()
+						case "whereInput":
+							m.WhereInput.Focus()
+						case "presetsInput":
+							m.PresetsInput.Focus()
 						}
 						return m, nil
 					}
@@ -249,6 +420,17 @@ func (m *RulesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.ExcludeInput.Blur()
 						m.OlderInput.Blur()
 						m.NewerInput.Blur()
+						m.AccessedBeforeInput.Blur()
+						m.AccessedAfterInput.Blur()
+						m.ChangedBeforeInput.Blur()
+						m.ChangedAfterInput.Blur()
+						m.OwnerInput.Blur()
+						m.NotOwnerInput.Blur()
+						m.UIDInput.Blur()
+						m.GroupInput.Blur()
+						m.PermInput.Blur()
+						m.WhereInput.Blur()
+						m.PresetsInput.Blur()
 
 						m.FocusedElement = fmt.Sprintf("rules_option_%d", i)
 						name := options.DefaultCleanOption[i-1]
@@ -287,6 +469,28 @@ func (m *RulesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.OlderInput, cmd = m.OlderInput.Update(msg)
 		case "newerInput":
 			m.NewerInput, cmd = m.NewerInput.Update(msg)
+		case "accessedBeforeInput":
+			m.AccessedBeforeInput, cmd = m.AccessedBeforeInput.Update(msg)
+		case "accessedAfterInput":
+			m.AccessedAfterInput, cmd = m.AccessedAfterInput.Update(msg)
+		case "changedBeforeInput":
+			m.ChangedBeforeInput, cmd = m.ChangedBeforeInput.Update(msg)
+		case "changedAfterInput":
+			m.ChangedAfterInput, cmd = m.ChangedAfterInput.Update(msg)
+		case "ownerInput":
+			m.OwnerInput, cmd = m.OwnerInput.Update(msg)
+		case "notOwnerInput":
+			m.NotOwnerInput, cmd = m.NotOwnerInput.Update(msg)
+		case "uidInput":
+			m.UIDInput, cmd = m.UIDInput.Update(msg)
+		case "groupInput":
+			m.GroupInput, cmd = m.GroupInput.Update(msg)
+		case "permInput":
+			m.PermInput, cmd = m.PermInput.Update(msg)
+		case "whereInput":
+			m.WhereInput, cmd = m.WhereInput.Update(msg)
+		case "presetsInput":
+			m.PresetsInput, cmd = m.PresetsInput.Update(msg)
 		}
 		cmds = append(cmds, cmd)
 	}
@@ -393,6 +597,28 @@ func (m *RulesModel) Handle(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.OlderInput, cmd = m.OlderInput.Update(msg)
 		case "newerInput":
 			m.NewerInput, cmd = m.NewerInput.Update(msg)
+		case "accessedBeforeInput":
+			m.AccessedBeforeInput, cmd = m.AccessedBeforeInput.Update(msg)
+		case "accessedAfterInput":
+			m.AccessedAfterInput, cmd = m.AccessedAfterInput.Update(msg)
+		case "changedBeforeInput":
+			m.ChangedBeforeInput, cmd = m.ChangedBeforeInput.Update(msg)
+		case "changedAfterInput":
+			m.ChangedAfterInput, cmd = m.ChangedAfterInput.Update(msg)
+		case "ownerInput":
+			m.OwnerInput, cmd = m.OwnerInput.Update(msg)
+		case "notOwnerInput":
+			m.NotOwnerInput, cmd = m.NotOwnerInput.Update(msg)
+		case "uidInput":
+			m.UIDInput, cmd = m.UIDInput.Update(msg)
+		case "groupInput":
+			m.GroupInput, cmd = m.GroupInput.Update(msg)
+		case "permInput":
+			m.PermInput, cmd = m.PermInput.Update(msg)
+		case "whereInput":
+			m.WhereInput, cmd = m.WhereInput.Update(msg)
+		case "presetsInput":
+			m.PresetsInput, cmd = m.PresetsInput.Update(msg)
 		}
 	}
 	return m, cmd
@@ -435,6 +661,50 @@ func (m *RulesModel) handleTab() (tea.Model, tea.Cmd) {
 			m.NewerInput.Focus()
 		case "newerInput":
 			m.NewerInput.Blur()
+			m.FocusedElement = "accessedBeforeInput"
+			m.AccessedBeforeInput.Focus()
+		case "accessedBeforeInput":
+			m.AccessedBeforeInput.Blur()
+			m.FocusedElement = "accessedAfterInput"
+			m.AccessedAfterInput.Focus()
+		case "accessedAfterInput":
+			m.AccessedAfterInput.Blur()
+			m.FocusedElement = "changedBeforeInput"
+			m.ChangedBeforeInput.Focus()
+		case "changedBeforeInput":
+			m.ChangedBeforeInput.Blur()
+			m.FocusedElement = "changedAfterInput"
+			m.ChangedAfterInput.Focus()
+		case "changedAfterInput":
+			m.ChangedAfterInput.Blur()
+			m.FocusedElement = "ownerInput"
+			m.OwnerInput.Focus()
+		case "ownerInput":
+			m.OwnerInput.Blur()
+			m.FocusedElement = "notOwnerInput"
+			m.NotOwnerInput.Focus()
+		case "notOwnerInput":
+			m.NotOwnerInput.Blur()
+			m.FocusedElement = "uidInput"
+			m.UIDInput.Focus()
+		case "uidInput":
+			m.UIDInput.Blur()
+			m.FocusedElement = "groupInput"
+			m.GroupInput.Focus()
+		case "groupInput":
+			m.GroupInput.Blur()
+			m.FocusedElement = "permInput"
+			m.PermInput.Focus()
+		case "permInput":
+			m.PermInput.Blur()
+			m.FocusedElement = "whereInput"
+			m.WhereInput.Focus()
+		case "whereInput":
+			m.WhereInput.Blur()
+			m.FocusedElement = "presetsInput"
+			m.PresetsInput.Focus()
+		case "presetsInput":
+			m.PresetsInput.Blur()
 			m.FocusedElement = "extensionsInput"
 			m.ExtensionsInput.Focus()
 		}
@@ -462,8 +732,8 @@ func (m *RulesModel) handleShiftTab() (tea.Model, tea.Cmd) {
 		switch m.FocusedElement {
 		case "extensionsInput":
 			m.ExtensionsInput.Blur()
-			m.FocusedElement = "newerInput"
-			m.NewerInput.Focus()
+			m.FocusedElement = "presetsInput"
+			m.PresetsInput.Focus()
 		case "minSizeInput":
 			m.MinSizeInput.Blur()
 			m.FocusedElement = "extensionsInput"
@@ -484,6 +754,50 @@ func (m *RulesModel) handleShiftTab() (tea.Model, tea.Cmd) {
 			m.NewerInput.Blur()
 			m.FocusedElement = "olderInput"
 			m.OlderInput.Focus()
+		case "accessedBeforeInput":
+			m.AccessedBeforeInput.Blur()
+			m.FocusedElement = "newerInput"
+			m.NewerInput.Focus()
+		case "accessedAfterInput":
+			m.AccessedAfterInput.Blur()
+			m.FocusedElement = "accessedBeforeInput"
+			m.AccessedBeforeInput.Focus()
+		case "changedBeforeInput":
+			m.ChangedBeforeInput.Blur()
+			m.FocusedElement = "accessedAfterInput"
+			m.AccessedAfterInput.Focus()
+		case "changedAfterInput":
+			m.ChangedAfterInput.Blur()
+			m.FocusedElement = "changedBeforeInput"
+			m.ChangedBeforeInput.Focus()
+		case "ownerInput":
+			m.OwnerInput.Blur()
+			m.FocusedElement = "changedAfterInput"
+			m.ChangedAfterInput.Focus()
+		case "notOwnerInput":
+			m.NotOwnerInput.Blur()
+			m.FocusedElement = "ownerInput"
+			m.OwnerInput.Focus()
+		case "uidInput":
+			m.UIDInput.Blur()
+			m.FocusedElement = "notOwnerInput"
+			m.NotOwnerInput.Focus()
+		case "groupInput":
+			m.GroupInput.Blur()
+			m.FocusedElement = "uidInput"
+			m.UIDInput.Focus()
+		case "permInput":
+			m.PermInput.Blur()
+			m.FocusedElement = "groupInput"
+			m.GroupInput.Focus()
+		case "whereInput":
+			m.WhereInput.Blur()
+			m.FocusedElement = "permInput"
+			m.PermInput.Focus()
+		case "presetsInput":
+			m.PresetsInput.Blur()
+			m.FocusedElement = "whereInput"
+			m.WhereInput.Focus()
 		}
 	case 2: // Options tab
 		m.FocusedElement = options.GetNextOption(m.FocusedElement, "rules_option_", len(options.DefaultCleanOption), false)
@@ -585,6 +899,9 @@ func (m *RulesModel) handleEnter() (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Validated above
+		uids, _ := parseIDs(m.UIDInput.Value())
+
 		// Save rules
 		err := m.rules.UpdateRules(
 			rules.WithPath(m.LocationInput.Value()),
@@ -594,6 +911,17 @@ func (m *RulesModel) handleEnter() (tea.Model, tea.Cmd) {
 			rules.WithExclude(utils.ParseExcludeToSlice(m.ExcludeInput.Value())),
 			rules.WithOlderThan(m.OlderInput.Value()),
 			rules.WithNewerThan(m.NewerInput.Value()),
+			rules.WithAccessedBefore(m.AccessedBeforeInput.Value()),
+			rules.WithAccessedAfter(m.AccessedAfterInput.Value()),
+			rules.WithChangedBefore(m.ChangedBeforeInput.Value()),
+			rules.WithChangedAfter(m.ChangedAfterInput.Value()),
+			rules.WithOwners(utils.ParseExcludeToSlice(m.OwnerInput.Value())),
+			rules.WithNotOwners(utils.ParseExcludeToSlice(m.NotOwnerInput.Value())),
+			rules.WithUIDs(uids),
+			rules.WithGroups(utils.ParseExcludeToSlice(m.GroupInput.Value())),
+			rules.WithPerm(m.PermInput.Value()),
+			rules.WithWhere(m.WhereInput.Value()),
+			rules.WithPresets(utils.ParseExcludeToSlice(strings.ToLower(m.PresetsInput.Value()))),
 			rules.WithOptions(
 				m.OptionState[options.ShowHiddenFiles],
 				m.OptionState[options.ConfirmDeletion],
@@ -606,6 +934,9 @@ func (m *RulesModel) handleEnter() (tea.Model, tea.Cmd) {
 				m.OptionState[options.DisableEmoji],
 				m.OptionState[options.ExitAfterDeletion],
 			),
+			rules.WithDeleteBrokenLinks(m.OptionState[options.DeleteBrokenLinks]),
+			rules.WithDeleteEmptyFiles(m.OptionState[options.DeleteEmptyFiles]),
+			rules.WithShred(m.OptionState[options.ShredFiles]),
 		)
 		if err != nil {
 			m.SuccessSaveText = ""
@@ -646,6 +977,17 @@ func (m *RulesModel) handleAltC() (tea.Model, tea.Cmd) {
 		m.ExcludeInput.SetValue("")
 		m.OlderInput.SetValue("")
 		m.NewerInput.SetValue("")
+		m.AccessedBeforeInput.SetValue("")
+		m.AccessedAfterInput.SetValue("")
+		m.ChangedBeforeInput.SetValue("")
+		m.ChangedAfterInput.SetValue("")
+		m.OwnerInput.SetValue("")
+		m.NotOwnerInput.SetValue("")
+		m.UIDInput.SetValue("")
+		m.GroupInput.SetValue("")
+		m.PermInput.SetValue("")
+		m.WhereInput.SetValue("")
+		m.PresetsInput.SetValue("")
 	case 2: // Options tab
 		for name := range m.OptionState {
 			m.OptionState[name] = false
@@ -683,6 +1025,60 @@ func (m *RulesModel) ValidateInputs() *errors.Error {
 		}
 	}
 
+	if m.AccessedBeforeInput.Value() != "" {
+		if m.Validator.ValidateTimeDuration(m.AccessedBeforeInput.Value()) != nil {
+			return errors.New(errors.ErrorTypeValidation, "Invalid (accessed before input) time format")
+		}
+	}
+
+	if m.AccessedAfterInput.Value() != "" {
+		if m.Validator.ValidateTimeDuration(m.AccessedAfterInput.Value()) != nil {
+			return errors.New(errors.ErrorTypeValidation, "Invalid (accessed after input) time format")
+		}
+	}
+
+	if m.ChangedBeforeInput.Value() != "" {
+		if m.Validator.ValidateTimeDuration(m.ChangedBeforeInput.Value()) != nil {
+			return errors.New(errors.ErrorTypeValidation, "Invalid (changed before input) time format")
+		}
+	}
+
+	if m.ChangedAfterInput.Value() != "" {
+		if m.Validator.ValidateTimeDuration(m.ChangedAfterInput.Value()) != nil {
+			return errors.New(errors.ErrorTypeValidation, "Invalid (changed after input) time format")
+		}
+	}
+
+	if _, err := filemanager.LookupOwners(utils.ParseExcludeToSlice(m.OwnerInput.Value())); err != nil {
+		return errors.New(errors.ErrorTypeValidation, fmt.Sprintf("Invalid (owner input): %v", err))
+	}
+
+	if _, err := filemanager.LookupOwners(utils.ParseExcludeToSlice(m.NotOwnerInput.Value())); err != nil {
+		return errors.New(errors.ErrorTypeValidation, fmt.Sprintf("Invalid (not owner input): %v", err))
+	}
+
+	if _, err := parseIDs(m.UIDInput.Value()); err != nil {
+		return errors.New(errors.ErrorTypeValidation, fmt.Sprintf("Invalid (uid input): %v", err))
+	}
+
+	if _, err := filemanager.LookupGroups(utils.ParseExcludeToSlice(m.GroupInput.Value())); err != nil {
+		return errors.New(errors.ErrorTypeValidation, fmt.Sprintf("Invalid (group input): %v", err))
+	}
+
+	if _, err := filemanager.ParsePermFilter(m.PermInput.Value()); err != nil {
+		return errors.New(errors.ErrorTypeValidation, fmt.Sprintf("Invalid (permissions input): %v", err))
+	}
+
+	if err := filemanager.CheckExpr(m.WhereInput.Value()); err != nil {
+		return errors.New(errors.ErrorTypeValidation, fmt.Sprintf("Invalid (where input): %v", err))
+	}
+
+	for _, name := range utils.ParseExcludeToSlice(m.PresetsInput.Value()) {
+		if _, ok := rules.LookupPreset(name); !ok {
+			return errors.New(errors.ErrorTypeValidation, fmt.Sprintf("Unknown preset %q (available: %s)", name, strings.Join(rules.PresetNames(), ", ")))
+		}
+	}
+
 	// Validate location input
 	if m.LocationInput.Value() != "" {
 		expandedPath := utils.ExpandTilde(m.LocationInput.Value())
@@ -743,4 +1139,48 @@ func (m *RulesModel) GetNewerInput() textinput.Model {
 	return m.NewerInput
 }
 
+func (m *RulesModel) GetAccessedBeforeInput() textinput.Model {
+	return m.AccessedBeforeInput
+}
+
+func (m *RulesModel) GetAccessedAfterInput() textinput.Model {
+	return m.AccessedAfterInput
+}
+
+func (m *RulesModel) GetChangedBeforeInput() textinput.Model {
+	return m.ChangedBeforeInput
+}
+
+func (m *RulesModel) GetChangedAfterInput() textinput.Model {
+	return m.ChangedAfterInput
+}
+
+func (m *RulesModel) GetOwnerInput() textinput.Model {
+	return m.OwnerInput
+}
+
+func (m *RulesModel) GetNotOwnerInput() textinput.Model {
+	return m.NotOwnerInput
+}
+
+func (m *RulesModel) GetUIDInput() textinput.Model {
+	return m.UIDInput
+}
+
+func (m *RulesModel) GetGroupInput() textinput.Model {
+	return m.GroupInput
+}
+
+func (m *RulesModel) GetPermInput() textinput.Model {
+	return m.PermInput
+}
+
+func (m *RulesModel) GetWhereInput() textinput.Model {
+	return m.WhereInput
+}
+
+func (m *RulesModel) GetPresetsInput() textinput.Model {
+	return m.PresetsInput
+}
+
 type RulesSavedMsg struct{}
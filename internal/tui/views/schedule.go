@@ -1,7 +1,9 @@
 package views
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,9 +32,17 @@ type ScheduledCleanCompletedMsg struct {
 	Err        error
 }
 
+// scheduleFocusOrder lists the focusable elements of the schedule page in
+// tab order
+var scheduleFocusOrder = []string{"delayInput", "ioniceInput", "niceInput", "rateLimitInput", "bwLimitInput", "scheduleButton"}
+
 // ScheduleCleanModel manages the one-off scheduled clean page.
 type ScheduleCleanModel struct {
 	DelayInput       textinput.Model
+	IONiceInput      textinput.Model // I/O class of this run, empty for the saved rules
+	NiceInput        textinput.Model // CPU niceness of this run, empty for the saved rules
+	RateLimitInput   textinput.Model // Files per time unit, empty for the saved rules
+	BWLimitInput     textinput.Model // Bytes per second, empty for the saved rules
 	FocusedElement   string
 	rules            rules.Rules
 	filemanager      filemanager.FileManager
@@ -42,6 +52,7 @@ type ScheduleCleanModel struct {
 	activeScheduleID int
 	isScheduled      bool
 	isRunning        bool
+	cancelRun        context.CancelFunc // Stops the running clean
 	status           string
 	Error            *errors.Error
 }
@@ -55,6 +66,10 @@ func NewScheduleCleanModel(ruleManager rules.Rules, fm filemanager.FileManager,
 
 	return &ScheduleCleanModel{
 		DelayInput:     delayInput,
+		IONiceInput:    newThrottleInput("I/O priority (idle or best-effort:0-7)"),
+		NiceInput:      newThrottleInput("CPU nice (1-19)"),
+		RateLimitInput: newThrottleInput("Files per second (e.g. 200/s, 1000/min)"),
+		BWLimitInput:   newThrottleInput("Archive and shred speed (e.g. 20MB/s)"),
 		FocusedElement: "delayInput",
 		rules:          ruleManager,
 		filemanager:    fm,
@@ -62,6 +77,27 @@ func NewScheduleCleanModel(ruleManager rules.Rules, fm filemanager.FileManager,
 	}
 }
 
+// newThrottleInput creates an input for one limit of a scheduled run
+func newThrottleInput(placeholder string) textinput.Model {
+	input := textinput.New()
+	input.Placeholder = placeholder + ", empty for saved rules"
+	input.PromptStyle = styles.TextInputPromptStyle
+	input.TextStyle = styles.TextInputTextStyle
+	input.Cursor.Style = styles.TextInputCursorStyle
+	return input
+}
+
+// inputs maps the focus names of the page to its text inputs
+func (m *ScheduleCleanModel) inputs() map[string]*textinput.Model {
+	return map[string]*textinput.Model{
+		"delayInput":     &m.DelayInput,
+		"ioniceInput":    &m.IONiceInput,
+		"niceInput":      &m.NiceInput,
+		"rateLimitInput": &m.RateLimitInput,
+		"bwLimitInput":   &m.BWLimitInput,
+	}
+}
+
 func (m *ScheduleCleanModel) Init() tea.Cmd {
 	m.DelayInput.Focus()
 	return textinput.Blink
@@ -93,12 +129,18 @@ func (m *ScheduleCleanModel) View() string {
 		action := "delete permanently"
 		if spec.SendFilesToTrash {
 			action = "move files to trash"
+		} else if spec.Shred {
+			action = "overwrite files, then delete them"
 		}
 
 		content.WriteString(fmt.Sprintf("Saved path: %s\n", spec.Path))
 		content.WriteString(fmt.Sprintf("Extensions: %s\n", extensions))
 		content.WriteString(fmt.Sprintf("Scope: %s\n", scope))
 		content.WriteString(fmt.Sprintf("Action: %s\n", action))
+		if spec.Rotates() {
+			content.WriteString(fmt.Sprintf("Rotation: %s\n", spec.Rotation))
+		}
+		content.WriteString(fmt.Sprintf("Saved limits: %s\n", spec.Throttle))
 		if spec.DeleteEmptySubfolders {
 			content.WriteString("Empty directories will be pruned after the run.\n")
 		}
@@ -112,6 +154,24 @@ func (m *ScheduleCleanModel) View() string {
 	content.WriteString(zone.Mark("schedule_delay_input", delayStyle.Render("Run in: "+m.DelayInput.View())))
 	content.WriteString("\n\n")
 
+	for _, field := range []struct {
+		focus, zone, label string
+		input              *textinput.Model
+	}{
+		{"ioniceInput", "schedule_ionice_input", "I/O priority: ", &m.IONiceInput},
+		{"niceInput", "schedule_nice_input", "Nice: ", &m.NiceInput},
+		{"rateLimitInput", "schedule_rate_limit_input", "Rate limit: ", &m.RateLimitInput},
+		{"bwLimitInput", "schedule_bw_limit_input", "Bandwidth: ", &m.BWLimitInput},
+	} {
+		style := styles.StandardInputStyle
+		if m.FocusedElement == field.focus {
+			style = styles.StandardInputFocusedStyle
+		}
+		content.WriteString(zone.Mark(field.zone, style.Render(field.label+field.input.View())))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+
 	buttonLabel := "Schedule one-off clean"
 	buttonStyle := styles.StandardButtonStyle
 	if m.FocusedElement == "scheduleButton" {
@@ -140,6 +200,9 @@ func (m *ScheduleCleanModel) View() string {
 
 	content.WriteString("\n\n")
 	content.WriteString(help.NavigateHelpText)
+	if m.isScheduled || m.isRunning {
+		content.WriteString("Ctrl+X: cancel scheduled clean\n")
+	}
 
 	return zone.Scan(content.String())
 }
@@ -151,14 +214,20 @@ func (m *ScheduleCleanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.MouseMsg:
 		// nolint:staticcheck
 		if msg.Type == tea.MouseLeft && msg.Action == tea.MouseActionPress {
-			if zone.Get("schedule_delay_input").InBounds(msg) {
-				m.FocusedElement = "delayInput"
-				m.DelayInput.Focus()
-				return m, nil
+			for zoneID, focus := range map[string]string{
+				"schedule_delay_input":      "delayInput",
+				"schedule_ionice_input":     "ioniceInput",
+				"schedule_nice_input":       "niceInput",
+				"schedule_rate_limit_input": "rateLimitInput",
+				"schedule_bw_limit_input":   "bwLimitInput",
+			} {
+				if zone.Get(zoneID).InBounds(msg) {
+					m.setFocus(focus)
+					return m, nil
+				}
 			}
 			if zone.Get("schedule_button").InBounds(msg) {
-				m.FocusedElement = "scheduleButton"
-				m.DelayInput.Blur()
+				m.setFocus("scheduleButton")
 				return m.scheduleOnce()
 			}
 		}
@@ -177,6 +246,7 @@ func (m *ScheduleCleanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		m.isRunning = false
+		m.cancelRun = nil
 		m.pendingSpec = nil
 		m.activeScheduleID = 0
 		m.scheduledFor = time.Time{}
@@ -196,6 +266,8 @@ func (m *ScheduleCleanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		action := "Deleted"
 		if msg.Result.UsedTrash {
 			action = "Moved to trash"
+		} else if msg.Result.UsedShred {
+			action = "Shredded"
 		}
 
 		status := fmt.Sprintf(
@@ -204,16 +276,31 @@ func (m *ScheduleCleanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			msg.Result.FilesCleaned,
 			utils.FormatSize(msg.Result.BytesCleared),
 		)
+		if msg.Result.FilesArchived > 0 {
+			status += fmt.Sprintf(" Archived or rotated %d file(s) (%s).", msg.Result.FilesArchived, utils.FormatSize(msg.Result.ArchivedSize))
+		}
+		if msg.Result.ShredThroughput > 0 {
+			status += fmt.Sprintf(" Overwrote at %s/s.", utils.FormatSize(int64(msg.Result.ShredThroughput)))
+		}
 		if msg.Result.EmptyDirsDeleted > 0 {
 			status += fmt.Sprintf(" Removed %d empty directorie(s).", msg.Result.EmptyDirsDeleted)
 		}
+		if msg.Result.InUseFiles > 0 {
+			status += fmt.Sprintf(" Skipped %d file(s) in use (%s).", msg.Result.InUseFiles, utils.FormatSize(msg.Result.InUseSize))
+		}
+		if msg.Result.DeletedOpenSize > 0 {
+			status += fmt.Sprintf(" %s still held by deleted files that are open.", utils.FormatSize(msg.Result.DeletedOpenSize))
+		}
+		if msg.Result.Cancelled {
+			status = "Cancelled. " + status
+		}
 		m.status = status
 		return m, nil
 	}
 
-	if m.FocusedElement == "delayInput" {
+	if input, ok := m.inputs()[m.FocusedElement]; ok {
 		var cmd tea.Cmd
-		m.DelayInput, cmd = m.DelayInput.Update(msg)
+		*input, cmd = input.Update(msg)
 		return m, cmd
 	}
 
@@ -231,36 +318,91 @@ func (m *ScheduleCleanModel) Handle(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m.scheduleOnce()
 		}
 	case "alt+c":
-		m.DelayInput.SetValue("")
+		for _, input := range m.inputs() {
+			input.SetValue("")
+		}
 		if !m.isScheduled && !m.isRunning {
 			m.status = ""
 		}
 		m.Error = nil
 		return m, nil
+	case "ctrl+x":
+		return m.cancelScheduled()
 	}
 
-	if m.FocusedElement == "delayInput" {
+	if input, ok := m.inputs()[m.FocusedElement]; ok {
 		var cmd tea.Cmd
-		m.DelayInput, cmd = m.DelayInput.Update(msg)
+		*input, cmd = input.Update(msg)
 		return m, cmd
 	}
 
 	return m, nil
 }
 
-func (m *ScheduleCleanModel) focusNext() (tea.Model, tea.Cmd) {
-	if m.FocusedElement == "delayInput" {
-		m.FocusedElement = "scheduleButton"
-		m.DelayInput.Blur()
-	} else {
-		m.FocusedElement = "delayInput"
-		m.DelayInput.Focus()
+// cancelScheduled stops a running clean before its next file, or drops a
+// clean that has not started yet
+func (m *ScheduleCleanModel) cancelScheduled() (tea.Model, tea.Cmd) {
+	if m.isRunning && m.cancelRun != nil {
+		m.cancelRun()
+		m.status = "Cancelling scheduled clean..."
+		return m, nil
+	}
+	if m.isScheduled {
+		// Bumping the ID makes the pending timer message stale
+		m.activeScheduleID++
+		m.isScheduled = false
+		m.pendingSpec = nil
+		m.scheduledFor = time.Time{}
+		m.status = "Scheduled clean cancelled"
 	}
 	return m, nil
 }
 
+// setFocus focuses an element of the page and blurs the other inputs
+func (m *ScheduleCleanModel) setFocus(focus string) {
+	m.FocusedElement = focus
+	for name, input := range m.inputs() {
+		if name == focus {
+			input.Focus()
+		} else {
+			input.Blur()
+		}
+	}
+}
+
+// moveFocus focuses the element step places away in tab order
+func (m *ScheduleCleanModel) moveFocus(step int) (tea.Model, tea.Cmd) {
+	current := 0
+	for i, focus := range scheduleFocusOrder {
+		if focus == m.FocusedElement {
+			current = i
+		}
+	}
+	next := (current + step + len(scheduleFocusOrder)) % len(scheduleFocusOrder)
+	m.setFocus(scheduleFocusOrder[next])
+	return m, nil
+}
+
+func (m *ScheduleCleanModel) focusNext() (tea.Model, tea.Cmd) {
+	return m.moveFocus(1)
+}
+
 func (m *ScheduleCleanModel) focusPrevious() (tea.Model, tea.Cmd) {
-	return m.focusNext()
+	return m.moveFocus(-1)
+}
+
+// throttle returns the limits typed for this run; empty inputs keep the
+// saved rules
+func (m *ScheduleCleanModel) throttle() (cleanup.Throttle, error) {
+	nice := 0
+	if value := strings.TrimSpace(m.NiceInput.Value()); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return cleanup.Throttle{}, fmt.Errorf("nice must be a number from 1 to 19, got %q", value)
+		}
+		nice = n
+	}
+	return cleanup.ParseThrottle(m.IONiceInput.Value(), nice, m.RateLimitInput.Value(), m.BWLimitInput.Value())
 }
 
 func (m *ScheduleCleanModel) scheduleOnce() (tea.Model, tea.Cmd) {
@@ -279,6 +421,15 @@ func (m *ScheduleCleanModel) scheduleOnce() (tea.Model, tea.Cmd) {
 		}
 	}
 
+	throttle, err := m.throttle()
+	if err != nil {
+		m.status = ""
+		return m, func() tea.Msg {
+			return errors.New(errors.ErrorTypeValidation, fmt.Sprintf("Invalid limits: %v", err))
+		}
+	}
+	spec.Throttle.Override(throttle)
+
 	parsedTime, err := utils.ParseTimeDuration(m.DelayInput.Value())
 	if err != nil {
 		m.status = ""
@@ -302,6 +453,9 @@ func (m *ScheduleCleanModel) scheduleOnce() (tea.Model, tea.Cmd) {
 	m.isRunning = false
 	m.Error = nil
 	m.status = fmt.Sprintf("One-off clean scheduled for %s", m.scheduledFor.Format("2006-01-02 15:04:05"))
+	if spec.Throttle != (cleanup.Throttle{}) {
+		m.status += fmt.Sprintf(" with limits: %s", spec.Throttle)
+	}
 
 	scheduleID := m.activeScheduleID
 	return m, tea.Tick(delay, func(time.Time) tea.Msg {
@@ -311,8 +465,10 @@ func (m *ScheduleCleanModel) scheduleOnce() (tea.Model, tea.Cmd) {
 
 func (m *ScheduleCleanModel) runScheduledClean(scheduleID int) tea.Cmd {
 	spec := m.pendingSpec
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelRun = cancel
 	return func() tea.Msg {
-		result, err := cleanup.RunOneOffClean(m.filemanager, spec)
+		result, err := cleanup.RunOneOffClean(ctx, m.filemanager, spec)
 		return ScheduledCleanCompletedMsg{
 			ScheduleID: scheduleID,
 			Result:     result,
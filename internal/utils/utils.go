@@ -133,17 +133,23 @@ func ToBytesOrDefault(sizeStr string) int64 {
 	return size
 }
 
+// DeletionRecord is a removed file as written to the deletion logs
+type DeletionRecord struct {
+	Path string // Full path of the removed file or directory
+	Size int64  // Size in bytes
+}
+
 // LogDeletionToFile writes deletion records to a log file
 // Each record includes timestamp, file path, and file size
-func LogDeletionToFile(files map[string]string) {
+func LogDeletionToFile(records []DeletionRecord) {
 	yellow := color.New(color.FgYellow).SprintFunc()
 	const (
 		DELETION_FILE_NAME = "deletor.log"
 	)
 	var deletionLogs string
 	deletionTimestamp := time.Now().Format("2006-01-02 15:04:05")
-	for path, size := range files {
-		deletionLogs += fmt.Sprintf("[%s] %s | %s\n", deletionTimestamp, path, size)
+	for _, record := range records {
+		deletionLogs += fmt.Sprintf("[%s] %s | %s\n", deletionTimestamp, record.Path, FormatSize(record.Size))
 	}
 
 	file, err := os.OpenFile(DELETION_FILE_NAME, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -160,7 +166,7 @@ func LogDeletionToFile(files map[string]string) {
 }
 
 // LogDeletionToFileAsJson generates JSON-formated deletion logs and writes to a file
-func LogDeletionToFileAsJson(files map[string]string, dir string) {
+func LogDeletionToFileAsJson(records []DeletionRecord, dir string) {
 	yellow := color.New(color.FgYellow).SprintFunc()
 	jsonFilePath := filepath.Join(dir, "deletor.json")
 	type DeletionLog struct {
@@ -189,17 +195,14 @@ func LogDeletionToFileAsJson(files map[string]string, dir string) {
 	}
 
 	// Generate new logs
-	newLogs := make([]DeletionLog, len(files))
-	index := 0
-
-	for path, size := range files {
-		newLogs[index] = DeletionLog{
-			Path:      path,
-			Size:      size,
-			Bytes:     ToBytesOrDefault(size),
+	newLogs := make([]DeletionLog, len(records))
+	for i, record := range records {
+		newLogs[i] = DeletionLog{
+			Path:      record.Path,
+			Size:      FormatSize(record.Size),
+			Bytes:     record.Size,
 			DeletedAt: deletionTimestamp,
 		}
-		index++
 	}
 
 	// Append new logs to existing logs
@@ -317,6 +320,68 @@ func ParseTimeDuration(timeStr string) (time.Time, error) {
 	return time.Now().Add(-duration), nil
 }
 
+// rateUnits maps the time units of a rate to seconds
+var rateUnits = map[string]float64{
+	"": 1, "s": 1, "sec": 1, "second": 1,
+	"m": 60, "min": 60, "minute": 60,
+	"h": 3600, "hr": 3600, "hour": 3600,
+}
+
+// ParseRate converts a count per time unit such as 200/s, 30/min or 1000/h
+// to a count per second. A bare number is per second, empty means none.
+func ParseRate(rateStr string) (float64, error) {
+	rateStr = strings.ToLower(strings.ReplaceAll(rateStr, " ", ""))
+	if rateStr == "" {
+		return 0, nil
+	}
+
+	count, unit, _ := strings.Cut(rateStr, "/")
+	seconds, ok := rateUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown rate unit: %s", unit)
+	}
+	num, err := strconv.ParseFloat(count, 64)
+	if err != nil || num < 0 {
+		return 0, fmt.Errorf("invalid rate: %s", rateStr)
+	}
+	return num / seconds, nil
+}
+
+// ParseByteRate converts a size per second such as 20MB/s or 512kb to bytes
+// per second. Empty means none.
+func ParseByteRate(rateStr string) (int64, error) {
+	rateStr = strings.ToLower(strings.ReplaceAll(rateStr, " ", ""))
+	if rateStr == "" {
+		return 0, nil
+	}
+	return ToBytes(strings.TrimSuffix(strings.TrimSuffix(rateStr, "/s"), "/sec"))
+}
+
+// ParseIONice checks an I/O scheduling class: idle, best-effort or
+// best-effort:LEVEL with LEVEL from 0 to 7, where be is short for
+// best-effort. It returns the class in its long form, empty for none.
+func ParseIONice(class string) (string, error) {
+	class = strings.ToLower(strings.TrimSpace(class))
+	name, level, hasLevel := strings.Cut(class, ":")
+	switch name {
+	case "":
+		return "", nil
+	case "idle":
+		if !hasLevel {
+			return "idle", nil
+		}
+	case "best-effort", "be":
+		if !hasLevel {
+			return "best-effort", nil
+		}
+		if num, err := strconv.Atoi(level); err == nil && num >= 0 && num <= 7 {
+			return fmt.Sprintf("best-effort:%d", num), nil
+		}
+		return "", fmt.Errorf("best-effort level must be between 0 and 7: %s", level)
+	}
+	return "", fmt.Errorf("unknown I/O class: %s, want idle, best-effort or best-effort:LEVEL", class)
+}
+
 // ParseJsonLogsPath gets the optional path provided for JSON-formatted logs
 func ParseJsonLogsPath(args []string, flagName string) string {
 	length := len(args)
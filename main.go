@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/pashkov256/deletor/internal/cli/config"
 	"github.com/pashkov256/deletor/internal/filemanager"
@@ -13,13 +17,39 @@ import (
 
 func main() {
 	var rules = rules.NewRules()
-	rules.SetupRulesConfig()
+	if err := rules.SetupRulesConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading rules: %v\n", err)
+	}
+	fm := filemanager.NewFileManager()
+
+	// Subcommands such as "deletor config explain" come before any flags
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		os.Exit(runner.RunCommand(fm, rules, os.Args[1:]))
+	}
+
 	config := config.GetFlags()
 	validator := validation.NewValidator()
-	fm := filemanager.NewFileManager()
+
+	// Scans list unchanged directories from the index and refresh the rest
+	if config.UseIndex {
+		index, err := filemanager.OpenDirIndex(filemanager.DefaultIndexPath())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening scan index: %v\n", err)
+		} else {
+			fm = filemanager.NewFileManagerWithIndex(index)
+			defer func() {
+				if err := index.Save(); err != nil {
+					fmt.Fprintf(os.Stderr, "Error saving scan index: %v\n", err)
+				}
+			}()
+		}
+	}
 
 	if config.IsCLIMode {
-		runner.RunCLI(fm, rules, config)
+		// Ctrl-C stops the run between files instead of killing it mid-delete
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		runner.RunCLI(ctx, fm, rules, config)
 	} else {
 		if err := runner.RunTUI(fm, rules, validator); err != nil {
 			fmt.Printf("Error: %v\n", err)